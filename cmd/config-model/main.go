@@ -6,30 +6,36 @@ package main
 
 import (
 	"context"
-	"crypto/tls"
 	"encoding/json"
 	"errors"
+	"fmt"
 	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
 	"github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-config-model/pkg/model/logging"
+	"github.com/onosproject/onos-config-model/pkg/model/openapi"
+	"github.com/onosproject/onos-config-model/pkg/model/plugin"
 	plugincache "github.com/onosproject/onos-config-model/pkg/model/plugin/cache"
 	"github.com/onosproject/onos-config-model/pkg/model/plugin/compiler"
 	"github.com/onosproject/onos-config-model/pkg/model/plugin/module"
 	"github.com/onosproject/onos-config-model/pkg/model/registry"
-	"github.com/onosproject/onos-lib-go/pkg/certs"
-	"github.com/onosproject/onos-lib-go/pkg/logging"
 	"github.com/onosproject/onos-lib-go/pkg/northbound"
+	"github.com/onosproject/onos-lib-go/pkg/prom"
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"gopkg.in/yaml.v2"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"strings"
 	"syscall"
+	"text/tabwriter"
+	"time"
 )
 
-var log = logging.GetLogger("config-model")
+var log = modellogging.GetLogger("config-model")
 
 const (
 	defaultCachePath    = "/etc/onos/plugins"
@@ -51,6 +57,31 @@ func getCmd() *cobra.Command {
 	}
 	cmd.AddCommand(getRegistryCmd())
 	cmd.AddCommand(getInitCmd())
+	cmd.AddCommand(getDoctorCmd())
+	cmd.AddCommand(getGenerateBindingsCmd())
+	cmd.AddCommand(getInspectPluginCmd())
+	return cmd
+}
+
+// getInspectPluginCmd returns the command with which the registry server inspects the plugins of tried out models
+// Plugins cannot be unloaded, so the server loads them in a short-lived process running this command.
+func getInspectPluginCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          modelregistry.InspectPluginCommand + " <path>",
+		Short:        "Load a plugin and write a summary of its model to a file",
+		Args:         cobra.ExactArgs(1),
+		Hidden:       true,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			output, _ := cmd.Flags().GetString("output")
+			pluginSymbols, _ := cmd.Flags().GetStringArray("plugin-symbol")
+			modelplugin.SetPluginSymbols(pluginSymbols...)
+			return modelregistry.InspectPluginToFile(args[0], output)
+		},
+	}
+	cmd.Flags().StringP("output", "o", "", "the file to which to write the JSON encoded summary")
+	cmd.Flags().StringArray("plugin-symbol", []string{}, "a symbol the plugin may export its model as, tried in order (may be repeated)")
+	_ = cmd.MarkFlagRequired("output")
 	return cmd
 }
 
@@ -61,24 +92,231 @@ func getInitCmd() *cobra.Command {
 		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			modPath, _ := cmd.Flags().GetString("mod-path")
-			modTarget, _ := cmd.Flags().GetString("mod-target")
-			modReplace, _ := cmd.Flags().GetString("mod-replace")
+			modTargets, _ := cmd.Flags().GetStringArray("mod-target")
+			modReplaces, _ := cmd.Flags().GetStringArray("mod-replace")
+			offline, _ := cmd.Flags().GetBool("offline")
+			offlineEnv, _ := cmd.Flags().GetStringArray("offline-env")
+			fetchRetries, _ := cmd.Flags().GetInt("mod-fetch-retries")
+			fetchRetryDelay, _ := cmd.Flags().GetDuration("mod-fetch-retry-delay")
+			vendorDir, _ := cmd.Flags().GetString("mod-vendor-dir")
+			targets, err := pluginmodule.ParseTargets(modTargets, modReplaces)
+			if err != nil {
+				return err
+			}
 			config := pluginmodule.ResolverConfig{
-				Path:    modPath,
-				Target:  modTarget,
-				Replace: modReplace,
+				Path:            modPath,
+				Targets:         targets,
+				Offline:         offline,
+				OfflineEnv:      getOfflineEnv(offlineEnv),
+				FetchRetries:    fetchRetries,
+				FetchRetryDelay: fetchRetryDelay,
+				VendorDir:       vendorDir,
 			}
+			var progressFunc pluginmodule.ProgressFunc
+			if progress, _ := cmd.Flags().GetBool("progress"); progress {
+				progressFunc = func(event pluginmodule.Progress) {
+					fmt.Printf("%s: %s\n", event.Phase, event.Message)
+				}
+			}
+			ctx, cancel := newContext()
+			defer cancel()
 			manager := pluginmodule.NewResolver(config)
-			_, _, err := manager.Resolve()
+			_, _, err = manager.ResolveWithProgress(ctx, progressFunc)
 			if err != nil {
-				log.Errorf("Failed to initialize module '%s': %s", modTarget, err)
+				log.Errorf("Failed to initialize modules '%s': %s", strings.Join(modTargets, "', '"), err)
 			}
 			return err
 		},
 	}
-	cmd.Flags().StringP("mod-target", "t", "", "the target Go module")
-	cmd.Flags().StringP("mod-replace", "r", "", "the replace Go module")
+	cmd.Flags().StringArrayP("mod-target", "t", []string{}, "a target Go module (may be repeated to merge multiple modules)")
+	cmd.Flags().StringArrayP("mod-replace", "r", []string{}, "the replace Go module for the target module at the same position")
 	cmd.Flags().StringP("mod-path", "p", defaultModPath, "the module path")
+	cmd.Flags().Bool("progress", false, "print the progress of fetching each target module")
+	addOfflineFlags(cmd)
+	addFetchRetryFlags(cmd)
+	addVendorDirFlag(cmd)
+	return cmd
+}
+
+func getDoctorCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "doctor",
+		Short:        "Check that the host can build and store model plugins",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			registryPath, _ := cmd.Flags().GetString("registry-path")
+			cachePath, _ := cmd.Flags().GetString("cache-path")
+			buildPath, _ := cmd.Flags().GetString("build-path")
+			modPath, _ := cmd.Flags().GetString("mod-path")
+			modTargets, _ := cmd.Flags().GetStringArray("mod-target")
+			modReplaces, _ := cmd.Flags().GetStringArray("mod-replace")
+			offline, _ := cmd.Flags().GetBool("offline")
+			offlineEnv, _ := cmd.Flags().GetStringArray("offline-env")
+			fetchRetries, _ := cmd.Flags().GetInt("mod-fetch-retries")
+			fetchRetryDelay, _ := cmd.Flags().GetDuration("mod-fetch-retry-delay")
+			vendorDir, _ := cmd.Flags().GetString("mod-vendor-dir")
+			goos, _ := cmd.Flags().GetString("goos")
+			goarch, _ := cmd.Flags().GetString("goarch")
+			goBinary, _ := cmd.Flags().GetString("go-binary")
+			targets, err := pluginmodule.ParseTargets(modTargets, modReplaces)
+			if err != nil {
+				return err
+			}
+			resolver := pluginmodule.NewResolver(pluginmodule.ResolverConfig{
+				Path:            modPath,
+				Targets:         targets,
+				Offline:         offline,
+				OfflineEnv:      getOfflineEnv(offlineEnv),
+				FetchRetries:    fetchRetries,
+				FetchRetryDelay: fetchRetryDelay,
+				VendorDir:       vendorDir,
+			})
+			if err := pluginmodule.NewPlatform(goos, goarch).Validate(); err != nil {
+				return err
+			}
+			compiler := plugincompiler.NewPluginCompiler(plugincompiler.CompilerConfig{
+				BuildPath:  buildPath,
+				Offline:    offline,
+				OfflineEnv: getOfflineEnv(offlineEnv),
+				GOOS:       goos,
+				GOARCH:     goarch,
+				GoBinary:   goBinary,
+			}, resolver)
+
+			checks := modelregistry.Doctor(compiler, resolver, modelregistry.DoctorConfig{
+				RegistryPath: registryPath,
+				CachePath:    cachePath,
+			})
+			failed := 0
+			writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(writer, "RESULT\tCHECK\tERROR")
+			for _, check := range checks {
+				result := "PASS"
+				if !check.Passed() {
+					result = "FAIL"
+					failed++
+				}
+				fmt.Fprintf(writer, "%s\t%s\t%s\n", result, check.Name, strings.ReplaceAll(strings.TrimSpace(check.Error), "\n", " "))
+			}
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().String("registry-path", defaultRegistryPath, "the path in which the registry models are stored")
+	cmd.Flags().String("cache-path", defaultCachePath, "the path in which the plugins are stored")
+	cmd.Flags().String("build-path", defaultBuildPath, "the path in which temporary build artifacts are stored")
+	cmd.Flags().String("mod-path", defaultModPath, "the path in which the module info is stored")
+	cmd.Flags().StringArrayP("mod-target", "t", []string{}, "a target Go module (may be repeated to merge multiple modules)")
+	cmd.Flags().StringArrayP("mod-replace", "r", []string{}, "the replace Go module for the target module at the same position")
+	cmd.Flags().String("goos", "", "the operating system for which plugins are built (defaults to the host's)")
+	cmd.Flags().String("goarch", "", "the architecture for which plugins are built (defaults to the host's)")
+	addGoBinaryFlag(cmd)
+	addOfflineFlags(cmd)
+	addFetchRetryFlags(cmd)
+	addVendorDirFlag(cmd)
+	return cmd
+}
+
+func getGenerateBindingsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "generate-bindings",
+		Short:        "Generate the Go bindings for a model without building its plugin",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			registryPath, _ := cmd.Flags().GetString("registry-path")
+			buildPath, _ := cmd.Flags().GetString("build-path")
+			name, _ := cmd.Flags().GetString("name")
+			version, _ := cmd.Flags().GetString("version")
+			yangDir, _ := cmd.Flags().GetString("yang-dir")
+			features, _ := cmd.Flags().GetStringSlice("feature")
+			generatorFlags, _ := cmd.Flags().GetStringArray("generator-flag")
+			generatorPackageName, _ := cmd.Flags().GetString("generator-package-name")
+			includeStandardModules, _ := cmd.Flags().GetBool("include-standard-modules")
+			preprocessor, _ := cmd.Flags().GetString("preprocessor")
+			preprocessorArgs, _ := cmd.Flags().GetStringArray("preprocessor-arg")
+			offline, _ := cmd.Flags().GetBool("offline")
+			offlineEnv, _ := cmd.Flags().GetStringArray("offline-env")
+			goBinary, _ := cmd.Flags().GetString("go-binary")
+			output, _ := cmd.Flags().GetString("output")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+			// Models are read from a directory of YANG files if one is given, or else from the registry
+			var model configmodel.ModelInfo
+			if yangDir != "" {
+				modules, files, err := modelregistry.ReadModelDir(yangDir)
+				if err != nil {
+					return err
+				}
+				model = configmodel.ModelInfo{
+					Name:     configmodel.Name(name),
+					Version:  configmodel.Version(version),
+					Modules:  modules,
+					Files:    files,
+					Features: features,
+				}
+			} else {
+				registry := modelregistry.NewConfigModelRegistry(modelregistry.Config{
+					Path: registryPath,
+				})
+				m, err := registry.GetModel(configmodel.Name(name), configmodel.Version(version))
+				if err != nil {
+					return err
+				}
+				model = m
+			}
+
+			if err := plugincompiler.ValidateGeneratorFlags(generatorFlags); err != nil {
+				return err
+			}
+			if generatorPackageName != "" {
+				if err := plugincompiler.ValidateGeneratorPackageName(generatorPackageName); err != nil {
+					return err
+				}
+			}
+			compiler := plugincompiler.NewPluginCompiler(plugincompiler.CompilerConfig{
+				BuildPath:              buildPath,
+				GeneratorFlags:         generatorFlags,
+				GeneratorPackageName:   generatorPackageName,
+				IncludeStandardModules: includeStandardModules,
+				Preprocessor:           preprocessor,
+				PreprocessorArgs:       preprocessorArgs,
+				Offline:                offline,
+				OfflineEnv:             getOfflineEnv(offlineEnv),
+				GoBinary:               goBinary,
+				DryRun:                 dryRun,
+			}, nil)
+
+			// Dry runs generate the whole plugin module, keeping it in the build path for inspection
+			if dryRun {
+				return compiler.CompilePlugin(model, "")
+			}
+			source, err := compiler.GenerateBindings(model)
+			if err != nil {
+				return err
+			}
+			return ioutil.WriteFile(output, source, 0666)
+		},
+	}
+	cmd.Flags().String("registry-path", defaultRegistryPath, "the path in which the registry models are stored")
+	cmd.Flags().String("build-path", defaultBuildPath, "the path in which to store temporary build artifacts")
+	cmd.Flags().StringP("name", "n", "", "the model name")
+	cmd.Flags().StringP("version", "v", "", "the model version")
+	cmd.Flags().String("yang-dir", "", "a directory of YANG files from which to generate the bindings in place of a model in the registry")
+	cmd.Flags().StringSlice("feature", []string{}, "a YANG feature enabled in a model read from the --yang-dir (all features are enabled if none are given)")
+	cmd.Flags().StringArray("generator-flag", []string{}, "an additional ygot generator flag, e.g. -compress_paths")
+	cmd.Flags().String("generator-package-name", "", "the package name of the generated bindings (defaults to configmodel)")
+	cmd.Flags().Bool("include-standard-modules", false, "generate bindings for the bundled standard IETF and OpenConfig modules imported but not provided by the model")
+	cmd.Flags().String("preprocessor", "", "a command that transforms each YANG file from stdin to stdout before bindings are generated")
+	cmd.Flags().StringArray("preprocessor-arg", []string{}, "an argument to pass to the --preprocessor command")
+	cmd.Flags().StringP("output", "o", "generated.go", "the file to which to write the bindings")
+	cmd.Flags().Bool("dry-run", false, "generate the plugin module and its bindings under the build path, keeping them for inspection, without writing the output or building the plugin")
+	addGoBinaryFlag(cmd)
+	addOfflineFlags(cmd)
 	return cmd
 }
 
@@ -89,8 +327,24 @@ func getRegistryCmd() *cobra.Command {
 	cmd.AddCommand(getRegistryServeCmd())
 	cmd.AddCommand(getRegistryGetCmd())
 	cmd.AddCommand(getRegistryListCmd())
+	cmd.AddCommand(getRegistryModulesCmd())
 	cmd.AddCommand(getRegistryPushCmd())
 	cmd.AddCommand(getRegistryDeleteCmd())
+	cmd.AddCommand(getRegistryHistoryCmd())
+	cmd.AddCommand(getRegistryOrphansCmd())
+	cmd.AddCommand(getRegistryVerifyCmd())
+	cmd.AddCommand(getRegistryPinCmd())
+	cmd.AddCommand(getRegistryUnpinCmd())
+	cmd.AddCommand(getRegistryCapabilitiesCmd())
+	cmd.AddCommand(getRegistryStatePathsCmd())
+	cmd.AddCommand(getRegistryValidateConfigCmd())
+	cmd.AddCommand(getRegistryDiffCmd())
+	cmd.AddCommand(getRegistryExportOpenAPICmd())
+	cmd.AddCommand(getRegistrySchemaCmd())
+	cmd.AddCommand(getRegistryExportK8sCmd())
+	cmd.AddCommand(getRegistryImportCmd())
+	cmd.AddCommand(getRegistryExportCmd())
+	cmd.AddCommand(getRegistryDepsCmd())
 	return cmd
 }
 
@@ -103,14 +357,33 @@ func getRegistryServeCmd() *cobra.Command {
 			caCert, _ := cmd.Flags().GetString("ca-cert")
 			cert, _ := cmd.Flags().GetString("cert")
 			key, _ := cmd.Flags().GetString("key")
-			registryPath, _ := cmd.Flags().GetString("registry-path")
-			cachePath, _ := cmd.Flags().GetString("cache-path")
-			buildPath, _ := cmd.Flags().GetString("build-path")
-			modPath, _ := cmd.Flags().GetString("mod-path")
-			modTarget, _ := cmd.Flags().GetString("mod-target")
-			modReplace, _ := cmd.Flags().GetString("mod-replace")
 			port, _ := cmd.Flags().GetInt16("port")
-			skipCleanup, _ := cmd.Flags().GetBool("skipcleanup")
+			metricsPort, _ := cmd.Flags().GetInt("metrics-port")
+			httpPort, _ := cmd.Flags().GetInt("http-port")
+			configPath, _ := cmd.Flags().GetString("config")
+			logFormat, _ := cmd.Flags().GetString("log-format")
+			pluginSymbols, _ := cmd.Flags().GetStringArray("plugin-symbol")
+
+			// The log format is set first so every line the server logs is in the same format
+			format, err := modellogging.ParseFormat(logFormat)
+			if err != nil {
+				return err
+			}
+			modellogging.SetFormat(format)
+			modelplugin.SetPluginSymbols(pluginSymbols...)
+
+			options := getServeOptions(cmd)
+
+			// Settings in the config file take precedence over flags
+			var config modelregistry.ServerConfig
+			if configPath != "" {
+				c, err := modelregistry.LoadServerConfig(configPath)
+				if err != nil {
+					return err
+				}
+				config = c
+				options.applyServerConfig(config)
+			}
 
 			server := northbound.NewServer(&northbound.ServerConfig{
 				CaPath:      &caCert,
@@ -121,34 +394,45 @@ func getRegistryServeCmd() *cobra.Command {
 				SecurityCfg: &northbound.SecurityConfig{},
 			})
 
-			resolverConfig := pluginmodule.ResolverConfig{
-				Path:    modPath,
-				Target:  modTarget,
-				Replace: modReplace,
+			resolver, err := newResolver(cmd)
+			if err != nil {
+				return err
 			}
-			resolver := pluginmodule.NewResolver(resolverConfig)
-
-			cacheConfig := plugincache.CacheConfig{
-				Path: cachePath,
+			cache, err := newPluginCache(cmd, options.cachePath, resolver)
+			if err != nil {
+				return err
+			}
+			compiler, err := newPluginCompiler(cmd, options.buildPath, options.compileTimeout, resolver)
+			if err != nil {
+				return err
 			}
-			cache, err := plugincache.NewPluginCache(cacheConfig, resolver)
+			registry, err := newRegistry(cmd, options.registryPath)
 			if err != nil {
 				return err
 			}
 
-			compilerConfig := plugincompiler.CompilerConfig{
-				BuildPath:   buildPath,
-				SkipCleanUp: skipCleanup,
+			service := modelregistry.NewService(options.service, registry, cache, compiler)
+			server.AddService(service)
+
+			if configPath != "" {
+				// Apply the settings that have no flag, e.g. the log level
+				if _, err := service.Reload(config); err != nil {
+					return err
+				}
+				go reloadOnHangup(service, configPath)
 			}
-			compiler := plugincompiler.NewPluginCompiler(compilerConfig, resolver)
 
-			registryConfig := modelregistry.Config{
-				Path: registryPath,
+			if metricsPort != 0 {
+				if err := startMetricsExporter(metricsPort, registry, cache, service); err != nil {
+					return err
+				}
 			}
-			registry := modelregistry.NewConfigModelRegistry(registryConfig)
 
-			service := modelregistry.NewService(registry, cache, compiler)
-			server.AddService(service)
+			if httpPort != 0 {
+				if err := startGateway(httpPort, caCert, cert, key, service); err != nil {
+					return err
+				}
+			}
 
 			c := make(chan os.Signal, 1)
 			signal.Notify(c, os.Interrupt, syscall.SIGTERM)
@@ -157,9 +441,9 @@ func getRegistryServeCmd() *cobra.Command {
 				os.Exit(0)
 			}()
 
-			log.Infof("Starting registry server at '%s'", registryPath)
+			log.Infof("Starting registry server at '%s'", options.registryPath)
 			err = server.Serve(func(address string) {
-				log.Infof("Serving models at '%s' on %s", registryPath, address)
+				log.Infof("Serving models at '%s' on %s", options.registryPath, address)
 			})
 			if err != nil {
 				log.Errorf("Registry serve failed: %v", err)
@@ -171,16 +455,400 @@ func getRegistryServeCmd() *cobra.Command {
 	cmd.Flags().Int16P("port", "p", 5151, "the registry service port")
 	cmd.Flags().String("registry-path", defaultRegistryPath, "the path in which to store the registry models")
 	cmd.Flags().String("mod-path", defaultModPath, "the path in which to store the module info")
-	cmd.Flags().StringP("mod-target", "t", "", "the target Go module")
-	cmd.Flags().StringP("mod-replace", "r", "", "the replace Go module")
+	cmd.Flags().StringArrayP("mod-target", "t", []string{}, "a target Go module (may be repeated to merge multiple modules)")
+	cmd.Flags().StringArrayP("mod-replace", "r", []string{}, "the replace Go module for the target module at the same position")
 	cmd.Flags().String("cache-path", defaultCachePath, "the path in which to store the plugins")
 	cmd.Flags().String("build-path", defaultBuildPath, "the path in which to store temporary build artifacts")
+	cmd.Flags().String("module-path-prefix", "", "the Go module path prefix for compiled plugins")
+	cmd.Flags().String("mod-file", "", "a go.mod to use verbatim for compiled plugins")
+	cmd.Flags().String("sum-file", "", "a go.sum to use verbatim with the --mod-file")
+	cmd.Flags().StringArray("generator-flag", []string{}, "an additional ygot generator flag, e.g. -compress_paths")
+	cmd.Flags().String("generator-package-name", "", "the package name of the generated model bindings (defaults to configmodel)")
+	cmd.Flags().String("preprocessor", "", "a command that transforms each YANG file from stdin to stdout before it's compiled")
+	cmd.Flags().StringArray("preprocessor-arg", []string{}, "an argument to pass to the --preprocessor command")
+	cmd.Flags().Bool("compress-storage", false, "gzip YANG files stored in the registry")
+	cmd.Flags().Bool("read-only-registry", false, "serve and compile the models in the registry without modifying it (detected if the registry path is not writable)")
+	cmd.Flags().Bool("read-only", false, "reject pushes and deletes, e.g. to serve a read replica of a registry")
+	cmd.Flags().Bool("memory-registry", false, "keep the registry models in memory rather than the registry path, discarding them on exit")
+	cmd.Flags().Bool("force", false, "wait for another server to release its lock on the registry path instead of failing fast")
 	cmd.Flags().String("ca-cert", "", "the CA certificate")
 	cmd.Flags().String("cert", "", "the certificate")
 	cmd.Flags().String("key", "", "the key")
+	cmd.Flags().Bool("auto-recompile", false, "recompile plugins built with an incompatible toolchain when they're loaded")
+	cmd.Flags().Int("metrics-port", 0, "the port on which to expose Prometheus metrics (disabled if 0)")
+	cmd.Flags().Int("http-port", 0, "the port on which to serve the registry API as JSON over HTTPS (disabled if 0)")
+	cmd.Flags().Int("compile-workers", 0, "the maximum number of plugins to compile concurrently (defaults to the number of CPUs divided by the build parallelism)")
+	cmd.Flags().Int("compile-queue-size", 0, "the maximum number of compilations waiting for a worker, beyond which pushes fail with ResourceExhausted (defaults to 100)")
+	cmd.Flags().Int("build-parallelism", 0, "the number of packages each plugin build compiles in parallel (defaults to the number of CPUs)")
+	cmd.Flags().Duration("compile-worker-idle-timeout", 0, "the time after which idle compile workers are shut down (never if 0)")
+	cmd.Flags().Int64("cache-max-size", 0, "the maximum total size in bytes of cached plugins (unlimited if 0)")
+	cmd.Flags().Int("cache-max-entries", 0, "the maximum number of cached plugins (unlimited if 0)")
+	cmd.Flags().Int("max-tryouts", 1, "the maximum number of models tried out concurrently")
+	cmd.Flags().Int64("max-model-bytes", 64*1024*1024, "the maximum total size of the YANG files of a pushed model")
+	cmd.Flags().Int("max-modules", 1000, "the maximum number of modules in a pushed model")
+	cmd.Flags().Int64("max-file-bytes", 16*1024*1024, "the maximum size of each YANG file of a pushed model")
+	cmd.Flags().String("compile-webhook", "", "a URL to which the result of each compile is posted")
+	cmd.Flags().String("webhook-url", "", "a URL to which an event is posted when a model is pushed or deleted")
+	cmd.Flags().Duration("plugin-grace-period", 0, "the time for which plugins replaced by a forced push are kept for their consumers (kept indefinitely if 0)")
+	cmd.Flags().String("upstream-address", "", "the address of a registry from which to fetch and compile models that are not found")
+	cmd.Flags().String("config", "", "a YAML server config file that is reloaded on SIGHUP")
+	cmd.Flags().Duration("compile-timeout", 0, "the maximum duration of each plugin compilation phase (unbounded if 0)")
+	cmd.Flags().String("goos", "", "the operating system for which to build plugins (defaults to the host's)")
+	cmd.Flags().String("goarch", "", "the architecture for which to build plugins, e.g. arm64 (defaults to the host's; cross-compiling requires a C compiler set with CC)")
+	cmd.Flags().Bool("reproducible", false, "build identical plugins from identical models with -trimpath (plugins can then only be loaded by binaries also built with -trimpath)")
+	cmd.Flags().Bool("include-standard-modules", false, "compile the bundled standard IETF and OpenConfig modules imported but not provided by pushed models")
+	cmd.Flags().String("build-cache-path", "", "the path in which to store compiled plugins by a hash of their inputs, to copy rather than rebuild plugins compiled from identical inputs (disabled if empty)")
+	cmd.Flags().String("log-format", string(modellogging.ConsoleFormat), "the log format: console or json")
+	cmd.Flags().StringArray("plugin-symbol", []string{}, "a symbol a plugin may export its model as, tried in order (may be repeated; defaults to ConfigModelPlugin and ConfigPlugin)")
+	addGoBinaryFlag(cmd)
+	addExtraReplaceFlag(cmd)
+	addOfflineFlags(cmd)
+	addFetchRetryFlags(cmd)
+	addVendorDirFlag(cmd)
 	return cmd
 }
 
+// serveOptions are the registry server settings that may be set by flags or the server config file
+type serveOptions struct {
+	registryPath   string
+	cachePath      string
+	buildPath      string
+	compileTimeout time.Duration
+	service        modelregistry.ServiceConfig
+}
+
+// getServeOptions returns the registry server settings set by flags
+func getServeOptions(cmd *cobra.Command) *serveOptions {
+	registryPath, _ := cmd.Flags().GetString("registry-path")
+	cachePath, _ := cmd.Flags().GetString("cache-path")
+	buildPath, _ := cmd.Flags().GetString("build-path")
+	compileTimeout, _ := cmd.Flags().GetDuration("compile-timeout")
+	autoRecompile, _ := cmd.Flags().GetBool("auto-recompile")
+	readOnly, _ := cmd.Flags().GetBool("read-only")
+	compileWorkers, _ := cmd.Flags().GetInt("compile-workers")
+	compileQueueSize, _ := cmd.Flags().GetInt("compile-queue-size")
+	compileWorkerIdleTimeout, _ := cmd.Flags().GetDuration("compile-worker-idle-timeout")
+	maxTryouts, _ := cmd.Flags().GetInt("max-tryouts")
+	compileWebhook, _ := cmd.Flags().GetString("compile-webhook")
+	webhookURL, _ := cmd.Flags().GetString("webhook-url")
+	pluginGracePeriod, _ := cmd.Flags().GetDuration("plugin-grace-period")
+	upstreamAddress, _ := cmd.Flags().GetString("upstream-address")
+	maxModelBytes, _ := cmd.Flags().GetInt64("max-model-bytes")
+	maxModules, _ := cmd.Flags().GetInt("max-modules")
+	maxFileBytes, _ := cmd.Flags().GetInt64("max-file-bytes")
+	return &serveOptions{
+		registryPath:   registryPath,
+		cachePath:      cachePath,
+		buildPath:      buildPath,
+		compileTimeout: compileTimeout,
+		service: modelregistry.ServiceConfig{
+			AutoRecompileOnABIMismatch: autoRecompile,
+			CompileWorkers:             compileWorkers,
+			CompileWorkerIdleTimeout:   compileWorkerIdleTimeout,
+			CompileQueueSize:           compileQueueSize,
+			MaxTryouts:                 maxTryouts,
+			CompileWebhook:             compileWebhook,
+			WebhookURL:                 webhookURL,
+			PluginGracePeriod:          pluginGracePeriod,
+			UpstreamAddress:            upstreamAddress,
+			MaxModelBytes:              maxModelBytes,
+			MaxModules:                 maxModules,
+			MaxFileBytes:               maxFileBytes,
+			ReadOnly:                   readOnly,
+		},
+	}
+}
+
+// applyServerConfig overrides the settings set by flags with those set in the server config file
+func (o *serveOptions) applyServerConfig(config modelregistry.ServerConfig) {
+	if config.RegistryPath != "" {
+		o.registryPath = config.RegistryPath
+	}
+	if config.CachePath != "" {
+		o.cachePath = config.CachePath
+	}
+	if config.BuildPath != "" {
+		o.buildPath = config.BuildPath
+	}
+	if config.CompileTimeout != 0 {
+		o.compileTimeout = config.CompileTimeout
+	}
+	if config.CompileWorkers != 0 {
+		o.service.CompileWorkers = config.CompileWorkers
+	}
+	if config.CompileQueueSize != 0 {
+		o.service.CompileQueueSize = config.CompileQueueSize
+	}
+	if config.ReadOnly {
+		o.service.ReadOnly = true
+	}
+	if config.CompileWorkerIdleTimeout != 0 {
+		o.service.CompileWorkerIdleTimeout = config.CompileWorkerIdleTimeout
+	}
+	if config.AutoRecompileOnABIMismatch {
+		o.service.AutoRecompileOnABIMismatch = true
+	}
+	if config.MaxTryouts != 0 {
+		o.service.MaxTryouts = config.MaxTryouts
+	}
+	if config.CompileWebhook != "" {
+		o.service.CompileWebhook = config.CompileWebhook
+	}
+	if config.WebhookURL != "" {
+		o.service.WebhookURL = config.WebhookURL
+	}
+	if config.PluginGracePeriod != 0 {
+		o.service.PluginGracePeriod = config.PluginGracePeriod
+	}
+	if config.UpstreamAddress != "" {
+		o.service.UpstreamAddress = config.UpstreamAddress
+	}
+	if config.MaxModelBytes != 0 {
+		o.service.MaxModelBytes = config.MaxModelBytes
+	}
+	if config.MaxModules != 0 {
+		o.service.MaxModules = config.MaxModules
+	}
+	if config.MaxFileBytes != 0 {
+		o.service.MaxFileBytes = config.MaxFileBytes
+	}
+}
+
+// newResolver creates the module resolver configured by the serve command flags
+func newResolver(cmd *cobra.Command) (*pluginmodule.Resolver, error) {
+	modPath, _ := cmd.Flags().GetString("mod-path")
+	modTargets, _ := cmd.Flags().GetStringArray("mod-target")
+	modReplaces, _ := cmd.Flags().GetStringArray("mod-replace")
+	offline, _ := cmd.Flags().GetBool("offline")
+	offlineEnv, _ := cmd.Flags().GetStringArray("offline-env")
+	fetchRetries, _ := cmd.Flags().GetInt("mod-fetch-retries")
+	fetchRetryDelay, _ := cmd.Flags().GetDuration("mod-fetch-retry-delay")
+	vendorDir, _ := cmd.Flags().GetString("mod-vendor-dir")
+
+	targets, err := pluginmodule.ParseTargets(modTargets, modReplaces)
+	if err != nil {
+		return nil, err
+	}
+	resolverConfig := pluginmodule.ResolverConfig{
+		Path:            modPath,
+		Targets:         targets,
+		Offline:         offline,
+		OfflineEnv:      getOfflineEnv(offlineEnv),
+		FetchRetries:    fetchRetries,
+		FetchRetryDelay: fetchRetryDelay,
+		VendorDir:       vendorDir,
+	}
+	return pluginmodule.NewResolver(resolverConfig), nil
+}
+
+// newPluginCache creates the plugin cache at the given path configured by the serve command flags
+func newPluginCache(cmd *cobra.Command, path string, resolver *pluginmodule.Resolver) (*plugincache.PluginCache, error) {
+	cacheMaxSize, _ := cmd.Flags().GetInt64("cache-max-size")
+	cacheMaxEntries, _ := cmd.Flags().GetInt("cache-max-entries")
+	goos, _ := cmd.Flags().GetString("goos")
+	goarch, _ := cmd.Flags().GetString("goarch")
+
+	if err := pluginmodule.NewPlatform(goos, goarch).Validate(); err != nil {
+		return nil, err
+	}
+	cacheConfig := plugincache.CacheConfig{
+		Path:         path,
+		MaxSizeBytes: cacheMaxSize,
+		MaxEntries:   cacheMaxEntries,
+		GOOS:         goos,
+		GOARCH:       goarch,
+	}
+	return plugincache.NewPluginCache(cacheConfig, resolver)
+}
+
+// newPluginCompiler creates the plugin compiler configured by the serve command flags
+func newPluginCompiler(cmd *cobra.Command, buildPath string, timeout time.Duration, resolver *pluginmodule.Resolver) (*plugincompiler.PluginCompiler, error) {
+	modulePathPrefix, _ := cmd.Flags().GetString("module-path-prefix")
+	skipCleanup, _ := cmd.Flags().GetBool("skipcleanup")
+	modFile, _ := cmd.Flags().GetString("mod-file")
+	sumFile, _ := cmd.Flags().GetString("sum-file")
+	generatorFlags, _ := cmd.Flags().GetStringArray("generator-flag")
+	generatorPackageName, _ := cmd.Flags().GetString("generator-package-name")
+	preprocessor, _ := cmd.Flags().GetString("preprocessor")
+	preprocessorArgs, _ := cmd.Flags().GetStringArray("preprocessor-arg")
+	buildParallelism, _ := cmd.Flags().GetInt("build-parallelism")
+	offline, _ := cmd.Flags().GetBool("offline")
+	offlineEnv, _ := cmd.Flags().GetStringArray("offline-env")
+	goos, _ := cmd.Flags().GetString("goos")
+	goarch, _ := cmd.Flags().GetString("goarch")
+	goBinary, _ := cmd.Flags().GetString("go-binary")
+	reproducible, _ := cmd.Flags().GetBool("reproducible")
+	includeStandardModules, _ := cmd.Flags().GetBool("include-standard-modules")
+	buildCachePath, _ := cmd.Flags().GetString("build-cache-path")
+
+	extraReplaces, err := getExtraReplaces(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if err := plugincompiler.ValidateGeneratorFlags(generatorFlags); err != nil {
+		return nil, err
+	}
+	if generatorPackageName != "" {
+		if err := plugincompiler.ValidateGeneratorPackageName(generatorPackageName); err != nil {
+			return nil, err
+		}
+	}
+	compilerConfig := plugincompiler.CompilerConfig{
+		BuildPath:              buildPath,
+		ModulePathPrefix:       modulePathPrefix,
+		SkipCleanUp:            skipCleanup,
+		ModFile:                modFile,
+		SumFile:                sumFile,
+		GeneratorFlags:         generatorFlags,
+		Preprocessor:           preprocessor,
+		PreprocessorArgs:       preprocessorArgs,
+		Timeout:                timeout,
+		BuildParallelism:       buildParallelism,
+		Offline:                offline,
+		OfflineEnv:             getOfflineEnv(offlineEnv),
+		GOOS:                   goos,
+		GOARCH:                 goarch,
+		GoBinary:               goBinary,
+		Reproducible:           reproducible,
+		ExtraReplaces:          extraReplaces,
+		IncludeStandardModules: includeStandardModules,
+		GeneratorPackageName:   generatorPackageName,
+		BuildCachePath:         buildCachePath,
+	}
+	return plugincompiler.NewPluginCompiler(compilerConfig, resolver), nil
+}
+
+// newRegistry creates the model registry at the given path configured by the serve command flags
+func newRegistry(cmd *cobra.Command, path string) (modelregistry.Registry, error) {
+	memoryRegistry, _ := cmd.Flags().GetBool("memory-registry")
+	compressStorage, _ := cmd.Flags().GetBool("compress-storage")
+	readOnlyRegistry, _ := cmd.Flags().GetBool("read-only-registry")
+	force, _ := cmd.Flags().GetBool("force")
+
+	if memoryRegistry {
+		return modelregistry.NewMemoryRegistry(), nil
+	}
+	registryConfig := modelregistry.Config{
+		Path:            path,
+		CompressStorage: compressStorage,
+		ReadOnly:        readOnlyRegistry,
+	}
+	// Lock the registry path so concurrent servers can't corrupt each other's descriptors
+	registry := modelregistry.NewConfigModelRegistry(registryConfig)
+	if err := registry.Lock(force); err != nil {
+		return nil, err
+	}
+	if _, err := registry.TranscodeModels(); err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
+
+// startMetricsExporter exposes the registry and server metrics to Prometheus on the given port
+func startMetricsExporter(port int, registry modelregistry.Registry, cache *plugincache.PluginCache, service *modelregistry.Service) error {
+	exporter := prom.NewExporter("/metrics", fmt.Sprintf(":%d", port))
+	if err := exporter.RegisterCollector("registry", modelregistry.NewCollector(registry, cache, 0)); err != nil {
+		return err
+	}
+	if err := exporter.RegisterCollector("server", service.Metrics()); err != nil {
+		return err
+	}
+	go func() {
+		if err := exporter.Run(); err != nil {
+			log.Errorf("Metrics exporter failed: %v", err)
+		}
+	}()
+	return nil
+}
+
+// startGateway serves the registry API as JSON over HTTPS on the given port
+func startGateway(port int, caCert, cert, key string, service *modelregistry.Service) error {
+	// The gateway is served with the TLS config of the gRPC server
+	tlsConfig, err := modelregistry.NewGatewayTLSConfig(caCert, cert, key)
+	if err != nil {
+		return err
+	}
+	gateway := &http.Server{
+		Addr:      fmt.Sprintf(":%d", port),
+		Handler:   service.Gateway(),
+		TLSConfig: tlsConfig,
+	}
+	go func() {
+		log.Infof("Serving HTTP gateway on %s", gateway.Addr)
+		if err := gateway.ListenAndServeTLS("", ""); err != nil {
+			log.Errorf("HTTP gateway failed: %v", err)
+		}
+	}()
+	return nil
+}
+
+// addGoBinaryFlag adds the flag for the go command with which plugins are built
+func addGoBinaryFlag(cmd *cobra.Command) {
+	cmd.Flags().String("go-binary", "", "the path to the go command with which to build plugins, which must be the Go version of the binaries loading them (defaults to go on the PATH)")
+}
+
+// addExtraReplaceFlag adds the flag for replace directives added to the go.mod of compiled plugins
+func addExtraReplaceFlag(cmd *cobra.Command) {
+	cmd.Flags().StringArray("mod-extra-replace", []string{}, "a replace directive added to the go.mod of compiled plugins in the old=new@version format, e.g. github.com/openconfig/ygot=github.com/example/ygot@v0.12.5")
+}
+
+// getExtraReplaces returns the replace directives added to the go.mod of compiled plugins
+func getExtraReplaces(cmd *cobra.Command) ([]plugincompiler.ModReplace, error) {
+	values, _ := cmd.Flags().GetStringArray("mod-extra-replace")
+	var replaces []plugincompiler.ModReplace
+	for _, value := range values {
+		replace, err := plugincompiler.ParseModReplace(value)
+		if err != nil {
+			return nil, err
+		}
+		replaces = append(replaces, replace)
+	}
+	return replaces, nil
+}
+
+// addOfflineFlags adds the flags for resolving modules and building plugins without network access
+func addOfflineFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("offline", false, "resolve modules and build plugins from the module cache without network access")
+	cmd.Flags().StringArray("offline-env", []string{}, "an environment variable for Go commands run --offline, e.g. GOPROXY=off (defaults to "+strings.Join(pluginmodule.DefaultOfflineEnv, " ")+")")
+}
+
+// addFetchRetryFlags adds the flags for retrying transient module fetch failures
+func addFetchRetryFlags(cmd *cobra.Command) {
+	cmd.Flags().Int("mod-fetch-retries", 0, "the number of times to retry fetching a module after a transient network or proxy failure")
+	cmd.Flags().Duration("mod-fetch-retry-delay", 0, "the delay before retrying a module fetch, doubling for each subsequent retry (defaults to 1s)")
+}
+
+// addVendorDirFlag adds the flag for resolving target modules from a vendor directory
+func addVendorDirFlag(cmd *cobra.Command) {
+	cmd.Flags().String("mod-vendor-dir", "", "a vendor directory from which to read the target modules instead of the module cache")
+}
+
+// getOfflineEnv returns the configured offline environment, or nil to use the default
+func getOfflineEnv(env []string) []string {
+	if len(env) == 0 {
+		return nil
+	}
+	return env
+}
+
+// reloadOnHangup reloads the server config file when the process receives SIGHUP
+func reloadOnHangup(service *modelregistry.Service, path string) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGHUP)
+	for range c {
+		log.Infof("Reloading config file '%s'", path)
+		config, err := modelregistry.LoadServerConfig(path)
+		if err != nil {
+			log.Errorf("Reloading config file '%s' failed: %v", path, err)
+			continue
+		}
+		// Changes to settings that require a restart are logged by the server
+		if _, err := service.Reload(config); err != nil {
+			log.Errorf("Reloading config file '%s' failed: %v", path, err)
+		}
+	}
+}
+
 func getRegistryGetCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:          "get",
@@ -190,6 +858,11 @@ func getRegistryGetCmd() *cobra.Command {
 			address, _ := cmd.Flags().GetString("address")
 			name, _ := cmd.Flags().GetString("name")
 			version, _ := cmd.Flags().GetString("version")
+			filesDir, _ := cmd.Flags().GetString("files-dir")
+			output, _ := cmd.Flags().GetString("output")
+			if err := checkOutputFormat(output); err != nil {
+				return err
+			}
 
 			conn, err := connect(address)
 			if err != nil {
@@ -204,42 +877,110 @@ func getRegistryGetCmd() *cobra.Command {
 			}
 			ctx, cancel := newContext()
 			defer cancel()
-			response, err := client.GetModel(ctx, request)
+			var header metadata.MD
+			response, err := client.GetModel(ctx, request, grpc.Header(&header))
 			if err != nil {
 				return err
 			}
 
-			var moduleInfos []configmodel.ModuleInfo
+			var buildInfo *configmodel.BuildInfo
+			if values := header.Get(modelregistry.BuildInfoKey); len(values) > 0 {
+				buildInfo = &configmodel.BuildInfo{}
+				if err := json.Unmarshal([]byte(values[0]), buildInfo); err != nil {
+					return err
+				}
+			}
+
+			var checksum string
+			if values := header.Get(modelregistry.ChecksumKey); len(values) > 0 {
+				checksum = values[0]
+			}
+
+			var labels map[string]string
+			if values := header.Get(modelregistry.ModelLabelsKey); len(values) > 0 {
+				if err := json.Unmarshal([]byte(values[0]), &labels); err != nil {
+					return err
+				}
+			}
+
+			var artifacts []configmodel.PluginArtifact
+			if values := header.Get(modelregistry.PluginArtifactsKey); len(values) > 0 {
+				if err := json.Unmarshal([]byte(values[0]), &artifacts); err != nil {
+					return err
+				}
+			}
+
+			// Deviation modules are returned as modules and named in a header
+			deviations := make(map[string]bool)
+			for _, deviation := range header.Get(modelregistry.DeviationsKey) {
+				deviations[deviation] = true
+			}
+			var moduleInfos, deviationInfos []configmodel.ModuleInfo
 			for _, module := range response.Model.Modules {
-				moduleInfos = append(moduleInfos, configmodel.ModuleInfo{
+				moduleInfo := configmodel.ModuleInfo{
 					Name:         configmodel.Name(module.Name),
 					Organization: module.Organization,
 					Revision:     configmodel.Revision(module.Revision),
 					File:         module.File,
-				})
+				}
+				if deviations[module.Name] {
+					deviationInfos = append(deviationInfos, moduleInfo)
+				} else {
+					moduleInfos = append(moduleInfos, moduleInfo)
+				}
+			}
+
+			// Servers that report module namespaces and prefixes send the complete modules in a header
+			if values := header.Get(modelregistry.ModulesKey); len(values) > 0 {
+				moduleInfos = nil
+				if err := json.Unmarshal([]byte(values[0]), &moduleInfos); err != nil {
+					return err
+				}
 			}
 
 			modelInfo := configmodel.ModelInfo{
-				Name:    configmodel.Name(response.Model.Name),
-				Version: configmodel.Version(response.Model.Version),
-				Modules: moduleInfos,
+				Name:         configmodel.Name(response.Model.Name),
+				Version:      configmodel.Version(response.Model.Version),
+				GetStateMode: modelregistry.NewGetStateMode(response.Model.GetStateMode),
+				Modules:      moduleInfos,
+				Features:     header.Get(modelregistry.FeaturesKey),
+				Deviations:   deviationInfos,
 				Plugin: configmodel.PluginInfo{
-					Name:    configmodel.Name(response.Model.Name),
-					Version: configmodel.Version(response.Model.Version),
+					Name:      configmodel.Name(response.Model.Name),
+					Version:   configmodel.Version(response.Model.Version),
+					Artifacts: artifacts,
 				},
+				Build:    buildInfo,
+				Checksum: checksum,
+				Labels:   labels,
 			}
 
-			bytes, err := json.MarshalIndent(modelInfo, "", "  ")
-			if err != nil {
+			if err := printModels(output, modelInfo); err != nil {
 				return err
 			}
-			println(string(bytes))
+
+			if filesDir != "" {
+				files, err := modelregistry.GetModelData(ctx, client, modelInfo.Name, modelInfo.Version)
+				if err != nil {
+					return err
+				}
+				if err := os.MkdirAll(filesDir, os.ModePerm); err != nil {
+					return err
+				}
+				for _, file := range files {
+					if err := ioutil.WriteFile(filepath.Join(filesDir, filepath.Base(file.Path)), file.Data, 0644); err != nil {
+						return err
+					}
+				}
+			}
 			return nil
 		},
 	}
 	cmd.Flags().StringP("address", "a", "localhost:5151", "the registry address")
 	cmd.Flags().StringP("name", "n", "", "the model name")
-	cmd.Flags().StringP("version", "v", "", "the model version")
+	cmd.Flags().StringP("version", "v", "", "the model version, or a version constraint such as ^1.0.0, ~1.2 or latest")
+	cmd.Flags().String("files-dir", "", "a directory to which to write the model's YANG files")
+	addOutputFlag(cmd)
 	return cmd
 }
 
@@ -250,20 +991,40 @@ func getRegistryListCmd() *cobra.Command {
 		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			address, _ := cmd.Flags().GetString("address")
+			pageSize, _ := cmd.Flags().GetInt("page-size")
+			selector, _ := cmd.Flags().GetString("selector")
+			output, _ := cmd.Flags().GetString("output")
+			if err := checkOutputFormat(output); err != nil {
+				return err
+			}
 			conn, err := connect(address)
 			if err != nil {
 				return err
 			}
 			defer conn.Close()
 			client := configmodelapi.NewConfigModelRegistryServiceClient(conn)
-			request := &configmodelapi.ListModelsRequest{}
 			ctx, cancel := newContext()
 			defer cancel()
-			response, err := client.ListModels(ctx, request)
+			ctx = modelregistry.WithSelector(ctx, selector)
+			var models []*configmodelapi.ConfigModel
+			var token string
+			for {
+				page, next, err := modelregistry.ListModelsPage(ctx, client, pageSize, token)
+				if err != nil {
+					return err
+				}
+				models = append(models, page...)
+				if next == "" {
+					break
+				}
+				token = next
+			}
+			labels, err := modelregistry.ListLabels(ctx, client)
 			if err != nil {
 				return err
 			}
-			for _, modelInfo := range response.Models {
+			modelInfos := make([]configmodel.ModelInfo, 0, len(models))
+			for _, modelInfo := range models {
 				var moduleInfos []configmodel.ModuleInfo
 				for _, module := range modelInfo.Modules {
 					moduleInfos = append(moduleInfos, configmodel.ModuleInfo{
@@ -282,30 +1043,77 @@ func getRegistryListCmd() *cobra.Command {
 						Version: configmodel.Version(modelInfo.Version),
 					},
 				}
-				bytes, err := json.MarshalIndent(model, "", "  ")
-				if err != nil {
-					return err
-				}
-				println(string(bytes))
+				model.Labels = labels[model.String()]
+				modelInfos = append(modelInfos, model)
 			}
-			return nil
+			return printModels(output, modelInfos...)
 		},
 	}
 	cmd.Flags().StringP("address", "a", "localhost:5151", "the registry address")
+	cmd.Flags().Int("page-size", 0, "the number of models to fetch per request (all at once if 0)")
+	cmd.Flags().String("selector", "", "a label selector for the models to list, e.g. vendor=cisco,env=prod")
+	addOutputFlag(cmd)
 	return cmd
 }
 
-func getRegistryPushCmd() *cobra.Command {
+func getRegistryModulesCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:          "push",
-		Short:        "Push a model to the registry",
+		Use:          "modules",
+		Short:        "List the distinct YANG modules referenced by the models in the registry",
 		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			address, _ := cmd.Flags().GetString("address")
-			name, _ := cmd.Flags().GetString("name")
-			version, _ := cmd.Flags().GetString("version")
-			files, _ := cmd.Flags().GetStringSlice("file")
+			selector, _ := cmd.Flags().GetString("selector")
+			conn, err := connect(address)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			ctx, cancel := newContext()
+			defer cancel()
+			modules, err := modelregistry.ListModules(modelregistry.WithSelector(ctx, selector), conn)
+			if err != nil {
+				return err
+			}
+			writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(writer, "NAME\tREVISION\tORGANIZATION\tMODELS")
+			for _, module := range modules {
+				fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", module.Name, module.Revision, module.Organization, strings.Join(module.Models, ","))
+			}
+			return writer.Flush()
+		},
+	}
+	cmd.Flags().StringP("address", "a", "localhost:5151", "the registry address")
+	cmd.Flags().String("selector", "", "a label selector for the models whose modules to list, e.g. vendor=cisco,env=prod")
+	return cmd
+}
+
+func getRegistryPushCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "push",
+		Short:        "Push a model to the registry",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			address, _ := cmd.Flags().GetString("address")
+			name, _ := cmd.Flags().GetString("name")
+			version, _ := cmd.Flags().GetString("version")
+			files, _ := cmd.Flags().GetStringSlice("file")
 			modules, _ := cmd.Flags().GetStringToString("module")
+			yangDir, _ := cmd.Flags().GetString("yang-dir")
+			skipCompile, _ := cmd.Flags().GetBool("skip-compile")
+			testConfigFiles, _ := cmd.Flags().GetStringSlice("test-config")
+			validateOnly, _ := cmd.Flags().GetBool("validate-only")
+			tryout, _ := cmd.Flags().GetBool("tryout")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			progress, _ := cmd.Flags().GetBool("progress")
+			force, _ := cmd.Flags().GetBool("force")
+			priority, _ := cmd.Flags().GetString("priority")
+			labels, _ := cmd.Flags().GetStringToString("label")
+			platform, _ := cmd.Flags().GetString("platform")
+			features, _ := cmd.Flags().GetStringSlice("feature")
+			deviations, _ := cmd.Flags().GetStringSlice("deviation")
+			compress, _ := cmd.Flags().GetBool("compress")
 			conn, err := connect(address)
 			if err != nil {
 				return err
@@ -316,6 +1124,7 @@ func getRegistryPushCmd() *cobra.Command {
 				Name:    name,
 				Version: version,
 				Modules: []*configmodelapi.ConfigModule{},
+				Files:   map[string]string{},
 			}
 
 			for _, path := range files {
@@ -339,11 +1148,105 @@ func getRegistryPushCmd() *cobra.Command {
 				})
 			}
 
-			request := &configmodelapi.PushModelRequest{
-				Model: model,
+			if yangDir != "" {
+				dirModules, dirFiles, err := modelregistry.ReadModelDir(yangDir)
+				if err != nil {
+					return err
+				}
+				for _, module := range dirModules {
+					model.Modules = append(model.Modules, &configmodelapi.ConfigModule{
+						Name:         string(module.Name),
+						Organization: module.Organization,
+						Revision:     string(module.Revision),
+						File:         module.File,
+					})
+				}
+				for _, file := range dirFiles {
+					model.Files[file.Path] = string(file.Data)
+				}
 			}
+
 			ctx, cancel := newContext()
 			defer cancel()
+			if validateOnly {
+				result, err := modelregistry.ValidateModelRemote(ctx, conn, model)
+				if err != nil {
+					return err
+				}
+				for _, diagnostic := range result.Diagnostics {
+					println(diagnostic.String())
+				}
+				if !result.Valid {
+					return fmt.Errorf("model '%s@%s' is not valid", name, version)
+				}
+				return nil
+			}
+			if tryout {
+				result, err := modelregistry.TryModelRemote(ctx, client, model)
+				if result != nil {
+					bytes, err := json.MarshalIndent(result, "", "  ")
+					if err != nil {
+						return err
+					}
+					println(string(bytes))
+				}
+				return err
+			}
+
+			for _, path := range testConfigFiles {
+				data, err := ioutil.ReadFile(path)
+				if err != nil {
+					return err
+				}
+				modelregistry.AddTestConfigs(model, data)
+			}
+			if compress {
+				files, err := modelregistry.EncodeFiles(model.Files, configmodel.GzipEncoding)
+				if err != nil {
+					return err
+				}
+				model.Files = files
+				ctx = modelregistry.WithFileEncoding(ctx, configmodel.GzipEncoding)
+			}
+			request := &configmodelapi.PushModelRequest{
+				Model: model,
+			}
+			if skipCompile {
+				ctx = modelregistry.WithSkipCompile(ctx)
+			}
+			if dryRun {
+				ctx = modelregistry.WithDryRun(ctx)
+			}
+			if force {
+				ctx = modelregistry.WithForce(ctx)
+			}
+			if priority != "" {
+				ctx = modelregistry.WithPriority(ctx, modelregistry.Priority(priority))
+			}
+			if len(labels) > 0 {
+				ctx = modelregistry.WithLabels(ctx, labels)
+			}
+			ctx = modelregistry.WithFeatures(ctx, features...)
+			ctx = modelregistry.WithDeviations(ctx, deviations...)
+			if platform != "" {
+				p, err := pluginmodule.ParsePlatform(platform)
+				if err != nil {
+					return err
+				}
+				ctx = modelregistry.WithPlatform(ctx, p)
+			}
+			if progress {
+				return modelregistry.PushModelStream(ctx, conn, model, func(event modelregistry.PushEvent) {
+					if event.Message != "" {
+						fmt.Printf("%s: %s\n", event.Phase, event.Message)
+					} else {
+						fmt.Println(event.Phase)
+					}
+					if event.Stderr != "" {
+						fmt.Print(event.Stderr)
+					}
+				})
+			}
 			_, err = client.PushModel(ctx, request)
 			return err
 		},
@@ -353,6 +1256,20 @@ func getRegistryPushCmd() *cobra.Command {
 	cmd.Flags().StringP("revision", "r", "", "the model revision")
 	cmd.Flags().StringSliceP("file", "f", []string{}, "model files")
 	cmd.Flags().StringToStringP("module", "m", map[string]string{}, "model module descriptors")
+	cmd.Flags().String("yang-dir", "", "a directory tree of YANG files whose modules are added to the model")
+	cmd.Flags().Bool("skip-compile", false, "register the model only if its plugin is already cached")
+	cmd.Flags().StringSlice("test-config", []string{}, "sample config files that must be valid for the model")
+	cmd.Flags().Bool("validate-only", false, "check the model's YANG files without adding it to the registry")
+	cmd.Flags().Bool("tryout", false, "compile and load the model's plugin without adding it to the registry")
+	cmd.Flags().Bool("dry-run", false, "generate the model's plugin module and YANG bindings to catch schema errors without building the plugin or adding the model to the registry")
+	cmd.Flags().Bool("progress", false, "wait for the model's plugin to compile, printing its progress")
+	cmd.Flags().Bool("force", false, "replace the model if it already exists, swapping in its plugin once compiled")
+	cmd.Flags().String("priority", "", "the priority of the model's compilation when the compile queue is busy (low, normal or high)")
+	cmd.Flags().StringToStringP("label", "l", map[string]string{}, "labels used to select the model, e.g. vendor=cisco")
+	cmd.Flags().String("platform", "", "the goos/goarch platform of a prebuilt plugin pushed with --skip-compile, e.g. linux/arm64 (defaults to the registry's)")
+	cmd.Flags().StringSlice("feature", []string{}, "a YANG feature enabled in the model (all features are enabled if none are given)")
+	cmd.Flags().StringSlice("deviation", []string{}, "the name of a model module that deviates the model's other modules")
+	cmd.Flags().Bool("compress", false, "send the model's YANG files gzip compressed")
 	return cmd
 }
 
@@ -365,40 +1282,707 @@ func getRegistryDeleteCmd() *cobra.Command {
 			address, _ := cmd.Flags().GetString("address")
 			name, _ := cmd.Flags().GetString("name")
 			version, _ := cmd.Flags().GetString("version")
+			force, _ := cmd.Flags().GetBool("force")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
 			conn, err := connect(address)
 			if err != nil {
 				return err
 			}
 			defer conn.Close()
 			client := configmodelapi.NewConfigModelRegistryServiceClient(conn)
-			request := &configmodelapi.DeleteModelRequest{
-				Name:    name,
-				Version: version,
+			ctx, cancel := newContext()
+			defer cancel()
+			if force {
+				ctx = modelregistry.WithForce(ctx)
+			}
+			if dryRun {
+				ctx = modelregistry.WithDryRun(ctx)
+			}
+			files, err := modelregistry.DeleteModel(ctx, client, configmodel.Name(name), configmodel.Version(version))
+			if err != nil {
+				return err
+			}
+			if dryRun {
+				for _, file := range files {
+					println(file)
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringP("address", "a", "localhost:5151", "the registry address")
+	cmd.Flags().StringP("name", "n", "", "the model name")
+	cmd.Flags().StringP("version", "v", "", "the model version")
+	cmd.Flags().Bool("force", false, "delete the model even if it's pinned")
+	cmd.Flags().Bool("dry-run", false, "list the files that would be removed without deleting the model")
+	return cmd
+}
+
+func getRegistryCapabilitiesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "capabilities",
+		Short:        "List the capabilities of the registry server",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			address, _ := cmd.Flags().GetString("address")
+			conn, err := connect(address)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+			client := configmodelapi.NewConfigModelRegistryServiceClient(conn)
+			ctx, cancel := newContext()
+			defer cancel()
+			capabilities, err := modelregistry.GetCapabilities(ctx, client)
+			if err != nil {
+				return err
+			}
+			for _, capability := range capabilities {
+				println(capability)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringP("address", "a", "localhost:5151", "the registry address")
+	return cmd
+}
+
+func getRegistryPinCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "pin",
+		Short:        "Pin a model in the registry to protect it from deletion",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			registryPath, _ := cmd.Flags().GetString("registry-path")
+			name, _ := cmd.Flags().GetString("name")
+			version, _ := cmd.Flags().GetString("version")
+			registry := modelregistry.NewConfigModelRegistry(modelregistry.Config{
+				Path: registryPath,
+			})
+			// Lock the registry so the descriptor isn't rewritten concurrently by a server
+			if err := registry.Lock(false); err != nil {
+				return err
+			}
+			defer registry.Unlock()
+			return registry.PinModel(configmodel.Name(name), configmodel.Version(version))
+		},
+	}
+	cmd.Flags().String("registry-path", defaultRegistryPath, "the path in which the registry models are stored")
+	cmd.Flags().StringP("name", "n", "", "the model name")
+	cmd.Flags().StringP("version", "v", "", "the model version")
+	return cmd
+}
+
+func getRegistryUnpinCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "unpin",
+		Short:        "Unpin a model in the registry",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			registryPath, _ := cmd.Flags().GetString("registry-path")
+			name, _ := cmd.Flags().GetString("name")
+			version, _ := cmd.Flags().GetString("version")
+			registry := modelregistry.NewConfigModelRegistry(modelregistry.Config{
+				Path: registryPath,
+			})
+			// Lock the registry so the descriptor isn't rewritten concurrently by a server
+			if err := registry.Lock(false); err != nil {
+				return err
+			}
+			defer registry.Unlock()
+			return registry.UnpinModel(configmodel.Name(name), configmodel.Version(version))
+		},
+	}
+	cmd.Flags().String("registry-path", defaultRegistryPath, "the path in which the registry models are stored")
+	cmd.Flags().StringP("name", "n", "", "the model name")
+	cmd.Flags().StringP("version", "v", "", "the model version")
+	return cmd
+}
+
+func getRegistryHistoryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "history",
+		Short:        "Get the compile history for a model in the registry",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			address, _ := cmd.Flags().GetString("address")
+			name, _ := cmd.Flags().GetString("name")
+			version, _ := cmd.Flags().GetString("version")
+			conn, err := connect(address)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			ctx, cancel := newContext()
+			defer cancel()
+			history, err := modelregistry.GetCompileHistoryRemote(ctx, conn, configmodel.Name(name), configmodel.Version(version))
+			if err != nil {
+				return err
+			}
+			bytes, err := json.MarshalIndent(history, "", "  ")
+			if err != nil {
+				return err
+			}
+			println(string(bytes))
+			return nil
+		},
+	}
+	cmd.Flags().StringP("address", "a", "localhost:5151", "the registry address")
+	cmd.Flags().StringP("name", "n", "", "the model name")
+	cmd.Flags().StringP("version", "v", "", "the model version")
+	return cmd
+}
+
+func getRegistryStatePathsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "state-paths",
+		Short:        "List the read-only paths for a model in the registry",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			registryPath, _ := cmd.Flags().GetString("registry-path")
+			name, _ := cmd.Flags().GetString("name")
+			version, _ := cmd.Flags().GetString("version")
+			registry := modelregistry.NewConfigModelRegistry(modelregistry.Config{
+				Path: registryPath,
+			})
+			paths, err := registry.GetModelStatePaths(configmodel.Name(name), configmodel.Version(version))
+			if err != nil {
+				return err
+			}
+			bytes, err := json.MarshalIndent(paths, "", "  ")
+			if err != nil {
+				return err
+			}
+			println(string(bytes))
+			return nil
+		},
+	}
+	cmd.Flags().String("registry-path", defaultRegistryPath, "the path in which the registry models are stored")
+	cmd.Flags().StringP("name", "n", "", "the model name")
+	cmd.Flags().StringP("version", "v", "", "the model version")
+	return cmd
+}
+
+func getRegistryValidateConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "validate-config",
+		Short:        "Validate a JSON configuration against a model in the registry",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			address, _ := cmd.Flags().GetString("address")
+			name, _ := cmd.Flags().GetString("name")
+			version, _ := cmd.Flags().GetString("version")
+			configFile, _ := cmd.Flags().GetString("config")
+			config, err := ioutil.ReadFile(configFile)
+			if err != nil {
+				return err
+			}
+			conn, err := connect(address)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			ctx, cancel := newContext()
+			defer cancel()
+			result, err := modelregistry.ValidateConfig(ctx, conn, configmodel.Name(name), configmodel.Version(version), config)
+			if err != nil {
+				return err
+			}
+			for _, problem := range result.Errors {
+				println(problem)
+			}
+			if !result.Valid {
+				return fmt.Errorf("configuration is not valid for model '%s@%s'", name, version)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringP("address", "a", "localhost:5151", "the registry address")
+	cmd.Flags().StringP("name", "n", "", "the model name")
+	cmd.Flags().StringP("version", "v", "", "the model version")
+	cmd.Flags().StringP("config", "c", "", "the path to the JSON configuration to validate")
+	return cmd
+}
+
+func getRegistryDiffCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "diff",
+		Short:        "Compare the schemas of two versions of a model in the registry",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			registryPath, _ := cmd.Flags().GetString("registry-path")
+			name, _ := cmd.Flags().GetString("name")
+			from, _ := cmd.Flags().GetString("from")
+			to, _ := cmd.Flags().GetString("to")
+			output, _ := cmd.Flags().GetString("output")
+			registry := modelregistry.NewConfigModelRegistry(modelregistry.Config{
+				Path: registryPath,
+			})
+			diff, err := registry.GetModelDiff(configmodel.Name(name), configmodel.Version(from), configmodel.Version(to))
+			if err != nil {
+				return err
 			}
+			switch output {
+			case "json":
+				bytes, err := json.MarshalIndent(diff, "", "  ")
+				if err != nil {
+					return err
+				}
+				println(string(bytes))
+			case "text":
+				for _, path := range diff.Removed {
+					fmt.Printf("- %s: %s\n", path.Path, path.Node)
+				}
+				for _, path := range diff.Added {
+					fmt.Printf("+ %s: %s\n", path.Path, path.Node)
+				}
+				for _, change := range diff.Changed {
+					fmt.Printf("~ %s: %s -> %s\n", change.Path, change.From, change.To)
+				}
+			default:
+				return errors.New("output format must be one of 'text' or 'json'")
+			}
+			return nil
+		},
+	}
+	cmd.Flags().String("registry-path", defaultRegistryPath, "the path in which the registry models are stored")
+	cmd.Flags().StringP("name", "n", "", "the model name")
+	cmd.Flags().String("from", "", "the model version to compare from")
+	cmd.Flags().String("to", "", "the model version to compare to")
+	cmd.Flags().StringP("output", "o", "text", "the output format: 'text' or 'json'")
+	return cmd
+}
+
+func getRegistryExportOpenAPICmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "export-openapi",
+		Short:        "Export an OpenAPI spec describing the config tree of a model in the registry",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			registryPath, _ := cmd.Flags().GetString("registry-path")
+			name, _ := cmd.Flags().GetString("name")
+			version, _ := cmd.Flags().GetString("version")
+			registry := modelregistry.NewConfigModelRegistry(modelregistry.Config{
+				Path: registryPath,
+			})
+			spec, err := registry.GetModelOpenAPI(configmodel.Name(name), configmodel.Version(version))
+			if err != nil {
+				return err
+			}
+			bytes, err := json.MarshalIndent(spec, "", "  ")
+			if err != nil {
+				return err
+			}
+			println(string(bytes))
+			return nil
+		},
+	}
+	cmd.Flags().String("registry-path", defaultRegistryPath, "the path in which the registry models are stored")
+	cmd.Flags().StringP("name", "n", "", "the model name")
+	cmd.Flags().StringP("version", "v", "", "the model version")
+	return cmd
+}
+
+func getRegistrySchemaCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "schema",
+		Short:        "Print a JSON Schema describing the config tree of a model loaded from its cached plugin",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cachePath, _ := cmd.Flags().GetString("cache-path")
+			modPath, _ := cmd.Flags().GetString("mod-path")
+			modTargets, _ := cmd.Flags().GetStringArray("mod-target")
+			modReplaces, _ := cmd.Flags().GetStringArray("mod-replace")
+			name, _ := cmd.Flags().GetString("name")
+			version, _ := cmd.Flags().GetString("version")
+
+			targets, err := pluginmodule.ParseTargets(modTargets, modReplaces)
+			if err != nil {
+				return err
+			}
+			resolver := pluginmodule.NewResolver(pluginmodule.ResolverConfig{
+				Path:    modPath,
+				Targets: targets,
+			})
+			cache, err := plugincache.NewPluginCache(plugincache.CacheConfig{
+				Path: cachePath,
+			}, resolver)
+			if err != nil {
+				return err
+			}
+
+			entry := cache.Entry(configmodel.Name(name), configmodel.Version(version))
 			ctx, cancel := newContext()
 			defer cancel()
-			_, err = client.DeleteModel(ctx, request)
+			if err := entry.RLock(ctx); err != nil {
+				return err
+			}
+			plugin, err := entry.Load()
+			if err := entry.RUnlock(ctx); err != nil {
+				return err
+			}
+			if err != nil {
+				return err
+			}
+			schema, err := modelopenapi.GetJSONSchema(plugin.Model())
+			if err != nil {
+				return err
+			}
+			bytes, err := json.MarshalIndent(schema, "", "  ")
+			if err != nil {
+				return err
+			}
+			println(string(bytes))
+			return nil
+		},
+	}
+	cmd.Flags().String("cache-path", defaultCachePath, "the path in which the plugins are stored")
+	cmd.Flags().String("mod-path", defaultModPath, "the path in which the module info is stored")
+	cmd.Flags().StringArrayP("mod-target", "t", []string{}, "a target Go module (may be repeated to merge multiple modules)")
+	cmd.Flags().StringArrayP("mod-replace", "r", []string{}, "the replace Go module for the target module at the same position")
+	cmd.Flags().StringP("name", "n", "", "the model name")
+	cmd.Flags().StringP("version", "v", "", "the model version")
+	return cmd
+}
+
+func getRegistryExportK8sCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "export-k8s",
+		Short:        "Export the models in the registry as Kubernetes manifests",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			registryPath, _ := cmd.Flags().GetString("registry-path")
+			out, _ := cmd.Flags().GetString("out")
+			apiVersion, _ := cmd.Flags().GetString("api-version")
+			kind, _ := cmd.Flags().GetString("kind")
+			namespace, _ := cmd.Flags().GetString("namespace")
+			includeFiles, _ := cmd.Flags().GetBool("include-files")
+			registry := modelregistry.NewConfigModelRegistry(modelregistry.Config{
+				Path: registryPath,
+			})
+			paths, err := modelregistry.ExportManifests(registry, out, modelregistry.ManifestConfig{
+				APIVersion:   apiVersion,
+				Kind:         kind,
+				Namespace:    namespace,
+				IncludeFiles: includeFiles,
+			})
+			for _, path := range paths {
+				println(path)
+			}
 			return err
 		},
 	}
+	cmd.Flags().String("registry-path", defaultRegistryPath, "the path in which the registry models are stored")
+	cmd.Flags().StringP("out", "o", ".", "the directory to which to write a manifest for each model")
+	cmd.Flags().String("api-version", "", "the API version of the resources (defaults to v1 for ConfigMaps)")
+	cmd.Flags().String("kind", "ConfigMap", "the kind of the resources, e.g. a custom resource kind")
+	cmd.Flags().String("namespace", "", "the namespace of the resources")
+	cmd.Flags().Bool("include-files", false, "include the models' YANG files in the resources")
+	return cmd
+}
+
+func getRegistryImportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "import",
+		Short:        "Push the models described by a directory of descriptors to the registry",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			address, _ := cmd.Flags().GetString("address")
+			dir, _ := cmd.Flags().GetString("dir")
+			overwrite, _ := cmd.Flags().GetBool("overwrite")
+			conn, err := connect(address)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			client := configmodelapi.NewConfigModelRegistryServiceClient(conn)
+			ctx, cancel := newContext()
+			defer cancel()
+			result, err := modelregistry.ImportModels(ctx, client, dir, overwrite)
+			if err != nil {
+				return err
+			}
+			bytes, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return err
+			}
+			println(string(bytes))
+			if len(result.Failed) > 0 {
+				return fmt.Errorf("failed to import %d descriptor(s) from '%s'", len(result.Failed), dir)
+			}
+			return nil
+		},
+	}
 	cmd.Flags().StringP("address", "a", "localhost:5151", "the registry address")
+	cmd.Flags().String("dir", ".", "the directory of model descriptors to import")
+	cmd.Flags().Bool("overwrite", false, "replace models that already exist in the registry")
+	return cmd
+}
+
+func getRegistryExportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "export",
+		Short:        "Export the descriptors of the models in the registry to a directory",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			registryPath, _ := cmd.Flags().GetString("registry-path")
+			dir, _ := cmd.Flags().GetString("dir")
+			registry := modelregistry.NewConfigModelRegistry(modelregistry.Config{
+				Path: registryPath,
+			})
+			paths, err := modelregistry.ExportModels(registry, dir)
+			for _, path := range paths {
+				println(path)
+			}
+			return err
+		},
+	}
+	cmd.Flags().String("registry-path", defaultRegistryPath, "the path in which the registry models are stored")
+	cmd.Flags().String("dir", ".", "the directory to which to write a descriptor for each model")
+	return cmd
+}
+
+func getRegistryDepsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "deps",
+		Short:        "List the Go modules the plugin for a model in the registry depends on",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			registryPath, _ := cmd.Flags().GetString("registry-path")
+			buildPath, _ := cmd.Flags().GetString("build-path")
+			modPath, _ := cmd.Flags().GetString("mod-path")
+			modTargets, _ := cmd.Flags().GetStringArray("mod-target")
+			modReplaces, _ := cmd.Flags().GetStringArray("mod-replace")
+			modulePathPrefix, _ := cmd.Flags().GetString("module-path-prefix")
+			modFile, _ := cmd.Flags().GetString("mod-file")
+			sumFile, _ := cmd.Flags().GetString("sum-file")
+			generatorFlags, _ := cmd.Flags().GetStringArray("generator-flag")
+			name, _ := cmd.Flags().GetString("name")
+			version, _ := cmd.Flags().GetString("version")
+			offline, _ := cmd.Flags().GetBool("offline")
+			offlineEnv, _ := cmd.Flags().GetStringArray("offline-env")
+			fetchRetries, _ := cmd.Flags().GetInt("mod-fetch-retries")
+			fetchRetryDelay, _ := cmd.Flags().GetDuration("mod-fetch-retry-delay")
+			vendorDir, _ := cmd.Flags().GetString("mod-vendor-dir")
+			goBinary, _ := cmd.Flags().GetString("go-binary")
+
+			registry := modelregistry.NewConfigModelRegistry(modelregistry.Config{
+				Path: registryPath,
+			})
+			model, err := registry.GetModel(configmodel.Name(name), configmodel.Version(version))
+			if err != nil {
+				return err
+			}
+
+			targets, err := pluginmodule.ParseTargets(modTargets, modReplaces)
+			if err != nil {
+				return err
+			}
+			resolver := pluginmodule.NewResolver(pluginmodule.ResolverConfig{
+				Path:            modPath,
+				Targets:         targets,
+				Offline:         offline,
+				OfflineEnv:      getOfflineEnv(offlineEnv),
+				FetchRetries:    fetchRetries,
+				FetchRetryDelay: fetchRetryDelay,
+				VendorDir:       vendorDir,
+			})
+			extraReplaces, err := getExtraReplaces(cmd)
+			if err != nil {
+				return err
+			}
+			compiler := plugincompiler.NewPluginCompiler(plugincompiler.CompilerConfig{
+				BuildPath:        buildPath,
+				ModulePathPrefix: modulePathPrefix,
+				ModFile:          modFile,
+				SumFile:          sumFile,
+				GeneratorFlags:   generatorFlags,
+				Offline:          offline,
+				OfflineEnv:       getOfflineEnv(offlineEnv),
+				GoBinary:         goBinary,
+				ExtraReplaces:    extraReplaces,
+			}, resolver)
+			deps, err := compiler.ResolveDependencies(model)
+			if err != nil {
+				return err
+			}
+			for _, dep := range deps {
+				println(dep)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().String("registry-path", defaultRegistryPath, "the path in which the registry models are stored")
+	cmd.Flags().String("build-path", defaultBuildPath, "the path in which to store temporary build artifacts")
+	cmd.Flags().String("mod-path", defaultModPath, "the path in which the module info is stored")
+	cmd.Flags().StringArrayP("mod-target", "t", []string{}, "a target Go module (may be repeated to merge multiple modules)")
+	cmd.Flags().StringArrayP("mod-replace", "r", []string{}, "the replace Go module for the target module at the same position")
+	cmd.Flags().String("module-path-prefix", "", "the Go module path prefix for compiled plugins")
+	cmd.Flags().String("mod-file", "", "a go.mod to use verbatim for compiled plugins")
+	cmd.Flags().String("sum-file", "", "a go.sum to use verbatim with the --mod-file")
+	cmd.Flags().StringArray("generator-flag", []string{}, "an additional ygot generator flag, e.g. -compress_paths")
 	cmd.Flags().StringP("name", "n", "", "the model name")
 	cmd.Flags().StringP("version", "v", "", "the model version")
+	addGoBinaryFlag(cmd)
+	addExtraReplaceFlag(cmd)
+	addOfflineFlags(cmd)
+	addFetchRetryFlags(cmd)
+	addVendorDirFlag(cmd)
 	return cmd
 }
 
-func connect(address string) (*grpc.ClientConn, error) {
-	cert, err := tls.X509KeyPair([]byte(certs.DefaultClientCrt), []byte(certs.DefaultClientKey))
-	if err != nil {
-		return nil, err
+func getRegistryOrphansCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "orphans",
+		Short:        "List cached plugins and models in the registry with no counterpart",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			registryPath, _ := cmd.Flags().GetString("registry-path")
+			cachePath, _ := cmd.Flags().GetString("cache-path")
+			modPath, _ := cmd.Flags().GetString("mod-path")
+			modTargets, _ := cmd.Flags().GetStringArray("mod-target")
+			modReplaces, _ := cmd.Flags().GetStringArray("mod-replace")
+
+			targets, err := pluginmodule.ParseTargets(modTargets, modReplaces)
+			if err != nil {
+				return err
+			}
+			resolver := pluginmodule.NewResolver(pluginmodule.ResolverConfig{
+				Path:    modPath,
+				Targets: targets,
+			})
+			cache, err := plugincache.NewPluginCache(plugincache.CacheConfig{
+				Path: cachePath,
+			}, resolver)
+			if err != nil {
+				return err
+			}
+			registry := modelregistry.NewConfigModelRegistry(modelregistry.Config{
+				Path: registryPath,
+			})
+
+			orphans, err := modelregistry.GetOrphans(registry, cache)
+			if err != nil {
+				return err
+			}
+			bytes, err := json.MarshalIndent(orphans, "", "  ")
+			if err != nil {
+				return err
+			}
+			println(string(bytes))
+			return nil
+		},
+	}
+	cmd.Flags().String("registry-path", defaultRegistryPath, "the path in which the registry models are stored")
+	cmd.Flags().String("cache-path", defaultCachePath, "the path in which the plugins are stored")
+	cmd.Flags().String("mod-path", defaultModPath, "the path in which the module info is stored")
+	cmd.Flags().StringArrayP("mod-target", "t", []string{}, "a target Go module (may be repeated to merge multiple modules)")
+	cmd.Flags().StringArrayP("mod-replace", "r", []string{}, "the replace Go module for the target module at the same position")
+	return cmd
+}
+
+func getRegistryVerifyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "verify",
+		Short:        "Verify that each model in the registry has a valid cached plugin",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			address, _ := cmd.Flags().GetString("address")
+			repair, _ := cmd.Flags().GetBool("repair")
+			conn, err := connect(address)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			ctx, cancel := newContext()
+			defer cancel()
+			report, err := modelregistry.VerifyRegistry(ctx, conn, repair)
+			if err != nil {
+				return err
+			}
+			bytes, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return err
+			}
+			println(string(bytes))
+			if !report.IsConsistent() {
+				return errors.New("registry and plugin cache are not consistent")
+			}
+			return nil
+		},
 	}
-	tlsConfig := &tls.Config{
-		Certificates:       []tls.Certificate{cert},
-		InsecureSkipVerify: true,
+	cmd.Flags().StringP("address", "a", "localhost:5151", "the registry address")
+	cmd.Flags().Bool("repair", false, "recompile missing, stale and unloadable plugins and remove orphaned plugin and lock files")
+	return cmd
+}
+
+const (
+	jsonOutput  = "json"
+	yamlOutput  = "yaml"
+	tableOutput = "table"
+)
+
+func addOutputFlag(cmd *cobra.Command) {
+	cmd.Flags().StringP("output", "o", jsonOutput, "the output format: json, yaml or table")
+}
+
+func checkOutputFormat(output string) error {
+	switch output {
+	case jsonOutput, yamlOutput, tableOutput:
+		return nil
+	}
+	return fmt.Errorf("unknown output format '%s'", output)
+}
+
+// printModels prints the given models in the given output format
+// YAML is converted from the JSON encoding so both formats use the same field names.
+func printModels(output string, models ...configmodel.ModelInfo) error {
+	switch output {
+	case yamlOutput:
+		for i, model := range models {
+			bytes, err := json.Marshal(model)
+			if err != nil {
+				return err
+			}
+			var value yaml.MapSlice
+			if err := yaml.Unmarshal(bytes, &value); err != nil {
+				return err
+			}
+			bytes, err = yaml.Marshal(value)
+			if err != nil {
+				return err
+			}
+			if i > 0 {
+				fmt.Println("---")
+			}
+			fmt.Print(string(bytes))
+		}
+		return nil
+	case tableOutput:
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "NAME\tVERSION\tMODULES")
+		for _, model := range models {
+			fmt.Fprintf(writer, "%s\t%s\t%d\n", model.Name, model.Version, len(model.Modules))
+		}
+		return writer.Flush()
+	default:
+		for _, model := range models {
+			bytes, err := json.MarshalIndent(model, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(bytes))
+		}
+		return nil
 	}
+}
 
-	// Connect to the first matching service
-	return grpc.Dial(address, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+func connect(address string) (*grpc.ClientConn, error) {
+	return modelregistry.Connect(address)
 }
 
 func newContext() (context.Context, context.CancelFunc) {
@@ -3,14 +3,19 @@ module github.com/onosproject/onos-config-model
 go 1.16
 
 require (
+	github.com/gogo/protobuf v1.3.2
 	github.com/onosproject/onos-api/go v0.7.110
 	github.com/onosproject/onos-lib-go v0.7.22
 	github.com/openconfig/gnmi v0.0.0-20210914185457-51254b657b7d
 	github.com/openconfig/goyang v0.3.1
 	github.com/openconfig/ygot v0.12.4
+	github.com/prometheus/client_golang v0.9.3
+	github.com/prometheus/client_model v0.0.0-20190812154241-14fe0d1b01d4
 	github.com/rogpeppe/go-internal v1.3.0
 	github.com/spf13/cobra v1.2.1
 	github.com/stretchr/testify v1.7.0
+	go.uber.org/zap v1.17.0
 	google.golang.org/grpc v1.38.0
 	google.golang.org/protobuf v1.26.0
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package configmodel
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"sort"
+)
+
+// ComputeChecksum computes a SHA-256 checksum of the model definition
+// The checksum covers the model's YANG files, modules, features, deviations and plugin, but not metadata
+// such as the model's labels, plugin artifacts or the build info recorded by the registry. Files and modules are sorted, so the checksum does not
+// depend on the order in which they were provided. Compressed files must be decompressed.
+func (m ModelInfo) ComputeChecksum() string {
+	h := sha256.New()
+	WriteChecksumField(h, string(m.Name))
+	WriteChecksumField(h, string(m.Version))
+	WriteChecksumField(h, string(m.GetStateMode))
+
+	files := make([]FileInfo, len(m.Files))
+	copy(files, m.Files)
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	for _, file := range files {
+		WriteChecksumField(h, file.Path)
+		WriteChecksumField(h, string(file.Data))
+	}
+
+	writeChecksumModules(h, m.Modules)
+	features := make([]string, len(m.Features))
+	copy(features, m.Features)
+	sort.Strings(features)
+	WriteChecksumField(h, fmt.Sprint(len(features)))
+	for _, feature := range features {
+		WriteChecksumField(h, feature)
+	}
+	writeChecksumModules(h, m.Deviations)
+
+	WriteChecksumField(h, string(m.Plugin.Name))
+	WriteChecksumField(h, string(m.Plugin.Version))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func writeChecksumModules(h hash.Hash, modules []ModuleInfo) {
+	sorted := make([]ModuleInfo, len(modules))
+	copy(sorted, modules)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Name == sorted[j].Name {
+			return sorted[i].Revision < sorted[j].Revision
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	WriteChecksumField(h, fmt.Sprint(len(sorted)))
+	for _, module := range sorted {
+		WriteChecksumField(h, string(module.Name))
+		WriteChecksumField(h, module.File)
+		WriteChecksumField(h, module.Organization)
+		WriteChecksumField(h, string(module.Revision))
+	}
+}
+
+// WriteChecksumField writes a length-prefixed field to the hash to keep adjacent fields distinct
+// Other checksums derived from models, e.g. the compiler's build cache keys, write their fields in the same way.
+func WriteChecksumField(h hash.Hash, value string) {
+	_, _ = fmt.Fprintf(h, "%d:%s", len(value), value)
+}
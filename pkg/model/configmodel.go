@@ -9,6 +9,7 @@ import (
 	"github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/openconfig/goyang/pkg/yang"
 	"github.com/openconfig/ygot/ygot"
+	"sort"
 )
 
 // Name is a config model name
@@ -46,7 +47,15 @@ type ModelInfo struct {
 	GetStateMode GetStateMode `json:"getStateMode"`
 	Files        []FileInfo   `json:"files"`
 	Modules      []ModuleInfo `json:"modules"`
+	Features     []string     `json:"features,omitempty"`
+	Deviations   []ModuleInfo `json:"deviations,omitempty"`
 	Plugin       PluginInfo   `json:"plugin"`
+	Pinned       bool         `json:"pinned,omitempty"`
+	Build        *BuildInfo   `json:"build,omitempty"`
+	// Checksum is the checksum of the model definition computed by ComputeChecksum
+	Checksum string `json:"checksum,omitempty"`
+	// Labels are arbitrary key/value pairs used to group and select models, e.g. by vendor or environment
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
 func (m ModelInfo) String() string {
@@ -58,19 +67,79 @@ type ModuleInfo struct {
 	Name         Name     `json:"name"`
 	File         string   `json:"file"`
 	Organization string   `json:"organization"`
+	Contact      string   `json:"contact,omitempty"`
 	Revision     Revision `json:"revision"`
+	Namespace    string   `json:"namespace,omitempty"`
+	Prefix       string   `json:"prefix,omitempty"`
+	BelongsTo    Name     `json:"belongsTo,omitempty"`
+}
+
+// IsSubmodule returns whether the module is a submodule of another module
+func (m ModuleInfo) IsSubmodule() bool {
+	return m.BelongsTo != ""
 }
 
 // FileInfo is a config file info
 type FileInfo struct {
 	Path string `json:"path"`
 	Data []byte `json:"data"`
+	// Encoding is the encoding of the file data
+	Encoding FileEncoding `json:"encoding,omitempty"`
 }
 
 // PluginInfo is config model plugin info
 type PluginInfo struct {
 	Name    Name    `json:"name"`
 	Version Version `json:"version"`
+	// Artifacts are the plugin binaries stored for the model, at most one per platform
+	// Models added before artifacts were recorded have no artifacts, though their plugin may be cached.
+	Artifacts []PluginArtifact `json:"artifacts,omitempty"`
+}
+
+// GetArtifact returns the plugin artifact for the given platform
+func (p PluginInfo) GetArtifact(goos, goarch string) (PluginArtifact, bool) {
+	for _, artifact := range p.Artifacts {
+		if artifact.GOOS == goos && artifact.GOARCH == goarch {
+			return artifact, true
+		}
+	}
+	return PluginArtifact{}, false
+}
+
+// SetArtifact adds the given artifact, replacing any artifact for the same platform
+func (p *PluginInfo) SetArtifact(artifact PluginArtifact) {
+	for i, existing := range p.Artifacts {
+		if existing.GOOS == artifact.GOOS && existing.GOARCH == artifact.GOARCH {
+			p.Artifacts[i] = artifact
+			return
+		}
+	}
+	p.Artifacts = append(p.Artifacts, artifact)
+	sort.Slice(p.Artifacts, func(i, j int) bool {
+		return p.Artifacts[i].String() < p.Artifacts[j].String()
+	})
+}
+
+// PluginArtifact is a plugin binary built for a platform
+type PluginArtifact struct {
+	GOOS   string `json:"goos"`
+	GOARCH string `json:"goarch"`
+}
+
+func (a PluginArtifact) String() string {
+	return fmt.Sprintf("%s/%s", a.GOOS, a.GOARCH)
+}
+
+// BuildInfo is the environment in which a model plugin was built
+// A plugin can only be loaded by a binary built with the same Go version and dependency versions.
+type BuildInfo struct {
+	GoVersion       string `json:"goVersion"`
+	CompilerVersion string `json:"compilerVersion"`
+	GOOS            string `json:"goos"`
+	GOARCH          string `json:"goarch"`
+	CGOEnabled      bool   `json:"cgoEnabled"`
+	// Dependencies is a map of key dependency module paths to versions
+	Dependencies map[string]string `json:"dependencies,omitempty"`
 }
 
 // ConfigModel is a configuration model data
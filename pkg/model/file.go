@@ -0,0 +1,80 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package configmodel
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"io/ioutil"
+)
+
+// gzipMagic is the header identifying gzip compressed data
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// FileEncoding is the encoding of a file's data
+type FileEncoding string
+
+const (
+	// IdentityEncoding indicates the file data is not encoded
+	IdentityEncoding FileEncoding = ""
+	// GzipEncoding indicates the file data is gzip compressed
+	GzipEncoding FileEncoding = "gzip"
+)
+
+// ParseFileEncoding parses a file encoding, accepting 'identity' for the IdentityEncoding
+func ParseFileEncoding(value string) (FileEncoding, error) {
+	switch FileEncoding(value) {
+	case IdentityEncoding, "identity":
+		return IdentityEncoding, nil
+	case GzipEncoding:
+		return GzipEncoding, nil
+	}
+	return IdentityEncoding, errors.NewInvalid("unknown file encoding '%s'", value)
+}
+
+// IsCompressed returns whether the file data is gzip compressed
+// Files compressed before their encoding was recorded are detected by the gzip header.
+func (f FileInfo) IsCompressed() bool {
+	return f.Encoding == GzipEncoding || bytes.HasPrefix(f.Data, gzipMagic)
+}
+
+// Compress returns a copy of the file with gzip compressed data
+func (f FileInfo) Compress() (FileInfo, error) {
+	if f.IsCompressed() {
+		f.Encoding = GzipEncoding
+		return f, nil
+	}
+	buf := &bytes.Buffer{}
+	writer := gzip.NewWriter(buf)
+	if _, err := writer.Write(f.Data); err != nil {
+		return f, err
+	}
+	if err := writer.Close(); err != nil {
+		return f, err
+	}
+	f.Data = buf.Bytes()
+	f.Encoding = GzipEncoding
+	return f, nil
+}
+
+// Decompress returns a copy of the file with its data decompressed if it's gzip compressed
+func (f FileInfo) Decompress() (FileInfo, error) {
+	if !f.IsCompressed() {
+		return f, nil
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(f.Data))
+	if err != nil {
+		return f, err
+	}
+	defer reader.Close()
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return f, err
+	}
+	f.Data = data
+	f.Encoding = IdentityEncoding
+	return f, nil
+}
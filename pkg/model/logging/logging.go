@@ -0,0 +1,263 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modellogging
+
+import (
+	"fmt"
+	"github.com/onosproject/onos-lib-go/pkg/logging"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// Structured logging fields shared by the packages of the module
+const (
+	// ModelField is the name of the model a log line is about
+	ModelField = "model"
+	// VersionField is the version of the model a log line is about
+	VersionField = "version"
+	// PhaseField is the phase of a model's compilation a log line is about
+	PhaseField = "phase"
+	// DurationField is the duration of the operation a log line is about
+	DurationField = "duration"
+)
+
+// Format is a log output format
+type Format string
+
+const (
+	// ConsoleFormat logs through the onos-lib-go loggers, which use the console encoding unless configured otherwise
+	ConsoleFormat Format = "console"
+	// JSONFormat logs JSON encoded lines to stdout
+	JSONFormat Format = "json"
+)
+
+// ParseFormat parses a log format
+func ParseFormat(value string) (Format, error) {
+	switch Format(value) {
+	case ConsoleFormat:
+		return ConsoleFormat, nil
+	case JSONFormat:
+		return JSONFormat, nil
+	}
+	return "", fmt.Errorf("unknown log format '%s'", value)
+}
+
+var (
+	loggers       = make(map[string]*Logger)
+	currentFormat = ConsoleFormat
+	writer        = zapcore.AddSync(os.Stdout)
+	mu            sync.Mutex
+)
+
+// GetLogger gets a logger by name
+// The onos-lib-go logging configuration is read once when the process starts, so its encoding cannot be
+// changed by flags. Loggers returned by GetLogger log through the onos-lib-go logger of the same name
+// until SetFormat changes their format.
+func GetLogger(names ...string) *Logger {
+	return getLogger(logging.GetLogger(names...))
+}
+
+func getLogger(logger logging.Logger) *Logger {
+	mu.Lock()
+	defer mu.Unlock()
+	if l, ok := loggers[logger.Name()]; ok {
+		return l
+	}
+	l := &Logger{Logger: logger}
+	l.setFormat(currentFormat)
+	loggers[logger.Name()] = l
+	return l
+}
+
+// SetFormat sets the output format of all loggers
+func SetFormat(format Format) {
+	mu.Lock()
+	defer mu.Unlock()
+	currentFormat = format
+	for _, logger := range loggers {
+		logger.setFormat(format)
+	}
+}
+
+// Logger is a logger whose output format can be set with SetFormat
+// Levels are always those of the underlying onos-lib-go logger, so they can still be changed at runtime.
+type Logger struct {
+	logging.Logger
+	output atomic.Value
+}
+
+type loggerOutput struct {
+	logging.Output
+}
+
+// GetLogger gets a descendant of this logger
+func (l *Logger) GetLogger(names ...string) logging.Logger {
+	return getLogger(l.Logger.GetLogger(names...))
+}
+
+func (l *Logger) setFormat(format Format) {
+	switch format {
+	case JSONFormat:
+		l.output.Store(loggerOutput{newJSONOutput(l)})
+	default:
+		l.output.Store(loggerOutput{l.Logger})
+	}
+}
+
+func (l *Logger) getOutput() logging.Output {
+	return l.output.Load().(loggerOutput).Output
+}
+
+// newJSONOutput returns a JSON encoded output for the given logger
+// The encoder is configured as the onos-lib-go encoders are, so JSON lines match those of the JSON sinks.
+func newJSONOutput(logger *Logger) logging.Output {
+	config := zapcore.EncoderConfig{
+		NameKey:        "logger",
+		MessageKey:     "message",
+		LevelKey:       "level",
+		TimeKey:        "timestamp",
+		CallerKey:      "caller",
+		StacktraceKey:  "trace",
+		EncodeName:     zapcore.FullNameEncoder,
+		EncodeTime:     zapcore.ISO8601TimeEncoder,
+		EncodeDuration: zapcore.NanosDurationEncoder,
+		EncodeLevel:    zapcore.CapitalLevelEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+	}
+	enabled := zap.LevelEnablerFunc(func(level zapcore.Level) bool {
+		return level >= getZapLevel(logger.Logger.GetLevel())
+	})
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(config), writer, enabled)
+	return zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1)).Named(logger.Name()).Sugar()
+}
+
+// getZapLevel returns the zap level for the given onos-lib-go level
+func getZapLevel(level logging.Level) zapcore.Level {
+	switch level {
+	case logging.DebugLevel:
+		return zapcore.DebugLevel
+	case logging.WarnLevel:
+		return zapcore.WarnLevel
+	case logging.ErrorLevel:
+		return zapcore.ErrorLevel
+	case logging.FatalLevel:
+		return zapcore.FatalLevel
+	case logging.PanicLevel:
+		return zapcore.PanicLevel
+	case logging.DPanicLevel:
+		return zapcore.DPanicLevel
+	}
+	return zapcore.InfoLevel
+}
+
+// Debug :
+func (l *Logger) Debug(args ...interface{}) {
+	l.getOutput().Debug(args...)
+}
+
+// Debugf :
+func (l *Logger) Debugf(template string, args ...interface{}) {
+	l.getOutput().Debugf(template, args...)
+}
+
+// Debugw :
+func (l *Logger) Debugw(msg string, keysAndValues ...interface{}) {
+	l.getOutput().Debugw(msg, keysAndValues...)
+}
+
+// Info :
+func (l *Logger) Info(args ...interface{}) {
+	l.getOutput().Info(args...)
+}
+
+// Infof :
+func (l *Logger) Infof(template string, args ...interface{}) {
+	l.getOutput().Infof(template, args...)
+}
+
+// Infow :
+func (l *Logger) Infow(msg string, keysAndValues ...interface{}) {
+	l.getOutput().Infow(msg, keysAndValues...)
+}
+
+// Warn :
+func (l *Logger) Warn(args ...interface{}) {
+	l.getOutput().Warn(args...)
+}
+
+// Warnf :
+func (l *Logger) Warnf(template string, args ...interface{}) {
+	l.getOutput().Warnf(template, args...)
+}
+
+// Warnw :
+func (l *Logger) Warnw(msg string, keysAndValues ...interface{}) {
+	l.getOutput().Warnw(msg, keysAndValues...)
+}
+
+// Error :
+func (l *Logger) Error(args ...interface{}) {
+	l.getOutput().Error(args...)
+}
+
+// Errorf :
+func (l *Logger) Errorf(template string, args ...interface{}) {
+	l.getOutput().Errorf(template, args...)
+}
+
+// Errorw :
+func (l *Logger) Errorw(msg string, keysAndValues ...interface{}) {
+	l.getOutput().Errorw(msg, keysAndValues...)
+}
+
+// Fatal :
+func (l *Logger) Fatal(args ...interface{}) {
+	l.getOutput().Fatal(args...)
+}
+
+// Fatalf :
+func (l *Logger) Fatalf(template string, args ...interface{}) {
+	l.getOutput().Fatalf(template, args...)
+}
+
+// Fatalw :
+func (l *Logger) Fatalw(msg string, keysAndValues ...interface{}) {
+	l.getOutput().Fatalw(msg, keysAndValues...)
+}
+
+// Panic :
+func (l *Logger) Panic(args ...interface{}) {
+	l.getOutput().Panic(args...)
+}
+
+// Panicf :
+func (l *Logger) Panicf(template string, args ...interface{}) {
+	l.getOutput().Panicf(template, args...)
+}
+
+// Panicw :
+func (l *Logger) Panicw(msg string, keysAndValues ...interface{}) {
+	l.getOutput().Panicw(msg, keysAndValues...)
+}
+
+// DPanic :
+func (l *Logger) DPanic(args ...interface{}) {
+	l.getOutput().DPanic(args...)
+}
+
+// DPanicf :
+func (l *Logger) DPanicf(template string, args ...interface{}) {
+	l.getOutput().DPanicf(template, args...)
+}
+
+// DPanicw :
+func (l *Logger) DPanicw(msg string, keysAndValues ...interface{}) {
+	l.getOutput().DPanicw(msg, keysAndValues...)
+}
+
+var _ logging.Logger = &Logger{}
@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modellogging
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/onosproject/onos-lib-go/pkg/logging"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat("json")
+	assert.NoError(t, err)
+	assert.Equal(t, JSONFormat, format)
+	format, err = ParseFormat("console")
+	assert.NoError(t, err)
+	assert.Equal(t, ConsoleFormat, format)
+	_, err = ParseFormat("xml")
+	assert.Error(t, err)
+}
+
+func TestSetFormat(t *testing.T) {
+	buf := &bytes.Buffer{}
+	writer = zapcore.AddSync(buf)
+	defer func() {
+		SetFormat(ConsoleFormat)
+	}()
+
+	log := GetLogger("config-model", "test")
+	assert.Same(t, log, GetLogger("config-model", "test"))
+	assert.Same(t, log, GetLogger("config-model").GetLogger("test"))
+
+	log.Infow("Compiled model", ModelField, "foo", VersionField, "1.0.0", DurationField, time.Second)
+	assert.Empty(t, buf.String())
+
+	SetFormat(JSONFormat)
+	log.Infow("Compiled model", ModelField, "foo", VersionField, "1.0.0", DurationField, time.Second)
+	var line map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "Compiled model", line["message"])
+	assert.Equal(t, "config-model/test", line["logger"])
+	assert.Equal(t, "INFO", line["level"])
+	assert.Equal(t, "foo", line[ModelField])
+	assert.Equal(t, "1.0.0", line[VersionField])
+	assert.Equal(t, float64(time.Second), line[DurationField])
+	assert.True(t, strings.HasPrefix(line["caller"].(string), "logging/logging_test.go"))
+
+	// Loggers created after the format is set use the format
+	buf.Reset()
+	GetLogger("config-model", "other").Warnf("Model '%s' is pinned", "foo")
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "Model 'foo' is pinned", line["message"])
+
+	// Levels are those of the onos-lib-go loggers
+	buf.Reset()
+	log.Debug("debug")
+	assert.Empty(t, buf.String())
+	log.SetLevel(logging.DebugLevel)
+	log.Debug("debug")
+	assert.NotEmpty(t, buf.String())
+}
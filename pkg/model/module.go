@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package configmodel
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/openconfig/goyang/pkg/yang"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// NewModelInfo creates the info for a model defined by the given YANG files, keyed by file name
+// Each file's module name, organization and newest revision are read from its module or submodule
+// statement. The compiler identifies files by their base names, so files are stored under their base
+// names, which must be unique. Files that cannot be parsed are reported together, one per line.
+func NewModelInfo(name, version string, files map[string][]byte) (ModelInfo, error) {
+	if name == "" || version == "" {
+		return ModelInfo{}, errors.NewInvalid("model name and version are required")
+	}
+	if len(files) == 0 {
+		return ModelInfo{}, errors.NewInvalid("model '%s@%s' has no YANG files", name, version)
+	}
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	modelInfo := ModelInfo{
+		Name:         Name(name),
+		Version:      Version(version),
+		GetStateMode: GetStateNone,
+		Plugin: PluginInfo{
+			Name:    Name(name),
+			Version: Version(version),
+		},
+	}
+	var problems []string
+	names := make(map[string]string)
+	for _, path := range paths {
+		base := filepath.Base(path)
+		if other, ok := names[base]; ok {
+			return ModelInfo{}, errors.NewInvalid("'%s' and '%s' have the same file name", other, path)
+		}
+		names[base] = path
+
+		file, err := FileInfo{Path: base, Data: files[path]}.Decompress()
+		if err != nil {
+			problems = append(problems, errors.NewInvalid("%s: %s", path, err).Error())
+			continue
+		}
+		module, err := ParseModuleInfo(path, file.Data)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		module.File = base
+		modelInfo.Modules = append(modelInfo.Modules, module)
+		modelInfo.Files = append(modelInfo.Files, FileInfo{
+			Path: base,
+			Data: files[path],
+		})
+	}
+	if len(problems) > 0 {
+		return ModelInfo{}, errors.NewInvalid("failed to parse YANG files of model '%s@%s':\n%s", name, version, strings.Join(problems, "\n"))
+	}
+	return modelInfo, nil
+}
+
+// ParseModuleInfo parses the given YANG file, returning the module it defines
+func ParseModuleInfo(path string, data []byte) (ModuleInfo, error) {
+	statements, err := yang.Parse(string(data), path)
+	if err != nil {
+		// Parse errors are already prefixed with the file's path and the location of the error
+		return ModuleInfo{}, errors.NewInvalid("%s", strings.TrimSpace(err.Error()))
+	}
+	for _, statement := range statements {
+		if statement.Keyword != "module" && statement.Keyword != "submodule" {
+			continue
+		}
+		module := ModuleInfo{
+			Name: Name(statement.Argument),
+		}
+		for _, child := range statement.SubStatements() {
+			switch child.Keyword {
+			case "organization":
+				module.Organization = child.Argument
+			case "contact":
+				module.Contact = child.Argument
+			case "revision":
+				// Revisions are compared as dates, so the newest is used regardless of their order
+				if revision := Revision(child.Argument); revision > module.Revision {
+					module.Revision = revision
+				}
+			}
+		}
+		return module, nil
+	}
+	return ModuleInfo{}, errors.NewInvalid("%s: no module or submodule statement", path)
+}
@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package configmodel
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewModelInfo(t *testing.T) {
+	test, err := FileInfo{Data: []byte(`module test {
+  namespace "http://opennetworking.org/test";
+  prefix t;
+  organization "ONF";
+  contact "onos-dev@onosproject.org";
+
+  revision 2021-06-01;
+  revision 2020-01-01;
+}
+`)}.Compress()
+	assert.NoError(t, err)
+	child := []byte(`submodule child {
+  belongs-to test { prefix t; }
+}
+`)
+
+	modelInfo, err := NewModelInfo("test", "1.0.0", map[string][]byte{
+		"yang/test.yang": test.Data,
+		"child.yang":     child,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, Name("test"), modelInfo.Name)
+	assert.Equal(t, Version("1.0.0"), modelInfo.Version)
+	assert.Equal(t, GetStateNone, modelInfo.GetStateMode)
+	assert.Equal(t, Name("test"), modelInfo.Plugin.Name)
+	assert.Equal(t, []ModuleInfo{
+		{
+			Name: "child",
+			File: "child.yang",
+		},
+		{
+			Name:         "test",
+			File:         "test.yang",
+			Organization: "ONF",
+			Contact:      "onos-dev@onosproject.org",
+			Revision:     "2021-06-01",
+		},
+	}, modelInfo.Modules)
+	assert.Equal(t, []FileInfo{
+		{Path: "child.yang", Data: child},
+		{Path: "test.yang", Data: test.Data},
+	}, modelInfo.Files)
+
+	// Each file that fails to parse is reported
+	_, err = NewModelInfo("test", "1.0.0", map[string][]byte{
+		"broken.yang": []byte("module broken {\n"),
+		"empty.yang":  nil,
+	})
+	assert.True(t, errors.IsInvalid(err))
+	assert.Contains(t, err.Error(), "broken.yang")
+	assert.Contains(t, err.Error(), "empty.yang: no module or submodule statement")
+
+	// Files are identified by name, so names must be unique
+	_, err = NewModelInfo("test", "1.0.0", map[string][]byte{
+		"a/test.yang": child,
+		"b/test.yang": child,
+	})
+	assert.True(t, errors.IsInvalid(err))
+
+	_, err = NewModelInfo("test", "1.0.0", nil)
+	assert.True(t, errors.IsInvalid(err))
+	_, err = NewModelInfo("", "1.0.0", map[string][]byte{"child.yang": child})
+	assert.True(t, errors.IsInvalid(err))
+}
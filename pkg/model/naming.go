@@ -0,0 +1,42 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package configmodel
+
+import (
+	"fmt"
+	"strings"
+)
+
+// fileNameSeparator separates the escaped name and version in file names
+const fileNameSeparator = "-"
+
+// GetFileName returns the base name, without extension, of the files stored for the given model
+// Names and versions are escaped as Go module paths are escaped in the module cache: upper case letters
+// are replaced by an exclamation mark followed by the lower case letter, so names differing only in case
+// don't collide on case-insensitive filesystems. Module paths must contain a dot, so model names cannot be
+// escaped with module.EncodePath; they're escaped here in the same way. Other characters that aren't valid
+// in file names on all filesystems are percent encoded, as are dashes in versions, so the last dash in a
+// file name always separates the name from the version, e.g. 'openconfig-interfaces-1.0.0'.
+func GetFileName(name Name, version Version) string {
+	return escapeFileName(string(name), true) + fileNameSeparator + escapeFileName(string(version), false)
+}
+
+// escapeFileName escapes the given name or version for use in file names
+func escapeFileName(s string, allowDash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z':
+			b.WriteByte('!')
+			b.WriteByte(c + 'a' - 'A')
+		case 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '.', c == '_', c == '+', c == '-' && allowDash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
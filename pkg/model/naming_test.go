@@ -0,0 +1,30 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package configmodel
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGetFileName(t *testing.T) {
+	// Names and versions that are valid file names are unchanged
+	assert.Equal(t, "test-1.0.0", GetFileName("test", "1.0.0"))
+	assert.Equal(t, "openconfig-interfaces-1.0.0", GetFileName("openconfig-interfaces", "1.0.0"))
+	assert.Equal(t, "openconfig_interfaces-2.0.0+build.1", GetFileName("openconfig_interfaces", "2.0.0+build.1"))
+
+	// Upper case letters are escaped so names differing in case don't collide on case-insensitive filesystems
+	assert.Equal(t, "!test_!model-1.0.0", GetFileName("Test_Model", "1.0.0"))
+	assert.NotEqual(t, GetFileName("Test", "1.0.0"), GetFileName("test", "1.0.0"))
+
+	// Characters that aren't valid in file names are encoded
+	assert.Equal(t, "a%2Fb-1.0.0", GetFileName("a/b", "1.0.0"))
+	assert.Equal(t, "a%3Ab-1%2A", GetFileName("a:b", "1*"))
+	assert.Equal(t, "%21a-1", GetFileName("!a", "1"))
+
+	// Dashes in versions are encoded so the last dash always separates the name from the version
+	assert.Equal(t, "openconfig-interfaces-1.0.0%2Drc1", GetFileName("openconfig-interfaces", "1.0.0-rc1"))
+	assert.NotEqual(t, GetFileName("a-1", "0"), GetFileName("a", "1-0"))
+}
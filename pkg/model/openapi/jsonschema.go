@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelopenapi
+
+import (
+	"fmt"
+	"github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/openconfig/goyang/pkg/yang"
+	"strings"
+)
+
+const jsonSchemaVersion = "http://json-schema.org/draft-07/schema#"
+
+// JSONSchema is a JSON Schema document describing the config tree of a model
+// Unlike an OpenAPI document, the tree is described by a single schema in which nested schemas are inlined.
+type JSONSchema struct {
+	SchemaVersion string `json:"$schema"`
+	Title         string `json:"title"`
+	*Schema
+}
+
+// GetJSONSchema returns a JSON Schema document describing the schema of the given model
+// The schema's fake root entry is the root of the config tree.
+func GetJSONSchema(model configmodel.ConfigModel) (*JSONSchema, error) {
+	root, err := getRoot(model)
+	if err != nil {
+		return nil, err
+	}
+	return NewJSONSchema(model.Info(), root), nil
+}
+
+// NewJSONSchema returns a JSON Schema document describing the config tree under the given roots
+// The children of all roots are properties of the document's root object, so roots are typically modules.
+func NewJSONSchema(model configmodel.ModelInfo, roots ...*yang.Entry) *JSONSchema {
+	builder := &specBuilder{
+		roots: roots,
+	}
+	schema := &Schema{
+		Type:        "object",
+		Description: fmt.Sprintf("Config tree of model '%s'", model),
+		Properties:  map[string]*Schema{},
+	}
+	for _, root := range roots {
+		builder.addProperties(root, schema)
+	}
+	return &JSONSchema{
+		SchemaVersion: jsonSchemaVersion,
+		Title:         string(model.Name),
+		Schema:        schema,
+	}
+}
+
+// getObjectSchema returns the schema for the given container or list item with its children inlined
+func (b *specBuilder) getObjectSchema(entry *yang.Entry) *Schema {
+	schema := &Schema{
+		Type:        "object",
+		Description: entry.Description,
+		Properties:  map[string]*Schema{},
+		ReadOnly:    entry.ReadOnly(),
+	}
+	b.addProperties(entry, schema)
+	return schema
+}
+
+// addProperties adds the schemas for the children of the given entry to the properties of the given schema
+func (b *specBuilder) addProperties(entry *yang.Entry, schema *Schema) {
+	for _, child := range getChildren(entry) {
+		switch {
+		case child.IsList():
+			// List items are identified by their keys, so the keys are required
+			keys := strings.Fields(child.Key)
+			item := b.getObjectSchema(child)
+			item.Description = ""
+			item.Required = append(append([]string{}, keys...), item.Required...)
+			schema.Properties[child.Name] = &Schema{
+				Type:        "array",
+				Description: child.Description,
+				Items:       item,
+				ReadOnly:    child.ReadOnly(),
+				Key:         keys,
+			}
+		case child.IsDir():
+			schema.Properties[child.Name] = b.getObjectSchema(child)
+		case child.IsLeafList():
+			schema.Properties[child.Name] = &Schema{
+				Type:        "array",
+				Description: child.Description,
+				Items:       b.getLeafSchema(child),
+				ReadOnly:    child.ReadOnly(),
+			}
+		default:
+			schema.Properties[child.Name] = b.getLeafSchema(child)
+			if child.Mandatory.Value() {
+				schema.Required = append(schema.Required, child.Name)
+			}
+		}
+	}
+}
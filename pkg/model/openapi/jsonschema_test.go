@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelopenapi
+
+import (
+	"encoding/json"
+	"github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewJSONSchema(t *testing.T) {
+	schema := NewJSONSchema(configmodel.ModelInfo{Name: "test", Version: "1.0.0"}, loadTestModule(t))
+	assert.Equal(t, "http://json-schema.org/draft-07/schema#", schema.SchemaVersion)
+	assert.Equal(t, "test", schema.Title)
+	assert.Equal(t, "object", schema.Type)
+
+	// Containers are inlined objects
+	system := schema.Properties["system"]
+	assert.Equal(t, "object", system.Type)
+	assert.Equal(t, "The system", system.Description)
+	assert.Equal(t, []string{"hostname"}, system.Required)
+
+	// Leaves are typed properties
+	assert.Equal(t, "string", system.Properties["hostname"].Type)
+	assert.Equal(t, float64(9000), *system.Properties["mtu"].Maximum)
+	assert.Equal(t, []string{"active", "standby"}, system.Properties["mode"].Enum)
+	assert.Equal(t, []string{"tcp", "udp"}, system.Properties["protocol"].Enum)
+	assert.Equal(t, "string", system.Properties["servers"].Items.Type)
+	assert.Equal(t, "int64", system.Properties["port"].Format)
+
+	// Lists are arrays of items that require their keys
+	list := schema.Properties["interfaces"].Properties["interface"]
+	assert.Equal(t, "array", list.Type)
+	assert.Equal(t, []string{"name"}, list.Key)
+	iface := list.Items
+	assert.Equal(t, "object", iface.Type)
+	assert.Equal(t, []string{"name"}, iface.Required)
+	assert.Equal(t, "string", iface.Properties["peer"].Type)
+	sublist := iface.Properties["subinterface"]
+	assert.Equal(t, []string{"name"}, sublist.Key)
+	assert.Equal(t, "integer", sublist.Items.Properties["name"].Type)
+	assert.Equal(t, "string", sublist.Items.Properties["parent"].Type)
+
+	// Read-only nodes are marked as such
+	state := iface.Properties["state"]
+	assert.True(t, state.ReadOnly)
+	assert.True(t, state.Properties["counter"].ReadOnly)
+
+	bytes, err := json.Marshal(schema)
+	assert.NoError(t, err)
+	var document map[string]interface{}
+	assert.NoError(t, json.Unmarshal(bytes, &document))
+	assert.Equal(t, "http://json-schema.org/draft-07/schema#", document["$schema"])
+	assert.Equal(t, "object", document["type"])
+	assert.Contains(t, document["properties"], "system")
+}
+
+func TestGetJSONSchema(t *testing.T) {
+	module := loadTestModule(t)
+	root := &yang.Entry{
+		Name:       "device",
+		Kind:       yang.DirectoryEntry,
+		Dir:        module.Dir,
+		Annotation: map[string]interface{}{fakeRootKey: true},
+	}
+	schema, err := GetJSONSchema(testModel{schema: map[string]*yang.Entry{
+		"Device": root,
+		"System": module.Dir["system"],
+	}})
+	assert.NoError(t, err)
+	assert.Contains(t, schema.Properties, "system")
+	assert.Contains(t, schema.Properties, "interfaces")
+
+	_, err = GetJSONSchema(testModel{schema: map[string]*yang.Entry{
+		"System": module.Dir["system"],
+	}})
+	assert.Error(t, err)
+}
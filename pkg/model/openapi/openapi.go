@@ -0,0 +1,540 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelopenapi
+
+import (
+	"fmt"
+	"github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/openconfig/goyang/pkg/yang"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	openAPIVersion  = "3.0.3"
+	jsonContentType = "application/json"
+	schemaRefPrefix = "#/components/schemas/"
+	fakeRootKey     = "isFakeRoot"
+)
+
+// Spec is an OpenAPI 3 document
+type Spec struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+// Info is the metadata of an OpenAPI document
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// Components holds the reusable schemas referenced by an OpenAPI document
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// PathItem is the set of operations on a resource
+type PathItem struct {
+	Parameters []*Parameter `json:"parameters,omitempty"`
+	Get        *Operation   `json:"get,omitempty"`
+	Put        *Operation   `json:"put,omitempty"`
+	Delete     *Operation   `json:"delete,omitempty"`
+}
+
+// Operation is an operation on a resource
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter is a resource path parameter
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody is the body of an operation's request
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response is an operation's response
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType is the content of a request or response body
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is an OpenAPI schema object
+type Schema struct {
+	Ref         string             `json:"$ref,omitempty"`
+	Type        string             `json:"type,omitempty"`
+	Format      string             `json:"format,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	Enum        []string           `json:"enum,omitempty"`
+	Minimum     *float64           `json:"minimum,omitempty"`
+	Maximum     *float64           `json:"maximum,omitempty"`
+	MinLength   *uint64            `json:"minLength,omitempty"`
+	MaxLength   *uint64            `json:"maxLength,omitempty"`
+	Pattern     string             `json:"pattern,omitempty"`
+	OneOf       []*Schema          `json:"oneOf,omitempty"`
+	ReadOnly    bool               `json:"readOnly,omitempty"`
+	// Leafref is the path referenced by a leafref leaf
+	Leafref string `json:"x-yang-leafref,omitempty"`
+	// Key is the names of the key leaves of a list's items
+	Key []string `json:"x-yang-key,omitempty"`
+}
+
+// GetSpec returns an OpenAPI document describing the schema of the given model
+// The schema's fake root entry is the root of the config tree.
+func GetSpec(model configmodel.ConfigModel) (*Spec, error) {
+	root, err := getRoot(model)
+	if err != nil {
+		return nil, err
+	}
+	return NewSpec(model.Info(), root), nil
+}
+
+// getRoot returns the fake root entry of the given model's schema
+func getRoot(model configmodel.ConfigModel) (*yang.Entry, error) {
+	schema, err := model.Schema()
+	if err != nil {
+		return nil, errors.NewInvalid("failed to load schema for model '%s': %s", model.Info(), err)
+	}
+	names := make([]string, 0, len(schema))
+	for name := range schema {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if entry := schema[name]; entry.Annotation[fakeRootKey] == true {
+			return entry, nil
+		}
+	}
+	return nil, errors.NewNotFound("schema for model '%s' has no root", model.Info())
+}
+
+// NewSpec returns an OpenAPI document describing the config tree under the given roots
+// Each root's children are the top level resources, so roots are typically modules.
+func NewSpec(model configmodel.ModelInfo, roots ...*yang.Entry) *Spec {
+	builder := &specBuilder{
+		spec: &Spec{
+			OpenAPI: openAPIVersion,
+			Info: Info{
+				Title:   string(model.Name),
+				Version: string(model.Version),
+			},
+			Paths: map[string]*PathItem{},
+			Components: Components{
+				Schemas: map[string]*Schema{},
+			},
+		},
+		roots:   roots,
+		schemas: map[*yang.Entry]string{},
+	}
+	for _, root := range roots {
+		builder.addChildren(root, resource{})
+	}
+	return builder.spec
+}
+
+// resource is the location of an entry in the resource tree
+type resource struct {
+	path       string
+	names      []string
+	parameters []*Parameter
+	readOnly   bool
+}
+
+// child returns the resource of the given child entry
+func (r resource) child(entry *yang.Entry) resource {
+	return resource{
+		path:       fmt.Sprintf("%s/%s", r.path, entry.Name),
+		names:      append(append([]string{}, r.names...), entry.Name),
+		parameters: r.parameters,
+		readOnly:   r.readOnly || entry.ReadOnly(),
+	}
+}
+
+// hasParameter returns whether the resource has a parameter with the given name
+func (r resource) hasParameter(name string) bool {
+	for _, parameter := range r.parameters {
+		if parameter.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+type specBuilder struct {
+	spec    *Spec
+	roots   []*yang.Entry
+	schemas map[*yang.Entry]string
+}
+
+// addChildren adds the resources and schema properties for the children of the given entry
+func (b *specBuilder) addChildren(entry *yang.Entry, parent resource) *Schema {
+	schema := &Schema{
+		Type:        "object",
+		Description: entry.Description,
+		Properties:  map[string]*Schema{},
+	}
+	for _, child := range getChildren(entry) {
+		switch {
+		case child.IsList():
+			schema.Properties[child.Name] = &Schema{
+				Type:  "array",
+				Items: b.addList(child, parent.child(child)),
+			}
+		case child.IsDir():
+			schema.Properties[child.Name] = b.addContainer(child, parent.child(child))
+		case child.IsLeafList():
+			schema.Properties[child.Name] = &Schema{
+				Type:        "array",
+				Description: child.Description,
+				Items:       b.getLeafSchema(child),
+				ReadOnly:    child.ReadOnly(),
+			}
+		default:
+			schema.Properties[child.Name] = b.getLeafSchema(child)
+			if child.Mandatory.Value() {
+				schema.Required = append(schema.Required, child.Name)
+			}
+		}
+	}
+	return schema
+}
+
+// addContainer adds the resource for the given container, returning a reference to its schema
+func (b *specBuilder) addContainer(entry *yang.Entry, r resource) *Schema {
+	name, ok := b.schemas[entry]
+	if ok {
+		return &Schema{Ref: schemaRefPrefix + name}
+	}
+	name = b.addSchemaName(entry, r)
+	b.spec.Components.Schemas[name] = b.addChildren(entry, r)
+	b.addPath(name, r, &Schema{Ref: schemaRefPrefix + name})
+	return &Schema{Ref: schemaRefPrefix + name}
+}
+
+// addList adds the collection and item resources for the given list, returning a reference to its item schema
+func (b *specBuilder) addList(entry *yang.Entry, r resource) *Schema {
+	name, ok := b.schemas[entry]
+	if ok {
+		return &Schema{Ref: schemaRefPrefix + name}
+	}
+	name = b.addSchemaName(entry, r)
+	ref := &Schema{Ref: schemaRefPrefix + name}
+	b.spec.Paths[r.path] = &PathItem{
+		Parameters: r.parameters,
+		Get:        newGetOperation(r, name+"-list", &Schema{Type: "array", Items: ref}),
+	}
+
+	item := r
+	item.parameters = append([]*Parameter{}, r.parameters...)
+	keys := strings.Fields(entry.Key)
+	for _, key := range keys {
+		param := key
+		if item.hasParameter(param) {
+			param = fmt.Sprintf("%s-%s", entry.Name, key)
+		}
+		item.path = fmt.Sprintf("%s/{%s}", item.path, param)
+		item.parameters = append(item.parameters, &Parameter{
+			Name:     param,
+			In:       "path",
+			Required: true,
+			Schema:   b.getLeafSchema(entry.Dir[key]),
+		})
+	}
+
+	schema := b.addChildren(entry, item)
+	schema.Required = append(keys, schema.Required...)
+	b.spec.Components.Schemas[name] = schema
+	b.addPath(name, item, ref)
+	return ref
+}
+
+// addSchemaName reserves a unique schema name for the given entry
+func (b *specBuilder) addSchemaName(entry *yang.Entry, r resource) string {
+	name := strings.Join(r.names, ".")
+	for i := 2; ; i++ {
+		if _, ok := b.spec.Components.Schemas[name]; !ok {
+			break
+		}
+		name = fmt.Sprintf("%s.%d", strings.Join(r.names, "."), i)
+	}
+	b.schemas[entry] = name
+	// Reserve the name before the schema is built so nested entries can't claim it
+	b.spec.Components.Schemas[name] = nil
+	return name
+}
+
+// addPath adds the operations for the given resource
+// Read-only resources can only be read.
+func (b *specBuilder) addPath(name string, r resource, schema *Schema) {
+	item := &PathItem{
+		Parameters: r.parameters,
+		Get:        newGetOperation(r, name, schema),
+	}
+	if !r.readOnly {
+		item.Put = &Operation{
+			OperationID: "put-" + name,
+			Summary:     fmt.Sprintf("Replaces %s", r.path),
+			RequestBody: &RequestBody{
+				Required: true,
+				Content: map[string]*MediaType{
+					jsonContentType: {Schema: schema},
+				},
+			},
+			Responses: map[string]*Response{
+				"204": {Description: "Replaced"},
+			},
+		}
+		item.Delete = &Operation{
+			OperationID: "delete-" + name,
+			Summary:     fmt.Sprintf("Deletes %s", r.path),
+			Responses: map[string]*Response{
+				"204": {Description: "Deleted"},
+				"404": {Description: "Not found"},
+			},
+		}
+	}
+	b.spec.Paths[r.path] = item
+}
+
+func newGetOperation(r resource, name string, schema *Schema) *Operation {
+	return &Operation{
+		OperationID: "get-" + name,
+		Summary:     fmt.Sprintf("Gets %s", r.path),
+		Responses: map[string]*Response{
+			"200": {
+				Description: "OK",
+				Content: map[string]*MediaType{
+					jsonContentType: {Schema: schema},
+				},
+			},
+			"404": {Description: "Not found"},
+		},
+	}
+}
+
+// getLeafSchema returns the schema for the type of the given leaf or leaf-list
+func (b *specBuilder) getLeafSchema(entry *yang.Entry) *Schema {
+	if entry == nil {
+		return &Schema{Type: "string"}
+	}
+	schema := b.getTypeSchema(entry, entry.Type, map[*yang.Entry]bool{})
+	schema.Description = entry.Description
+	schema.ReadOnly = entry.ReadOnly()
+	return schema
+}
+
+// getTypeSchema returns the schema for the given type of the given leaf
+// Leafrefs are resolved to the type of the referenced leaf, tracking the visited leaves to break cycles.
+func (b *specBuilder) getTypeSchema(entry *yang.Entry, t *yang.YangType, visited map[*yang.Entry]bool) *Schema {
+	if t == nil {
+		return &Schema{Type: "string"}
+	}
+	switch t.Kind {
+	case yang.Yint8, yang.Yint16, yang.Yint32, yang.Yuint8, yang.Yuint16:
+		return withRange(&Schema{Type: "integer", Format: "int32"}, t.Range)
+	case yang.Yint64, yang.Yuint32, yang.Yuint64:
+		return withRange(&Schema{Type: "integer", Format: "int64"}, t.Range)
+	case yang.Ydecimal64:
+		return withRange(&Schema{Type: "number", Format: "double"}, t.Range)
+	case yang.Ybool, yang.Yempty:
+		return &Schema{Type: "boolean"}
+	case yang.Ybinary:
+		return withLength(&Schema{Type: "string", Format: "byte"}, t.Length)
+	case yang.Yenum:
+		schema := &Schema{Type: "string"}
+		if t.Enum != nil {
+			schema.Enum = t.Enum.Names()
+		}
+		return schema
+	case yang.Yidentityref:
+		schema := &Schema{Type: "string"}
+		if t.IdentityBase != nil {
+			for _, identity := range t.IdentityBase.Values {
+				schema.Enum = append(schema.Enum, identity.Name)
+			}
+			sort.Strings(schema.Enum)
+		}
+		return schema
+	case yang.Yunion:
+		schema := &Schema{}
+		for _, member := range t.Type {
+			schema.OneOf = append(schema.OneOf, b.getTypeSchema(entry, member, visited))
+		}
+		return schema
+	case yang.Yleafref:
+		visited[entry] = true
+		if target := b.findLeafref(entry, t.Path); target != nil && !visited[target] {
+			schema := b.getTypeSchema(target, target.Type, visited)
+			schema.Leafref = t.Path
+			return schema
+		}
+		return &Schema{Type: "string", Leafref: t.Path}
+	case yang.Ystring:
+		schema := withLength(&Schema{Type: "string"}, t.Length)
+		if len(t.Pattern) == 1 {
+			schema.Pattern = t.Pattern[0]
+		}
+		return schema
+	default:
+		return &Schema{Type: "string"}
+	}
+}
+
+// findLeafref finds the leaf referenced by the given leafref path relative to the given leaf
+func (b *specBuilder) findLeafref(entry *yang.Entry, path string) *yang.Entry {
+	parts := strings.Split(removePredicates(path), "/")
+	if parts[0] == "" {
+		for _, root := range b.roots {
+			if target := findEntry(root, parts[1:]); target != nil {
+				return target
+			}
+		}
+		return nil
+	}
+	return findEntry(entry, parts)
+}
+
+// findEntry finds the data node at the given path relative to the given entry
+func findEntry(entry *yang.Entry, parts []string) *yang.Entry {
+	for _, part := range parts {
+		if entry == nil {
+			return nil
+		}
+		switch part {
+		case "", ".":
+		case "..":
+			entry = getDataParent(entry)
+		default:
+			if i := strings.Index(part, ":"); i >= 0 {
+				part = part[i+1:]
+			}
+			entry = findChild(entry, part)
+		}
+	}
+	return entry
+}
+
+// findChild finds the child data node of the given entry, looking through choices and cases
+func findChild(entry *yang.Entry, name string) *yang.Entry {
+	for _, child := range entry.Dir {
+		if child.IsChoice() || child.IsCase() {
+			if found := findChild(child, name); found != nil {
+				return found
+			}
+		} else if child.Name == name {
+			return child
+		}
+	}
+	return nil
+}
+
+// getDataParent returns the data node parent of the given entry, skipping choices and cases
+func getDataParent(entry *yang.Entry) *yang.Entry {
+	parent := entry.Parent
+	for parent != nil && (parent.IsChoice() || parent.IsCase()) {
+		parent = parent.Parent
+	}
+	return parent
+}
+
+// getChildren returns the data node children of the given entry sorted by name
+// Choice and case nodes do not appear in the data tree, so their children are returned in their place.
+func getChildren(entry *yang.Entry) []*yang.Entry {
+	var children []*yang.Entry
+	for _, child := range entry.Dir {
+		if child.IsChoice() || child.IsCase() {
+			children = append(children, getChildren(child)...)
+		} else {
+			children = append(children, child)
+		}
+	}
+	sort.Slice(children, func(i, j int) bool {
+		return children[i].Name < children[j].Name
+	})
+	return children
+}
+
+// removePredicates removes the predicates from the given path
+func removePredicates(path string) string {
+	var b strings.Builder
+	depth := 0
+	for _, c := range path {
+		switch {
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case depth == 0 && c != ' ':
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// withRange sets the minimum and maximum of the given schema from the given range
+func withRange(schema *Schema, r yang.YangRange) *Schema {
+	if len(r) == 0 {
+		return schema
+	}
+	schema.Minimum = getNumber(r[0].Min)
+	schema.Maximum = getNumber(r[len(r)-1].Max)
+	return schema
+}
+
+// withLength sets the minimum and maximum length of the given schema from the given length range
+func withLength(schema *Schema, r yang.YangRange) *Schema {
+	if len(r) == 0 {
+		return schema
+	}
+	if min := r[0].Min; min.Kind == yang.Positive && min.Value > 0 {
+		value := min.Value
+		schema.MinLength = &value
+	}
+	if max := r[len(r)-1].Max; max.Kind == yang.Positive && max.Value < math.MaxUint64 {
+		value := max.Value
+		schema.MaxLength = &value
+	}
+	return schema
+}
+
+// getNumber returns the value of the given range bound, or nil if it's unbounded
+func getNumber(n yang.Number) *float64 {
+	var value float64
+	switch n.Kind {
+	case yang.Positive:
+		value = float64(n.Value)
+	case yang.Negative:
+		value = -float64(n.Value)
+	default:
+		return nil
+	}
+	if n.FractionDigits > 0 {
+		value /= math.Pow10(int(n.FractionDigits))
+	}
+	return &value
+}
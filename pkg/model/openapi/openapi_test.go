@@ -0,0 +1,232 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelopenapi
+
+import (
+	"encoding/json"
+	"github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const testModule = `
+module test {
+  namespace "urn:test";
+  prefix t;
+
+  identity protocol;
+  identity tcp { base protocol; }
+  identity udp { base protocol; }
+
+  container system {
+    description "The system";
+    leaf hostname {
+      type string { length "1..64"; }
+      mandatory true;
+    }
+    leaf mtu {
+      type uint16 { range "68..9000"; }
+    }
+    leaf mode {
+      type enumeration {
+        enum active;
+        enum standby;
+      }
+    }
+    leaf protocol {
+      type identityref { base protocol; }
+    }
+    leaf address {
+      type union {
+        type int32;
+        type string;
+      }
+    }
+    leaf-list servers {
+      type string;
+    }
+    choice transport {
+      case remote {
+        leaf port { type uint32; }
+      }
+    }
+  }
+
+  container interfaces {
+    list interface {
+      key "name";
+      leaf name {
+        type string;
+      }
+      leaf peer {
+        type leafref {
+          path "../../interface/name";
+        }
+      }
+      leaf loop {
+        type leafref {
+          path "../loop";
+        }
+      }
+      list subinterface {
+        key "name";
+        leaf name {
+          type uint32;
+        }
+        leaf parent {
+          type leafref {
+            path "/t:interfaces/t:interface[t:name=current()/../../name]/t:name";
+          }
+        }
+      }
+      container state {
+        config false;
+        leaf counter {
+          type decimal64 { fraction-digits 2; range "-1.5..100"; }
+        }
+      }
+    }
+  }
+}
+`
+
+func loadTestModule(t *testing.T) *yang.Entry {
+	modules := yang.NewModules()
+	assert.NoError(t, modules.Parse(testModule, "test.yang"))
+	assert.Empty(t, modules.Process())
+	entry, errs := modules.GetModule("test")
+	assert.Empty(t, errs)
+	return entry
+}
+
+func TestNewSpec(t *testing.T) {
+	spec := NewSpec(configmodel.ModelInfo{Name: "test", Version: "1.0.0"}, loadTestModule(t))
+	assert.Equal(t, "3.0.3", spec.OpenAPI)
+	assert.Equal(t, "test", spec.Info.Title)
+	assert.Equal(t, "1.0.0", spec.Info.Version)
+
+	// Containers are resources whose schemas are objects
+	system := spec.Components.Schemas["system"]
+	assert.Equal(t, "object", system.Type)
+	assert.Equal(t, "The system", system.Description)
+	assert.Equal(t, []string{"hostname"}, system.Required)
+	assert.NotNil(t, spec.Paths["/system"].Get)
+	assert.NotNil(t, spec.Paths["/system"].Put)
+	assert.Equal(t, "#/components/schemas/system", spec.Paths["/system"].Put.RequestBody.Content[jsonContentType].Schema.Ref)
+
+	// Leaves are typed properties
+	hostname := system.Properties["hostname"]
+	assert.Equal(t, "string", hostname.Type)
+	assert.Equal(t, uint64(1), *hostname.MinLength)
+	assert.Equal(t, uint64(64), *hostname.MaxLength)
+	mtu := system.Properties["mtu"]
+	assert.Equal(t, "integer", mtu.Type)
+	assert.Equal(t, float64(68), *mtu.Minimum)
+	assert.Equal(t, float64(9000), *mtu.Maximum)
+	assert.Equal(t, []string{"active", "standby"}, system.Properties["mode"].Enum)
+	assert.Equal(t, []string{"tcp", "udp"}, system.Properties["protocol"].Enum)
+	assert.Len(t, system.Properties["address"].OneOf, 2)
+	assert.Equal(t, "array", system.Properties["servers"].Type)
+	assert.Equal(t, "string", system.Properties["servers"].Items.Type)
+
+	// Choices and cases are not part of the data tree
+	assert.Equal(t, "int64", system.Properties["port"].Format)
+
+	// Lists are collections of items addressed by their keys
+	assert.Equal(t, "array", spec.Components.Schemas["interfaces"].Properties["interface"].Type)
+	assert.Equal(t, "#/components/schemas/interfaces.interface", spec.Components.Schemas["interfaces"].Properties["interface"].Items.Ref)
+	assert.Equal(t, "array", spec.Paths["/interfaces/interface"].Get.Responses["200"].Content[jsonContentType].Schema.Type)
+	assert.Nil(t, spec.Paths["/interfaces/interface"].Put)
+	item := spec.Paths["/interfaces/interface/{name}"]
+	assert.NotNil(t, item.Put)
+	assert.Len(t, item.Parameters, 1)
+	assert.Equal(t, "name", item.Parameters[0].Name)
+	assert.Equal(t, "path", item.Parameters[0].In)
+	iface := spec.Components.Schemas["interfaces.interface"]
+	assert.Equal(t, []string{"name"}, iface.Required)
+
+	// Nested list keys with the same name are disambiguated
+	subitem := spec.Paths["/interfaces/interface/{name}/subinterface/{subinterface-name}"]
+	assert.NotNil(t, subitem)
+	assert.Len(t, subitem.Parameters, 2)
+	assert.Equal(t, "integer", subitem.Parameters[1].Schema.Type)
+
+	// Leafrefs have the type of the referenced leaf
+	assert.Equal(t, "string", iface.Properties["peer"].Type)
+	assert.Equal(t, "../../interface/name", iface.Properties["peer"].Leafref)
+	parent := spec.Components.Schemas["interfaces.interface.subinterface"].Properties["parent"]
+	assert.Equal(t, "string", parent.Type)
+	assert.Equal(t, "/t:interfaces/t:interface[t:name=current()/../../name]/t:name", parent.Leafref)
+
+	// Leafrefs that refer to themselves fall back to strings
+	assert.Equal(t, "string", iface.Properties["loop"].Type)
+
+	// Read-only resources can only be read
+	state := spec.Paths["/interfaces/interface/{name}/state"]
+	assert.NotNil(t, state.Get)
+	assert.Nil(t, state.Put)
+	assert.Nil(t, state.Delete)
+	counter := spec.Components.Schemas["interfaces.interface.state"].Properties["counter"]
+	assert.True(t, counter.ReadOnly)
+	assert.Equal(t, "number", counter.Type)
+	assert.Equal(t, -1.5, *counter.Minimum)
+	assert.Equal(t, float64(100), *counter.Maximum)
+
+	_, err := json.Marshal(spec)
+	assert.NoError(t, err)
+}
+
+type testModel struct {
+	schema map[string]*yang.Entry
+}
+
+func (m testModel) Info() configmodel.ModelInfo {
+	return configmodel.ModelInfo{Name: "test", Version: "1.0.0"}
+}
+
+func (m testModel) Data() []*gnmi.ModelData {
+	return nil
+}
+
+func (m testModel) Schema() (map[string]*yang.Entry, error) {
+	return m.schema, nil
+}
+
+func (m testModel) GetStateMode() configmodel.GetStateMode {
+	return configmodel.GetStateNone
+}
+
+func (m testModel) Unmarshaler() configmodel.Unmarshaler {
+	return nil
+}
+
+func (m testModel) Validator() configmodel.Validator {
+	return nil
+}
+
+func TestGetSpec(t *testing.T) {
+	module := loadTestModule(t)
+	root := &yang.Entry{
+		Name:       "device",
+		Kind:       yang.DirectoryEntry,
+		Dir:        module.Dir,
+		Annotation: map[string]interface{}{fakeRootKey: true},
+	}
+	spec, err := GetSpec(testModel{schema: map[string]*yang.Entry{
+		"Device": root,
+		"System": module.Dir["system"],
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, "test", spec.Info.Title)
+	assert.Contains(t, spec.Paths, "/system")
+	assert.Contains(t, spec.Paths, "/interfaces/interface/{name}")
+
+	_, err = GetSpec(testModel{schema: map[string]*yang.Entry{
+		"System": module.Dir["system"],
+	}})
+	assert.Error(t, err)
+}
@@ -6,26 +6,42 @@ package plugincache
 
 import (
 	"encoding/base64"
-	"fmt"
 	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	modellogging "github.com/onosproject/onos-config-model/pkg/model/logging"
 	pluginmodule "github.com/onosproject/onos-config-model/pkg/model/plugin/module"
-	"github.com/onosproject/onos-lib-go/pkg/logging"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 )
 
-var log = logging.GetLogger("config-model", "plugin", "cache")
+var log = modellogging.GetLogger("config-model", "plugin", "cache")
 
 const (
 	defaultPath      = "/etc/onos/plugins"
 	lockAttemptDelay = 5 * time.Second
+	pluginExt        = ".so"
+	lockExt          = ".lock"
+	swapExt          = ".swap"
+	detachExt        = ".detached"
+	// versionChecksumLen is the length of the checksum prefix that addresses plugin versions
+	versionChecksumLen = 16
 )
 
 // CacheConfig is a plugin cache configuration
 type CacheConfig struct {
 	Path string `yaml:"path" json:"path"`
+	// MaxSizeBytes is the maximum total size of the plugins in the cache
+	// If zero, the size of the cache is not limited.
+	MaxSizeBytes int64 `yaml:"maxSizeBytes" json:"maxSizeBytes"`
+	// MaxEntries is the maximum number of plugins in the cache
+	// If zero, the number of plugins in the cache is not limited.
+	MaxEntries int `yaml:"maxEntries" json:"maxEntries"`
+	// GOOS and GOARCH are the platform of the cached plugins, defaulting to the host's
+	// Plugins for other platforms are cached separately, so they're never served to hosts that cannot load them.
+	GOOS   string `yaml:"goos" json:"goos"`
+	GOARCH string `yaml:"goarch" json:"goarch"`
 }
 
 // NewPluginCache creates a new plugin cache
@@ -39,30 +55,66 @@ func NewPluginCache(config CacheConfig, resolver *pluginmodule.Resolver) (*Plugi
 		return nil, err
 	}
 
-	config.Path = filepath.Join(config.Path, base64.RawURLEncoding.EncodeToString(hash))
-	if _, err := os.Stat(config.Path); os.IsNotExist(err) {
-		if err := os.MkdirAll(config.Path, os.ModePerm); err != nil {
-			return nil, err
-		}
+	// The cache directory may be created concurrently by another process sharing the cache path
+	root := config.Path
+	platform := pluginmodule.NewPlatform(config.GOOS, config.GOARCH)
+	config.Path = getPlatformPath(root, hash, platform)
+	if err := os.MkdirAll(config.Path, os.ModePerm); err != nil && !os.IsExist(err) {
+		return nil, err
 	}
 	return &PluginCache{
 		Config:  config,
+		root:    root,
+		hash:    hash,
 		entries: make(map[string]*PluginEntry),
 	}, nil
 }
 
+// getPlatformPath returns the directory in which plugins for the given platform and module hash are cached
+func getPlatformPath(root string, hash []byte, platform pluginmodule.Platform) string {
+	return filepath.Join(root, base64.RawURLEncoding.EncodeToString(hash)+platform.Suffix())
+}
+
 // PluginCache is a model plugin cache
 type PluginCache struct {
 	Config  CacheConfig
+	root    string
+	hash    []byte
 	entries map[string]*PluginEntry
 	mu      sync.RWMutex
 }
 
+// Platform returns the platform of the plugins in the cache
+func (c *PluginCache) Platform() pluginmodule.Platform {
+	return pluginmodule.NewPlatform(c.Config.GOOS, c.Config.GOARCH)
+}
+
 // Entry returns the entry for the given plugin name+version
 func (c *PluginCache) Entry(name configmodel.Name, version configmodel.Version) *PluginEntry {
-	path := fmt.Sprintf("%s-%s", name, version)
+	return c.entry(c.Config.Path, getEntryKey(c.Config.Path, name, version))
+}
+
+// PlatformEntry returns the entry for the given plugin name+version built for the given platform
+// Plugins for other platforms are stored alongside the cache in the directory a cache configured for
+// the platform would use, so they can be built externally or by another registry.
+func (c *PluginCache) PlatformEntry(name configmodel.Name, version configmodel.Version, platform pluginmodule.Platform) (*PluginEntry, error) {
+	if platform == c.Platform() {
+		return c.Entry(name, version), nil
+	}
+	if err := platform.Validate(); err != nil {
+		return nil, err
+	}
+	path := getPlatformPath(c.root, c.hash, platform)
+	if err := os.MkdirAll(path, os.ModePerm); err != nil && !os.IsExist(err) {
+		return nil, err
+	}
+	return c.entry(path, getEntryKey(path, name, version)), nil
+}
+
+func (c *PluginCache) entry(path string, key string) *PluginEntry {
+	id := filepath.Join(path, key)
 	c.mu.RLock()
-	entry, ok := c.entries[path]
+	entry, ok := c.entries[id]
 	c.mu.RUnlock()
 	if ok {
 		return entry
@@ -71,12 +123,27 @@ func (c *PluginCache) Entry(name configmodel.Name, version configmodel.Version)
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	entry, ok = c.entries[path]
+	entry, ok = c.entries[id]
 	if ok {
 		return entry
 	}
 
-	entry = newPluginEntry(c.Config.Path, name, version)
-	c.entries[path] = entry
+	entry = newPluginEntry(path, key)
+	c.entries[id] = entry
 	return entry
 }
+
+// List lists the paths of the plugins in the cache
+func (c *PluginCache) List() ([]string, error) {
+	var paths []string
+	err := filepath.Walk(c.Config.Path, func(file string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && strings.HasSuffix(file, pluginExt) {
+			paths = append(paths, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
@@ -0,0 +1,270 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package plugincache
+
+import (
+	"context"
+	"github.com/onosproject/onos-config-model/pkg/model/plugin/module"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+const helperPathEnv = "CONFIG_MODEL_CACHE_TEST_PATH"
+
+// writeTestMod pre-populates the resolved module to avoid fetching the target module
+func writeTestMod(t *testing.T, path string) {
+	modPath := filepath.Join(path, "mod")
+	assert.NoError(t, os.MkdirAll(modPath, os.ModePerm))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(modPath, "go.mod"), []byte("module github.com/onosproject/onos-config\n"), 0666))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(modPath, "mod.md5"), []byte("test"), 0666))
+}
+
+func newTestCache(path string) (*PluginCache, error) {
+	resolver := pluginmodule.NewResolver(pluginmodule.ResolverConfig{
+		Path: filepath.Join(path, "mod"),
+	})
+	return NewPluginCache(CacheConfig{
+		Path: filepath.Join(path, "cache"),
+	}, resolver)
+}
+
+// TestCacheHelperProcess is run in a separate process by TestConcurrentCaches
+func TestCacheHelperProcess(t *testing.T) {
+	path := os.Getenv(helperPathEnv)
+	if path == "" {
+		t.Skip("helper process")
+	}
+	cache, err := newTestCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := cache.Entry("test", "1.0.0")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := entry.Lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer entry.Unlock(ctx)
+	cached, err := entry.Cached()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cached {
+		if err := ioutil.WriteFile(entry.Path, []byte(os.Args[0]), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestConcurrentCaches(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-model-cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeTestMod(t, dir)
+
+	// Initialize caches sharing the same path from separate processes
+	var cmds []*exec.Cmd
+	for i := 0; i < 2; i++ {
+		cmd := exec.Command(os.Args[0], "-test.run=TestCacheHelperProcess")
+		cmd.Env = append(os.Environ(), helperPathEnv+"="+dir)
+		assert.NoError(t, cmd.Start())
+		cmds = append(cmds, cmd)
+	}
+	for _, cmd := range cmds {
+		assert.NoError(t, cmd.Wait())
+	}
+
+	cache1, err := newTestCache(dir)
+	assert.NoError(t, err)
+	cache2, err := newTestCache(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, cache1.Config.Path, cache2.Config.Path)
+
+	paths, err := cache1.List()
+	assert.NoError(t, err)
+	assert.Len(t, paths, 1)
+
+	// Entries for the same plugin in separate instances exclude each other
+	entry1 := cache1.Entry("test", "1.0.0")
+	entry2 := cache2.Entry("test", "1.0.0")
+	assert.NoError(t, entry1.Lock(context.Background()))
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.Error(t, entry2.RLock(ctx))
+	assert.NoError(t, entry1.Unlock(context.Background()))
+	assert.NoError(t, entry2.RLock(context.Background()))
+	assert.NoError(t, entry2.RUnlock(context.Background()))
+}
+
+func TestSwapPlugin(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-model-cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	writeTestMod(t, dir)
+	cache, err := newTestCache(dir)
+	assert.NoError(t, err)
+
+	entry := cache.Entry("test", "1.0.0")
+	_, err = entry.Swap(entry.VersionPath("abc"))
+	assert.Error(t, err)
+	assert.NoError(t, entry.Lock(context.Background()))
+	defer entry.Unlock(context.Background())
+
+	// A plugin compiled in place is preserved when it's replaced
+	assert.NoError(t, ioutil.WriteFile(entry.Path, []byte("v1"), 0666))
+	v2 := entry.VersionPath("0123456789abcdef0123456789abcdef")
+	assert.Equal(t, entry.Path+".0123456789abcdef", v2)
+	assert.NoError(t, ioutil.WriteFile(v2, []byte("v2"), 0666))
+	previous, err := entry.Swap(v2)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, previous)
+	bytes, err := ioutil.ReadFile(previous)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", string(bytes))
+	bytes, err = ioutil.ReadFile(entry.Path)
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", string(bytes))
+
+	// Versions are not listed as cached plugins
+	paths, err := cache.List()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{entry.Path}, paths)
+
+	v3 := entry.VersionPath("fedcba")
+	assert.NoError(t, ioutil.WriteFile(v3, []byte("v3"), 0666))
+	previous, err = entry.Swap(v3)
+	assert.NoError(t, err)
+	assert.Equal(t, v2, previous)
+	bytes, err = ioutil.ReadFile(entry.Path)
+	assert.NoError(t, err)
+	assert.Equal(t, "v3", string(bytes))
+
+	// Swapping in the current version replaces nothing
+	previous, err = entry.Swap(v3)
+	assert.NoError(t, err)
+	assert.Empty(t, previous)
+
+	assert.NoError(t, entry.Remove())
+	_, err = os.Lstat(entry.Path)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(v3)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestCachePlatform(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-model-cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	writeTestMod(t, dir)
+
+	resolver := pluginmodule.NewResolver(pluginmodule.ResolverConfig{
+		Path: filepath.Join(dir, "mod"),
+	})
+	host, err := NewPluginCache(CacheConfig{
+		Path:   filepath.Join(dir, "cache"),
+		GOOS:   runtime.GOOS,
+		GOARCH: runtime.GOARCH,
+	}, resolver)
+	assert.NoError(t, err)
+	defaultHost, err := newTestCache(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, defaultHost.Entry("test", "1.0.0").Path, host.Entry("test", "1.0.0").Path)
+
+	// Plugins for other platforms are cached separately from the host's plugins
+	goarch := "arm64"
+	if runtime.GOARCH == goarch {
+		goarch = "amd64"
+	}
+	other, err := NewPluginCache(CacheConfig{
+		Path:   filepath.Join(dir, "cache"),
+		GOOS:   "linux",
+		GOARCH: goarch,
+	}, resolver)
+	assert.NoError(t, err)
+	assert.NotEqual(t, host.Config.Path, other.Config.Path)
+	assert.Equal(t, filepath.Dir(host.Config.Path), filepath.Dir(other.Config.Path))
+	assert.True(t, strings.HasSuffix(other.Config.Path, "_linux_"+goarch))
+}
+
+func TestCachePlatformEntry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-model-cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	writeTestMod(t, dir)
+
+	cache, err := newTestCache(dir)
+	assert.NoError(t, err)
+	entry, err := cache.PlatformEntry("test", "1.0.0", cache.Platform())
+	assert.NoError(t, err)
+	assert.Same(t, cache.Entry("test", "1.0.0"), entry)
+
+	// Entries for other platforms are stored where a cache for the platform would store them
+	goarch := "arm64"
+	if runtime.GOARCH == goarch {
+		goarch = "amd64"
+	}
+	platform := pluginmodule.NewPlatform("linux", goarch)
+	entry, err = cache.PlatformEntry("test", "1.0.0", platform)
+	assert.NoError(t, err)
+	other, err := NewPluginCache(CacheConfig{
+		Path:   filepath.Join(dir, "cache"),
+		GOOS:   platform.GOOS,
+		GOARCH: platform.GOARCH,
+	}, pluginmodule.NewResolver(pluginmodule.ResolverConfig{
+		Path: filepath.Join(dir, "mod"),
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, other.Entry("test", "1.0.0").Path, entry.Path)
+	assert.NotEqual(t, cache.Entry("test", "1.0.0").Path, entry.Path)
+
+	// Other platforms' plugins are not listed with the cache's plugins
+	assert.NoError(t, ioutil.WriteFile(entry.Path, []byte("plugin"), 0666))
+	paths, err := cache.List()
+	assert.NoError(t, err)
+	assert.Empty(t, paths)
+
+	_, err = cache.PlatformEntry("test", "1.0.0", pluginmodule.NewPlatform("windows", "amd64"))
+	assert.True(t, errors.IsInvalid(err))
+}
+
+func TestCacheEntryNames(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-model-cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	writeTestMod(t, dir)
+
+	cache, err := newTestCache(dir)
+	assert.NoError(t, err)
+
+	// Plugins are named as the registry names model files, so names and versions never collide
+	assert.Equal(t, "openconfig-interfaces-1.0.0.so", filepath.Base(cache.Entry("openconfig-interfaces", "1.0.0").Path))
+	assert.Equal(t, "!open%2F!config-1.0.0.so", filepath.Base(cache.Entry("Open/Config", "1.0.0").Path))
+	assert.NotEqual(t, cache.Entry("openconfig-interfaces", "1.0.0").Path, cache.Entry("openconfig", "interfaces-1.0.0").Path)
+	assert.Equal(t, cache.Config.Path, filepath.Dir(cache.Entry("../../openconfig", "1.0.0").Path))
+
+	// Plugins cached before names were escaped are still found by their unescaped names
+	legacyPath := filepath.Join(cache.Config.Path, "Legacy-1.0.0-rc1.so")
+	assert.NoError(t, ioutil.WriteFile(legacyPath, []byte{}, 0666))
+	assert.Equal(t, legacyPath, cache.Entry("Legacy", "1.0.0-rc1").Path)
+
+	// Escaped plugins take precedence over legacy plugins
+	escapedPath := filepath.Join(cache.Config.Path, "!legacy-2.0.0.so")
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(cache.Config.Path, "Legacy-2.0.0.so"), []byte{}, 0666))
+	assert.NoError(t, ioutil.WriteFile(escapedPath, []byte{}, 0666))
+	assert.Equal(t, escapedPath, cache.Entry("Legacy", "2.0.0").Path)
+
+	// Unescaped names that are the escaped names of other models are never used
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(cache.Config.Path, "openconfig-interfaces-1.0.0.so"), []byte{}, 0666))
+	assert.Equal(t, "openconfig-interfaces%2D1.0.0.so", filepath.Base(cache.Entry("openconfig", "interfaces-1.0.0").Path))
+}
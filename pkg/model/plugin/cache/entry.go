@@ -12,19 +12,55 @@ import (
 	"github.com/onosproject/onos-lib-go/pkg/errors"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 )
 
-func newPluginEntry(path string, name configmodel.Name, version configmodel.Version) *PluginEntry {
+// getEntryKey returns the key of the entry for the given plugin name+version in the given cache directory
+// Plugins cached before names were escaped are still found by their unescaped names, as the registry finds
+// legacy model descriptors.
+func getEntryKey(path string, name configmodel.Name, version configmodel.Version) string {
+	key := configmodel.GetFileName(name, version)
+	if legacyKey := fmt.Sprintf("%s-%s", name, version); isLegacyEntry(path, key, legacyKey) {
+		key = legacyKey
+	}
+	return key
+}
+
+// isLegacyEntry returns whether the plugin for an entry is cached under its unescaped name
+// Unlike model descriptors, plugins don't describe their model, so an unescaped name that is the escaped
+// name of another model, e.g. 'openconfig-interfaces-1.0.0' for version 'interfaces-1.0.0', is never used.
+func isLegacyEntry(path string, key string, legacyKey string) bool {
+	if legacyKey == key || filepath.Base(legacyKey) != legacyKey {
+		return false
+	}
+	if _, err := os.Stat(filepath.Join(path, key+pluginExt)); !os.IsNotExist(err) {
+		return false
+	}
+	if _, err := os.Stat(filepath.Join(path, legacyKey+pluginExt)); err != nil {
+		return false
+	}
+	i := strings.LastIndex(legacyKey, "-")
+	return configmodel.GetFileName(configmodel.Name(legacyKey[:i]), configmodel.Version(legacyKey[i+1:])) != legacyKey
+}
+
+func newPluginEntry(path string, key string) *PluginEntry {
 	return &PluginEntry{
-		Path: filepath.Join(path, fmt.Sprintf("%s-%s.so", name, version)),
-		lock: newPluginLock(filepath.Join(path, fmt.Sprintf("%s-%s.lock", name, version))),
+		Path: filepath.Join(path, key+pluginExt),
+		lock: newPluginLock(filepath.Join(path, key+lockExt)),
+		load: modelplugin.Load,
 	}
 }
 
 // PluginEntry is an entry for a plugin in the cache
 type PluginEntry struct {
-	Path string
-	lock *pluginLock
+	Path       string
+	lock       *pluginLock
+	load       func(path string) (modelplugin.ConfigModelPlugin, error)
+	loaded     *loadedPlugin
+	lastAccess time.Time
+	mu         sync.RWMutex
 }
 
 // Lock acquires a write lock on the cache
@@ -37,6 +73,11 @@ func (e *PluginEntry) IsLocked() bool {
 	return e.lock.IsLocked()
 }
 
+// TryLock attempts to acquire a write lock on the cache without waiting
+func (e *PluginEntry) TryLock() (bool, error) {
+	return e.lock.TryLock()
+}
+
 // Unlock releases a write lock from the cache
 func (e *PluginEntry) Unlock(ctx context.Context) error {
 	return e.lock.Unlock(ctx)
@@ -73,5 +114,126 @@ func (e *PluginEntry) Load() (modelplugin.ConfigModelPlugin, error) {
 	if !e.IsRLocked() {
 		return nil, errors.NewConflict("cache is not locked")
 	}
-	return modelplugin.Load(e.Path)
+	e.touch()
+	return e.loadPlugin()
+}
+
+// LoadFresh loads a copy of the plugin from the cache
+// LoadFresh bypasses the plugin package's cache of plugins by path, at the cost of a transient copy
+// of the plugin on disk. See modelplugin.LoadFresh for details.
+func (e *PluginEntry) LoadFresh() (modelplugin.ConfigModelPlugin, error) {
+	if !e.IsRLocked() {
+		return nil, errors.NewConflict("cache is not locked")
+	}
+	e.touch()
+	return modelplugin.LoadFresh(e.Path)
+}
+
+// VersionPath returns the content addressed path for the version of the plugin with the given checksum
+// Plugin versions are not cached plugins until they're swapped in with Swap.
+func (e *PluginEntry) VersionPath(checksum string) string {
+	if len(checksum) > versionChecksumLen {
+		checksum = checksum[:versionChecksumLen]
+	}
+	return fmt.Sprintf("%s.%s", e.Path, checksum)
+}
+
+// Swap atomically replaces the cached plugin with the plugin version at the given path
+// The plugin path is replaced with a link to the version, so there's no window in which the plugin is
+// missing, and processes that have already loaded the replaced plugin are unaffected. The path of the
+// replaced version is returned, if any, for the caller to remove once it's no longer in use.
+func (e *PluginEntry) Swap(path string) (string, error) {
+	if !e.IsLocked() {
+		return "", errors.NewConflict("cache is not locked")
+	}
+
+	var previous string
+	if target, err := os.Readlink(e.Path); err == nil {
+		previous = filepath.Join(filepath.Dir(e.Path), target)
+	} else if _, err := os.Lstat(e.Path); err == nil {
+		// Plugins compiled in place are linked to a version path so they survive being replaced
+		previous = fmt.Sprintf("%s.%d", e.Path, time.Now().UnixNano())
+		if err := os.Link(e.Path, previous); err != nil {
+			return "", errors.NewInternal("failed to preserve plugin '%s': %s", e.Path, err)
+		}
+	}
+
+	link := e.Path + swapExt
+	if err := os.Remove(link); err != nil && !os.IsNotExist(err) {
+		return "", errors.NewInternal("failed to swap plugin '%s': %s", e.Path, err)
+	}
+	if err := os.Symlink(filepath.Base(path), link); err != nil {
+		return "", errors.NewInternal("failed to swap plugin '%s': %s", e.Path, err)
+	}
+	if err := os.Rename(link, e.Path); err != nil {
+		_ = os.Remove(link)
+		return "", errors.NewInternal("failed to swap plugin '%s': %s", e.Path, err)
+	}
+	if previous == path {
+		return "", nil
+	}
+	return previous, nil
+}
+
+// Detach moves the plugin aside so it's no longer found in the cache
+// A detached plugin is restored with Attach, or removed along with the version it links to with Remove.
+func (e *PluginEntry) Detach() error {
+	if !e.IsLocked() {
+		return errors.NewConflict("cache is not locked")
+	}
+	if err := os.Rename(e.Path, e.Path+detachExt); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Attach restores a plugin moved aside with Detach
+func (e *PluginEntry) Attach() error {
+	if !e.IsLocked() {
+		return errors.NewConflict("cache is not locked")
+	}
+	if err := os.Rename(e.Path+detachExt, e.Path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Remove removes the plugin from the cache, including the version it links to and any detached plugin
+func (e *PluginEntry) Remove() error {
+	if !e.IsLocked() {
+		return errors.NewConflict("cache is not locked")
+	}
+	for _, path := range []string{e.Path, e.Path + detachExt} {
+		if target, err := os.Readlink(path); err == nil {
+			if err := os.Remove(filepath.Join(filepath.Dir(path), target)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// LastAccess returns the time at which the plugin was last loaded by this process
+// If the plugin has not been loaded, the time at which it was written to the cache is returned.
+func (e *PluginEntry) LastAccess() (time.Time, error) {
+	e.mu.RLock()
+	lastAccess := e.lastAccess
+	e.mu.RUnlock()
+	if !lastAccess.IsZero() {
+		return lastAccess, nil
+	}
+	info, err := os.Stat(e.Path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+func (e *PluginEntry) touch() {
+	e.mu.Lock()
+	e.lastAccess = time.Now()
+	e.mu.Unlock()
 }
@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package plugincache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// evictionCandidate is a cached plugin considered for eviction
+type evictionCandidate struct {
+	entry      *PluginEntry
+	size       int64
+	lastAccess time.Time
+}
+
+// Evict removes the least recently loaded plugins from the cache until it is within the configured limits
+// Plugins for which keep returns true and plugins that are locked are never evicted. Returns the paths
+// of the evicted plugins.
+func (c *PluginCache) Evict(keep func(path string) bool) ([]string, error) {
+	if c.Config.MaxSizeBytes == 0 && c.Config.MaxEntries == 0 {
+		return nil, nil
+	}
+
+	var candidates []evictionCandidate
+	var size int64
+	err := filepath.Walk(c.Config.Path, func(file string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(file, pluginExt) {
+			return nil
+		}
+		// Swapped plugins are links to the plugin version
+		if info.Mode()&os.ModeSymlink != 0 {
+			if info, err = os.Stat(file); err != nil {
+				return nil
+			}
+		}
+		entry := c.entry(c.Config.Path, strings.TrimSuffix(filepath.Base(file), pluginExt))
+		lastAccess, err := entry.LastAccess()
+		if err != nil {
+			return nil
+		}
+		candidates = append(candidates, evictionCandidate{
+			entry:      entry,
+			size:       info.Size(),
+			lastAccess: lastAccess,
+		})
+		size += info.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].lastAccess.Before(candidates[j].lastAccess)
+	})
+
+	var evicted []string
+	entries := len(candidates)
+	for _, candidate := range candidates {
+		if !c.isOverLimit(size, entries) {
+			break
+		}
+		if keep != nil && keep(candidate.entry.Path) {
+			continue
+		}
+		ok, err := c.evict(candidate.entry)
+		if err != nil {
+			log.Warnf("Evicting plugin '%s' failed: %s", candidate.entry.Path, err)
+			continue
+		} else if !ok {
+			log.Debugf("Skipping eviction of plugin '%s': plugin is in use", candidate.entry.Path)
+			continue
+		}
+		log.Infof("Evicted plugin '%s'", candidate.entry.Path)
+		evicted = append(evicted, candidate.entry.Path)
+		size -= candidate.size
+		entries--
+	}
+	return evicted, nil
+}
+
+func (c *PluginCache) isOverLimit(size int64, entries int) bool {
+	return (c.Config.MaxSizeBytes > 0 && size > c.Config.MaxSizeBytes) ||
+		(c.Config.MaxEntries > 0 && entries > c.Config.MaxEntries)
+}
+
+// evict removes the plugin for the given entry if it's not in use
+func (c *PluginCache) evict(entry *PluginEntry) (bool, error) {
+	ok, err := entry.TryLock()
+	if err != nil || !ok {
+		return false, err
+	}
+	defer func() {
+		_ = entry.Unlock(context.Background())
+	}()
+	if err := entry.Remove(); err != nil {
+		return false, err
+	}
+
+	// A plugin written to the entry later is considered new
+	entry.mu.Lock()
+	entry.lastAccess = time.Time{}
+	entry.mu.Unlock()
+	return true, nil
+}
@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package plugincache
+
+import (
+	"context"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+// newEvictionTestCache creates a cache containing plugins a-d, from least to most recently written
+func newEvictionTestCache(t *testing.T) *PluginCache {
+	dir, err := ioutil.TempDir("", "config-model-cache")
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = os.RemoveAll(dir)
+	})
+	writeTestMod(t, dir)
+	cache, err := newTestCache(dir)
+	assert.NoError(t, err)
+
+	now := time.Now()
+	for i, name := range []configmodel.Name{"a", "b", "c", "d"} {
+		path := cache.Entry(name, "1.0.0").Path
+		assert.NoError(t, ioutil.WriteFile(path, make([]byte, 10), 0666))
+		modTime := now.Add(time.Duration(i-4) * time.Hour)
+		assert.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	return cache
+}
+
+func TestEvictMaxEntries(t *testing.T) {
+	cache := newEvictionTestCache(t)
+	evicted, err := cache.Evict(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, evicted)
+
+	// Loading a plugin makes it the most recently used
+	cache.Entry("a", "1.0.0").touch()
+
+	cache.Config.MaxEntries = 2
+	evicted, err = cache.Evict(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{cache.Entry("b", "1.0.0").Path, cache.Entry("c", "1.0.0").Path}, evicted)
+
+	paths, err := cache.List()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{cache.Entry("a", "1.0.0").Path, cache.Entry("d", "1.0.0").Path}, paths)
+}
+
+func TestEvictMaxSize(t *testing.T) {
+	cache := newEvictionTestCache(t)
+	cache.Config.MaxSizeBytes = 25
+
+	// Plugins that are in use or kept by the caller are not evicted
+	entry := cache.Entry("a", "1.0.0")
+	assert.NoError(t, entry.RLock(context.Background()))
+	defer entry.RUnlock(context.Background())
+	keep := func(path string) bool {
+		return path == cache.Entry("b", "1.0.0").Path
+	}
+
+	evicted, err := cache.Evict(keep)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{cache.Entry("c", "1.0.0").Path, cache.Entry("d", "1.0.0").Path}, evicted)
+	cached, err := entry.Cached()
+	assert.NoError(t, err)
+	assert.True(t, cached)
+}
@@ -44,6 +44,32 @@ func (l *pluginLock) Lock(ctx context.Context) error {
 	return nil
 }
 
+// TryLock attempts to acquire a write lock on the cache without waiting
+// Unlike Lock, TryLock fails if the lock is already held for reading or writing in this process.
+func (l *pluginLock) TryLock() (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.wlocked || l.rlocked {
+		return false, nil
+	}
+
+	if err := l.openFH(); err != nil {
+		return false, err
+	}
+	defer l.ensureFhState()
+
+	err := syscall.Flock(int(l.fh.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	switch err {
+	case syscall.EWOULDBLOCK:
+		return false, nil
+	case nil:
+		l.wlocked = true
+		return true, nil
+	}
+	return false, err
+}
+
 // IsLocked checks whether the cache is write locked
 func (l *pluginLock) IsLocked() bool {
 	l.mu.RLock()
@@ -0,0 +1,146 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package plugincache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	modelplugin "github.com/onosproject/onos-config-model/pkg/model/plugin"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// loadedPlugin is a plugin loaded from the cache by this process
+type loadedPlugin struct {
+	path     string
+	checksum string
+	plugin   modelplugin.ConfigModelPlugin
+}
+
+// Reload re-points the given plugin to the plugin currently on disk, e.g. after it was recompiled
+// Reload verifies the plugin on disk differs from the plugin loaded by this process and evicts the loaded
+// plugin, so the new plugin is returned by the next Load. Go cannot unload plugins, so the ConfigModel of
+// the replaced plugin remains in the process and its memory is never released. Go also refuses to load a
+// plugin built with the same plugin path (-pluginpath linker flag) as a loaded plugin, so each version of
+// a plugin must be built with a unique plugin path to be reloaded.
+func (c *PluginCache) Reload(ctx context.Context, name configmodel.Name, version configmodel.Version) (*PluginEntry, error) {
+	entry := c.Entry(name, version)
+	if err := entry.Lock(ctx); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := entry.Unlock(context.Background()); err != nil {
+			log.Errorf("Failed to release cache lock: %s", err)
+		}
+	}()
+	if err := entry.reload(); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// reload evicts the loaded plugin if the plugin on disk differs from it
+func (e *PluginEntry) reload() error {
+	checksum, err := getFileChecksum(e.Path)
+	if os.IsNotExist(err) {
+		return errors.NewNotFound("plugin '%s' not found", e.Path)
+	} else if err != nil {
+		return errors.NewInternal("failed to read plugin '%s': %s", e.Path, err)
+	}
+
+	e.mu.Lock()
+	loaded := e.loaded
+	e.mu.Unlock()
+	if loaded == nil {
+		return nil
+	}
+	if loaded.checksum == checksum {
+		return errors.NewAlreadyExists("plugin '%s' has not changed since it was loaded", e.Path)
+	}
+
+	// The plugin package caches plugins by path, so a plugin rebuilt in place is linked to a version path
+	// from which it has not been loaded
+	if path, err := filepath.EvalSymlinks(e.Path); err == nil && path == loaded.path {
+		versionPath := e.VersionPath(checksum)
+		if err := os.Link(path, versionPath); err != nil && !os.IsExist(err) {
+			return errors.NewInternal("failed to reload plugin '%s': %s", e.Path, err)
+		}
+		previous, err := e.Swap(versionPath)
+		if err != nil {
+			return err
+		}
+		if previous != "" {
+			if err := os.Remove(previous); err != nil && !os.IsNotExist(err) {
+				log.Warnf("Failed to remove replaced plugin '%s': %s", previous, err)
+			}
+		}
+	}
+
+	e.mu.Lock()
+	e.loaded = nil
+	e.mu.Unlock()
+	log.Infof("Reloading plugin '%s'", e.Path)
+	return nil
+}
+
+// LoadedPath returns the path from which the plugin was loaded by this process, if it's loaded
+// Cached plugins are links to versions of the plugin, so the path changes when a new version is reloaded.
+func (e *PluginEntry) LoadedPath() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.loaded == nil {
+		return ""
+	}
+	return e.loaded.path
+}
+
+// loadPlugin returns the loaded plugin, loading it if a different version of the plugin is on disk
+func (e *PluginEntry) loadPlugin() (modelplugin.ConfigModelPlugin, error) {
+	path, err := filepath.EvalSymlinks(e.Path)
+	if err != nil {
+		return nil, err
+	}
+	e.mu.RLock()
+	loaded := e.loaded
+	e.mu.RUnlock()
+	if loaded != nil && loaded.path == path {
+		return loaded.plugin, nil
+	}
+
+	plugin, err := e.load(path)
+	if err != nil {
+		return nil, err
+	}
+	checksum, err := getFileChecksum(path)
+	if err != nil {
+		return nil, err
+	}
+	e.mu.Lock()
+	e.loaded = &loadedPlugin{
+		path:     path,
+		checksum: checksum,
+		plugin:   plugin,
+	}
+	e.mu.Unlock()
+	return plugin, nil
+}
+
+// getFileChecksum returns the hex encoded SHA-256 checksum of the file at the given path
+func getFileChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
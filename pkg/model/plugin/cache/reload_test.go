@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package plugincache
+
+import (
+	"context"
+	modelplugin "github.com/onosproject/onos-config-model/pkg/model/plugin"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReloadPlugin(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-model-cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	writeTestMod(t, dir)
+	cache, err := newTestCache(dir)
+	assert.NoError(t, err)
+
+	var loads []string
+	entry := cache.Entry("test", "1.0.0")
+	entry.load = func(path string) (modelplugin.ConfigModelPlugin, error) {
+		loads = append(loads, path)
+		return nil, nil
+	}
+	load := func() {
+		assert.NoError(t, entry.RLock(context.Background()))
+		_, err := entry.Load()
+		assert.NoError(t, err)
+		assert.NoError(t, entry.RUnlock(context.Background()))
+	}
+
+	// Plugins that are not cached cannot be reloaded
+	_, err = cache.Reload(context.Background(), "test", "1.0.0")
+	assert.True(t, errors.IsNotFound(err))
+
+	// The loaded plugin is returned until the plugin is reloaded
+	assert.NoError(t, ioutil.WriteFile(entry.Path, []byte("v1"), 0666))
+	load()
+	load()
+	path, err := filepath.EvalSymlinks(entry.Path)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{path}, loads)
+	assert.Equal(t, path, entry.LoadedPath())
+
+	// Unchanged plugins are not reloaded
+	_, err = cache.Reload(context.Background(), "test", "1.0.0")
+	assert.True(t, errors.IsAlreadyExists(err))
+
+	// A plugin rebuilt in place is reloaded from a new path
+	assert.NoError(t, ioutil.WriteFile(entry.Path, []byte("v2"), 0666))
+	reloaded, err := cache.Reload(context.Background(), "test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, entry, reloaded)
+	assert.Empty(t, entry.LoadedPath())
+	load()
+	assert.Len(t, loads, 2)
+	assert.NotEqual(t, path, entry.LoadedPath())
+	bytes, err := ioutil.ReadFile(entry.LoadedPath())
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", string(bytes))
+	assert.False(t, entry.IsLocked())
+
+	// A plugin swapped to a new version is reloaded from the version path
+	v3 := entry.VersionPath("v3")
+	assert.NoError(t, ioutil.WriteFile(v3, []byte("v3"), 0666))
+	assert.NoError(t, entry.Lock(context.Background()))
+	_, err = entry.Swap(v3)
+	assert.NoError(t, err)
+	assert.NoError(t, entry.Unlock(context.Background()))
+	_, err = cache.Reload(context.Background(), "test", "1.0.0")
+	assert.NoError(t, err)
+	load()
+	assert.Len(t, loads, 3)
+	path, err = filepath.EvalSymlinks(v3)
+	assert.NoError(t, err)
+	assert.Equal(t, path, entry.LoadedPath())
+}
@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package plugincache
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ListLocks lists the paths of the plugin lock files in the cache
+// Lock files are created the first time a plugin is locked and are never removed with the plugin, so
+// the cache may hold lock files for plugins that no longer exist.
+func (c *PluginCache) ListLocks() ([]string, error) {
+	var paths []string
+	err := filepath.Walk(c.Config.Path, func(file string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && strings.HasSuffix(file, lockExt) {
+			paths = append(paths, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// PathEntry returns the entry for the plugin or plugin lock file at the given path in the cache
+// Entries are usually addressed by model name and version, but files with no model descriptor can
+// only be addressed by path, e.g. to lock them before they're removed.
+func (c *PluginCache) PathEntry(path string) *PluginEntry {
+	key := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(path), lockExt), pluginExt)
+	return c.entry(filepath.Dir(path), key)
+}
+
+// LockPath returns the path of the plugin's lock file
+func (e *PluginEntry) LockPath() string {
+	return e.lock.path
+}
+
+// IsStale returns whether the plugin was compiled from a model definition other than that with the given checksum
+// Plugins replaced by a forced push link to a version addressed by the checksum of the definition from which
+// it was compiled, and plugins reloaded after being rebuilt in place link to a version addressed by its own
+// checksum. Plugins compiled in place cannot be traced to a definition, so they're never reported as stale.
+func (e *PluginEntry) IsStale(checksum string) (bool, error) {
+	if !e.IsRLocked() {
+		return false, errors.NewConflict("cache is not locked")
+	}
+	target, err := os.Readlink(e.Path)
+	if err != nil {
+		return false, nil
+	}
+	path := target
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(e.Path), target)
+	}
+	if path == e.VersionPath(checksum) {
+		return false, nil
+	}
+	fileChecksum, err := getFileChecksum(path)
+	if err != nil {
+		return false, err
+	}
+	return path != e.VersionPath(fileChecksum), nil
+}
+
+// Prune removes the plugin, the version it links to and its lock file from the cache
+// Prune is used to remove plugins with no model descriptor. The lock file is removed while the lock is
+// held, so the lock must not be waited on by other processes.
+func (e *PluginEntry) Prune() error {
+	if err := e.Remove(); err != nil {
+		return err
+	}
+	if err := os.Remove(e.lock.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package plugincache
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestStalePlugin(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-model-cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	writeTestMod(t, dir)
+	cache, err := newTestCache(dir)
+	assert.NoError(t, err)
+
+	entry := cache.Entry("test", "1.0.0")
+	_, err = entry.IsStale("abc")
+	assert.Error(t, err)
+	assert.NoError(t, entry.Lock(context.Background()))
+	defer entry.Unlock(context.Background())
+
+	// Plugins compiled in place cannot be traced to a definition
+	assert.NoError(t, ioutil.WriteFile(entry.Path, []byte("v1"), 0666))
+	stale, err := entry.IsStale("0123456789abcdef")
+	assert.NoError(t, err)
+	assert.False(t, stale)
+
+	// Versions are compiled from the definition with the checksum they're addressed by
+	version := entry.VersionPath("0123456789abcdef")
+	assert.NoError(t, ioutil.WriteFile(version, []byte("v2"), 0666))
+	_, err = entry.Swap(version)
+	assert.NoError(t, err)
+	stale, err = entry.IsStale("0123456789abcdef")
+	assert.NoError(t, err)
+	assert.False(t, stale)
+	stale, err = entry.IsStale("fedcba9876543210")
+	assert.NoError(t, err)
+	assert.True(t, stale)
+
+	// Versions reloaded after being rebuilt in place are addressed by their own checksum
+	checksum, err := getFileChecksum(version)
+	assert.NoError(t, err)
+	assert.NoError(t, os.Rename(version, entry.VersionPath(checksum)))
+	_, err = entry.Swap(entry.VersionPath(checksum))
+	assert.NoError(t, err)
+	stale, err = entry.IsStale("fedcba9876543210")
+	assert.NoError(t, err)
+	assert.False(t, stale)
+}
+
+func TestPruneOrphans(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-model-cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	writeTestMod(t, dir)
+	cache, err := newTestCache(dir)
+	assert.NoError(t, err)
+
+	entry := cache.Entry("test", "1.0.0")
+	assert.NoError(t, entry.Lock(context.Background()))
+	assert.NoError(t, ioutil.WriteFile(entry.Path, []byte("v1"), 0666))
+	assert.NoError(t, entry.Unlock(context.Background()))
+
+	// Entries for files in the cache are the entries for their model
+	locks, err := cache.ListLocks()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{entry.LockPath()}, locks)
+	assert.Same(t, entry, cache.PathEntry(entry.Path))
+	assert.Same(t, entry, cache.PathEntry(entry.LockPath()))
+
+	assert.Error(t, entry.Prune())
+	locked, err := entry.TryLock()
+	assert.NoError(t, err)
+	assert.True(t, locked)
+	assert.NoError(t, entry.Prune())
+	assert.NoError(t, entry.Unlock(context.Background()))
+
+	paths, err := cache.List()
+	assert.NoError(t, err)
+	assert.Empty(t, paths)
+	locks, err = cache.ListLocks()
+	assert.NoError(t, err)
+	assert.Empty(t, locks)
+}
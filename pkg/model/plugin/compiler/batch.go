@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package plugincompiler
+
+import (
+	"fmt"
+	"github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CompileErrors is the set of models that failed to compile in a batch, keyed by model
+type CompileErrors map[string]error
+
+func (e CompileErrors) Error() string {
+	models := make([]string, 0, len(e))
+	for model := range e {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	failures := make([]string, len(models))
+	for i, model := range models {
+		failures[i] = fmt.Sprintf("model '%s': %s", model, e[model])
+	}
+	return fmt.Sprintf("failed to compile %s", strings.Join(failures, "; "))
+}
+
+// CompilePlugins compiles a batch of model plugins to the given paths
+// The plugins are generated as packages of a single module, so the module's dependencies are
+// resolved once for the batch. Go can only build one plugin per invocation, so each plugin is
+// built separately, sharing compiled dependencies through the build cache. A model that fails
+// to compile does not prevent the rest of the batch from being compiled; failures are returned
+// as CompileErrors.
+func (c *PluginCompiler) CompilePlugins(models []configmodel.ModelInfo, paths []string) error {
+	if len(models) != len(paths) {
+		return errors.NewInvalid("%d models cannot be compiled to %d paths", len(models), len(paths))
+	}
+	if len(models) == 0 {
+		return nil
+	}
+	log.Infof("Compiling %d ConfigModels", len(models))
+	if err := c.GetPlatform().Validate(); err != nil {
+		log.Errorf("Compiling ConfigModels failed: %s", err)
+		return err
+	}
+
+	compiler, err := c.newBuild("batch")
+	if err != nil {
+		log.Errorf("Compiling ConfigModels failed: %s", err)
+		return err
+	}
+	buildPath := compiler.Config.BuildPath
+	defer c.removeDir(buildPath)
+
+	// Each model is generated as a package of the batch module
+	compiler.Config.ModulePathPrefix = path.Join(c.Config.ModulePathPrefix, filepath.Base(buildPath))
+	if err := compiler.writeMod(models[0], compiler.Config.ModulePathPrefix, buildPath); err != nil {
+		log.Errorf("Compiling ConfigModels failed: %s", err)
+		return err
+	}
+
+	failures := make(CompileErrors)
+	var generated []int
+	for i, model := range models {
+		if err := compiler.generatePackage(model); err != nil {
+			log.Errorf("Compiling ConfigModel '%s/%s' failed: %s", model.Name, model.Version, err)
+			failures[model.String()] = err
+			continue
+		}
+		generated = append(generated, i)
+	}
+
+	if c.Config.ModFile == "" && len(generated) > 0 {
+		if err := compiler.tidyMod(buildPath); err != nil {
+			for _, i := range generated {
+				failures[models[i].String()] = err
+			}
+			return failures
+		}
+	}
+
+	for _, i := range generated {
+		model := models[i]
+		c.createDir(filepath.Dir(paths[i]))
+		if err := compiler.buildPlugin(buildPath, compiler.getPluginMod(model), paths[i]); err != nil {
+			log.Errorf("Compiling ConfigModel '%s/%s' failed: %s", model.Name, model.Version, err)
+			failures[model.String()] = err
+		}
+	}
+
+	if len(failures) > 0 {
+		return failures
+	}
+	return nil
+}
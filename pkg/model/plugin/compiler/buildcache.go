@@ -0,0 +1,179 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package plugincompiler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/onosproject/onos-config-model/pkg/model"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const buildCacheExt = ".so"
+
+// getModelChecksum returns the checksum of the given model's definition
+// The model checksum covers the sorted files and modules, which must be decompressed.
+func getModelChecksum(model configmodel.ModelInfo) (string, error) {
+	files := make([]configmodel.FileInfo, len(model.Files))
+	for i, file := range model.Files {
+		file, err := file.Decompress()
+		if err != nil {
+			return "", err
+		}
+		files[i] = file
+	}
+	model.Files = files
+	return model.ComputeChecksum(), nil
+}
+
+// getBuildCacheKey returns the key by which the plugin compiled from the given model is stored in the build cache
+// The key is a SHA-256 hash of the model's sorted YANG files and modules and of every input of the compilation
+// that does not depend on the model: the generator flags, the templates, the compiler and Go versions, the
+// platform and the go.mod with which the plugin is built. Plugins compiled from identical inputs are therefore
+// identical, however their cache entries are addressed. Preprocessors and local replaces are keyed by their
+// command and directory rather than their behavior and contents. If no build cache is configured, the key is empty.
+func (c *PluginCompiler) getBuildCacheKey(model configmodel.ModelInfo) (string, error) {
+	if c.Config.BuildCachePath == "" {
+		return "", nil
+	}
+
+	checksum, err := getModelChecksum(model)
+	if err != nil {
+		return "", err
+	}
+	goVersion, err := c.GetGoVersion()
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	configmodel.WriteChecksumField(h, checksum)
+	configmodel.WriteChecksumField(h, getModuleVersion())
+	configmodel.WriteChecksumField(h, fmt.Sprint(isReleaseVersion()))
+	configmodel.WriteChecksumField(h, goVersion)
+	configmodel.WriteChecksumField(h, c.GetPlatform().String())
+	configmodel.WriteChecksumField(h, c.Config.ModulePathPrefix)
+	configmodel.WriteChecksumField(h, c.Config.GeneratorPackageName)
+	writeBuildCacheFields(h, c.Config.GeneratorFlags)
+	configmodel.WriteChecksumField(h, c.Config.Preprocessor)
+	writeBuildCacheFields(h, c.Config.PreprocessorArgs)
+	if c.Config.IncludeStandardModules {
+		configmodel.WriteChecksumField(h, StandardModulesVersion)
+	} else {
+		configmodel.WriteChecksumField(h, "")
+	}
+	configmodel.WriteChecksumField(h, fmt.Sprint(c.Config.Reproducible))
+	configmodel.WriteChecksumField(h, fmt.Sprint(c.Config.Offline))
+	for _, name := range []string{modTemplate, mainTemplate, pluginTemplate, modelTemplate} {
+		data, err := c.readTemplate(name)
+		if err != nil {
+			return "", err
+		}
+		configmodel.WriteChecksumField(h, string(data))
+	}
+
+	// The dependencies of the plugin are determined by the configured or resolved go.mod and its replaces
+	replaces := make([]string, len(c.Config.ExtraReplaces))
+	for i, replace := range c.Config.ExtraReplaces {
+		replaces[i] = replace.String()
+	}
+	writeBuildCacheFields(h, replaces)
+	if c.Config.ModFile != "" {
+		for _, path := range []string{c.Config.ModFile, c.Config.SumFile} {
+			var data []byte
+			if path != "" {
+				data, err = ioutil.ReadFile(path)
+				if err != nil {
+					return "", err
+				}
+			}
+			configmodel.WriteChecksumField(h, string(data))
+		}
+	} else if c.resolver != nil {
+		mod, _, err := c.resolver.Resolve()
+		if err != nil {
+			return "", err
+		}
+		data, err := mod.Format()
+		if err != nil {
+			return "", err
+		}
+		configmodel.WriteChecksumField(h, string(data))
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeBuildCacheFields writes a counted list of fields to the hash in the given order
+func writeBuildCacheFields(h hash.Hash, values []string) {
+	configmodel.WriteChecksumField(h, fmt.Sprint(len(values)))
+	for _, value := range values {
+		configmodel.WriteChecksumField(h, value)
+	}
+}
+
+// getBuildCachePath returns the path of the plugin stored in the build cache with the given key
+func (c *PluginCompiler) getBuildCachePath(key string) string {
+	return filepath.Join(c.Config.BuildCachePath, key+buildCacheExt)
+}
+
+// restoreBuild copies the plugin stored in the build cache with the given key to the given path
+// Returns false if no plugin is stored with the key. Plugins are copied rather than linked, so the
+// build cache can be cleared without affecting the plugins compiled from it.
+func (c *PluginCompiler) restoreBuild(key string, path string) (bool, error) {
+	cachePath := c.getBuildCachePath(key)
+	if _, err := os.Stat(cachePath); os.IsNotExist(err) {
+		return false, nil
+	}
+	c.createDir(filepath.Dir(path))
+	if err := copyFileAtomic(cachePath, path); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// storeBuild stores the plugin compiled to the given path in the build cache with the given key
+// The build cache only shortcuts later compilations, so failing to store the plugin does not fail the compilation.
+func (c *PluginCompiler) storeBuild(key string, path string) {
+	c.createDir(c.Config.BuildCachePath)
+	if err := copyFileAtomic(path, c.getBuildCachePath(key)); err != nil {
+		log.Warnf("Storing plugin '%s' in the build cache failed: %s", path, err)
+	}
+}
+
+// copyFileAtomic copies the file at the given source path to the given destination path
+// The file is copied to a temporary file that replaces the destination, so concurrent compilations never
+// read a partially copied plugin.
+func copyFileAtomic(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := ioutil.TempFile(filepath.Dir(dst), filepath.Base(dst)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(out.Name())
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(out.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Rename(out.Name(), dst)
+}
@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package plugincompiler
+
+import (
+	"fmt"
+	"github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-model-compiler")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// Replace the go command with one that records each build and writes a fake plugin
+	buildLog := filepath.Join(dir, "build.log")
+	goBinary := filepath.Join(dir, "go")
+	script := fmt.Sprintf("#!/bin/sh\ncase \"$1\" in\nenv) echo go0.0.1 ;;\nbuild) echo \"$3\" >> %s; echo plugin > \"$3\" ;;\nesac\nexit 0\n", buildLog)
+	assert.NoError(t, ioutil.WriteFile(goBinary, []byte(script), 0755))
+	getBuilds := func() []string {
+		bytes, err := ioutil.ReadFile(buildLog)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		assert.NoError(t, err)
+		return strings.Fields(string(bytes))
+	}
+
+	config := CompilerConfig{
+		TemplatePath:   "templates",
+		BuildPath:      filepath.Join(dir, "build"),
+		ModFile:        writePinnedModFile(t, dir),
+		GoBinary:       goBinary,
+		BuildCachePath: filepath.Join(dir, "build-cache"),
+	}
+	compiler := NewPluginCompiler(config, nil)
+	model := newTestModel(t)
+	model.Modules = append(model.Modules, configmodel.ModuleInfo{Name: "extra", File: "extra.yang"})
+	model.Files = append(model.Files, configmodel.FileInfo{Path: "extra.yang", Data: []byte("module extra {}")})
+	assert.NoError(t, compiler.CompilePlugin(model, filepath.Join(dir, "a", "test-1.0.0.so")))
+	assert.Len(t, getBuilds(), 1)
+
+	// A plugin compiled from identical inputs to another path, e.g. in a cache with a new resolver hash, is
+	// copied from the build cache without invoking 'go build'
+	path := filepath.Join(dir, "b", "test-1.0.0.so")
+	assert.NoError(t, NewPluginCompiler(config, nil).CompilePlugin(model, path))
+	assert.Len(t, getBuilds(), 1)
+	bytes, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "plugin\n", string(bytes))
+
+	// Reordering the model's files and modules does not change its inputs
+	reordered := model
+	reordered.Modules = []configmodel.ModuleInfo{model.Modules[1], model.Modules[0]}
+	reordered.Files = []configmodel.FileInfo{model.Files[1], model.Files[0]}
+	assert.NoError(t, compiler.CompilePlugin(reordered, filepath.Join(dir, "c", "test-1.0.0.so")))
+	assert.Len(t, getBuilds(), 1)
+
+	// Changing the generator flags or the platform rebuilds the plugin
+	config.GeneratorFlags = []string{"-compress_paths"}
+	assert.NoError(t, NewPluginCompiler(config, nil).CompilePlugin(model, filepath.Join(dir, "d", "test-1.0.0.so")))
+	assert.Len(t, getBuilds(), 2)
+	config.GOARCH = "arm64"
+	if compiler.GetPlatform().GOARCH == "arm64" {
+		config.GOARCH = "amd64"
+	}
+	assert.NoError(t, NewPluginCompiler(config, nil).CompilePlugin(model, filepath.Join(dir, "e", "test-1.0.0.so")))
+	assert.Len(t, getBuilds(), 3)
+
+	// Plugins are always rebuilt if no build cache is configured
+	config.BuildCachePath = ""
+	assert.NoError(t, NewPluginCompiler(config, nil).CompilePlugin(model, filepath.Join(dir, "f", "test-1.0.0.so")))
+	assert.NoError(t, NewPluginCompiler(config, nil).CompilePlugin(model, filepath.Join(dir, "f", "test-1.0.0.so")))
+	assert.Len(t, getBuilds(), 5)
+}
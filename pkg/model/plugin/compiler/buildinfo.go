@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package plugincompiler
+
+import (
+	"fmt"
+	"github.com/onosproject/onos-config-model/pkg/model"
+	"os"
+	"strings"
+)
+
+// keyDependencies are the dependencies that must match between a plugin and the binary loading it
+var keyDependencies = []string{
+	"github.com/openconfig/ygot",
+	"github.com/openconfig/goyang",
+	"github.com/openconfig/gnmi",
+}
+
+// GetBuildInfo returns the environment in which the plugin at the given path was built
+func (c *PluginCompiler) GetBuildInfo(path string) (configmodel.BuildInfo, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return configmodel.BuildInfo{}, err
+	}
+	out, err := c.exec(fmt.Sprintf("reading build info for '%s'", path), wd, c.getGoBinary(), "version", "-m", path)
+	if err != nil {
+		log.Errorf("Reading build info for '%s' failed: %s", path, err)
+		return configmodel.BuildInfo{}, err
+	}
+	info := parseBuildInfo(out)
+	info.CompilerVersion = getModuleVersion()
+	return info, nil
+}
+
+// parseBuildInfo parses the output of 'go version -m'
+func parseBuildInfo(out string) configmodel.BuildInfo {
+	info := configmodel.BuildInfo{
+		Dependencies: make(map[string]string),
+	}
+	lines := strings.Split(out, "\n")
+	if i := strings.LastIndex(lines[0], ": "); i >= 0 {
+		info.GoVersion = strings.TrimSpace(lines[0][i+2:])
+	}
+
+	var dep string
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "dep":
+			dep = ""
+			if len(fields) > 2 && isKeyDependency(fields[1]) {
+				dep = fields[1]
+				info.Dependencies[dep] = fields[2]
+			}
+		case "=>":
+			// Replaced dependencies are followed by the replacement module
+			if dep != "" && len(fields) > 2 {
+				info.Dependencies[dep] = fields[2]
+			}
+		case "build":
+			key, value := splitBuildSetting(fields[1])
+			switch key {
+			case "GOOS":
+				info.GOOS = value
+			case "GOARCH":
+				info.GOARCH = value
+			case "CGO_ENABLED":
+				info.CGOEnabled = value == "1"
+			}
+		}
+	}
+	return info
+}
+
+func isKeyDependency(path string) bool {
+	for _, dep := range keyDependencies {
+		if path == dep {
+			return true
+		}
+	}
+	return false
+}
+
+func splitBuildSetting(setting string) (string, string) {
+	if i := strings.Index(setting, "="); i >= 0 {
+		return setting[:i], setting[i+1:]
+	}
+	return setting, ""
+}
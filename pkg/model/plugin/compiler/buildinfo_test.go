@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package plugincompiler
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"runtime"
+	"testing"
+)
+
+const testBuildInfo = `/etc/onos/plugins/test-1.0.0.so: go1.16.15
+	path	github.com/onosproject/onos-config-model/test_1_0_0
+	mod	github.com/onosproject/onos-config-model/test_1_0_0	(devel)	
+	dep	github.com/golang/protobuf	v1.5.2	h1:ROPKBNFfQgOUMifHyP+KYbvpjbdoFNs+aK7DXlji0Tw=
+	dep	github.com/openconfig/gnmi	v0.0.0-20210914185457-51254b657b7d	h1:ENKx1I2+/8C70C69qGDw8zfHXFsPnSMtZyf9F2GjN/k=
+	dep	github.com/openconfig/goyang	v0.3.1	h1:27E8ibpoCh/c6TvptzkEl2l207xSlEfRPk2jaFw83VI=
+	dep	github.com/openconfig/ygot	v0.12.4
+	=>	github.com/example/ygot	v0.12.5	h1:r4sSaXuYfJGCVJvOrpRqAD/H1NFGC1iDfeDE7EMy78I=
+	build	-buildmode=plugin
+	build	CGO_ENABLED=1
+	build	GOARCH=arm64
+	build	GOOS=linux
+`
+
+func TestParseBuildInfo(t *testing.T) {
+	info := parseBuildInfo(testBuildInfo)
+	assert.Equal(t, "go1.16.15", info.GoVersion)
+	assert.Equal(t, "linux", info.GOOS)
+	assert.Equal(t, "arm64", info.GOARCH)
+	assert.True(t, info.CGOEnabled)
+	assert.Equal(t, map[string]string{
+		"github.com/openconfig/gnmi":   "v0.0.0-20210914185457-51254b657b7d",
+		"github.com/openconfig/goyang": "v0.3.1",
+		"github.com/openconfig/ygot":   "v0.12.5",
+	}, info.Dependencies)
+}
+
+func TestGetBuildInfo(t *testing.T) {
+	// The test binary is built with the same toolchain and dependencies as a plugin would be
+	compiler := NewPluginCompiler(CompilerConfig{}, nil)
+	info, err := compiler.GetBuildInfo(os.Args[0])
+	assert.NoError(t, err)
+	assert.Equal(t, runtime.Version(), info.GoVersion)
+	assert.Equal(t, runtime.GOOS, info.GOOS)
+	assert.Equal(t, runtime.GOARCH, info.GOARCH)
+	assert.Equal(t, getModuleVersion(), info.CompilerVersion)
+	assert.Contains(t, info.Dependencies, "github.com/openconfig/ygot")
+}
@@ -5,27 +5,36 @@
 package plugincompiler
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-config-model/pkg/model/logging"
 	"github.com/onosproject/onos-config-model/pkg/model/plugin/module"
-	"github.com/onosproject/onos-lib-go/pkg/logging"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
 	_ "github.com/openconfig/gnmi/proto/gnmi" // gnmi
 	_ "github.com/openconfig/goyang/pkg/yang" // yang
 	_ "github.com/openconfig/ygot/genutil"    // genutil
 	_ "github.com/openconfig/ygot/ygen"       // ygen
 	_ "github.com/openconfig/ygot/ygot"       // ygot
 	_ "github.com/openconfig/ygot/ytypes"     // ytypes
-	_ "google.golang.org/protobuf/proto"      // proto
+	"github.com/rogpeppe/go-internal/modfile"
+	"go/token"
+	_ "google.golang.org/protobuf/proto" // proto
+	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
-	"text/template"
+	"sync"
+	"time"
 )
 
-var log = logging.GetLogger("config-model", "compiler")
+var log = modellogging.GetLogger("config-model", "compiler")
 
 const versionFile = "VERSION"
 
@@ -43,14 +52,20 @@ const (
 
 const (
 	modFile    = "go.mod"
+	sumFile    = "go.sum"
 	mainFile   = "main.go"
 	pluginFile = "plugin.go"
 	modelFile  = "model.go"
+	// generatedFile is the file to which the ygot generator writes the model's bindings
+	generatedFile = "generated.go"
 )
 
 const (
-	defaultBuildPath    = "/etc/onos/build"
-	defaultTemplatePath = "pkg/model/plugin/compiler/templates"
+	defaultBuildPath        = "/etc/onos/build"
+	defaultModulePathPrefix = "github.com/onosproject/onos-config-model"
+	defaultGoBinary         = "go"
+	// defaultGeneratorPackageName is the package name of the bindings generated for models by default
+	defaultGeneratorPackageName = "configmodel"
 )
 
 var (
@@ -79,15 +94,86 @@ type CompilerInfo struct {
 
 // TemplateInfo provides all the variables for templates
 type TemplateInfo struct {
-	Model    configmodel.ModelInfo
-	Compiler CompilerInfo
+	Model      configmodel.ModelInfo
+	Compiler   CompilerInfo
+	ModulePath string
+	// PackageName is the package name of the generated model package
+	PackageName string
 }
 
 // CompilerConfig is a plugin compiler configuration
 type CompilerConfig struct {
-	TemplatePath string
-	BuildPath    string
-	SkipCleanUp  bool
+	// TemplatePath is a directory from which to read the plugin templates in place of the embedded templates
+	TemplatePath     string
+	BuildPath        string
+	ModulePathPrefix string
+	SkipCleanUp      bool
+	// ModFile is the path to a go.mod to use for plugin modules in place of the resolved module
+	// When set, plugins are built with -mod=readonly to pin the dependencies.
+	ModFile string
+	// SumFile is the path to a go.sum to use with the ModFile
+	SumFile string
+	// Timeout is the maximum duration of each compilation phase, e.g. 'go mod tidy' or 'go build'
+	// If the timeout is zero, the phases are not bounded. The timeout can be changed with SetTimeout.
+	Timeout time.Duration
+	// GeneratorFlags are additional flags passed to the ygot generator, e.g. -compress_paths
+	// Flags with values must be given in the form -name=value.
+	GeneratorFlags []string
+	// Preprocessor is a command that transforms each YANG file before it's compiled
+	// If no command is configured, YANG files are compiled as is.
+	Preprocessor string
+	// PreprocessorArgs are the arguments passed to the Preprocessor command
+	PreprocessorArgs []string
+	// BuildParallelism is the number of packages each plugin build compiles in parallel (go build -p)
+	// If zero, the go command's default of the number of CPUs is used.
+	BuildParallelism int
+	// Offline indicates whether plugins are built without network access
+	// Offline builds skip 'go mod tidy' and load dependencies only from the module cache.
+	Offline bool
+	// OfflineEnv is the environment with which Go commands are run offline, defaulting to pluginmodule.DefaultOfflineEnv
+	OfflineEnv []string
+	// GOOS and GOARCH are the platform for which plugins are built, defaulting to the host's
+	// Plugins require cgo, so cross-compiling requires a C compiler for the platform to be set with the CC
+	// environment variable. Plugins built for another platform cannot be loaded by the compiling process.
+	GOOS   string
+	GOARCH string
+	// GoBinary is the path to the go command with which plugins are built, defaulting to 'go' on the PATH
+	// Plugins can only be loaded by binaries built with the same Go version, so the go command should be
+	// the version with which the binaries loading the plugins are built.
+	GoBinary string
+	// Reproducible indicates whether plugins are built with -trimpath, so identical models build identical plugins
+	// Plugins can only be loaded by binaries built with the same flags, so reproducible plugins can only be
+	// loaded by binaries that are also built with -trimpath.
+	Reproducible bool
+	// ExtraReplaces are replace directives added to the go.mod of compiled plugins, e.g. to build with a fork of ygot
+	// The replaces are applied to the resolved or configured go.mod, superseding its replaces of the same modules.
+	// Cached plugins are not invalidated when the replaces change.
+	ExtraReplaces []ModReplace
+	// IncludeStandardModules indicates whether the bundled standard IETF and OpenConfig modules are added to
+	// compiled models that import them without providing them. See GetStandardModules for the bundled modules.
+	IncludeStandardModules bool
+	// GeneratorPackageName is the package name of the generated model bindings, 'configmodel' by default
+	// The name must be a valid Go package name other than 'main', which is the package name of the plugin itself.
+	GeneratorPackageName string
+	// BuildCachePath is the directory in which compiled plugins are stored by a hash of their inputs
+	// Compiling a plugin whose inputs match a stored plugin copies the stored plugin rather than rebuilding it,
+	// e.g. after the resolved module changes without changing the plugin's dependencies. If empty, plugins
+	// are always rebuilt.
+	BuildCachePath string
+	// DryRun indicates whether compilations stop once the plugin module and its YANG bindings are generated
+	// Dry runs catch schema errors without the cost of building the plugin, so no plugin is written to the
+	// compiled path. The build directory is kept for inspection, and the build cache is not used.
+	DryRun bool
+}
+
+// reservedGeneratorFlags are ygot generator flags that are set by the compiler
+// The plugin template uses the generated fake root 'Device', so the fake root flags are also reserved.
+var reservedGeneratorFlags = []string{
+	"path",
+	"output_file",
+	"package_name",
+	"generate_fakeroot",
+	"fakeroot_name",
 }
 
 // NewPluginCompiler creates a new model plugin compiler
@@ -95,117 +181,473 @@ func NewPluginCompiler(config CompilerConfig, resolver *pluginmodule.Resolver) *
 	if config.BuildPath == "" {
 		config.BuildPath = defaultBuildPath
 	}
-	if config.TemplatePath == "" {
-		config.TemplatePath = defaultTemplatePath
+	if config.ModulePathPrefix == "" {
+		config.ModulePathPrefix = defaultModulePathPrefix
 	}
-	return &PluginCompiler{
-		Config:   config,
-		resolver: resolver,
+	if config.GeneratorPackageName == "" {
+		config.GeneratorPackageName = defaultGeneratorPackageName
 	}
+	compiler := &PluginCompiler{
+		Config:       config,
+		Preprocessor: newPreprocessor(config),
+		resolver:     resolver,
+		timeout:      &phaseTimeout{value: config.Timeout},
+	}
+	if err := compiler.CheckGoVersion(); err != nil {
+		log.Warn(err)
+	}
+	return compiler
 }
 
 // PluginCompiler is a model plugin compiler
 type PluginCompiler struct {
-	Config   CompilerConfig
-	resolver *pluginmodule.Resolver
+	Config CompilerConfig
+	// Preprocessor transforms YANG files before they're compiled
+	Preprocessor Preprocessor
+	resolver     *pluginmodule.Resolver
+	progress     ProgressFunc
+	stderr       *bytes.Buffer
+	ctx          context.Context
+	phase        Phase
+	timeout      *phaseTimeout
+}
+
+// phaseTimeout is the compilation phase timeout shared by a compiler and its copies
+// Compilers are copied for each compilation, so the timeout is held by reference to be changed for all copies.
+type phaseTimeout struct {
+	value time.Duration
+	mu    sync.RWMutex
+}
+
+// WithContext returns a copy of the compiler whose compilations are aborted when the given context is done
+// Compilation commands, e.g. 'go build', are killed when the context is canceled.
+func (c *PluginCompiler) WithContext(ctx context.Context) *PluginCompiler {
+	compiler := *c
+	compiler.ctx = ctx
+	return &compiler
 }
 
 // CompilePlugin compiles a model plugin to the given path
+// Each compilation generates the plugin module in its own directory under the build path, so concurrent
+// compilations never share generated files. The directory is kept if clean up is skipped. If a build cache
+// is configured, plugins compiled from identical inputs are copied from the cache rather than rebuilt. Dry runs
+// only generate the plugin module, keeping its build directory.
 func (c *PluginCompiler) CompilePlugin(model configmodel.ModelInfo, path string) error {
-	log.Infof("Compiling ConfigModel '%s/%s' to '%s'", model.Name, model.Version, path)
+	start := time.Now()
+	log.Infow("Compiling plugin", modellogging.ModelField, model.Name, modellogging.VersionField, model.Version, "path", path)
+	if err := c.GetPlatform().Validate(); err != nil {
+		log.Errorw("Compiling plugin failed", modellogging.ModelField, model.Name, modellogging.VersionField, model.Version, "error", err)
+		return err
+	}
+	if c.Config.DryRun {
+		return c.generatePluginDryRun(model, start)
+	}
+	key, err := c.getBuildCacheKey(model)
+	if err != nil {
+		log.Errorw("Compiling plugin failed", modellogging.ModelField, model.Name, modellogging.VersionField, model.Version, "error", err)
+		return err
+	}
+	if key != "" {
+		if ok, err := c.restoreBuild(key, path); err != nil {
+			log.Warnw("Restoring plugin from the build cache failed", modellogging.ModelField, model.Name, modellogging.VersionField, model.Version, "error", err)
+		} else if ok {
+			c.report(BuildFinishedPhase, fmt.Sprintf("restored plugin '%s' from the build cache", path))
+			log.Infow("Compiled plugin from the build cache", modellogging.ModelField, model.Name, modellogging.VersionField, model.Version, modellogging.DurationField, time.Since(start))
+			return nil
+		}
+	}
+	compiler, err := c.newBuild(c.getSafeQualifiedName(model) + "-")
+	if err != nil {
+		log.Errorw("Compiling plugin failed", modellogging.ModelField, model.Name, modellogging.VersionField, model.Version, "error", err)
+		return err
+	}
+	defer c.removeDir(compiler.Config.BuildPath)
+	failed := func(err error) {
+		log.Errorw("Compiling plugin failed", modellogging.ModelField, model.Name, modellogging.VersionField, model.Version,
+			modellogging.PhaseField, compiler.phase, modellogging.DurationField, time.Since(start), "error", err)
+	}
+
+	// Generate the plugin module
+	if err := compiler.generatePlugin(model); err != nil {
+		failed(err)
+		compiler.cleanFailedBuild(model)
+		return err
+	}
 
+	// Compile the plugin
+	compiler.createDir(filepath.Dir(path))
+	if err := compiler.compilePlugin(model, path); err != nil {
+		failed(err)
+		compiler.cleanFailedBuild(model)
+		return err
+	}
+
+	// Clean up the build
+	if err := compiler.cleanBuild(model); err != nil {
+		failed(err)
+		return err
+	}
+	if key != "" {
+		c.storeBuild(key, path)
+	}
+	log.Infow("Compiled plugin", modellogging.ModelField, model.Name, modellogging.VersionField, model.Version, modellogging.DurationField, time.Since(start))
+	return nil
+}
+
+// generatePluginDryRun generates the plugin module for the given model without building the plugin
+// The build directory is kept whether or not generation succeeds, so the generated files can be inspected.
+func (c *PluginCompiler) generatePluginDryRun(model configmodel.ModelInfo, start time.Time) error {
+	compiler, err := c.newBuild(c.getSafeQualifiedName(model) + "-")
+	if err != nil {
+		log.Errorw("Generating plugin failed", modellogging.ModelField, model.Name, modellogging.VersionField, model.Version, "error", err)
+		return err
+	}
+	if err := compiler.generatePlugin(model); err != nil {
+		log.Errorw("Generating plugin failed", modellogging.ModelField, model.Name, modellogging.VersionField, model.Version,
+			modellogging.PhaseField, compiler.phase, "path", compiler.Config.BuildPath, "error", err)
+		return err
+	}
+	log.Infow("Generated plugin without building it", modellogging.ModelField, model.Name, modellogging.VersionField, model.Version,
+		"path", compiler.Config.BuildPath, modellogging.DurationField, time.Since(start))
+	return nil
+}
+
+// ResolveDependencies returns the modules (module@version) the plugin for the given model depends on
+// The plugin module is generated in a temporary build directory, but the plugin is not built.
+func (c *PluginCompiler) ResolveDependencies(model configmodel.ModelInfo) ([]string, error) {
+	log.Infof("Resolving dependencies for ConfigModel '%s/%s'", model.Name, model.Version)
+	compiler, err := c.newBuild("deps")
+	if err != nil {
+		log.Errorf("Resolving dependencies for ConfigModel '%s/%s' failed: %s", model.Name, model.Version, err)
+		return nil, err
+	}
+	defer os.RemoveAll(compiler.Config.BuildPath)
+
+	if err := compiler.generatePlugin(model); err != nil {
+		log.Errorf("Resolving dependencies for ConfigModel '%s/%s' failed: %s", model.Name, model.Version, err)
+		return nil, err
+	}
+	if compiler.Config.ModFile == "" {
+		if err := compiler.tidyMod(compiler.getModuleDir(model)); err != nil {
+			log.Errorf("Resolving dependencies for ConfigModel '%s/%s' failed: %s", model.Name, model.Version, err)
+			return nil, err
+		}
+	}
+	out, err := compiler.exec("listing module dependencies", compiler.getModuleDir(model), compiler.getGoBinary(), "list", "-m", "-f", "{{if not .Main}}{{.Path}}@{{.Version}}{{end}}", "all")
+	if err != nil {
+		log.Errorf("Resolving dependencies for ConfigModel '%s/%s' failed: %s", model.Name, model.Version, err)
+		return nil, err
+	}
+	var deps []string
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			deps = append(deps, line)
+		}
+	}
+	return deps, nil
+}
+
+// GenerateBindings generates the ygot Go bindings for the given model and returns their source
+// Only the bindings are generated in a temporary build directory; the plugin module is neither generated
+// nor built. The bindings are generated as they would be compiled into the model's plugin, e.g. in the
+// configured generator package.
+func (c *PluginCompiler) GenerateBindings(model configmodel.ModelInfo) ([]byte, error) {
+	log.Infow("Generating bindings", modellogging.ModelField, model.Name, modellogging.VersionField, model.Version)
+	compiler, err := c.newBuild(c.getSafeQualifiedName(model) + "-bindings-")
+	if err != nil {
+		log.Errorw("Generating bindings failed", modellogging.ModelField, model.Name, modellogging.VersionField, model.Version, "error", err)
+		return nil, err
+	}
+	defer c.removeDir(compiler.Config.BuildPath)
+
+	compiler.createDir(compiler.getModelDir(model))
+	compiler.createDir(compiler.getYangDir(model))
+	compiler.report(GeneratingBindingsPhase, fmt.Sprintf("generating YANG bindings for %d modules", len(model.Modules)))
+	if err := compiler.copyFiles(model); err != nil {
+		log.Errorw("Generating bindings failed", modellogging.ModelField, model.Name, modellogging.VersionField, model.Version, "error", err)
+		return nil, err
+	}
+	if err := compiler.copyStandardModules(model); err != nil {
+		log.Errorw("Generating bindings failed", modellogging.ModelField, model.Name, modellogging.VersionField, model.Version, "error", err)
+		return nil, err
+	}
+	if err := compiler.generateYangBindings(model); err != nil {
+		log.Errorw("Generating bindings failed", modellogging.ModelField, model.Name, modellogging.VersionField, model.Version, "error", err)
+		return nil, err
+	}
+	return ioutil.ReadFile(compiler.getModelPath(model, generatedFile))
+}
+
+// newBuild returns a copy of the compiler that builds in a new unique directory under the build path
+// The directory name begins with the given prefix.
+func (c *PluginCompiler) newBuild(prefix string) (*PluginCompiler, error) {
+	c.createDir(c.Config.BuildPath)
+	buildPath, err := ioutil.TempDir(c.Config.BuildPath, prefix)
+	if err != nil {
+		return nil, err
+	}
+	compiler := *c
+	compiler.Config.BuildPath = buildPath
+	return &compiler, nil
+}
+
+// generatePlugin generates the plugin module for the given model in the build directory
+func (c *PluginCompiler) generatePlugin(model configmodel.ModelInfo) error {
 	// Ensure the build directory exists
 	c.createDir(c.Config.BuildPath)
 
 	// Create the module files
 	c.createDir(c.getModuleDir(model))
 	if err := c.generateMod(model); err != nil {
-		log.Errorf("Compiling ConfigModel '%s/%s' failed: %s", model.Name, model.Version, err)
 		return err
 	}
+	return c.generatePackage(model)
+}
+
+// generatePackage generates the plugin package for the given model in the module directory
+func (c *PluginCompiler) generatePackage(model configmodel.ModelInfo) error {
+	c.createDir(c.getModuleDir(model))
 	if err := c.generateMain(model); err != nil {
-		log.Errorf("Compiling ConfigModel '%s/%s' failed: %s", model.Name, model.Version, err)
 		return err
 	}
 
 	// Create the model plugin
 	c.createDir(c.getModelDir(model))
 	if err := c.generateConfigModel(model); err != nil {
-		log.Errorf("Compiling ConfigModel '%s/%s' failed: %s", model.Name, model.Version, err)
 		return err
 	}
 	if err := c.generateModelPlugin(model); err != nil {
-		log.Errorf("Compiling ConfigModel '%s/%s' failed: %s", model.Name, model.Version, err)
 		return err
 	}
 
 	// Generate the YANG bindings
+	c.report(GeneratingBindingsPhase, fmt.Sprintf("generating YANG bindings for %d modules", len(model.Modules)))
 	c.createDir(c.getYangDir(model))
 	if err := c.copyFiles(model); err != nil {
-		log.Errorf("Compiling ConfigModel '%s/%s' failed: %s", model.Name, model.Version, err)
 		return err
 	}
-	if err := c.generateYangBindings(model); err != nil {
-		log.Errorf("Compiling ConfigModel '%s/%s' failed: %s", model.Name, model.Version, err)
-		return err
-	}
-
-	// Compile the plugin
-	c.createDir(filepath.Dir(path))
-	if err := c.compilePlugin(model, path); err != nil {
-		log.Errorf("Compiling ConfigModel '%s/%s' failed: %s", model.Name, model.Version, err)
-		return err
-	}
-
-	// Clean up the build
-	if err := c.cleanBuild(model); err != nil {
-		log.Errorf("Compiling ConfigModel '%s/%s' failed: %s", model.Name, model.Version, err)
+	if err := c.copyStandardModules(model); err != nil {
 		return err
 	}
-	return nil
+	return c.generateYangBindings(model)
 }
 
 func (c *PluginCompiler) getTemplateInfo(model configmodel.ModelInfo) (TemplateInfo, error) {
+	// Plugins record the checksum of the model they're compiled from, so prebuilt plugins can be verified
+	checksum, err := getModelChecksum(model)
+	if err != nil {
+		return TemplateInfo{}, err
+	}
+	model.Checksum = checksum
 	return TemplateInfo{
-		Model: model,
+		Model: getSortedModel(model),
 		Compiler: CompilerInfo{
 			Version:   getModuleVersion(),
 			IsRelease: isReleaseVersion(),
 			Root:      moduleRoot,
 		},
+		ModulePath:  c.getPluginMod(model),
+		PackageName: c.Config.GeneratorPackageName,
 	}, nil
 }
 
+// getSortedModel returns a copy of the given model with its modules, deviations and features sorted
+// Generated files must not depend on the order in which a model's modules were listed, so identical
+// models generate identical plugins.
+func getSortedModel(model configmodel.ModelInfo) configmodel.ModelInfo {
+	model.Modules = getSortedModules(model.Modules)
+	model.Deviations = getSortedModules(model.Deviations)
+	model.Features = append([]string(nil), model.Features...)
+	sort.Strings(model.Features)
+	return model
+}
+
+// getSortedModules returns a copy of the given modules sorted by name, revision and file
+func getSortedModules(modules []configmodel.ModuleInfo) []configmodel.ModuleInfo {
+	sorted := append([]configmodel.ModuleInfo(nil), modules...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Name != sorted[j].Name {
+			return sorted[i].Name < sorted[j].Name
+		}
+		if sorted[i].Revision != sorted[j].Revision {
+			return sorted[i].Revision < sorted[j].Revision
+		}
+		return sorted[i].File < sorted[j].File
+	})
+	return sorted
+}
+
 func (c *PluginCompiler) getPluginMod(model configmodel.ModelInfo) string {
-	return fmt.Sprintf("github.com/onosproject/onos-config-model/%s", c.getSafeQualifiedName(model))
+	return path.Join(c.Config.ModulePathPrefix, c.getSafeQualifiedName(model))
 }
 
 func (c *PluginCompiler) compilePlugin(model configmodel.ModelInfo, path string) error {
-	log.Infof("Compiling plugin '%s'", path)
-	log.Infof("go build -o %s -buildmode=plugin %s", path, c.getPluginMod(model))
-	_, err := c.exec(c.getModuleDir(model), "go", "mod", "tidy")
+	log.Debugf("Building plugin '%s'", path)
+	if c.Config.ModFile == "" {
+		if err := c.tidyMod(c.getModuleDir(model)); err != nil {
+			return err
+		}
+	}
+	return c.buildPlugin(c.getModuleDir(model), c.getPluginMod(model), path)
+}
+
+func (c *PluginCompiler) tidyMod(dir string) error {
+	// Tidying may query the module proxy, so offline builds add missing requirements from the module cache as they build
+	if c.Config.Offline {
+		log.Debugf("Skipping 'go mod tidy' in '%s' offline", dir)
+		return nil
+	}
+	_, err := c.exec(fmt.Sprintf("running 'go mod tidy' in '%s'", dir), dir, c.getGoBinary(), "mod", "tidy")
 	if err != nil {
-		log.Errorf("running 'go mod tidy' in '%s' failed: %s", path, err)
+		log.Errorf("running 'go mod tidy' in '%s' failed: %s", dir, err)
 		return err
 	}
-	_, err = c.exec(c.getModuleDir(model), "go", "build", "-o", path, "-buildmode=plugin", c.getPluginMod(model))
+	return nil
+}
+
+func (c *PluginCompiler) buildPlugin(dir string, pkg string, path string) error {
+	args := c.getBuildArgs(pkg, path)
+	log.Infof("go %s", strings.Join(args, " "))
+	c.report(BuildStartedPhase, fmt.Sprintf("go %s", strings.Join(args, " ")))
+	// Only the plugin is built for the target platform, since the other Go commands run tools on the host
+	env := append(c.getEnv(), c.GetPlatform().Env()...)
+	_, err := c.execEnv(fmt.Sprintf("building plugin '%s'", path), dir, env, c.getGoBinary(), args...)
 	if err != nil {
 		log.Errorf("Compiling plugin '%s' failed: %s", path, err)
 		return err
 	}
+	c.report(BuildFinishedPhase, fmt.Sprintf("built plugin '%s'", path))
 	return nil
 }
 
-func (c *PluginCompiler) exec(dir string, name string, args ...string) (string, error) {
-	cmd := exec.Command(name, args...)
+// exec runs a command for the given compilation phase in the given directory, returning its stdout
+// If the command fails, the returned CompileError includes its combined stdout and stderr.
+func (c *PluginCompiler) exec(phase string, dir string, name string, args ...string) (string, error) {
+	return c.execEnv(phase, dir, c.getEnv(), name, args...)
+}
+
+// execEnv runs a command with the given environment for the given compilation phase in the given directory
+func (c *PluginCompiler) execEnv(phase string, dir string, env []string, name string, args ...string) (string, error) {
+	ctx, cancel := c.newContext()
+	defer cancel()
+	cmd := exec.CommandContext(ctx, name, args...)
 	cmd.Dir = dir
-	cmd.Env = append(os.Environ(), "GO111MODULE=on", "CGO_ENABLED=1")
-	cmd.Stderr = os.Stderr
-	out, err := cmd.Output()
-	if err != nil {
-		return "", err
+	cmd.Env = env
+	var stdout bytes.Buffer
+	output := &outputBuffer{}
+	cmd.Stdout = io.MultiWriter(&stdout, output)
+	cmd.Stderr = io.MultiWriter(c.getStderr(), output)
+	if err := cmd.Run(); err != nil {
+		return "", c.getCompileError(ctx, phase, output.String(), err)
+	}
+	return stdout.String(), nil
+}
+
+// getEnv returns the environment with which Go commands are run on the host
+func (c *PluginCompiler) getEnv() []string {
+	return pluginmodule.GetEnv(c.Config.Offline, c.Config.OfflineEnv)
+}
+
+// getGoBinary returns the go command with which plugins are built
+func (c *PluginCompiler) getGoBinary() string {
+	if c.Config.GoBinary == "" {
+		return defaultGoBinary
+	}
+	return c.Config.GoBinary
+}
+
+// GetPlatform returns the platform for which plugins are built
+func (c *PluginCompiler) GetPlatform() pluginmodule.Platform {
+	return pluginmodule.NewPlatform(c.Config.GOOS, c.Config.GOARCH)
+}
+
+func (c *PluginCompiler) getBuildArgs(pkg string, path string) []string {
+	args := []string{"build", "-o", path, "-buildmode=plugin"}
+	if c.Config.Reproducible {
+		// Each compilation generates the plugin module in its own directory, so paths are trimmed to build
+		// identical plugins from identical models. The build ID is derived from the build's inputs, but is
+		// cleared as well.
+		args = append(args, "-trimpath", "-ldflags=-buildid=")
+	}
+	if c.Config.BuildParallelism > 0 {
+		args = append(args, fmt.Sprintf("-p=%d", c.Config.BuildParallelism))
+	}
+	if c.Config.ModFile != "" {
+		// The supplied go.mod/go.sum are used verbatim to pin the dependencies
+		args = append(args, "-mod=readonly")
+	}
+	return append(args, pkg)
+}
+
+// GetBuildParallelism returns the number of packages each plugin build compiles in parallel
+func (c *PluginCompiler) GetBuildParallelism() int {
+	if c.Config.BuildParallelism > 0 {
+		return c.Config.BuildParallelism
+	}
+	return runtime.NumCPU()
+}
+
+// SetTimeout changes the compilation phase timeout of the compiler and its copies
+// Phases that are already running keep the timeout with which they were started. The configured Timeout
+// is not changed, so copying the compiler never races with changes to the timeout.
+func (c *PluginCompiler) SetTimeout(timeout time.Duration) {
+	c.timeout.mu.Lock()
+	defer c.timeout.mu.Unlock()
+	c.timeout.value = timeout
+}
+
+// GetTimeout returns the compilation phase timeout
+func (c *PluginCompiler) GetTimeout() time.Duration {
+	c.timeout.mu.RLock()
+	defer c.timeout.mu.RUnlock()
+	return c.timeout.value
+}
+
+// getContext returns the context of the compilation, which is never done unless set WithContext
+func (c *PluginCompiler) getContext() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// newContext returns a context for a compilation phase bounded by the configured compilation phase timeout
+func (c *PluginCompiler) newContext() (context.Context, context.CancelFunc) {
+	timeout := c.GetTimeout()
+	if timeout == 0 {
+		return context.WithCancel(c.getContext())
+	}
+	return context.WithTimeout(c.getContext(), timeout)
+}
+
+func (c *PluginCompiler) getPhaseError(ctx context.Context, phase string, err error) error {
+	if err := c.getContext().Err(); err != nil {
+		return errors.NewCanceled("%s was aborted: %s", phase, err)
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return errors.NewTimeout("%s timed out after %s", phase, c.GetTimeout())
+	}
+	return err
+}
+
+// getCompileError returns the error for a failed compilation command with the given output
+// Timeouts and cancellations are reported as such rather than with the output of the killed command.
+func (c *PluginCompiler) getCompileError(ctx context.Context, phase string, output string, err error) error {
+	if ctx.Err() != nil {
+		return c.getPhaseError(ctx, phase, err)
+	}
+	return &CompileError{
+		Phase:  phase,
+		Output: output,
+		Err:    err,
+	}
+}
+
+// cleanFailedBuild cleans up the build of a model after a compile failure
+func (c *PluginCompiler) cleanFailedBuild(model configmodel.ModelInfo) {
+	if err := c.cleanBuild(model); err != nil {
+		log.Warnf("Cleaning up build for ConfigModel '%s/%s' failed: %s", model.Name, model.Version, err)
 	}
-	return string(out), nil
 }
 
 func (c *PluginCompiler) cleanBuild(model configmodel.ModelInfo) error {
@@ -231,7 +673,24 @@ func (c *PluginCompiler) copyFile(model configmodel.ModelInfo, file configmodel.
 	path := c.getYangPath(model, file)
 	log.Debugf("Copying YANG module '%s' to '%s'", file.Path, path)
 	if _, err := os.Stat(path); os.IsNotExist(err) {
-		err := ioutil.WriteFile(path, file.Data, os.ModePerm)
+		file, err := file.Decompress()
+		if err != nil {
+			log.Errorf("Copying YANG module '%s' failed: %s", file.Path, err)
+			return err
+		}
+		data, err := c.preprocess(file)
+		if err != nil {
+			log.Errorf("Copying YANG module '%s' failed: %s", file.Path, err)
+			return err
+		}
+		if len(model.Features) > 0 {
+			data, err = pruneFeatures(file.Path, data, model.Features)
+			if err != nil {
+				log.Errorf("Copying YANG module '%s' failed: %s", file.Path, err)
+				return err
+			}
+		}
+		err = ioutil.WriteFile(path, data, os.ModePerm)
 		if err != nil {
 			log.Errorf("Copying YANG module '%s' failed: %s", file.Path, err)
 			return err
@@ -240,36 +699,112 @@ func (c *PluginCompiler) copyFile(model configmodel.ModelInfo, file configmodel.
 	return nil
 }
 
+func (c *PluginCompiler) preprocess(file configmodel.FileInfo) ([]byte, error) {
+	if c.Preprocessor == nil {
+		return file.Data, nil
+	}
+	ctx, cancel := c.newContext()
+	defer cancel()
+	data, err := c.Preprocessor.Preprocess(ctx, file.Path, file.Data)
+	if err != nil {
+		return nil, c.getPhaseError(ctx, fmt.Sprintf("preprocessing YANG module '%s'", file.Path), err)
+	}
+	return data, nil
+}
+
 func (c *PluginCompiler) generateYangBindings(model configmodel.ModelInfo) error {
-	path := filepath.Join(c.getModelPath(model, "generated.go"))
+	path := c.getModelPath(model, generatedFile)
 	log.Debugf("Generating YANG bindings '%s'", path)
+	args, err := c.getGeneratorArgs(model)
+	if err != nil {
+		log.Errorf("Generating YANG bindings '%s' failed: %s", path, err)
+		return err
+	}
+
+	log.Infof("Run compilation in %s with %s %s", c.getModuleDir(model), c.getGoBinary(), strings.Join(args, " "))
+	// The generator is run with 'go run', so it's always built for the host rather than the target platform
+	ctx, cancel := c.newContext()
+	defer cancel()
+	cmd := exec.CommandContext(ctx, c.getGoBinary(), args...)
+	cmd.Env = c.getEnv()
+	output := &outputBuffer{}
+	cmd.Stdout = io.MultiWriter(os.Stdout, output)
+	cmd.Stderr = io.MultiWriter(c.getStderr(), output)
+	if err := cmd.Run(); err != nil {
+		err = c.getCompileError(ctx, fmt.Sprintf("generating YANG bindings '%s'", path), output.String(), err)
+		log.Errorf("Generating YANG bindings '%s' failed: %s", path, err)
+		return err
+	}
+	return nil
+}
+
+func (c *PluginCompiler) getGeneratorArgs(model configmodel.ModelInfo) ([]string, error) {
+	if err := ValidateGeneratorFlags(c.Config.GeneratorFlags); err != nil {
+		return nil, err
+	}
+	if err := ValidateGeneratorPackageName(c.Config.GeneratorPackageName); err != nil {
+		return nil, err
+	}
 	args := []string{
 		"run",
 		"github.com/openconfig/ygot/generator",
 		fmt.Sprintf("-path=%s/yang", c.getModuleDir(model)),
 		fmt.Sprintf("-output_file=%s/model/generated.go", c.getModuleDir(model)),
-		"-package_name=configmodel",
+		fmt.Sprintf("-package_name=%s", c.Config.GeneratorPackageName),
 		"-generate_fakeroot",
 	}
+	args = append(args, c.Config.GeneratorFlags...)
 
+	// Submodules are found on the path by the modules that include them
+	model = getSortedModel(model)
+	files := make(map[string]bool)
 	for _, module := range model.Modules {
+		if module.IsSubmodule() {
+			continue
+		}
+		files[module.File] = true
 		args = append(args, module.File)
 	}
 
-	log.Infof("Run compilation in %s with go %s", c.getModuleDir(model), strings.Join(args, " "))
-	cmd := exec.Command("go", args...)
-	cmd.Env = os.Environ()
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		log.Errorf("Generating YANG bindings '%s' failed: %s", path, err)
-		return err
+	// Deviation modules are applied to the modules they deviate when they're loaded by the generator
+	for _, module := range model.Deviations {
+		if module.IsSubmodule() || files[module.File] {
+			continue
+		}
+		files[module.File] = true
+		args = append(args, module.File)
+	}
+	return args, nil
+}
+
+// ValidateGeneratorFlags checks that the given ygot generator flags do not override the flags set by the compiler
+func ValidateGeneratorFlags(flags []string) error {
+	for _, flag := range flags {
+		if !strings.HasPrefix(flag, "-") {
+			return errors.NewInvalid("generator flag '%s' is not a flag", flag)
+		}
+		name := strings.TrimLeft(flag, "-")
+		if i := strings.Index(name, "="); i >= 0 {
+			name = name[:i]
+		}
+		for _, reserved := range reservedGeneratorFlags {
+			if name == reserved {
+				return errors.NewInvalid("generator flag '%s' is set by the compiler", flag)
+			}
+		}
 	}
 	return nil
 }
 
-func (c *PluginCompiler) getTemplatePath(name string) string {
-	return filepath.Join(c.Config.TemplatePath, name)
+// ValidateGeneratorPackageName checks that the given package name of the generated bindings is a valid package name
+func ValidateGeneratorPackageName(name string) error {
+	if !token.IsIdentifier(name) || name == "_" {
+		return errors.NewInvalid("generator package name '%s' is not a valid package name", name)
+	}
+	if name == "main" {
+		return errors.NewInvalid("generator package name '%s' is reserved for the plugin package", name)
+	}
+	return nil
 }
 
 func (c *PluginCompiler) generateMain(model configmodel.ModelInfo) error {
@@ -277,17 +812,17 @@ func (c *PluginCompiler) generateMain(model configmodel.ModelInfo) error {
 	if err != nil {
 		return err
 	}
-	return applyTemplate(mainTemplate, c.getTemplatePath(mainTemplate), c.getModulePath(model, mainFile), info)
+	return c.applyTemplate(mainTemplate, c.getModulePath(model, mainFile), info)
 }
 
-func (c *PluginCompiler) generateTemplate(model configmodel.ModelInfo, template, inPath, outPath string) error {
+func (c *PluginCompiler) generateTemplate(model configmodel.ModelInfo, template, outPath string) error {
 	log.Debugf("Generating '%s'", outPath)
 	info, err := c.getTemplateInfo(model)
 	if err != nil {
 		log.Errorf("Generating '%s' failed: %s", outPath, err)
 		return err
 	}
-	if err := applyTemplate(template, inPath, outPath, info); err != nil {
+	if err := c.applyTemplate(template, outPath, info); err != nil {
 		log.Errorf("Generating '%s' failed: %s", outPath, err)
 		return err
 	}
@@ -295,15 +830,37 @@ func (c *PluginCompiler) generateTemplate(model configmodel.ModelInfo, template,
 }
 
 func (c *PluginCompiler) generateMod(model configmodel.ModelInfo) error {
+	c.report(FetchingModulesPhase, fmt.Sprintf("generating module '%s'", c.getPluginMod(model)))
+	return c.writeMod(model, c.getPluginMod(model), c.getModuleDir(model))
+}
+
+// writeMod writes the go.mod for a module with the given path to the given directory
+func (c *PluginCompiler) writeMod(model configmodel.ModelInfo, modulePath string, dir string) error {
+	if c.Config.ModFile != "" {
+		return c.copyMod(modulePath, dir)
+	}
 	if c.resolver == nil {
-		return c.generateTemplate(model, modTemplate, c.getTemplatePath(modTemplate), c.getModulePath(model, modFile))
+		outPath := filepath.Join(dir, modFile)
+		log.Debugf("Generating '%s'", outPath)
+		info, err := c.getTemplateInfo(model)
+		if err != nil {
+			log.Errorf("Generating '%s' failed: %s", outPath, err)
+			return err
+		}
+		info.ModulePath = modulePath
+		if err := c.applyTemplate(modTemplate, outPath, info); err != nil {
+			log.Errorf("Generating '%s' failed: %s", outPath, err)
+			return err
+		}
+		return nil
 	}
-	return c.fetchMod(model)
+	return c.fetchMod(modulePath, dir)
 }
 
-func (c *PluginCompiler) fetchMod(model configmodel.ModelInfo) error {
-	log.Debugf("Generating '%s'", c.getModulePath(model, modFile))
-	mod, _, err := c.resolver.Resolve()
+func (c *PluginCompiler) fetchMod(modulePath string, dir string) error {
+	pluginModPath := filepath.Join(dir, modFile)
+	log.Debugf("Generating '%s'", pluginModPath)
+	mod, _, err := c.resolver.ResolveContext(c.getContext())
 	if err != nil {
 		log.Error(err)
 		return err
@@ -311,7 +868,11 @@ func (c *PluginCompiler) fetchMod(model configmodel.ModelInfo) error {
 
 	// Rename the target dependency module to adopt its dependencies for the plugin module
 	pluginModFile := mod
-	if err := pluginModFile.AddModuleStmt(c.getPluginMod(model)); err != nil {
+	if err := pluginModFile.AddModuleStmt(modulePath); err != nil {
+		return err
+	}
+	if err := c.addModReplaces(pluginModFile); err != nil {
+		log.Error(err)
 		return err
 	}
 
@@ -323,7 +884,6 @@ func (c *PluginCompiler) fetchMod(model configmodel.ModelInfo) error {
 	}
 
 	// Write the plugin module go.mod
-	pluginModPath := c.getModulePath(model, modFile)
 	if err := ioutil.WriteFile(pluginModPath, pluginMod, 0666); err != nil {
 		log.Error(err)
 		return err
@@ -331,12 +891,61 @@ func (c *PluginCompiler) fetchMod(model configmodel.ModelInfo) error {
 	return nil
 }
 
+func (c *PluginCompiler) copyMod(modulePath string, dir string) error {
+	pluginModPath := filepath.Join(dir, modFile)
+	log.Debugf("Copying '%s' to '%s'", c.Config.ModFile, pluginModPath)
+	modBytes, err := ioutil.ReadFile(c.Config.ModFile)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	pluginModFile, err := modfile.Parse(c.Config.ModFile, modBytes, nil)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	// Only the module path and replaces are changed; the requirements are used as is
+	if err := pluginModFile.AddModuleStmt(modulePath); err != nil {
+		return err
+	}
+	if err := c.addModReplaces(pluginModFile); err != nil {
+		log.Error(err)
+		return err
+	}
+	pluginMod, err := pluginModFile.Format()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	if err := ioutil.WriteFile(pluginModPath, pluginMod, 0666); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	if c.Config.SumFile == "" {
+		return nil
+	}
+	pluginSumPath := filepath.Join(dir, sumFile)
+	log.Debugf("Copying '%s' to '%s'", c.Config.SumFile, pluginSumPath)
+	sumBytes, err := ioutil.ReadFile(c.Config.SumFile)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	if err := ioutil.WriteFile(pluginSumPath, sumBytes, 0666); err != nil {
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
 func (c *PluginCompiler) generateModelPlugin(model configmodel.ModelInfo) error {
-	return c.generateTemplate(model, pluginTemplate, c.getTemplatePath(pluginTemplate), c.getModelPath(model, pluginFile))
+	return c.generateTemplate(model, pluginTemplate, c.getModelPath(model, pluginFile))
 }
 
 func (c *PluginCompiler) generateConfigModel(model configmodel.ModelInfo) error {
-	return c.generateTemplate(model, modelTemplate, c.getTemplatePath(modelTemplate), c.getModelPath(model, modelFile))
+	return c.generateTemplate(model, modelTemplate, c.getModelPath(model, modelFile))
 }
 
 func (c *PluginCompiler) getModuleDir(model configmodel.ModelInfo) string {
@@ -364,7 +973,7 @@ func (c *PluginCompiler) getYangPath(model configmodel.ModelInfo, file configmod
 }
 
 func (c *PluginCompiler) getSafeQualifiedName(model configmodel.ModelInfo) string {
-	return strings.ReplaceAll(fmt.Sprintf("%s_%s", model.Name, model.Version), ".", "_")
+	return strings.ReplaceAll(fmt.Sprintf("%s_%s%s", model.Name, model.Version, c.GetPlatform().Suffix()), ".", "_")
 }
 
 func (c *PluginCompiler) createDir(dir string) {
@@ -387,29 +996,3 @@ func (c *PluginCompiler) removeDir(dir string) {
 		}
 	}
 }
-
-func applyTemplate(name, tplPath, outPath string, data TemplateInfo) error {
-	var funcs template.FuncMap = map[string]interface{}{
-		"quote": func(value interface{}) string {
-			return fmt.Sprintf("\"%s\"", value)
-		},
-		"replace": func(search, replace string, value interface{}) string {
-			return strings.ReplaceAll(fmt.Sprint(value), search, replace)
-		},
-	}
-
-	tpl, err := template.New(name).
-		Funcs(funcs).
-		ParseFiles(tplPath)
-	if err != nil {
-		return err
-	}
-
-	file, err := os.Create(outPath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	return tpl.Execute(file, data)
-}
@@ -6,13 +6,23 @@ package plugincompiler
 
 import (
 	"context"
+	"crypto/sha256"
+	"fmt"
 	"github.com/onosproject/onos-config-model/pkg/model"
+	modelplugin "github.com/onosproject/onos-config-model/pkg/model/plugin"
 	plugincache "github.com/onosproject/onos-config-model/pkg/model/plugin/cache"
 	pluginmodule "github.com/onosproject/onos-config-model/pkg/model/plugin/module"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"go/parser"
+	"go/token"
 	"io/ioutil"
+	"os"
 	"path/filepath"
+	"runtime"
+	"syscall"
 	"testing"
+	"time"
 )
 
 func TestCompiler(t *testing.T) {
@@ -75,3 +85,1012 @@ func TestCompiler(t *testing.T) {
 	err = entry.Unlock(context.TODO())
 	assert.NoError(t, err)
 }
+
+func TestModulePathPrefix(t *testing.T) {
+	buildPath, err := ioutil.TempDir("", "config-model-build")
+	assert.NoError(t, err)
+	defer os.RemoveAll(buildPath)
+
+	modelInfo := configmodel.ModelInfo{
+		Name:    "test",
+		Version: "1.0.0",
+	}
+
+	// Prefixes are joined to the module name whether or not they end with a slash
+	for _, prefix := range []string{"example.com/models/", "example.com/models"} {
+		config := CompilerConfig{
+			TemplatePath:     filepath.Join(moduleRoot, "pkg", "model", "plugin", "compiler", "templates"),
+			BuildPath:        buildPath,
+			ModulePathPrefix: prefix,
+		}
+		compiler := NewPluginCompiler(config, nil)
+		assert.Equal(t, "example.com/models/test_1_0_0", compiler.getPluginMod(modelInfo))
+
+		compiler.createDir(compiler.getModuleDir(modelInfo))
+		assert.NoError(t, compiler.generateMod(modelInfo))
+		assert.NoError(t, compiler.generateMain(modelInfo))
+
+		mod, err := ioutil.ReadFile(compiler.getModulePath(modelInfo, modFile))
+		assert.NoError(t, err)
+		assert.Contains(t, string(mod), "module example.com/models/test_1_0_0\n")
+
+		main, err := ioutil.ReadFile(compiler.getModulePath(modelInfo, mainFile))
+		assert.NoError(t, err)
+		assert.Contains(t, string(main), `"example.com/models/test_1_0_0/model"`)
+	}
+}
+
+func TestCompileModulePathPrefix(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping plugin build in short mode")
+	}
+
+	dir, err := ioutil.TempDir("", "config-model-compiler")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	compiler := NewPluginCompiler(CompilerConfig{
+		TemplatePath:     "templates",
+		BuildPath:        filepath.Join(dir, "build"),
+		ModFile:          writePinnedModFile(t, dir),
+		SumFile:          filepath.Join(moduleRoot, "go.sum"),
+		ModulePathPrefix: "example.com/models",
+		SkipCleanUp:      true,
+	}, nil)
+	model := newTestModel(t)
+
+	// The plugin is built as a module under the prefix
+	path := filepath.Join(dir, "test-1.0.0.so")
+	assert.NoError(t, compiler.CompilePlugin(model, path))
+	_, err = os.Stat(path)
+	assert.NoError(t, err)
+	pluginMod, err := ioutil.ReadFile(filepath.Join(getKeptModuleDir(t, compiler, model), modFile))
+	assert.NoError(t, err)
+	assert.Contains(t, string(pluginMod), "module example.com/models/test_1_0_0\n")
+}
+
+func TestCopyCompressedFile(t *testing.T) {
+	buildPath, err := ioutil.TempDir("", "config-model-build")
+	assert.NoError(t, err)
+	defer os.RemoveAll(buildPath)
+
+	compiler := NewPluginCompiler(CompilerConfig{BuildPath: buildPath}, nil)
+
+	data, err := ioutil.ReadFile(filepath.Join(moduleRoot, "test", "test@2020-11-18.yang"))
+	assert.NoError(t, err)
+	file, err := configmodel.FileInfo{Path: "test@2020-11-18.yang", Data: data}.Compress()
+	assert.NoError(t, err)
+	assert.True(t, file.IsCompressed())
+
+	modelInfo := configmodel.ModelInfo{
+		Name:    "test",
+		Version: "1.0.0",
+		Files:   []configmodel.FileInfo{file},
+	}
+	compiler.createDir(compiler.getYangDir(modelInfo))
+	assert.NoError(t, compiler.copyFiles(modelInfo))
+
+	copied, err := ioutil.ReadFile(compiler.getYangPath(modelInfo, file))
+	assert.NoError(t, err)
+	assert.Equal(t, data, copied)
+}
+
+func TestGenerateModelFeatures(t *testing.T) {
+	buildPath, err := ioutil.TempDir("", "config-model-build")
+	assert.NoError(t, err)
+	defer os.RemoveAll(buildPath)
+
+	compiler := NewPluginCompiler(CompilerConfig{
+		TemplatePath: filepath.Join(moduleRoot, "pkg", "model", "plugin", "compiler", "templates"),
+		BuildPath:    buildPath,
+	}, nil)
+
+	modelInfo := configmodel.ModelInfo{
+		Name:         "test",
+		Version:      "1.0.0",
+		GetStateMode: configmodel.GetStateNone,
+		Features:     []string{"feature-a", "feature-b"},
+		Deviations: []configmodel.ModuleInfo{
+			{Name: "test-deviations", File: "test-deviations.yang", Revision: "2020-11-18"},
+		},
+	}
+	compiler.createDir(compiler.getModelDir(modelInfo))
+	assert.NoError(t, compiler.generateConfigModel(modelInfo))
+
+	path := compiler.getModelPath(modelInfo, modelFile)
+	_, err = parser.ParseFile(token.NewFileSet(), path, nil, parser.AllErrors)
+	assert.NoError(t, err)
+
+	model, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(model), `"feature-a",`)
+	assert.Contains(t, string(model), `"feature-b",`)
+	assert.Contains(t, string(model), `Name: configmodel.Name("test-deviations")`)
+}
+
+// writePinnedModFile writes a go.mod pinning plugin dependencies to those of this module
+func writePinnedModFile(t *testing.T, dir string) string {
+	modPath := filepath.Join(dir, "go.mod")
+	mod := fmt.Sprintf(`module example.com/pinned
+
+go 1.16
+
+require (
+	github.com/golang/protobuf v1.5.2
+	github.com/onosproject/onos-config-model v0.0.0
+	github.com/openconfig/gnmi v0.0.0-20210914185457-51254b657b7d
+	github.com/openconfig/goyang v0.3.1
+	github.com/openconfig/ygot v0.12.4
+)
+
+replace github.com/onosproject/onos-config-model => %s
+`, moduleRoot)
+	assert.NoError(t, ioutil.WriteFile(modPath, []byte(mod), 0666))
+	return modPath
+}
+
+// getKeptModuleDir returns the module directory of the only build of the given model kept by the compiler
+func getKeptModuleDir(t *testing.T, compiler *PluginCompiler, model configmodel.ModelInfo) string {
+	name := compiler.getSafeQualifiedName(model)
+	dirs, err := filepath.Glob(filepath.Join(compiler.Config.BuildPath, name+"-*", name))
+	assert.NoError(t, err)
+	assert.Len(t, dirs, 1)
+	return dirs[0]
+}
+
+func newTestModel(t *testing.T) configmodel.ModelInfo {
+	bytes, err := ioutil.ReadFile(filepath.Join(moduleRoot, "test", "test@2020-11-18.yang"))
+	assert.NoError(t, err)
+	return configmodel.ModelInfo{
+		Name:         "test",
+		Version:      "1.0.0",
+		GetStateMode: configmodel.GetStateNone,
+		Modules: []configmodel.ModuleInfo{
+			{
+				Name:     "test",
+				Revision: "2020-11-18",
+				File:     "test@2020-11-18.yang",
+			},
+		},
+		Files: []configmodel.FileInfo{
+			{
+				Path: "test@2020-11-18.yang",
+				Data: bytes,
+			},
+		},
+	}
+}
+
+func TestCompileWithModFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping plugin build in short mode")
+	}
+
+	dir, err := ioutil.TempDir("", "config-model-compiler")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	compiler := NewPluginCompiler(CompilerConfig{
+		TemplatePath: "templates",
+		BuildPath:    filepath.Join(dir, "build"),
+		ModFile:      writePinnedModFile(t, dir),
+		SumFile:      filepath.Join(moduleRoot, "go.sum"),
+		SkipCleanUp:  true,
+	}, nil)
+	model := newTestModel(t)
+
+	path := filepath.Join(dir, "test-1.0.0.so")
+	assert.NoError(t, compiler.CompilePlugin(model, path))
+	_, err = os.Stat(path)
+	assert.NoError(t, err)
+
+	// The supplied go.sum is used as is
+	moduleDir := getKeptModuleDir(t, compiler, model)
+	sum, err := ioutil.ReadFile(filepath.Join(moduleDir, sumFile))
+	assert.NoError(t, err)
+	expected, err := ioutil.ReadFile(filepath.Join(moduleRoot, "go.sum"))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, sum)
+
+	pluginMod, err := ioutil.ReadFile(filepath.Join(moduleDir, modFile))
+	assert.NoError(t, err)
+	assert.Contains(t, string(pluginMod), "module github.com/onosproject/onos-config-model/test_1_0_0")
+	assert.Contains(t, string(pluginMod), "github.com/onosproject/onos-config-model v0.0.0")
+
+	// The plugin is versioned to be checked when it's loaded
+	main, err := ioutil.ReadFile(filepath.Join(moduleDir, mainFile))
+	assert.NoError(t, err)
+	assert.Contains(t, string(main), fmt.Sprintf("var PluginVersion = %q", getModuleVersion()))
+}
+
+func TestReproducibleBuild(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping plugin build in short mode")
+	}
+
+	dir, err := ioutil.TempDir("", "config-model-compiler")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	compiler := NewPluginCompiler(CompilerConfig{
+		TemplatePath: "templates",
+		BuildPath:    filepath.Join(dir, "build"),
+		ModFile:      writePinnedModFile(t, dir),
+		SumFile:      filepath.Join(moduleRoot, "go.sum"),
+		Reproducible: true,
+	}, nil)
+	model := newTestModel(t)
+
+	// Each compilation generates the plugin module in a different directory, which must not be embedded in the plugin
+	var checksums []string
+	for _, name := range []string{"test-1.0.0.so", "test-1.0.0-copy.so"} {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, compiler.CompilePlugin(model, path))
+		bytes, err := ioutil.ReadFile(path)
+		assert.NoError(t, err)
+		checksums = append(checksums, fmt.Sprintf("%x", sha256.Sum256(bytes)))
+	}
+	assert.Equal(t, checksums[0], checksums[1])
+
+	// Generated files do not depend on the order of the model's modules, deviations and features
+	// The plugin is otherwise only as reproducible as the Go toolchain and C linker, so plugins built with
+	// different go commands, CC or CGO_CFLAGS may still differ.
+	model.Modules = append(model.Modules, configmodel.ModuleInfo{Name: "a", File: "a.yang"})
+	model.Features = []string{"b", "a"}
+	reordered := model
+	reordered.Modules = []configmodel.ModuleInfo{model.Modules[1], model.Modules[0]}
+	reordered.Features = []string{"a", "b"}
+	info, err := compiler.getTemplateInfo(model)
+	assert.NoError(t, err)
+	reorderedInfo, err := compiler.getTemplateInfo(reordered)
+	assert.NoError(t, err)
+	assert.Equal(t, info, reorderedInfo)
+	assert.Equal(t, configmodel.Name("a"), info.Model.Modules[0].Name)
+	assert.Equal(t, []string{"b", "a"}, model.Features)
+	args, err := compiler.getGeneratorArgs(model)
+	assert.NoError(t, err)
+	reorderedArgs, err := compiler.getGeneratorArgs(reordered)
+	assert.NoError(t, err)
+	assert.Equal(t, args, reorderedArgs)
+}
+
+func TestResolveDependencies(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping plugin generation in short mode")
+	}
+
+	dir, err := ioutil.TempDir("", "config-model-compiler")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	buildPath := filepath.Join(dir, "build")
+	compiler := NewPluginCompiler(CompilerConfig{
+		TemplatePath: "templates",
+		BuildPath:    buildPath,
+		ModFile:      writePinnedModFile(t, dir),
+		SumFile:      filepath.Join(moduleRoot, "go.sum"),
+	}, nil)
+
+	deps, err := compiler.ResolveDependencies(newTestModel(t))
+	assert.NoError(t, err)
+	assert.Contains(t, deps, "github.com/openconfig/ygot@v0.12.4")
+	assert.Contains(t, deps, "github.com/openconfig/goyang@v0.3.1")
+
+	// The temporary build directory is removed
+	files, err := ioutil.ReadDir(buildPath)
+	assert.NoError(t, err)
+	assert.Len(t, files, 0)
+}
+
+func TestCompilePlugins(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping plugin build in short mode")
+	}
+
+	dir, err := ioutil.TempDir("", "config-model-compiler")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	buildPath := filepath.Join(dir, "build")
+	compiler := NewPluginCompiler(CompilerConfig{
+		TemplatePath: "templates",
+		BuildPath:    buildPath,
+		ModFile:      writePinnedModFile(t, dir),
+		SumFile:      filepath.Join(moduleRoot, "go.sum"),
+	}, nil)
+
+	model1 := newTestModel(t)
+	model2 := newTestModel(t)
+	model2.Version = "2.0.0"
+	bad := newTestModel(t)
+	bad.Name = "bad"
+	bad.Files[0].Data = []byte("module test {")
+
+	paths := []string{
+		filepath.Join(dir, "test-1.0.0.so"),
+		filepath.Join(dir, "bad-1.0.0.so"),
+		filepath.Join(dir, "test-2.0.0.so"),
+	}
+	err = compiler.CompilePlugins([]configmodel.ModelInfo{model1, bad, model2}, paths)
+	assert.Error(t, err)
+	failures, ok := err.(CompileErrors)
+	assert.True(t, ok)
+	assert.Len(t, failures, 1)
+	assert.Contains(t, failures, "bad@1.0.0")
+
+	// The remaining models in the batch are compiled
+	_, err = os.Stat(paths[0])
+	assert.NoError(t, err)
+	_, err = os.Stat(paths[1])
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(paths[2])
+	assert.NoError(t, err)
+
+	files, err := ioutil.ReadDir(buildPath)
+	assert.NoError(t, err)
+	assert.Len(t, files, 0)
+}
+
+func TestCompileTimeout(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping plugin generation in short mode")
+	}
+
+	dir, err := ioutil.TempDir("", "config-model-compiler")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	buildPath := filepath.Join(dir, "build")
+	compiler := NewPluginCompiler(CompilerConfig{
+		TemplatePath: "templates",
+		BuildPath:    buildPath,
+		ModFile:      writePinnedModFile(t, dir),
+		SumFile:      filepath.Join(moduleRoot, "go.sum"),
+		Timeout:      time.Nanosecond,
+	}, nil)
+	model := newTestModel(t)
+
+	err = compiler.CompilePlugin(model, filepath.Join(dir, "test-1.0.0.so"))
+	assert.True(t, errors.IsTimeout(err))
+	assert.Contains(t, err.Error(), "generating YANG bindings")
+
+	// The partial build is cleaned up
+	files, err := ioutil.ReadDir(buildPath)
+	assert.NoError(t, err)
+	assert.Len(t, files, 0)
+}
+
+func TestSetTimeout(t *testing.T) {
+	compiler := NewPluginCompiler(CompilerConfig{
+		BuildPath: t.TempDir(),
+		Timeout:   time.Minute,
+	}, nil)
+	copied := compiler.WithContext(context.Background())
+
+	// Copies of the compiler share its timeout, and copying the compiler does not race with changing it
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			compiler.WithContext(context.Background())
+		}
+	}()
+	compiler.SetTimeout(time.Hour)
+	<-done
+	assert.Equal(t, time.Hour, compiler.GetTimeout())
+	assert.Equal(t, time.Hour, copied.GetTimeout())
+	assert.Equal(t, time.Minute, compiler.Config.Timeout)
+}
+
+func TestCompileCanceled(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-model-compiler")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	buildPath := filepath.Join(dir, "build")
+	compiler := NewPluginCompiler(CompilerConfig{
+		TemplatePath: "templates",
+		BuildPath:    buildPath,
+		ModFile:      writePinnedModFile(t, dir),
+		SumFile:      filepath.Join(moduleRoot, "go.sum"),
+	}, nil)
+	model := newTestModel(t)
+
+	// Replace the go command with one that records its pid and hangs until it's killed
+	binPath := filepath.Join(dir, "bin")
+	assert.NoError(t, os.Mkdir(binPath, 0755))
+	pidPath := filepath.Join(dir, "go.pid")
+	script := fmt.Sprintf("#!/bin/sh\necho $$ > %s.tmp\nmv %s.tmp %s\nexec sleep 60\n", pidPath, pidPath, pidPath)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(binPath, "go"), []byte(script), 0755))
+	path := os.Getenv("PATH")
+	assert.NoError(t, os.Setenv("PATH", binPath+string(os.PathListSeparator)+path))
+	defer os.Setenv("PATH", path)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- compiler.WithContext(ctx).CompilePlugin(model, filepath.Join(dir, "test-1.0.0.so"))
+	}()
+
+	var pid int
+	assert.Eventually(t, func() bool {
+		bytes, err := ioutil.ReadFile(pidPath)
+		if err != nil {
+			return false
+		}
+		_, err = fmt.Sscanf(string(bytes), "%d", &pid)
+		return err == nil
+	}, 10*time.Second, 10*time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errCh:
+		assert.True(t, errors.IsCanceled(err))
+	case <-time.After(10 * time.Second):
+		t.Fatal("compilation was not aborted")
+	}
+
+	// The go command is killed and the partial build is cleaned up
+	process, err := os.FindProcess(pid)
+	if err == nil {
+		assert.Error(t, process.Signal(syscall.Signal(0)))
+	}
+	files, err := ioutil.ReadDir(buildPath)
+	assert.NoError(t, err)
+	assert.Len(t, files, 0)
+}
+
+func TestGoBinary(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-model-compiler")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// The go command on the PATH is used by default
+	compiler := NewPluginCompiler(CompilerConfig{BuildPath: filepath.Join(dir, "build")}, nil)
+	version, err := compiler.GetGoVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, runtime.Version(), version)
+	assert.NoError(t, compiler.CheckGoVersion())
+
+	// A go command with a different version is used for every compilation phase
+	goBinary := filepath.Join(dir, "go")
+	script := "#!/bin/sh\nif [ \"$1\" = env ]; then echo go0.0.1; exit 0; fi\necho \"fake go $*\" >&2\nexit 1\n"
+	assert.NoError(t, ioutil.WriteFile(goBinary, []byte(script), 0755))
+	compiler = NewPluginCompiler(CompilerConfig{
+		TemplatePath: "templates",
+		BuildPath:    filepath.Join(dir, "build"),
+		GoBinary:     goBinary,
+	}, nil)
+	version, err = compiler.GetGoVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, "go0.0.1", version)
+	err = compiler.CheckGoVersion()
+	assert.True(t, errors.IsInvalid(err))
+	assert.Contains(t, err.Error(), runtime.Version())
+	err = compiler.CompilePlugin(newTestModel(t), filepath.Join(dir, "test-1.0.0.so"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "fake go")
+
+	compiler = NewPluginCompiler(CompilerConfig{GoBinary: filepath.Join(dir, "missing")}, nil)
+	assert.True(t, errors.IsUnavailable(compiler.CheckGoVersion()))
+}
+
+func TestSmokeTest(t *testing.T) {
+	if testing.Short() || raceEnabled {
+		t.Skip("building plugins is slow and they cannot be loaded by binaries built with -race")
+	}
+
+	dir, err := ioutil.TempDir("", "config-model-compiler")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	compiler := NewPluginCompiler(CompilerConfig{BuildPath: filepath.Join(dir, "build")}, nil)
+	assert.NoError(t, compiler.SmokeTest())
+
+	// The test plugin is removed once built
+	files, err := ioutil.ReadDir(filepath.Join(dir, "build"))
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+
+	compiler = NewPluginCompiler(CompilerConfig{
+		BuildPath: filepath.Join(dir, "build"),
+		GoBinary:  filepath.Join(dir, "missing"),
+	}, nil)
+	assert.Error(t, compiler.SmokeTest())
+}
+
+func TestConcurrentCompile(t *testing.T) {
+	if testing.Short() || raceEnabled {
+		t.Skip("building plugins is slow and they cannot be loaded by binaries built with -race")
+	}
+
+	dir, err := ioutil.TempDir("", "config-model-compiler")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	buildPath := filepath.Join(dir, "build")
+	compiler := NewPluginCompiler(CompilerConfig{
+		TemplatePath: "templates",
+		BuildPath:    buildPath,
+		ModFile:      writePinnedModFile(t, dir),
+		SumFile:      filepath.Join(moduleRoot, "go.sum"),
+	}, nil)
+
+	// Distinct models compiled at the same time share the build path
+	model1 := newTestModel(t)
+	model2 := newTestModel(t)
+	model2.Name = "other"
+	models := []configmodel.ModelInfo{model1, model2}
+	paths := []string{
+		filepath.Join(dir, "test-1.0.0.so"),
+		filepath.Join(dir, "other-1.0.0.so"),
+	}
+	errs := make(chan error, len(models))
+	for i := range models {
+		go func(model configmodel.ModelInfo, path string) {
+			errs <- compiler.CompilePlugin(model, path)
+		}(models[i], paths[i])
+	}
+	for range models {
+		assert.NoError(t, <-errs)
+	}
+
+	for i, path := range paths {
+		plugin, err := modelplugin.Load(path)
+		assert.NoError(t, err)
+		assert.Equal(t, models[i].Name, plugin.Model().Info().Name)
+	}
+
+	files, err := ioutil.ReadDir(buildPath)
+	assert.NoError(t, err)
+	assert.Len(t, files, 0)
+}
+
+func TestGeneratorFlags(t *testing.T) {
+	compiler := NewPluginCompiler(CompilerConfig{
+		BuildPath:      "build",
+		GeneratorFlags: []string{"-compress_paths", "-exclude_state", "-generate_getters"},
+	}, nil)
+	model := configmodel.ModelInfo{
+		Name:    "test",
+		Version: "1.0.0",
+		Modules: []configmodel.ModuleInfo{
+			{
+				Name: "test",
+				File: "test@2020-11-18.yang",
+			},
+		},
+	}
+	args, err := compiler.getGeneratorArgs(model)
+	assert.NoError(t, err)
+	assert.Contains(t, args, "-package_name=configmodel")
+	assert.Contains(t, args, "-generate_fakeroot")
+	assert.Equal(t, []string{"-compress_paths", "-exclude_state", "-generate_getters", "test@2020-11-18.yang"}, args[len(args)-4:])
+
+	assert.NoError(t, ValidateGeneratorFlags(nil))
+	assert.True(t, errors.IsInvalid(ValidateGeneratorFlags([]string{"-output_file=foo.go"})))
+	assert.True(t, errors.IsInvalid(ValidateGeneratorFlags([]string{"--path=yang"})))
+	assert.True(t, errors.IsInvalid(ValidateGeneratorFlags([]string{"-package_name=foo"})))
+	assert.True(t, errors.IsInvalid(ValidateGeneratorFlags([]string{"compress_paths"})))
+
+	// The plugin template depends on the generated fake root
+	assert.True(t, errors.IsInvalid(ValidateGeneratorFlags([]string{"-fakeroot_name=foo"})))
+	assert.True(t, errors.IsInvalid(ValidateGeneratorFlags([]string{"-generate_fakeroot=false"})))
+
+	compiler.Config.GeneratorFlags = []string{"-path=yang"}
+	_, err = compiler.getGeneratorArgs(model)
+	assert.True(t, errors.IsInvalid(err))
+}
+
+func TestGeneratorPackageName(t *testing.T) {
+	model := newTestModel(t)
+	args, err := NewPluginCompiler(CompilerConfig{BuildPath: "build"}, nil).getGeneratorArgs(model)
+	assert.NoError(t, err)
+	assert.Contains(t, args, "-package_name=configmodel")
+
+	compiler := NewPluginCompiler(CompilerConfig{BuildPath: "build", GeneratorPackageName: "testmodel"}, nil)
+	args, err = compiler.getGeneratorArgs(model)
+	assert.NoError(t, err)
+	assert.Contains(t, args, "-package_name=testmodel")
+	info, err := compiler.getTemplateInfo(model)
+	assert.NoError(t, err)
+	assert.Equal(t, "testmodel", info.PackageName)
+
+	assert.NoError(t, ValidateGeneratorPackageName("testmodel"))
+	assert.True(t, errors.IsInvalid(ValidateGeneratorPackageName("main")))
+	assert.True(t, errors.IsInvalid(ValidateGeneratorPackageName("_")))
+	assert.True(t, errors.IsInvalid(ValidateGeneratorPackageName("test-model")))
+	assert.True(t, errors.IsInvalid(ValidateGeneratorPackageName("func")))
+
+	compiler.Config.GeneratorPackageName = "main"
+	_, err = compiler.getGeneratorArgs(model)
+	assert.True(t, errors.IsInvalid(err))
+
+	if testing.Short() {
+		t.Skip("skipping plugin build in short mode")
+	}
+
+	dir, err := ioutil.TempDir("", "config-model-compiler")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	compiler = NewPluginCompiler(CompilerConfig{
+		TemplatePath:         "templates",
+		BuildPath:            filepath.Join(dir, "build"),
+		ModFile:              writePinnedModFile(t, dir),
+		SumFile:              filepath.Join(moduleRoot, "go.sum"),
+		SkipCleanUp:          true,
+		GeneratorPackageName: "testmodel",
+	}, nil)
+	assert.NoError(t, compiler.CompilePlugin(model, filepath.Join(dir, "test-1.0.0.so")))
+
+	// The generated bindings and the templates are compiled in the configured package
+	moduleDir := getKeptModuleDir(t, compiler, model)
+	for _, file := range []string{"generated.go", modelFile, pluginFile} {
+		data, err := ioutil.ReadFile(filepath.Join(moduleDir, "model", file))
+		assert.NoError(t, err)
+		assert.Contains(t, string(data), "package testmodel\n")
+	}
+	main, err := ioutil.ReadFile(filepath.Join(moduleDir, mainFile))
+	assert.NoError(t, err)
+	assert.Contains(t, string(main), "var ConfigModelPlugin testmodel.ConfigModelPlugin")
+}
+
+func TestGenerateBindings(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping plugin generation in short mode")
+	}
+
+	dir, err := ioutil.TempDir("", "config-model-compiler")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	buildPath := filepath.Join(dir, "build")
+	compiler := NewPluginCompiler(CompilerConfig{
+		TemplatePath:         "templates",
+		BuildPath:            buildPath,
+		GeneratorPackageName: "testmodel",
+	}, nil)
+	source, err := compiler.GenerateBindings(newTestModel(t))
+	assert.NoError(t, err)
+	file, err := parser.ParseFile(token.NewFileSet(), generatedFile, source, parser.PackageClauseOnly)
+	assert.NoError(t, err)
+	assert.Equal(t, "testmodel", file.Name.Name)
+
+	// Only the bindings are generated, and the build is cleaned up
+	files, err := ioutil.ReadDir(buildPath)
+	assert.NoError(t, err)
+	assert.Len(t, files, 0)
+}
+
+func TestCompileDryRun(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping plugin generation in short mode")
+	}
+
+	dir, err := ioutil.TempDir("", "config-model-compiler")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	buildPath := filepath.Join(dir, "build")
+	compiler := NewPluginCompiler(CompilerConfig{
+		TemplatePath:   "templates",
+		BuildPath:      buildPath,
+		ModFile:        writePinnedModFile(t, dir),
+		BuildCachePath: filepath.Join(dir, "build-cache"),
+		DryRun:         true,
+	}, nil)
+	model := newTestModel(t)
+	path := filepath.Join(dir, "test-1.0.0.so")
+	assert.NoError(t, compiler.CompilePlugin(model, path))
+
+	// No plugin is built or cached
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(dir, "build-cache"))
+	assert.True(t, os.IsNotExist(err))
+
+	// The generated plugin module is kept in the build directory
+	builds, err := ioutil.ReadDir(buildPath)
+	assert.NoError(t, err)
+	assert.Len(t, builds, 1)
+	build := compiler.Config
+	build.BuildPath = filepath.Join(buildPath, builds[0].Name())
+	generated := NewPluginCompiler(build, nil)
+	_, err = os.Stat(filepath.Join(generated.getModuleDir(model), modFile))
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(generated.getModelDir(model), generatedFile))
+	assert.NoError(t, err)
+
+	// Schema errors fail the dry run
+	model.Files[0].Data = []byte("module test {")
+	assert.Error(t, compiler.CompilePlugin(model, path))
+}
+
+const testSubmoduleParentYang = `module parent {
+  namespace "http://opennetworking.org/test/parent";
+  prefix p;
+  include child;
+
+  container parent {
+    leaf name {
+      type string;
+    }
+  }
+}
+`
+
+const testSubmoduleChildYang = `submodule child {
+  belongs-to parent {
+    prefix p;
+  }
+
+  container child {
+    leaf value {
+      type string;
+    }
+  }
+}
+`
+
+func TestCompileSubmodule(t *testing.T) {
+	model := configmodel.ModelInfo{
+		Name:         "parent",
+		Version:      "1.0.0",
+		GetStateMode: configmodel.GetStateNone,
+		Modules: []configmodel.ModuleInfo{
+			{
+				Name: "parent",
+				File: "parent.yang",
+			},
+			{
+				Name:      "child",
+				File:      "child.yang",
+				BelongsTo: "parent",
+			},
+		},
+		Files: []configmodel.FileInfo{
+			{
+				Path: "parent.yang",
+				Data: []byte(testSubmoduleParentYang),
+			},
+			{
+				Path: "child.yang",
+				Data: []byte(testSubmoduleChildYang),
+			},
+		},
+		Plugin: configmodel.PluginInfo{
+			Name:    "parent",
+			Version: "1.0.0",
+		},
+	}
+
+	// Submodules are not passed to the generator as top-level modules
+	args, err := NewPluginCompiler(CompilerConfig{BuildPath: "build"}, nil).getGeneratorArgs(model)
+	assert.NoError(t, err)
+	assert.Equal(t, "parent.yang", args[len(args)-1])
+	assert.NotContains(t, args, "child.yang")
+
+	if testing.Short() {
+		t.Skip("skipping plugin build in short mode")
+	}
+
+	dir, err := ioutil.TempDir("", "config-model-compiler")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	compiler := NewPluginCompiler(CompilerConfig{
+		TemplatePath: "templates",
+		BuildPath:    filepath.Join(dir, "build"),
+		ModFile:      writePinnedModFile(t, dir),
+		SumFile:      filepath.Join(moduleRoot, "go.sum"),
+		SkipCleanUp:  true,
+	}, nil)
+
+	path := filepath.Join(dir, "parent-1.0.0.so")
+	assert.NoError(t, compiler.CompilePlugin(model, path))
+	_, err = os.Stat(path)
+	assert.NoError(t, err)
+
+	// The submodule is copied to the yang path and its definitions are generated with the parent's
+	moduleDir := getKeptModuleDir(t, compiler, model)
+	_, err = os.Stat(filepath.Join(moduleDir, "yang", "child.yang"))
+	assert.NoError(t, err)
+	generated, err := ioutil.ReadFile(filepath.Join(moduleDir, "model", "generated.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(generated), "Parent_Child")
+	assert.Contains(t, string(generated), "Parent_Parent")
+}
+
+const testFeatureYang = `module device {
+  namespace "http://opennetworking.org/test/device";
+  prefix d;
+
+  feature ntp;
+  feature syslog;
+
+  container system {
+    leaf hostname {
+      type string;
+    }
+    leaf domain {
+      type string;
+    }
+    container ntp {
+      if-feature ntp;
+      leaf server {
+        type string;
+      }
+    }
+    container syslog {
+      if-feature syslog;
+      leaf server {
+        type string;
+      }
+    }
+  }
+}
+`
+
+const testDeviationYang = `module device-deviations {
+  namespace "http://opennetworking.org/test/device-deviations";
+  prefix dd;
+  import device {
+    prefix d;
+  }
+
+  deviation /d:system/d:domain {
+    deviate not-supported;
+  }
+}
+`
+
+func TestCompileFeatures(t *testing.T) {
+	model := configmodel.ModelInfo{
+		Name:         "device",
+		Version:      "1.0.0",
+		GetStateMode: configmodel.GetStateNone,
+		Modules: []configmodel.ModuleInfo{
+			{
+				Name: "device",
+				File: "device.yang",
+			},
+		},
+		Features: []string{"ntp"},
+		Deviations: []configmodel.ModuleInfo{
+			{
+				Name: "device-deviations",
+				File: "device-deviations.yang",
+			},
+		},
+		Files: []configmodel.FileInfo{
+			{
+				Path: "device.yang",
+				Data: []byte(testFeatureYang),
+			},
+			{
+				Path: "device-deviations.yang",
+				Data: []byte(testDeviationYang),
+			},
+		},
+		Plugin: configmodel.PluginInfo{
+			Name:    "device",
+			Version: "1.0.0",
+		},
+	}
+
+	// Deviation modules are passed to the generator with the modules they deviate
+	args, err := NewPluginCompiler(CompilerConfig{BuildPath: "build"}, nil).getGeneratorArgs(model)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"device.yang", "device-deviations.yang"}, args[len(args)-2:])
+
+	if testing.Short() {
+		t.Skip("skipping plugin build in short mode")
+	}
+
+	dir, err := ioutil.TempDir("", "config-model-compiler")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	compiler := NewPluginCompiler(CompilerConfig{
+		TemplatePath: "templates",
+		BuildPath:    filepath.Join(dir, "build"),
+		ModFile:      writePinnedModFile(t, dir),
+		SumFile:      filepath.Join(moduleRoot, "go.sum"),
+		SkipCleanUp:  true,
+	}, nil)
+
+	path := filepath.Join(dir, "device-1.0.0.so")
+	assert.NoError(t, compiler.CompilePlugin(model, path))
+
+	// Nodes guarded by features that are not enabled and nodes deviated as not supported are not generated
+	moduleDir := getKeptModuleDir(t, compiler, model)
+	generated, err := ioutil.ReadFile(filepath.Join(moduleDir, "model", "generated.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(generated), "Device_System_Ntp")
+	assert.NotContains(t, string(generated), "Device_System_Syslog")
+	assert.Contains(t, string(generated), "Hostname")
+	assert.NotContains(t, string(generated), "Domain")
+}
+
+func TestBuildParallelism(t *testing.T) {
+	compiler := NewPluginCompiler(CompilerConfig{BuildPath: "build"}, nil)
+	assert.Equal(t, runtime.NumCPU(), compiler.GetBuildParallelism())
+	assert.Equal(t, []string{"build", "-o", "test.so", "-buildmode=plugin", "example.com/test"}, compiler.getBuildArgs("example.com/test", "test.so"))
+
+	compiler = NewPluginCompiler(CompilerConfig{BuildPath: "build", BuildParallelism: 2, ModFile: "go.mod", Reproducible: true}, nil)
+	assert.Equal(t, 2, compiler.GetBuildParallelism())
+	assert.Equal(t, []string{"build", "-o", "test.so", "-buildmode=plugin", "-trimpath", "-ldflags=-buildid=", "-p=2", "-mod=readonly", "example.com/test"}, compiler.getBuildArgs("example.com/test", "test.so"))
+}
+
+func TestCompileOffline(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-model-compiler")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// Offline builds do not tidy the plugin module, which could query the module proxy
+	compiler := NewPluginCompiler(CompilerConfig{BuildPath: filepath.Join(dir, "build"), Offline: true}, nil)
+	assert.NoError(t, compiler.tidyMod(filepath.Join(dir, "missing")))
+
+	// Go commands are run with the offline environment
+	out, err := compiler.exec("printing the environment", dir, "go", "env", "GOPROXY", "GOFLAGS")
+	assert.NoError(t, err)
+	assert.Equal(t, "off\n-mod=mod\n", out)
+	compiler.Config.OfflineEnv = []string{"GOPROXY=file:///mirror"}
+	out, err = compiler.exec("printing the environment", dir, "go", "env", "GOPROXY")
+	assert.NoError(t, err)
+	assert.Equal(t, "file:///mirror\n", out)
+}
+
+func TestCompileError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-model-compiler")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/broken\n"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {\n\tundefinedFunc()\n}\n"), 0644))
+
+	// The compiler's diagnostics are returned in the error
+	compiler := NewPluginCompiler(CompilerConfig{BuildPath: filepath.Join(dir, "build")}, nil)
+	_, err = compiler.exec("building 'example.com/broken'", dir, "go", "build", "-o", filepath.Join(dir, "broken"), ".")
+	compileErr, ok := GetCompileError(err)
+	if assert.True(t, ok) {
+		assert.Equal(t, "building 'example.com/broken'", compileErr.Phase)
+		assert.Contains(t, compileErr.Output, "undefined: undefinedFunc")
+		assert.Error(t, compileErr.Err)
+	}
+	assert.Contains(t, err.Error(), "undefined: undefinedFunc")
+
+	// Errors wrapping the compile error can be unwrapped
+	_, ok = GetCompileError(fmt.Errorf("failed to compile: %w", err))
+	assert.True(t, ok)
+	_, ok = GetCompileError(errors.NewInvalid("failed to compile"))
+	assert.False(t, ok)
+}
+
+func TestCompilePlatform(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-model-compiler")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	model := configmodel.ModelInfo{Name: "test", Version: "1.0.0"}
+	compiler := NewPluginCompiler(CompilerConfig{BuildPath: filepath.Join(dir, "build")}, nil)
+	assert.True(t, compiler.GetPlatform().IsHost())
+	assert.Equal(t, "test_1_0_0", compiler.getSafeQualifiedName(model))
+
+	// Plugins built for other platforms are generated under distinct names
+	compiler = NewPluginCompiler(CompilerConfig{BuildPath: filepath.Join(dir, "build"), GOOS: "linux", GOARCH: "s390x"}, nil)
+	if !compiler.GetPlatform().IsHost() {
+		assert.Equal(t, "test_1_0_0_linux_s390x", compiler.getSafeQualifiedName(model))
+	}
+
+	// Plugins cannot be built for platforms that don't support them
+	compiler = NewPluginCompiler(CompilerConfig{BuildPath: filepath.Join(dir, "build"), GOOS: "windows", GOARCH: "amd64"}, nil)
+	err = compiler.CompilePlugin(newTestModel(t), filepath.Join(dir, "test-1.0.0.so"))
+	assert.True(t, errors.IsInvalid(err))
+	err = compiler.CompilePlugins([]configmodel.ModelInfo{newTestModel(t)}, []string{filepath.Join(dir, "test-1.0.0.so")})
+	assert.True(t, errors.IsInvalid(err))
+	_, err = os.Stat(filepath.Join(dir, "build"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package plugincompiler
+
+import (
+	"bytes"
+	goerrors "errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// CompileError is the failure of a command run to compile a plugin
+// The error includes the combined stdout and stderr of the command, e.g. the Go compiler's diagnostics.
+type CompileError struct {
+	// Phase describes the compilation phase in which the command failed
+	Phase string
+	// Output is the combined stdout and stderr of the command
+	Output string
+	// Err is the error returned by the command
+	Err error
+}
+
+func (e *CompileError) Error() string {
+	output := strings.TrimSpace(e.Output)
+	if output == "" {
+		return fmt.Sprintf("%s failed: %s", e.Phase, e.Err)
+	}
+	return fmt.Sprintf("%s failed: %s\n%s", e.Phase, e.Err, output)
+}
+
+func (e *CompileError) Unwrap() error {
+	return e.Err
+}
+
+// GetCompileError returns the compile error wrapped by the given error, if any
+func GetCompileError(err error) (*CompileError, bool) {
+	var compileErr *CompileError
+	if goerrors.As(err, &compileErr) {
+		return compileErr, true
+	}
+	return nil, false
+}
+
+// outputBuffer is a buffer to which a command's stdout and stderr can be written concurrently
+type outputBuffer struct {
+	buf bytes.Buffer
+	mu  sync.Mutex
+}
+
+func (b *outputBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *outputBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
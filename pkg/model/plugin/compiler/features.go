@@ -0,0 +1,366 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package plugincompiler
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"strings"
+)
+
+// pruneFeatures removes the statements of a YANG module that are conditional on features that are not enabled
+// The ygot generator assumes all features are present, so statements guarded by an 'if-feature' whose
+// expression is false for the enabled features are removed before the bindings are generated. Features
+// are matched by name, ignoring the prefix of the module defining them. Feature definitions are kept so
+// references to them still resolve.
+func pruneFeatures(path string, data []byte, features []string) ([]byte, error) {
+	enabled := make(map[string]bool)
+	for _, feature := range features {
+		enabled[getFeatureName(feature)] = true
+	}
+
+	p := &yangParser{input: string(data)}
+	statements, err := p.parseStatements(0)
+	if err != nil {
+		return nil, errors.NewInvalid("failed to parse YANG module '%s': %s", path, err)
+	}
+
+	var spans [][2]int
+	var prune func(statements []*yangStatement) error
+	prune = func(statements []*yangStatement) error {
+		for _, statement := range statements {
+			if statement.keyword != "feature" {
+				ok, err := statement.isEnabled(enabled)
+				if err != nil {
+					return errors.NewInvalid("invalid if-feature in YANG module '%s': %s", path, err)
+				}
+				if !ok {
+					spans = append(spans, p.getLines(statement.start, statement.end))
+					continue
+				}
+			}
+			if err := prune(statement.children); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := prune(statements); err != nil {
+		return nil, err
+	}
+	if len(spans) == 0 {
+		return data, nil
+	}
+
+	var pruned strings.Builder
+	offset := 0
+	for _, span := range spans {
+		pruned.WriteString(p.input[offset:span[0]])
+		offset = span[1]
+	}
+	pruned.WriteString(p.input[offset:])
+	return []byte(pruned.String()), nil
+}
+
+// getFeatureName returns the name of a feature without the prefix of its module
+func getFeatureName(feature string) string {
+	if i := strings.Index(feature, ":"); i >= 0 {
+		return feature[i+1:]
+	}
+	return feature
+}
+
+// yangStatement is a YANG statement and its location in the module source
+type yangStatement struct {
+	keyword  string
+	argument string
+	start    int
+	end      int
+	children []*yangStatement
+}
+
+// isEnabled returns whether all the statement's if-feature expressions are true for the enabled features
+func (s *yangStatement) isEnabled(enabled map[string]bool) (bool, error) {
+	for _, child := range s.children {
+		if child.keyword != "if-feature" {
+			continue
+		}
+		e := &featureExpr{tokens: tokenizeFeatureExpr(child.argument), enabled: enabled}
+		ok, err := e.parseOr()
+		if err != nil {
+			return false, err
+		}
+		if e.pos != len(e.tokens) {
+			return false, errors.NewInvalid("unexpected '%s' in '%s'", e.tokens[e.pos], child.argument)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// yangParser parses the generic statement structure of a YANG module
+type yangParser struct {
+	input string
+	pos   int
+}
+
+// getLines extends the given span to the whole lines it occupies if nothing else is on them
+func (p *yangParser) getLines(start, end int) [2]int {
+	lineStart := strings.LastIndexByte(p.input[:start], '\n') + 1
+	if strings.TrimSpace(p.input[lineStart:start]) != "" {
+		return [2]int{start, end}
+	}
+	lineEnd := len(p.input)
+	if i := strings.IndexByte(p.input[end:], '\n'); i >= 0 {
+		lineEnd = end + i + 1
+	}
+	if strings.TrimSpace(p.input[end:lineEnd]) != "" {
+		return [2]int{start, end}
+	}
+	return [2]int{lineStart, lineEnd}
+}
+
+// parseStatements parses statements until the end of the enclosing block
+func (p *yangParser) parseStatements(depth int) ([]*yangStatement, error) {
+	var statements []*yangStatement
+	for {
+		if err := p.skipSpace(); err != nil {
+			return nil, err
+		}
+		if p.pos == len(p.input) {
+			if depth > 0 {
+				return nil, errors.NewInvalid("unexpected end of module: missing '}'")
+			}
+			return statements, nil
+		}
+		if p.input[p.pos] == '}' {
+			if depth == 0 {
+				return nil, errors.NewInvalid("unexpected '}' at offset %d", p.pos)
+			}
+			return statements, nil
+		}
+		statement, err := p.parseStatement(depth)
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, statement)
+	}
+}
+
+// parseStatement parses a statement: keyword [argument] (";" / "{" *statement "}")
+func (p *yangParser) parseStatement(depth int) (*yangStatement, error) {
+	statement := &yangStatement{start: p.pos}
+	keyword, err := p.parseString()
+	if err != nil {
+		return nil, err
+	}
+	statement.keyword = keyword
+
+	if err := p.skipSpace(); err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.input) && p.input[p.pos] != ';' && p.input[p.pos] != '{' {
+		argument, err := p.parseArgument()
+		if err != nil {
+			return nil, err
+		}
+		statement.argument = argument
+		if err := p.skipSpace(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.pos == len(p.input) {
+		return nil, errors.NewInvalid("unexpected end of module in statement '%s'", keyword)
+	}
+	switch p.input[p.pos] {
+	case ';':
+		p.pos++
+	case '{':
+		p.pos++
+		children, err := p.parseStatements(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		statement.children = children
+		p.pos++
+	default:
+		return nil, errors.NewInvalid("expected ';' or '{' after statement '%s' at offset %d", keyword, p.pos)
+	}
+	statement.end = p.pos
+	return statement, nil
+}
+
+// parseArgument parses a statement argument, concatenating quoted strings joined by '+'
+func (p *yangParser) parseArgument() (string, error) {
+	argument, err := p.parseString()
+	if err != nil {
+		return "", err
+	}
+	for {
+		pos := p.pos
+		if err := p.skipSpace(); err != nil {
+			return "", err
+		}
+		if p.pos == len(p.input) || p.input[p.pos] != '+' {
+			p.pos = pos
+			return argument, nil
+		}
+		p.pos++
+		if err := p.skipSpace(); err != nil {
+			return "", err
+		}
+		next, err := p.parseString()
+		if err != nil {
+			return "", err
+		}
+		argument += next
+	}
+}
+
+// parseString parses a quoted or unquoted string
+func (p *yangParser) parseString() (string, error) {
+	start := p.pos
+	switch p.input[p.pos] {
+	case '"':
+		var value strings.Builder
+		for p.pos++; p.pos < len(p.input); p.pos++ {
+			switch c := p.input[p.pos]; c {
+			case '"':
+				p.pos++
+				return value.String(), nil
+			case '\\':
+				p.pos++
+				if p.pos < len(p.input) {
+					switch p.input[p.pos] {
+					case 'n':
+						value.WriteByte('\n')
+					case 't':
+						value.WriteByte('\t')
+					default:
+						value.WriteByte(p.input[p.pos])
+					}
+				}
+			default:
+				value.WriteByte(c)
+			}
+		}
+		return "", errors.NewInvalid("unterminated string at offset %d", start)
+	case '\'':
+		end := strings.IndexByte(p.input[p.pos+1:], '\'')
+		if end < 0 {
+			return "", errors.NewInvalid("unterminated string at offset %d", start)
+		}
+		p.pos += end + 2
+		return p.input[start+1 : p.pos-1], nil
+	}
+	for p.pos < len(p.input) && !strings.ContainsRune(" \t\r\n;{}", rune(p.input[p.pos])) &&
+		!strings.HasPrefix(p.input[p.pos:], "//") && !strings.HasPrefix(p.input[p.pos:], "/*") {
+		p.pos++
+	}
+	if p.pos == start {
+		return "", errors.NewInvalid("unexpected '%c' at offset %d", p.input[p.pos], p.pos)
+	}
+	return p.input[start:p.pos], nil
+}
+
+// skipSpace skips whitespace and comments
+func (p *yangParser) skipSpace() error {
+	for p.pos < len(p.input) {
+		switch {
+		case strings.ContainsRune(" \t\r\n", rune(p.input[p.pos])):
+			p.pos++
+		case strings.HasPrefix(p.input[p.pos:], "//"):
+			end := strings.IndexByte(p.input[p.pos:], '\n')
+			if end < 0 {
+				p.pos = len(p.input)
+			} else {
+				p.pos += end + 1
+			}
+		case strings.HasPrefix(p.input[p.pos:], "/*"):
+			end := strings.Index(p.input[p.pos+2:], "*/")
+			if end < 0 {
+				return errors.NewInvalid("unterminated comment at offset %d", p.pos)
+			}
+			p.pos += end + 4
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// tokenizeFeatureExpr splits an if-feature expression into feature names, operators and parentheses
+func tokenizeFeatureExpr(expr string) []string {
+	expr = strings.NewReplacer("(", " ( ", ")", " ) ").Replace(expr)
+	return strings.Fields(expr)
+}
+
+// featureExpr evaluates an if-feature expression
+// The 'not' operator binds tighter than 'and', which binds tighter than 'or'.
+type featureExpr struct {
+	tokens  []string
+	pos     int
+	enabled map[string]bool
+}
+
+func (e *featureExpr) parseOr() (bool, error) {
+	value, err := e.parseAnd()
+	if err != nil {
+		return false, err
+	}
+	for e.pos < len(e.tokens) && e.tokens[e.pos] == "or" {
+		e.pos++
+		next, err := e.parseAnd()
+		if err != nil {
+			return false, err
+		}
+		value = value || next
+	}
+	return value, nil
+}
+
+func (e *featureExpr) parseAnd() (bool, error) {
+	value, err := e.parseNot()
+	if err != nil {
+		return false, err
+	}
+	for e.pos < len(e.tokens) && e.tokens[e.pos] == "and" {
+		e.pos++
+		next, err := e.parseNot()
+		if err != nil {
+			return false, err
+		}
+		value = value && next
+	}
+	return value, nil
+}
+
+func (e *featureExpr) parseNot() (bool, error) {
+	if e.pos == len(e.tokens) {
+		return false, errors.NewInvalid("unexpected end of expression")
+	}
+	token := e.tokens[e.pos]
+	e.pos++
+	switch token {
+	case "not":
+		value, err := e.parseNot()
+		return !value, err
+	case "(":
+		value, err := e.parseOr()
+		if err != nil {
+			return false, err
+		}
+		if e.pos == len(e.tokens) || e.tokens[e.pos] != ")" {
+			return false, errors.NewInvalid("missing ')'")
+		}
+		e.pos++
+		return value, nil
+	case ")", "and", "or":
+		return false, errors.NewInvalid("unexpected '%s'", token)
+	}
+	return e.enabled[getFeatureName(token)], nil
+}
@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package plugincompiler
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const testFeaturesYang = `module features {
+  namespace "http://opennetworking.org/test/features";
+  prefix f;
+
+  feature a;
+  feature b {
+    if-feature a;
+  }
+
+  container features {
+    leaf always {
+      type string;
+    }
+    /* A leaf present only with feature a; } */
+    leaf with-a {
+      if-feature "f:a";
+      type string;
+    }
+    leaf with-a-and-b {
+      if-feature "a and" + ' b';
+      type string;
+    }
+    leaf without-b {
+      if-feature "not b";
+      type string;
+    }
+    container with-a-or-b {
+      if-feature "(b or a)";
+      leaf value {
+        type string;
+        description "// not a comment";
+      }
+    }
+  }
+}
+`
+
+func TestPruneFeatures(t *testing.T) {
+	// Statements are kept when their if-feature expressions are true
+	data, err := pruneFeatures("features.yang", []byte(testFeaturesYang), []string{"a", "f:b"})
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "leaf with-a-and-b")
+	assert.Contains(t, string(data), "container with-a-or-b")
+	assert.NotContains(t, string(data), "without-b")
+	assert.Contains(t, string(data), "    }\n    container with-a-or-b")
+
+	// Statements conditional on features that are not enabled are removed
+	data, err = pruneFeatures("features.yang", []byte(testFeaturesYang), []string{"a"})
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "leaf always")
+	assert.Contains(t, string(data), "leaf with-a ")
+	assert.NotContains(t, string(data), "leaf with-a-and-b")
+	assert.Contains(t, string(data), "leaf without-b")
+	assert.Contains(t, string(data), "container with-a-or-b")
+	assert.Contains(t, string(data), "feature b {")
+
+	data, err = pruneFeatures("features.yang", []byte(testFeaturesYang), []string{"c"})
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "leaf always")
+	assert.NotContains(t, string(data), "leaf with-a ")
+	assert.Contains(t, string(data), "leaf without-b")
+	assert.NotContains(t, string(data), "container with-a-or-b")
+	assert.NotContains(t, string(data), "// not a comment")
+
+	// Invalid modules and expressions are rejected
+	_, err = pruneFeatures("features.yang", []byte("module features { container c {"), []string{"a"})
+	assert.True(t, errors.IsInvalid(err))
+	_, err = pruneFeatures("features.yang", []byte("module features { leaf l { if-feature \"a or\"; } }"), []string{"a"})
+	assert.True(t, errors.IsInvalid(err))
+	_, err = pruneFeatures("features.yang", []byte("module features { leaf l { if-feature \"(a\"; } }"), []string{"a"})
+	assert.True(t, errors.IsInvalid(err))
+}
@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package plugincompiler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// PreprocessorFileEnv is the environment variable in which exec preprocessors receive the path of the YANG file
+const PreprocessorFileEnv = "CONFIG_MODEL_YANG_FILE"
+
+// Preprocessor transforms the content of YANG files before they're compiled
+type Preprocessor interface {
+	// Preprocess returns the transformed content of the YANG file at the given path
+	Preprocess(ctx context.Context, path string, data []byte) ([]byte, error)
+}
+
+// NewIdentityPreprocessor returns a preprocessor that leaves YANG files unchanged
+func NewIdentityPreprocessor() Preprocessor {
+	return identityPreprocessor{}
+}
+
+type identityPreprocessor struct{}
+
+func (p identityPreprocessor) Preprocess(ctx context.Context, path string, data []byte) ([]byte, error) {
+	return data, nil
+}
+
+// NewExecPreprocessor returns a preprocessor that runs the given command for each YANG file
+// The command reads the file content from stdin and writes the transformed content to stdout.
+// The path of the file is provided in the CONFIG_MODEL_YANG_FILE environment variable.
+func NewExecPreprocessor(command string, args ...string) Preprocessor {
+	return &execPreprocessor{
+		command: command,
+		args:    args,
+	}
+}
+
+type execPreprocessor struct {
+	command string
+	args    []string
+}
+
+func (p *execPreprocessor) Preprocess(ctx context.Context, path string, data []byte) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, p.command, p.args...)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%s", PreprocessorFileEnv, path))
+	cmd.Stdin = bytes.NewReader(data)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("%s: %s", err, message)
+		}
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
+
+// newPreprocessor returns the preprocessor for the given compiler configuration
+func newPreprocessor(config CompilerConfig) Preprocessor {
+	if config.Preprocessor == "" {
+		return NewIdentityPreprocessor()
+	}
+	return NewExecPreprocessor(config.Preprocessor, config.PreprocessorArgs...)
+}
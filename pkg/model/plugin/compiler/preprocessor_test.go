@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package plugincompiler
+
+import (
+	"context"
+	"github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestExecPreprocessor(t *testing.T) {
+	buildPath, err := ioutil.TempDir("", "config-model-build")
+	assert.NoError(t, err)
+	defer os.RemoveAll(buildPath)
+
+	// The preprocessor expands a macro in the YANG file and receives the file path in the environment
+	compiler := NewPluginCompiler(CompilerConfig{
+		BuildPath:        buildPath,
+		Preprocessor:     "sh",
+		PreprocessorArgs: []string{"-c", `sed "s/@ORGANIZATION@/ONF ($CONFIG_MODEL_YANG_FILE)/"`},
+	}, nil)
+
+	file, err := configmodel.FileInfo{
+		Path: "test.yang",
+		Data: []byte(`module test { organization "@ORGANIZATION@"; }`),
+	}.Compress()
+	assert.NoError(t, err)
+	modelInfo := configmodel.ModelInfo{
+		Name:    "test",
+		Version: "1.0.0",
+		Files:   []configmodel.FileInfo{file},
+	}
+	compiler.createDir(compiler.getYangDir(modelInfo))
+	assert.NoError(t, compiler.copyFiles(modelInfo))
+
+	copied, err := ioutil.ReadFile(compiler.getYangPath(modelInfo, file))
+	assert.NoError(t, err)
+	assert.Equal(t, `module test { organization "ONF (test.yang)"; }`, string(copied))
+
+	// Preprocessor failures fail the copy with the command's output
+	compiler.Config.PreprocessorArgs = []string{"-c", "echo 'bad macro' >&2; exit 1"}
+	compiler.Preprocessor = newPreprocessor(compiler.Config)
+	assert.NoError(t, os.Remove(compiler.getYangPath(modelInfo, file)))
+	err = compiler.copyFiles(modelInfo)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "bad macro")
+}
+
+type commentPreprocessor struct{}
+
+func (p commentPreprocessor) Preprocess(ctx context.Context, path string, data []byte) ([]byte, error) {
+	return []byte("// preprocessed\n" + string(data)), nil
+}
+
+func TestCustomPreprocessor(t *testing.T) {
+	buildPath, err := ioutil.TempDir("", "config-model-build")
+	assert.NoError(t, err)
+	defer os.RemoveAll(buildPath)
+
+	compiler := NewPluginCompiler(CompilerConfig{BuildPath: buildPath}, nil)
+	data, err := compiler.Preprocessor.Preprocess(context.Background(), "test.yang", []byte("module test {}"))
+	assert.NoError(t, err)
+	assert.Equal(t, "module test {}", string(data))
+
+	compiler.Preprocessor = commentPreprocessor{}
+	file := configmodel.FileInfo{Path: "test.yang", Data: []byte("module test {}")}
+	modelInfo := configmodel.ModelInfo{
+		Name:    "test",
+		Version: "1.0.0",
+		Files:   []configmodel.FileInfo{file},
+	}
+	compiler.createDir(compiler.getYangDir(modelInfo))
+	assert.NoError(t, compiler.copyFiles(modelInfo))
+	copied, err := ioutil.ReadFile(compiler.getYangPath(modelInfo, file))
+	assert.NoError(t, err)
+	assert.Equal(t, "// preprocessed\nmodule test {}", string(copied))
+}
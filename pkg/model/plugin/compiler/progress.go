@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package plugincompiler
+
+import (
+	"bytes"
+	"github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-config-model/pkg/model/logging"
+	"io"
+	"os"
+)
+
+// Phase is a phase of plugin compilation reported to progress listeners
+type Phase string
+
+const (
+	// FetchingModulesPhase is reported when the plugin module's dependencies are being resolved
+	FetchingModulesPhase Phase = "fetching-modules"
+	// GeneratingBindingsPhase is reported when the YANG bindings are being generated
+	GeneratingBindingsPhase Phase = "generating-bindings"
+	// BuildStartedPhase is reported when the plugin build is started
+	BuildStartedPhase Phase = "build-started"
+	// BuildFinishedPhase is reported when the plugin has been built
+	BuildFinishedPhase Phase = "build-finished"
+	// FailedPhase is reported when compilation fails, with the stderr of the failed command
+	FailedPhase Phase = "failed"
+)
+
+// Progress is a compilation progress event
+type Progress struct {
+	Phase   Phase  `json:"phase"`
+	Message string `json:"message,omitempty"`
+	Stderr  string `json:"stderr,omitempty"`
+}
+
+// ProgressFunc is a function called with compilation progress events
+type ProgressFunc func(Progress)
+
+// CompilePluginWithProgress compiles a model plugin to the given path, reporting the progress of each phase
+// The progress function is called synchronously from the goroutine compiling the plugin.
+func (c *PluginCompiler) CompilePluginWithProgress(model configmodel.ModelInfo, path string, progress ProgressFunc) error {
+	compiler := *c
+	compiler.progress = progress
+	compiler.stderr = &bytes.Buffer{}
+	err := compiler.CompilePlugin(model, path)
+	if err != nil {
+		compiler.report(FailedPhase, err.Error())
+	}
+	return err
+}
+
+// report records the current compilation phase and reports a progress event if a progress function is set
+func (c *PluginCompiler) report(phase Phase, message string) {
+	if phase != FailedPhase {
+		c.phase = phase
+		log.Debugw(message, modellogging.PhaseField, phase)
+	}
+	if c.progress == nil {
+		return
+	}
+	progress := Progress{
+		Phase:   phase,
+		Message: message,
+	}
+	if phase == FailedPhase {
+		progress.Stderr = c.stderr.String()
+	}
+	c.progress(progress)
+}
+
+// getStderr returns the writer to which the stderr of the next compilation command is written
+// When progress is reported, the stderr is also captured to report failures.
+func (c *PluginCompiler) getStderr() io.Writer {
+	if c.progress == nil {
+		return os.Stderr
+	}
+	c.stderr.Reset()
+	return io.MultiWriter(os.Stderr, c.stderr)
+}
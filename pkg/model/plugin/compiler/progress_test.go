@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package plugincompiler
+
+import (
+	"github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompileProgressFailure(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping plugin generation in short mode")
+	}
+
+	dir, err := ioutil.TempDir("", "config-model-compiler")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	compiler := NewPluginCompiler(CompilerConfig{
+		TemplatePath: "templates",
+		BuildPath:    filepath.Join(dir, "build"),
+		ModFile:      writePinnedModFile(t, dir),
+		SumFile:      filepath.Join(moduleRoot, "go.sum"),
+	}, nil)
+	model := configmodel.ModelInfo{
+		Name:         "broken",
+		Version:      "1.0.0",
+		GetStateMode: configmodel.GetStateNone,
+		Modules: []configmodel.ModuleInfo{
+			{
+				Name: "broken",
+				File: "broken.yang",
+			},
+		},
+		Files: []configmodel.FileInfo{
+			{
+				Path: "broken.yang",
+				Data: []byte("module broken { namespace \"urn:broken\"; prefix b; leaf value { type unknown; } }"),
+			},
+		},
+	}
+
+	var events []Progress
+	err = compiler.CompilePluginWithProgress(model, filepath.Join(dir, "broken-1.0.0.so"), func(progress Progress) {
+		events = append(events, progress)
+	})
+	assert.Error(t, err)
+	if assert.Len(t, events, 3) {
+		assert.Equal(t, FetchingModulesPhase, events[0].Phase)
+		assert.Equal(t, GeneratingBindingsPhase, events[1].Phase)
+		assert.Equal(t, FailedPhase, events[2].Phase)
+		assert.Equal(t, err.Error(), events[2].Message)
+		assert.Contains(t, events[2].Stderr, "unknown type: b:unknown")
+	}
+
+	// The generator's output is returned in the error
+	compileErr, ok := GetCompileError(err)
+	if assert.True(t, ok) {
+		assert.Contains(t, compileErr.Phase, "generating YANG bindings")
+		assert.Contains(t, compileErr.Output, "unknown type: b:unknown")
+	}
+}
@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package plugincompiler
+
+import (
+	"fmt"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/rogpeppe/go-internal/modfile"
+	"path/filepath"
+	"strings"
+)
+
+// ModReplace is a replace directive added to the go.mod of compiled plugins
+type ModReplace struct {
+	// Old is the path of the module to replace
+	Old string
+	// New is the path of the replacement module, either a module path or a local directory
+	New string
+	// Version is the version of the replacement module, which must be empty for local directories
+	Version string
+}
+
+func (r ModReplace) String() string {
+	if r.Version == "" {
+		return fmt.Sprintf("%s => %s", r.Old, r.New)
+	}
+	return fmt.Sprintf("%s => %s %s", r.Old, r.New, r.Version)
+}
+
+// isLocal returns whether the replacement module is a local directory
+func (r ModReplace) isLocal() bool {
+	return filepath.IsAbs(r.New) || strings.HasPrefix(r.New, "./") || strings.HasPrefix(r.New, "../")
+}
+
+// Validate checks that the replace directive is well formed
+func (r ModReplace) Validate() error {
+	if r.Old == "" || r.New == "" {
+		return errors.NewInvalid("replace '%s' must have an old and new module", r)
+	}
+	if r.isLocal() && r.Version != "" {
+		return errors.NewInvalid("replace '%s' of a local directory cannot have a version", r)
+	}
+	if !r.isLocal() && r.Version == "" {
+		return errors.NewInvalid("replace '%s' of a module path must have a version", r)
+	}
+	return nil
+}
+
+// ParseModReplace parses a replace directive in the old=new@version format, e.g.
+// github.com/openconfig/ygot=github.com/example/ygot@v0.12.5
+// Local directories are given without a version, e.g. github.com/openconfig/ygot=../ygot
+func ParseModReplace(replace string) (ModReplace, error) {
+	i := strings.Index(replace, "=")
+	if i < 0 {
+		return ModReplace{}, errors.NewInvalid("replace '%s' is not in the old=new@version format", replace)
+	}
+	r := ModReplace{
+		Old: replace[:i],
+		New: replace[i+1:],
+	}
+	if !r.isLocal() {
+		if j := strings.LastIndex(r.New, "@"); j >= 0 {
+			r.Version = r.New[j+1:]
+			r.New = r.New[:j]
+		}
+	}
+	return r, r.Validate()
+}
+
+// addModReplaces adds the configured replace directives to the given plugin go.mod
+// A configured replace supersedes any replace of the same module already in the go.mod, e.g. one
+// produced by the resolver, so the go.mod never contains conflicting directives for a module.
+func (c *PluginCompiler) addModReplaces(mod *modfile.File) error {
+	for _, replace := range c.Config.ExtraReplaces {
+		if err := replace.Validate(); err != nil {
+			return err
+		}
+		for _, existing := range mod.Replace {
+			if existing.Old.Path == replace.Old && (existing.New.Path != replace.New || existing.New.Version != replace.Version) {
+				log.Infof("Superseding the go.mod replace of module '%s' with '%s'", replace.Old, replace)
+			}
+		}
+		// Replaces without an old version supersede the replaces of every version of the module
+		if err := mod.AddReplace(replace.Old, "", replace.New, replace.Version); err != nil {
+			return errors.NewInvalid("failed to add replace '%s': %s", replace, err)
+		}
+	}
+	mod.Cleanup()
+	return nil
+}
@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package plugincompiler
+
+import (
+	pluginmodule "github.com/onosproject/onos-config-model/pkg/model/plugin/module"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testReplaceMod = `module github.com/onosproject/onos-config
+
+go 1.16
+
+require (
+	github.com/openconfig/goyang v0.3.1
+	github.com/openconfig/ygot v0.12.4
+)
+
+replace github.com/openconfig/goyang v0.3.1 => github.com/openconfig/goyang v0.3.0
+`
+
+func TestExtraReplaces(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-model-compiler")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// Pre-populate the resolved module with a replace of its own
+	modPath := filepath.Join(dir, "mod")
+	assert.NoError(t, os.MkdirAll(modPath, os.ModePerm))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(modPath, "go.mod"), []byte(testReplaceMod), 0666))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(modPath, "mod.md5"), []byte("test"), 0666))
+	resolver := pluginmodule.NewResolver(pluginmodule.ResolverConfig{Path: modPath})
+
+	compiler := NewPluginCompiler(CompilerConfig{
+		BuildPath: filepath.Join(dir, "build"),
+		ExtraReplaces: []ModReplace{
+			{Old: "github.com/openconfig/ygot", New: "github.com/example/ygot", Version: "v0.12.5"},
+			{Old: "github.com/openconfig/goyang", New: "../goyang"},
+		},
+	}, resolver)
+
+	// The replaces are merged into the resolved go.mod, superseding the resolver's replace of the same module
+	pluginDir := filepath.Join(dir, "plugin")
+	assert.NoError(t, os.MkdirAll(pluginDir, os.ModePerm))
+	assert.NoError(t, compiler.fetchMod("github.com/onosproject/onos-config-model/test", pluginDir))
+	bytes, err := ioutil.ReadFile(filepath.Join(pluginDir, "go.mod"))
+	assert.NoError(t, err)
+	mod := string(bytes)
+	assert.Contains(t, mod, "module github.com/onosproject/onos-config-model/test")
+	assert.Contains(t, mod, "github.com/openconfig/ygot => github.com/example/ygot v0.12.5")
+	assert.Contains(t, mod, "github.com/openconfig/goyang => ../goyang")
+	assert.NotContains(t, mod, "goyang v0.3.0")
+	assert.Equal(t, 1, strings.Count(mod, "github.com/openconfig/ygot =>"))
+	assert.Equal(t, 1, strings.Count(mod, "github.com/openconfig/goyang =>"))
+
+	// Compiling again does not duplicate the replaces
+	assert.NoError(t, compiler.fetchMod("github.com/onosproject/onos-config-model/test", pluginDir))
+	bytes, err = ioutil.ReadFile(filepath.Join(pluginDir, "go.mod"))
+	assert.NoError(t, err)
+	assert.Equal(t, mod, string(bytes))
+
+	// The replaces are also merged into a configured go.mod
+	compiler.Config.ModFile = filepath.Join(modPath, "go.mod")
+	assert.NoError(t, compiler.copyMod("github.com/onosproject/onos-config-model/test", pluginDir))
+	bytes, err = ioutil.ReadFile(filepath.Join(pluginDir, "go.mod"))
+	assert.NoError(t, err)
+	assert.Equal(t, mod, string(bytes))
+
+	compiler.Config.ExtraReplaces = []ModReplace{{Old: "github.com/openconfig/ygot", New: "github.com/example/ygot"}}
+	assert.True(t, errors.IsInvalid(compiler.copyMod("github.com/onosproject/onos-config-model/test", pluginDir)))
+}
+
+func TestParseModReplace(t *testing.T) {
+	replace, err := ParseModReplace("github.com/openconfig/ygot=github.com/example/ygot@v0.12.5")
+	assert.NoError(t, err)
+	assert.Equal(t, ModReplace{Old: "github.com/openconfig/ygot", New: "github.com/example/ygot", Version: "v0.12.5"}, replace)
+
+	replace, err = ParseModReplace("github.com/openconfig/ygot=../ygot")
+	assert.NoError(t, err)
+	assert.Equal(t, ModReplace{Old: "github.com/openconfig/ygot", New: "../ygot"}, replace)
+
+	_, err = ParseModReplace("github.com/openconfig/ygot")
+	assert.True(t, errors.IsInvalid(err))
+	_, err = ParseModReplace("github.com/openconfig/ygot=github.com/example/ygot")
+	assert.True(t, errors.IsInvalid(err))
+	_, err = ParseModReplace("=../ygot")
+	assert.True(t, errors.IsInvalid(err))
+}
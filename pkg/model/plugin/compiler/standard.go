@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package plugincompiler
+
+import (
+	"embed"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/openconfig/goyang/pkg/yang"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const yangExt = ".yang"
+
+// StandardModulesVersion is the version of the bundled standard modules
+// The bundle is versioned so a change to the modules a model is compiled with can be detected from the version.
+const StandardModulesVersion = "v1"
+
+//go:embed standard/v1/*.yang
+var standardModulesFS embed.FS
+
+// GetStandardModules returns the names of the bundled standard modules
+func GetStandardModules() []string {
+	entries, err := fs.ReadDir(standardModulesFS, getStandardModulesDir())
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), yangExt))
+	}
+	return names
+}
+
+func getStandardModulesDir() string {
+	return path.Join("standard", StandardModulesVersion)
+}
+
+// getStandardModule returns the bundled standard module with the given name, if any
+func getStandardModule(name string) ([]byte, bool) {
+	data, err := standardModulesFS.ReadFile(path.Join(getStandardModulesDir(), name+yangExt))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// copyStandardModules copies the bundled standard modules imported by the model but not provided with it
+// Standard modules are only included if IncludeStandardModules is set. Modules imported by the copied
+// standard modules are copied as well. Imports that are neither provided nor bundled are left for the
+// generator to report.
+func (c *PluginCompiler) copyStandardModules(model configmodel.ModelInfo) error {
+	if !c.Config.IncludeStandardModules {
+		return nil
+	}
+
+	provided := make(map[string]bool)
+	var imports []string
+	for _, file := range model.Files {
+		file, err := file.Decompress()
+		if err != nil {
+			return err
+		}
+		names, fileImports, err := getModuleImports(file.Path, file.Data)
+		if err != nil {
+			// Files that cannot be parsed are reported by the generator
+			log.Warnf("Failed to read the imports of YANG module '%s': %s", file.Path, err)
+			continue
+		}
+		for _, name := range names {
+			provided[name] = true
+		}
+		imports = append(imports, fileImports...)
+	}
+
+	for len(imports) > 0 {
+		name := imports[0]
+		imports = imports[1:]
+		if provided[name] {
+			continue
+		}
+		provided[name] = true
+		data, ok := getStandardModule(name)
+		if !ok {
+			continue
+		}
+		yangPath := filepath.Join(c.getYangDir(model), name+yangExt)
+		log.Debugf("Copying standard YANG module '%s' to '%s'", name, yangPath)
+		if err := ioutil.WriteFile(yangPath, data, os.ModePerm); err != nil {
+			log.Errorf("Copying standard YANG module '%s' failed: %s", name, err)
+			return err
+		}
+		_, moduleImports, err := getModuleImports(name+yangExt, data)
+		if err != nil {
+			return err
+		}
+		imports = append(imports, moduleImports...)
+	}
+	return nil
+}
+
+// getModuleImports returns the names of the modules and submodules defined in the given YANG file, and
+// the names of the modules they import
+func getModuleImports(path string, data []byte) ([]string, []string, error) {
+	statements, err := yang.Parse(string(data), path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var names []string
+	var imports []string
+	for _, statement := range statements {
+		if statement.Keyword != "module" && statement.Keyword != "submodule" {
+			continue
+		}
+		names = append(names, statement.Argument)
+		for _, child := range statement.SubStatements() {
+			if child.Keyword == "import" {
+				imports = append(imports, child.Argument)
+			}
+		}
+	}
+	return names, imports, nil
+}
@@ -0,0 +1,161 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package plugincompiler
+
+import (
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testStandardYang = `
+module device {
+  namespace "urn:device";
+  prefix "d";
+
+  import ietf-yang-types { prefix yang; }
+  import openconfig-types { prefix oc-types; }
+
+  container system {
+    leaf mac-address {
+      type yang:mac-address;
+    }
+    leaf temperature {
+      type oc-types:ieeefloat32;
+    }
+  }
+}
+`
+
+const testProvidedTypesYang = `
+module openconfig-types {
+  namespace "urn:test-types";
+  prefix "oc-types";
+
+  typedef ieeefloat32 {
+    type binary;
+  }
+}
+`
+
+func TestGetStandardModules(t *testing.T) {
+	modules := GetStandardModules()
+	assert.Contains(t, modules, "ietf-yang-types")
+	assert.Contains(t, modules, "ietf-inet-types")
+	assert.Contains(t, modules, "openconfig-extensions")
+	assert.Contains(t, modules, "openconfig-types")
+
+	data, ok := getStandardModule("ietf-yang-types")
+	assert.True(t, ok)
+	names, imports, err := getModuleImports("ietf-yang-types.yang", data)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ietf-yang-types"}, names)
+	assert.Empty(t, imports)
+
+	data, ok = getStandardModule("openconfig-types")
+	assert.True(t, ok)
+	_, imports, err = getModuleImports("openconfig-types.yang", data)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"openconfig-extensions"}, imports)
+
+	_, ok = getStandardModule("device")
+	assert.False(t, ok)
+}
+
+func TestCopyStandardModules(t *testing.T) {
+	buildPath, err := ioutil.TempDir("", "config-model-build")
+	assert.NoError(t, err)
+	defer os.RemoveAll(buildPath)
+
+	model := configmodel.ModelInfo{
+		Name:    "device",
+		Version: "1.0.0",
+		Files: []configmodel.FileInfo{
+			{
+				Path: "device.yang",
+				Data: []byte(testStandardYang),
+			},
+		},
+	}
+
+	// Standard modules are not included unless enabled
+	compiler := NewPluginCompiler(CompilerConfig{BuildPath: buildPath}, nil)
+	compiler.createDir(compiler.getYangDir(model))
+	assert.NoError(t, compiler.copyStandardModules(model))
+	_, err = os.Stat(filepath.Join(compiler.getYangDir(model), "ietf-yang-types.yang"))
+	assert.True(t, os.IsNotExist(err))
+
+	// Imported modules are copied with the modules they import
+	compiler = NewPluginCompiler(CompilerConfig{BuildPath: buildPath, IncludeStandardModules: true}, nil)
+	assert.NoError(t, compiler.copyStandardModules(model))
+	for _, name := range []string{"ietf-yang-types", "openconfig-types", "openconfig-extensions"} {
+		expected, _ := getStandardModule(name)
+		copied, err := ioutil.ReadFile(filepath.Join(compiler.getYangDir(model), name+yangExt))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, copied)
+	}
+	_, err = os.Stat(filepath.Join(compiler.getYangDir(model), "ietf-inet-types.yang"))
+	assert.True(t, os.IsNotExist(err))
+
+	// Modules provided with the model are not replaced by the bundled modules
+	model.Version = "2.0.0"
+	model.Files = append(model.Files, configmodel.FileInfo{
+		Path: "types.yang",
+		Data: []byte(testProvidedTypesYang),
+	})
+	compiler.createDir(compiler.getYangDir(model))
+	assert.NoError(t, compiler.copyStandardModules(model))
+	_, err = os.Stat(filepath.Join(compiler.getYangDir(model), "ietf-yang-types.yang"))
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(compiler.getYangDir(model), "openconfig-types.yang"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(compiler.getYangDir(model), "openconfig-extensions.yang"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestCompileStandardModules(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping plugin build in short mode")
+	}
+
+	dir, err := ioutil.TempDir("", "config-model-compiler")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	model := configmodel.ModelInfo{
+		Name:         "device",
+		Version:      "1.0.0",
+		GetStateMode: configmodel.GetStateNone,
+		Modules: []configmodel.ModuleInfo{
+			{
+				Name: "device",
+				File: "device.yang",
+			},
+		},
+		Files: []configmodel.FileInfo{
+			{
+				Path: "device.yang",
+				Data: []byte(testStandardYang),
+			},
+		},
+		Plugin: configmodel.PluginInfo{
+			Name:    "device",
+			Version: "1.0.0",
+		},
+	}
+
+	// The model imports standard modules it does not provide
+	compiler := NewPluginCompiler(CompilerConfig{
+		TemplatePath:           "templates",
+		BuildPath:              filepath.Join(dir, "build"),
+		ModFile:                writePinnedModFile(t, dir),
+		SumFile:                filepath.Join(moduleRoot, "go.sum"),
+		IncludeStandardModules: true,
+	}, nil)
+	assert.NoError(t, compiler.CompilePlugin(model, filepath.Join(dir, "device-1.0.0.so")))
+}
@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package plugincompiler
+
+import (
+	"embed"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+const templatesDir = "templates"
+
+//go:embed templates/*.tpl
+var templatesFS embed.FS
+
+// readTemplate returns the contents of the plugin template with the given name
+// Templates are read from the configured template path if one is set, or else from the templates embedded
+// in the binary, so the compiler does not depend on the location of the source tree.
+func (c *PluginCompiler) readTemplate(name string) ([]byte, error) {
+	if c.Config.TemplatePath != "" {
+		return ioutil.ReadFile(filepath.Join(c.Config.TemplatePath, name))
+	}
+	return templatesFS.ReadFile(path.Join(templatesDir, name))
+}
+
+// applyTemplate writes the plugin template with the given name, applied to the given data, to the given path
+func (c *PluginCompiler) applyTemplate(name, outPath string, data TemplateInfo) error {
+	text, err := c.readTemplate(name)
+	if err != nil {
+		return err
+	}
+
+	var funcs template.FuncMap = map[string]interface{}{
+		"quote": func(value interface{}) string {
+			return fmt.Sprintf("\"%s\"", value)
+		},
+		"replace": func(search, replace string, value interface{}) string {
+			return strings.ReplaceAll(fmt.Sprint(value), search, replace)
+		},
+	}
+
+	tpl, err := template.New(name).
+		Funcs(funcs).
+		Parse(string(text))
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return tpl.Execute(file, data)
+}
@@ -1,4 +1,4 @@
-module github.com/onosproject/onos-config-model/{{ .Model.Name }}_{{ .Model.Version | replace "." "_" }}
+module {{ .ModulePath }}
 
 go 1.14
 
@@ -1,7 +1,10 @@
 package main
 
 import (
-	"github.com/onosproject/onos-config-model/{{ .Model.Name }}_{{ .Model.Version | replace "." "_" }}/model"
+	"{{ .ModulePath }}/model"
 )
 
-var ConfigModelPlugin configmodel.ConfigModelPlugin
+var ConfigModelPlugin {{ .PackageName }}.ConfigModelPlugin
+
+// PluginVersion is the version of onos-config-model with which the plugin was compiled
+var PluginVersion = "{{ .Compiler.Version }}"
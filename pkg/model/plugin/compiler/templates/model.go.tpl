@@ -1,4 +1,4 @@
-package configmodel
+package {{ .PackageName }}
 
 import (
     "errors"
@@ -17,13 +17,26 @@ const (
 
 var modelData = []*gnmi.ModelData{
     {{- range .Model.Modules }}
+	{{- if not .BelongsTo }}
 	{Name: {{ .Name | quote }}, Organization: {{ .Organization | quote }}, Version: {{ .Revision | quote }}},
 	{{- end }}
+	{{- end }}
 }
 
 var ModelInfo = configmodel.ModelInfo{
     Name: configmodel.Name({{ .Model.Name | quote }}),
     Version: configmodel.Version({{ .Model.Version | quote }}),
+    Features: []string{
+        {{- range .Model.Features }}
+        {{ . | quote }},
+        {{- end }}
+    },
+    Deviations: []configmodel.ModuleInfo{
+        {{- range .Model.Deviations }}
+        {Name: configmodel.Name({{ .Name | quote }}), File: {{ .File | quote }}, Organization: {{ .Organization | quote }}, Revision: configmodel.Revision({{ .Revision | quote }})},
+        {{- end }}
+    },
+    Checksum: {{ .Model.Checksum | quote }},
 }
 
 // ConfigModel defines the config model for {{ .Model.Name }} {{ .Model.Version }}
@@ -1,4 +1,4 @@
-package configmodel
+package {{ .PackageName }}
 
 import (
 	_ "github.com/golang/protobuf/proto"
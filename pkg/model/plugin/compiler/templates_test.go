@@ -0,0 +1,37 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package plugincompiler
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadTemplate(t *testing.T) {
+	// Templates are embedded by default, so the compiler does not depend on the working directory
+	compiler := NewPluginCompiler(CompilerConfig{BuildPath: t.TempDir()}, nil)
+	for _, name := range []string{modTemplate, mainTemplate, pluginTemplate, modelTemplate} {
+		embedded, err := compiler.readTemplate(name)
+		assert.NoError(t, err)
+		data, err := ioutil.ReadFile(filepath.Join(templatesDir, name))
+		assert.NoError(t, err)
+		assert.Equal(t, string(data), string(embedded))
+	}
+
+	// Templates are only read from the template path when one is configured
+	dir := t.TempDir()
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, mainTemplate), []byte("package {{ .PackageName }}\n"), 0644))
+	compiler = NewPluginCompiler(CompilerConfig{BuildPath: t.TempDir(), TemplatePath: dir}, nil)
+	outPath := filepath.Join(dir, mainFile)
+	assert.NoError(t, compiler.applyTemplate(mainTemplate, outPath, TemplateInfo{PackageName: "custom"}))
+	data, err := ioutil.ReadFile(outPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "package custom\n", string(data))
+	_, err = compiler.readTemplate(modTemplate)
+	assert.True(t, os.IsNotExist(err))
+}
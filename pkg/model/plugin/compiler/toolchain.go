@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package plugincompiler
+
+import (
+	"fmt"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// smokeTestMain is the source of the plugin built by SmokeTest
+// The plugin imports C so the build fails if cgo is disabled or no C compiler is available.
+const smokeTestMain = `package main
+
+import "C"
+
+func main() {}
+`
+
+// GetGoVersion returns the version of the go command with which plugins are built, e.g. go1.16.15
+func (c *PluginCompiler) GetGoVersion() (string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	out, err := c.exec(fmt.Sprintf("reading the version of '%s'", c.getGoBinary()), wd, c.getGoBinary(), "env", "GOVERSION")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// CheckGoVersion checks that plugins are built with the Go version of the running binary
+// Plugins built with another version cannot be loaded by the running binary, e.g. to validate pushed models.
+func (c *PluginCompiler) CheckGoVersion() error {
+	version, err := c.GetGoVersion()
+	if err != nil {
+		return errors.NewUnavailable("failed to detect the Go version used to build plugins: %s", err)
+	}
+	if version != runtime.Version() {
+		return errors.NewInvalid("plugins are built with %s (%s) but onos-config-model was built with %s; plugins can only be loaded by binaries built with the same Go version", version, c.getGoBinary(), runtime.Version())
+	}
+	return nil
+}
+
+// SmokeTest builds a trivial plugin in the build path to check that plugins can be built on the host
+// The plugin is built with the environment and for the platform with which model plugins are built, so the
+// build fails as compilations would if e.g. cgo is disabled or no C compiler is available.
+func (c *PluginCompiler) SmokeTest() error {
+	if err := os.MkdirAll(c.Config.BuildPath, os.ModePerm); err != nil {
+		return err
+	}
+	dir, err := ioutil.TempDir(c.Config.BuildPath, "smoke-test-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module smoketest\n"), 0666); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte(smokeTestMain), 0666); err != nil {
+		return err
+	}
+	env := append(c.getEnv(), c.GetPlatform().Env()...)
+	_, err = c.execEnv("building a test plugin", dir, env, c.getGoBinary(), "build", "-o", filepath.Join(dir, "smoke-test.so"), "-buildmode=plugin", ".")
+	return err
+}
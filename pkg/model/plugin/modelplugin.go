@@ -5,13 +5,49 @@
 package modelplugin
 
 import (
+	"fmt"
 	"github.com/onosproject/onos-config-model/pkg/model"
 	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"io"
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"plugin"
+	"reflect"
+	"strings"
+	"sync"
 )
 
-const pluginSymbol = "ConfigModelPlugin"
+// DefaultPluginSymbols are the symbols a plugin may export its ConfigModelPlugin as
+// Plugins built by the current compiler export ConfigModelPlugin, while older plugins export ConfigPlugin.
+var DefaultPluginSymbols = []string{"ConfigModelPlugin", "ConfigPlugin"}
+
+var (
+	pluginSymbols   = DefaultPluginSymbols
+	pluginSymbolsMu sync.RWMutex
+)
+
+// SetPluginSymbols sets the symbols tried in order by Load and LoadFresh
+// If no symbols are given, the DefaultPluginSymbols are tried.
+func SetPluginSymbols(symbols ...string) {
+	pluginSymbolsMu.Lock()
+	defer pluginSymbolsMu.Unlock()
+	if len(symbols) == 0 {
+		symbols = DefaultPluginSymbols
+	}
+	pluginSymbols = symbols
+}
+
+// GetPluginSymbols returns the symbols tried in order by Load and LoadFresh
+func GetPluginSymbols() []string {
+	pluginSymbolsMu.RLock()
+	defer pluginSymbolsMu.RUnlock()
+	return pluginSymbols
+}
+
+// abiMismatchMessage is the error reported by the plugin package when a plugin was built
+// with a different version of Go or of a package shared with the loading binary
+const abiMismatchMessage = "plugin was built with a different version of package"
 
 // ConfigModelPlugin provides a config model
 type ConfigModelPlugin interface {
@@ -20,18 +56,110 @@ type ConfigModelPlugin interface {
 }
 
 // Load loads the plugin at the given path
+// Plugins compiled with a different version of onos-config-model than the running binary are rejected.
+// The plugin is looked up by each of the symbols set with SetPluginSymbols in order.
 func Load(path string) (ConfigModelPlugin, error) {
+	return LoadSymbols(path, GetPluginSymbols()...)
+}
+
+// LoadSymbols loads the plugin at the given path, looking up the plugin by each of the given symbols in order
+// The first symbol exported by the plugin is loaded; if it is not a ConfigModelPlugin, the plugin is rejected.
+func LoadSymbols(path string, symbols ...string) (ConfigModelPlugin, error) {
 	module, err := plugin.Open(path)
 	if err != nil {
 		return nil, err
 	}
-	symbol, err := module.Lookup(pluginSymbol)
-	if err != nil {
+	if err := checkPluginVersion(path, module); err != nil {
 		return nil, err
 	}
+	name, symbol, ok := lookupSymbol(module, symbols)
+	if !ok {
+		return nil, errors.NewNotFound("module %s exports none of the symbols %s", filepath.Base(path), strings.Join(symbols, ", "))
+	}
 	plugin, ok := symbol.(ConfigModelPlugin)
 	if !ok {
-		return nil, errors.NewInvalid("symbol loaded from module %s is not a %s", filepath.Base(path), pluginSymbol)
+		return nil, errors.NewInvalid("symbol %s loaded from module %s is not a ConfigModelPlugin: %s", name, filepath.Base(path), describeSymbol(symbol))
 	}
 	return plugin, nil
 }
+
+// symbolLookup looks up a symbol in a plugin
+type symbolLookup interface {
+	Lookup(symName string) (plugin.Symbol, error)
+}
+
+// lookupSymbol returns the first of the given symbols found in the plugin
+func lookupSymbol(module symbolLookup, symbols []string) (string, plugin.Symbol, bool) {
+	for _, name := range symbols {
+		if symbol, err := module.Lookup(name); err == nil {
+			return name, symbol, true
+		}
+	}
+	return "", nil, false
+}
+
+// describeSymbol describes how the given symbol fails to implement ConfigModelPlugin
+func describeSymbol(symbol plugin.Symbol) string {
+	if symbol == nil {
+		return "found nil"
+	}
+	method := reflect.ValueOf(symbol).MethodByName("Model")
+	if !method.IsValid() {
+		return fmt.Sprintf("found %T which lacks Model() ConfigModel", symbol)
+	}
+	expected, _ := reflect.TypeOf((*ConfigModelPlugin)(nil)).Elem().MethodByName("Model")
+	return fmt.Sprintf("found %T whose Model method is %s rather than %s", symbol, method.Type(), expected.Type)
+}
+
+// Inspect loads the plugin at the given path and returns the info for the model it provides
+func Inspect(path string) (configmodel.ModelInfo, error) {
+	plugin, err := Load(path)
+	if err != nil {
+		return configmodel.ModelInfo{}, err
+	}
+	return plugin.Model().Info(), nil
+}
+
+// LoadFresh loads a copy of the plugin at the given path
+// The plugin package caches plugins (and plugin load failures) by path for the lifetime of the process,
+// so LoadFresh copies the plugin to a unique path in the same directory before loading it to ensure the
+// current content of the file is loaded. The copy is removed once the plugin has been loaded, but the space
+// it occupies on disk is not released until the process exits, so each fresh load costs the size of the plugin.
+// Note that Go also refuses to load two plugins built with the same plugin path (-pluginpath linker flag).
+func LoadFresh(path string) (ConfigModelPlugin, error) {
+	copyPath, err := copyPlugin(path)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(copyPath)
+	return Load(copyPath)
+}
+
+// copyPlugin copies the plugin at the given path to a unique path
+func copyPlugin(path string) (string, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	ext := filepath.Ext(path)
+	pattern := strings.TrimSuffix(filepath.Base(path), ext) + "-*" + ext
+	dst, err := ioutil.TempFile(filepath.Dir(path), pattern)
+	if err != nil {
+		return "", err
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, src); err != nil {
+		os.Remove(dst.Name())
+		return "", err
+	}
+	return dst.Name(), nil
+}
+
+// IsABIMismatch returns whether the given load error indicates the plugin is not compatible with the running binary
+// Plugins compiled with a different version of onos-config-model are incompatible even if Go can load them.
+func IsABIMismatch(err error) bool {
+	return err != nil && (strings.Contains(err.Error(), abiMismatchMessage) || strings.Contains(err.Error(), versionMismatchMessage))
+}
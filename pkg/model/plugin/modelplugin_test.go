@@ -0,0 +1,222 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelplugin
+
+import (
+	"fmt"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"plugin"
+	"testing"
+)
+
+// buildTestPlugin builds the test plugin with the given version to the given path
+// The plugin is built from files to ensure each version is assigned a unique plugin path.
+func buildTestPlugin(t *testing.T, path string, version string) {
+	dir, err := ioutil.TempDir("testdata", "plugin-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	main, err := ioutil.ReadFile(filepath.Join("testdata", "plugin", "main.go"))
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "main.go"), main, 0666))
+	versionFile := fmt.Sprintf("package main\n\nconst version = %q\n", version)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "version.go"), []byte(versionFile), 0666))
+
+	cmd := exec.Command("go", "build", "-buildmode=plugin", "-o", path, "main.go", "version.go")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "CGO_ENABLED=1")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("failed to build test plugin: %s\n%s", err, out)
+	}
+}
+
+func TestLoadFresh(t *testing.T) {
+	if testing.Short() || raceEnabled {
+		t.Skip("building plugins is slow and they cannot be loaded by binaries built with -race")
+	}
+
+	dir, err := ioutil.TempDir("", "config-model-plugin")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test-1.0.0.so")
+
+	buildTestPlugin(t, path, "1")
+	plugin, err := Load(path)
+	assert.NoError(t, err)
+	assert.Equal(t, configmodel.Version("1"), plugin.Model().Info().Version)
+
+	// Rebuild the plugin in place
+	buildTestPlugin(t, path, "2")
+
+	// Loading by the same path returns the plugin cached by the plugin package
+	plugin, err = Load(path)
+	assert.NoError(t, err)
+	assert.Equal(t, configmodel.Version("1"), plugin.Model().Info().Version)
+
+	// Loading a fresh copy returns the new build
+	plugin, err = LoadFresh(path)
+	assert.NoError(t, err)
+	assert.Equal(t, configmodel.Version("2"), plugin.Model().Info().Version)
+
+	// The copy is removed once loaded
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+func TestInspect(t *testing.T) {
+	if testing.Short() || raceEnabled {
+		t.Skip("building plugins is slow and they cannot be loaded by binaries built with -race")
+	}
+
+	dir, err := ioutil.TempDir("", "config-model-plugin")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test-3.so")
+
+	buildTestPlugin(t, path, "3")
+	info, err := Inspect(path)
+	assert.NoError(t, err)
+	assert.Equal(t, configmodel.Name("test"), info.Name)
+	assert.Equal(t, configmodel.Version("3"), info.Version)
+	assert.Equal(t, []string{"feature-a"}, info.Features)
+}
+
+func TestLoadWrongSymbolType(t *testing.T) {
+	if testing.Short() || raceEnabled {
+		t.Skip("building plugins is slow and they cannot be loaded by binaries built with -race")
+	}
+
+	dir, err := ioutil.TempDir("", "config-model-plugin")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "wrong-1.0.0.so")
+
+	cmd := exec.Command("go", "build", "-buildmode=plugin", "-o", path, "main.go")
+	cmd.Dir = filepath.Join("testdata", "wrongplugin")
+	cmd.Env = append(os.Environ(), "CGO_ENABLED=1")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("failed to build test plugin: %s\n%s", err, out)
+	}
+
+	_, err = Load(path)
+	assert.True(t, errors.IsInvalid(err))
+	assert.Contains(t, err.Error(), "found *main.wrongPlugin whose Model method is func() string rather than func() configmodel.ConfigModel")
+}
+
+func TestLoadVersionMismatch(t *testing.T) {
+	if testing.Short() || raceEnabled {
+		t.Skip("building plugins is slow and they cannot be loaded by binaries built with -race")
+	}
+
+	dir, err := ioutil.TempDir("", "config-model-plugin")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test-4.so")
+
+	srcDir, err := ioutil.TempDir("testdata", "plugin-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(srcDir)
+	main, err := ioutil.ReadFile(filepath.Join("testdata", "plugin", "main.go"))
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(srcDir, "main.go"), main, 0666))
+	versionFile := "package main\n\nconst version = \"4\"\n\n// PluginVersion is the version of the compiler\nvar PluginVersion = \"v0.0.0-mismatch\"\n"
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(srcDir, "version.go"), []byte(versionFile), 0666))
+
+	cmd := exec.Command("go", "build", "-buildmode=plugin", "-o", path, "main.go", "version.go")
+	cmd.Dir = srcDir
+	cmd.Env = append(os.Environ(), "CGO_ENABLED=1")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("failed to build test plugin: %s\n%s", err, out)
+	}
+
+	// Plugins compiled with another version of the module are rejected, and can be recompiled
+	_, err = Load(path)
+	assert.True(t, errors.IsInvalid(err))
+	assert.Contains(t, err.Error(), "test-4.so was compiled with onos-config-model v0.0.0-mismatch")
+	assert.True(t, IsABIMismatch(err))
+}
+
+func TestCheckVersion(t *testing.T) {
+	assert.NotEmpty(t, GetModuleVersion())
+	assert.NoError(t, checkVersion("test.so", "v1.0.0", "v1.0.0"))
+	assert.NoError(t, checkVersion("test.so", "", "v1.0.0"))
+	assert.NoError(t, checkVersion("test.so", "v1.0.0", ""))
+
+	err := checkVersion("test.so", "v1.0.0", "v1.1.0")
+	assert.True(t, errors.IsInvalid(err))
+	assert.EqualError(t, err, "plugin test.so was compiled with onos-config-model v1.0.0, but the running binary uses v1.1.0; the plugin must be recompiled")
+}
+
+func TestDescribeSymbol(t *testing.T) {
+	var value int
+	assert.Equal(t, "found *int which lacks Model() ConfigModel", describeSymbol(&value))
+	assert.Equal(t, "found nil", describeSymbol(nil))
+}
+
+type testSymbols map[string]plugin.Symbol
+
+func (s testSymbols) Lookup(symName string) (plugin.Symbol, error) {
+	if symbol, ok := s[symName]; ok {
+		return symbol, nil
+	}
+	return nil, fmt.Errorf("symbol %s not found", symName)
+}
+
+func TestLookupSymbol(t *testing.T) {
+	legacy := 1
+	current := 2
+	name, symbol, ok := lookupSymbol(testSymbols{"ConfigPlugin": &legacy}, DefaultPluginSymbols)
+	assert.True(t, ok)
+	assert.Equal(t, "ConfigPlugin", name)
+	assert.Same(t, &legacy, symbol)
+
+	// Symbols are tried in order
+	name, symbol, ok = lookupSymbol(testSymbols{"ConfigPlugin": &legacy, "ConfigModelPlugin": &current}, DefaultPluginSymbols)
+	assert.True(t, ok)
+	assert.Equal(t, "ConfigModelPlugin", name)
+	assert.Same(t, &current, symbol)
+
+	_, _, ok = lookupSymbol(testSymbols{"Other": &current}, DefaultPluginSymbols)
+	assert.False(t, ok)
+}
+
+func TestSetPluginSymbols(t *testing.T) {
+	defer SetPluginSymbols()
+	assert.Equal(t, DefaultPluginSymbols, GetPluginSymbols())
+	SetPluginSymbols("Plugin")
+	assert.Equal(t, []string{"Plugin"}, GetPluginSymbols())
+	SetPluginSymbols()
+	assert.Equal(t, DefaultPluginSymbols, GetPluginSymbols())
+}
+
+func TestLoadSymbols(t *testing.T) {
+	if testing.Short() || raceEnabled {
+		t.Skip("building plugins is slow and they cannot be loaded by binaries built with -race")
+	}
+
+	dir, err := ioutil.TempDir("", "config-model-plugin")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test-5.so")
+
+	buildTestPlugin(t, path, "5")
+	plugin, err := LoadSymbols(path, "ConfigPlugin", "ConfigModelPlugin")
+	assert.NoError(t, err)
+	assert.Equal(t, configmodel.Version("5"), plugin.Model().Info().Version)
+
+	_, err = LoadSymbols(path, "ConfigPlugin", "Plugin")
+	assert.True(t, errors.IsNotFound(err))
+	assert.EqualError(t, err, "module test-5.so exports none of the symbols ConfigPlugin, Plugin")
+}
@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package pluginmodule
+
+import (
+	"fmt"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// pluginPlatforms are the platforms on which the go command supports -buildmode=plugin
+var pluginPlatforms = map[string]bool{
+	"linux/amd64":   true,
+	"linux/arm":     true,
+	"linux/arm64":   true,
+	"linux/386":     true,
+	"linux/loong64": true,
+	"linux/riscv64": true,
+	"linux/s390x":   true,
+	"linux/ppc64":   true,
+	"linux/ppc64le": true,
+	"android/amd64": true,
+	"android/386":   true,
+	"darwin/amd64":  true,
+	"darwin/arm64":  true,
+	"freebsd/amd64": true,
+}
+
+// Platform is the operating system and architecture for which plugins are built
+// Plugins can only be loaded by binaries built for the same platform.
+type Platform struct {
+	GOOS   string
+	GOARCH string
+}
+
+// NewPlatform returns the platform with the given GOOS and GOARCH, each defaulting to the host's
+func NewPlatform(goos, goarch string) Platform {
+	if goos == "" {
+		goos = runtime.GOOS
+	}
+	if goarch == "" {
+		goarch = runtime.GOARCH
+	}
+	return Platform{
+		GOOS:   goos,
+		GOARCH: goarch,
+	}
+}
+
+// ParsePlatform parses a platform in the goos/goarch format, e.g. linux/arm64
+func ParsePlatform(platform string) (Platform, error) {
+	parts := strings.Split(platform, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return Platform{}, errors.NewInvalid("platform '%s' is not in the goos/goarch format", platform)
+	}
+	return NewPlatform(parts[0], parts[1]), nil
+}
+
+func (p Platform) String() string {
+	return fmt.Sprintf("%s/%s", p.GOOS, p.GOARCH)
+}
+
+// IsHost returns whether the platform is the platform of the running binary
+func (p Platform) IsHost() bool {
+	return p.GOOS == runtime.GOOS && p.GOARCH == runtime.GOARCH
+}
+
+// Validate checks that plugins can be built for the platform
+func (p Platform) Validate() error {
+	if pluginPlatforms[p.String()] {
+		return nil
+	}
+	platforms := make([]string, 0, len(pluginPlatforms))
+	for platform := range pluginPlatforms {
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+	return errors.NewInvalid("plugins cannot be built for '%s': -buildmode=plugin is only supported on %s", p, strings.Join(platforms, ", "))
+}
+
+// Env returns the environment with which Go commands build for the platform
+// Plugins require cgo, so cross-compiling also requires a C compiler for the platform, e.g. CC=aarch64-linux-gnu-gcc.
+func (p Platform) Env() []string {
+	return []string{
+		fmt.Sprintf("GOOS=%s", p.GOOS),
+		fmt.Sprintf("GOARCH=%s", p.GOARCH),
+	}
+}
+
+// Suffix returns a suffix distinguishing names of files built for the platform from files built for the host
+// The suffix is empty for the host platform, so the names of files built for the host are unchanged.
+func (p Platform) Suffix() string {
+	if p.IsHost() {
+		return ""
+	}
+	return fmt.Sprintf("_%s_%s", p.GOOS, p.GOARCH)
+}
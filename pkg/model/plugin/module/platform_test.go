@@ -0,0 +1,43 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package pluginmodule
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"runtime"
+	"testing"
+)
+
+func TestPlatform(t *testing.T) {
+	platform := NewPlatform("", "")
+	assert.Equal(t, runtime.GOOS, platform.GOOS)
+	assert.Equal(t, runtime.GOARCH, platform.GOARCH)
+	assert.True(t, platform.IsHost())
+	assert.Empty(t, platform.Suffix())
+
+	platform = NewPlatform("linux", "s390x")
+	assert.Equal(t, "linux/s390x", platform.String())
+	assert.NoError(t, platform.Validate())
+	assert.Equal(t, []string{"GOOS=linux", "GOARCH=s390x"}, platform.Env())
+	if runtime.GOARCH != "s390x" {
+		assert.False(t, platform.IsHost())
+		assert.Equal(t, "_linux_s390x", platform.Suffix())
+	}
+
+	// Plugins are not supported on every platform Go can build for
+	err := NewPlatform("windows", "amd64").Validate()
+	assert.True(t, errors.IsInvalid(err))
+	assert.Contains(t, err.Error(), "plugins cannot be built for 'windows/amd64'")
+	assert.Contains(t, err.Error(), "linux/arm64")
+
+	platform, err = ParsePlatform("linux/arm64")
+	assert.NoError(t, err)
+	assert.Equal(t, NewPlatform("linux", "arm64"), platform)
+	for _, value := range []string{"", "linux", "linux/", "/arm64", "linux/arm64/v8"} {
+		_, err = ParsePlatform(value)
+		assert.True(t, errors.IsInvalid(err), value)
+	}
+}
@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package pluginmodule
+
+import (
+	"context"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/rogpeppe/go-internal/modfile"
+	"time"
+)
+
+// Phase is a phase of module resolution reported to progress listeners
+type Phase string
+
+const (
+	// ModuleCreatedPhase is reported when the temporary module with which a target is fetched has been created
+	ModuleCreatedPhase Phase = "module-created"
+	// ModuleFetchedPhase is reported when 'go get' has added a target to the temporary module
+	ModuleFetchedPhase Phase = "module-fetched"
+	// CacheReadPhase is reported when a target's go.mod and hash have been read from the module cache
+	CacheReadPhase Phase = "cache-read"
+)
+
+// Progress is a module resolution progress event
+type Progress struct {
+	Phase   Phase  `json:"phase"`
+	Target  string `json:"target"`
+	Message string `json:"message,omitempty"`
+}
+
+// ProgressFunc is a function called with module resolution progress events
+type ProgressFunc func(Progress)
+
+// ResolveContext resolves the module info for the target module, aborting resolution when the given context is done
+// Go commands, e.g. 'go get', are killed when the context is canceled, and resolution fails with a Canceled error.
+func (r *Resolver) ResolveContext(ctx context.Context) (*modfile.File, Hash, error) {
+	return r.ResolveWithProgress(ctx, nil)
+}
+
+// ResolveWithProgress resolves the module info for the target module, reporting the progress of each phase
+// The progress function is called synchronously from the goroutine resolving the module, and is only called
+// for targets that are fetched rather than read from the resolver's path.
+func (r *Resolver) ResolveWithProgress(ctx context.Context, progress ProgressFunc) (*modfile.File, Hash, error) {
+	resolver := *r
+	resolver.ctx = ctx
+	resolver.progress = progress
+	return resolver.resolve()
+}
+
+// report reports a progress event for the given target if a progress function is set
+func (r *Resolver) report(phase Phase, target string, message string) {
+	log.Debugf("%s: %s", target, message)
+	if r.progress == nil {
+		return
+	}
+	r.progress(Progress{
+		Phase:   phase,
+		Target:  target,
+		Message: message,
+	})
+}
+
+// getContext returns the context of the resolution, which is never done unless set by ResolveContext
+func (r *Resolver) getContext() context.Context {
+	if r.ctx == nil {
+		return context.Background()
+	}
+	return r.ctx
+}
+
+// getAbortError returns a Canceled error for the given step if the resolution's context is done
+func (r *Resolver) getAbortError(step string) error {
+	if err := r.getContext().Err(); err != nil {
+		return errors.NewCanceled("%s was aborted: %s", step, err)
+	}
+	return nil
+}
+
+// sleep waits for the given delay, returning a Canceled error for the given step if the context is done first
+func (r *Resolver) sleep(step string, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-r.getContext().Done():
+		return r.getAbortError(step)
+	}
+}
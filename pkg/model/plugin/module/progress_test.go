@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package pluginmodule
+
+import (
+	"context"
+	"fmt"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestResolveWithProgress(t *testing.T) {
+	modCache := t.TempDir()
+	downloadDir := filepath.Join(modCache, "cache", "download", "example.com", "foo", "@v")
+	assert.NoError(t, os.MkdirAll(downloadDir, 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(downloadDir, "v1.0.0.mod"), []byte(fooMod), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(downloadDir, "v1.0.0.ziphash"), []byte("h1:foo"), 0644))
+
+	// Fake 'go get' by adding the target to the temporary module, and 'go env' with the fake module cache
+	resolver := NewResolver(ResolverConfig{
+		Path:   t.TempDir(),
+		Target: "example.com/foo@v1.0.0",
+	})
+	resolver.command = func(dir string, name string, args ...string) (string, error) {
+		switch args[0] {
+		case "get":
+			return "", ioutil.WriteFile(filepath.Join(dir, modFile), []byte("module m\n\nrequire example.com/foo v1.0.0\n"), 0666)
+		case "env":
+			return fmt.Sprintf(`{"GOMODCACHE": %q}`, modCache), nil
+		}
+		return "", errors.NewInvalid("unexpected command %s %v", name, args)
+	}
+
+	var phases []Phase
+	mod, hash, err := resolver.ResolveWithProgress(context.Background(), func(progress Progress) {
+		assert.Equal(t, "example.com/foo@v1.0.0", progress.Target)
+		assert.NotEmpty(t, progress.Message)
+		phases = append(phases, progress.Phase)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com/foo", mod.Module.Mod.Path)
+	assert.Equal(t, Hash("h1:foo"), hash)
+	assert.Equal(t, []Phase{ModuleCreatedPhase, ModuleFetchedPhase, CacheReadPhase}, phases)
+
+	// Modules read from the resolver's path are not fetched again
+	phases = nil
+	_, _, err = resolver.ResolveWithProgress(context.Background(), func(progress Progress) {
+		phases = append(phases, progress.Phase)
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, phases)
+}
+
+func TestResolveContextCanceled(t *testing.T) {
+	var calls int
+	resolver := NewResolver(ResolverConfig{
+		Path:            t.TempDir(),
+		Target:          "example.com/foo@v1.0.0",
+		FetchRetries:    3,
+		FetchRetryDelay: time.Hour,
+	})
+	resolver.command = func(dir string, name string, args ...string) (string, error) {
+		calls++
+		return "", errors.NewUnavailable("502 Bad Gateway")
+	}
+
+	// Commands are not run once the context is canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err := resolver.ResolveContext(ctx)
+	assert.True(t, errors.IsCanceled(err))
+	assert.Equal(t, 0, calls)
+
+	// Canceling the context aborts the delay before retrying a transient failure
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	_, _, err = resolver.ResolveContext(ctx)
+	assert.True(t, errors.IsCanceled(err))
+	assert.Equal(t, 1, calls)
+}
@@ -5,10 +5,13 @@
 package pluginmodule
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	modellogging "github.com/onosproject/onos-config-model/pkg/model/logging"
 	"github.com/onosproject/onos-lib-go/pkg/errors"
-	"github.com/onosproject/onos-lib-go/pkg/logging"
 	_ "github.com/openconfig/gnmi/proto/gnmi" // gnmi
 	_ "github.com/openconfig/goyang/pkg/yang" // yang
 	_ "github.com/openconfig/ygot/genutil"    // genutil
@@ -17,15 +20,19 @@ import (
 	_ "github.com/openconfig/ygot/ytypes"     // ytypes
 	"github.com/rogpeppe/go-internal/modfile"
 	"github.com/rogpeppe/go-internal/module"
+	"github.com/rogpeppe/go-internal/semver"
 	_ "google.golang.org/protobuf/proto" // proto
+	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 )
 
-var log = logging.GetLogger("config-model", "plugin", "module")
+var log = modellogging.GetLogger("config-model", "plugin", "module")
 
 const (
 	defaultPath   = "/etc/onos/mod"
@@ -34,6 +41,47 @@ const (
 	modVersionSep = "@"
 )
 
+// defaultFetchRetryDelay is the default delay before retrying a transient module fetch failure
+const defaultFetchRetryDelay = time.Second
+
+// retryableFetchErrors are the lowercase messages of transient network and module proxy errors
+var retryableFetchErrors = []string{
+	"500 internal server error",
+	"502 bad gateway",
+	"503 service unavailable",
+	"504 gateway timeout",
+	"429 too many requests",
+	"connection refused",
+	"connection reset",
+	"i/o timeout",
+	"tls handshake timeout",
+	"temporary failure in name resolution",
+	"unexpected eof",
+}
+
+// missingVersionErrors are the lowercase messages of errors for module versions that don't exist
+var missingVersionErrors = []string{
+	"unknown revision",
+	"not found",
+}
+
+// DefaultOfflineEnv is the environment with which Go commands are run offline
+// Modules are only loaded from the module cache, and the checksum database is not consulted for them.
+var DefaultOfflineEnv = []string{"GOFLAGS=-mod=mod", "GOPROXY=off", "GOSUMDB=off"}
+
+// GetEnv returns the environment for Go commands, with the given offline environment if offline
+// If no offline environment is given, the DefaultOfflineEnv is used.
+func GetEnv(offline bool, offlineEnv []string) []string {
+	env := append(os.Environ(), "GO111MODULE=on", "CGO_ENABLED=1")
+	if !offline {
+		return env
+	}
+	if offlineEnv == nil {
+		offlineEnv = DefaultOfflineEnv
+	}
+	return append(env, offlineEnv...)
+}
+
 // Hash is a module hash
 type Hash []byte
 
@@ -42,6 +90,45 @@ type ResolverConfig struct {
 	Path    string
 	Target  string
 	Replace string
+	// Targets is a list of additional target modules whose requirements are merged into the resolved module
+	Targets []TargetConfig
+	// Offline indicates whether modules are resolved without network access
+	// Offline targets must be pinned to versions that are already in the module cache.
+	Offline bool
+	// OfflineEnv is the environment with which Go commands are run offline, defaulting to DefaultOfflineEnv
+	OfflineEnv []string
+	// FetchRetries is the number of times fetching a module is retried after a transient failure
+	// Failures that cannot succeed on retry, e.g. unknown modules or versions, are not retried.
+	FetchRetries int
+	// FetchRetryDelay is the delay before the first retry, which doubles for each subsequent retry
+	// If zero, the delay defaults to one second.
+	FetchRetryDelay time.Duration
+	// VendorDir is a vendor directory from which target modules are read instead of the module cache
+	// When set, each target's go.mod is read from the vendor directory and its hash is computed from
+	// the vendor directory's modules.txt, so no modules are downloaded.
+	VendorDir string
+}
+
+// TargetConfig is a target module configuration
+type TargetConfig struct {
+	Target  string
+	Replace string
+}
+
+// ParseTargets pairs the given target modules with the replace modules at the same index
+// Targets without a replace module may be followed by an empty replace or omitted from the end of the replaces.
+func ParseTargets(targets []string, replaces []string) ([]TargetConfig, error) {
+	if len(replaces) > len(targets) {
+		return nil, errors.NewInvalid("%d replace modules configured for %d target modules", len(replaces), len(targets))
+	}
+	configs := make([]TargetConfig, len(targets))
+	for i, target := range targets {
+		configs[i].Target = target
+		if i < len(replaces) {
+			configs[i].Replace = replaces[i]
+		}
+	}
+	return configs, nil
 }
 
 // NewResolver creates a new module resolver
@@ -50,21 +137,45 @@ func NewResolver(config ResolverConfig) *Resolver {
 		config.Path = defaultPath
 	}
 	ensureDir(config.Path)
-	return &Resolver{config}
+	return &Resolver{Config: config}
 }
 
+// commandFunc runs a command in the given directory, returning its stdout
+type commandFunc func(dir string, name string, args ...string) (string, error)
+
 // Resolver is a module resolver
 type Resolver struct {
 	Config ResolverConfig
+	// command runs commands in place of exec, e.g. to fake the go command in tests
+	command  commandFunc
+	ctx      context.Context
+	progress ProgressFunc
 }
 
+// exec runs a command in the given directory, returning its stdout
+// If the command fails, the returned error includes its stderr. The command is killed if the resolution's
+// context is done, in which case a Canceled error is returned.
 func (r *Resolver) exec(dir string, name string, args ...string) (string, error) {
-	cmd := exec.Command(name, args...)
+	step := strings.Join(append([]string{name}, args...), " ")
+	if err := r.getAbortError(step); err != nil {
+		return "", err
+	}
+	if r.command != nil {
+		return r.command(dir, name, args...)
+	}
+	cmd := exec.CommandContext(r.getContext(), name, args...)
 	cmd.Dir = dir
-	cmd.Env = append(os.Environ(), "GO111MODULE=on", "CGO_ENABLED=1")
-	cmd.Stderr = os.Stderr
+	cmd.Env = GetEnv(r.Config.Offline, r.Config.OfflineEnv)
+	var stderr bytes.Buffer
+	cmd.Stderr = io.MultiWriter(os.Stderr, &stderr)
 	out, err := cmd.Output()
 	if err != nil {
+		if err := r.getAbortError(step); err != nil {
+			return "", err
+		}
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("%w: %s", err, message)
+		}
 		return "", err
 	}
 	return string(out), nil
@@ -100,14 +211,103 @@ func (r *Resolver) getGoModCacheDir() (string, error) {
 	return modCache, nil
 }
 
+// resolvedMod is a module resolved from the files in a resolver's path
+type resolvedMod struct {
+	modBytes []byte
+	modTime  time.Time
+	hash     Hash
+	hashTime time.Time
+}
+
+// resolvedMods are the modules resolved in this process, keyed by the resolver path and target modules
+var resolvedMods = make(map[string]*resolvedMod)
+var resolvedModsMu sync.RWMutex
+
+// getResolvedModKey returns the key of the module resolved by the resolver
+func (r *Resolver) getResolvedModKey() string {
+	var b strings.Builder
+	b.WriteString(r.Config.Path)
+	for _, target := range r.getTargets() {
+		b.WriteString("|")
+		b.WriteString(target.Target)
+		b.WriteString("=>")
+		b.WriteString(target.Replace)
+	}
+	return b.String()
+}
+
+// getResolvedMod returns the module previously resolved by a resolver with the same configuration
+// The module is only returned if the resolved files have not been modified since it was resolved.
+func (r *Resolver) getResolvedMod() (*modfile.File, Hash, bool) {
+	resolvedModsMu.RLock()
+	resolved, ok := resolvedMods[r.getResolvedModKey()]
+	resolvedModsMu.RUnlock()
+	if !ok {
+		return nil, nil, false
+	}
+	modInfo, err := os.Stat(r.getModPath())
+	if err != nil || !modInfo.ModTime().Equal(resolved.modTime) {
+		return nil, nil, false
+	}
+	hashInfo, err := os.Stat(r.getHashPath())
+	if err != nil || !hashInfo.ModTime().Equal(resolved.hashTime) {
+		return nil, nil, false
+	}
+	// Callers modify the returned module, so each caller gets its own copy
+	mod, err := modfile.Parse(r.getModPath(), resolved.modBytes, nil)
+	if err != nil {
+		return nil, nil, false
+	}
+	return mod, resolved.hash, true
+}
+
+// setResolvedMod records the module resolved from the given files for resolvers with the same configuration
+func (r *Resolver) setResolvedMod(modBytes []byte, hash Hash) {
+	modInfo, err := os.Stat(r.getModPath())
+	if err != nil {
+		return
+	}
+	hashInfo, err := os.Stat(r.getHashPath())
+	if err != nil {
+		return
+	}
+	resolvedModsMu.Lock()
+	defer resolvedModsMu.Unlock()
+	resolvedMods[r.getResolvedModKey()] = &resolvedMod{
+		modBytes: modBytes,
+		modTime:  modInfo.ModTime(),
+		hash:     hash,
+		hashTime: hashInfo.ModTime(),
+	}
+}
+
+// Invalidate discards the module resolved by resolvers with the same configuration
+// The module is read again from the resolver's path by the next call to Resolve, and fetched if it's not there.
+func (r *Resolver) Invalidate() {
+	resolvedModsMu.Lock()
+	defer resolvedModsMu.Unlock()
+	delete(resolvedMods, r.getResolvedModKey())
+}
+
 // Resolve resolves the module info for the target module
+// Resolved modules are shared by resolvers with the same configuration in the process until the resolved
+// files are modified or the module is invalidated.
 func (r *Resolver) Resolve() (*modfile.File, Hash, error) {
+	return r.ResolveContext(context.Background())
+}
+
+// resolve resolves the module info for the target module with the resolver's context
+func (r *Resolver) resolve() (*modfile.File, Hash, error) {
+	if mod, hash, ok := r.getResolvedMod(); ok {
+		return mod, hash, nil
+	}
+
 	modPath := r.getModPath()
 	modBytes, modErr := ioutil.ReadFile(modPath)
 	hashPath := r.getHashPath()
 	hashBytes, hashErr := ioutil.ReadFile(hashPath)
 	if modErr != nil || hashErr != nil {
-		mod, hash, err := r.fetchMod()
+		mod, hash, err := r.fetchMods()
 		if err != nil {
 			return nil, nil, err
 		}
@@ -116,14 +316,17 @@ func (r *Resolver) Resolve() (*modfile.File, Hash, error) {
 			log.Errorf("Failed to format go.mod: %s", err)
 			return nil, nil, err
 		}
-		if err := ioutil.WriteFile(r.getModPath(), modBytes, 0666); err != nil {
+		// Processes sharing the module path may resolve the module concurrently, so the files
+		// are replaced atomically to ensure they're never read partially written
+		if err := writeFileAtomic(r.getModPath(), modBytes); err != nil {
 			log.Errorf("Failed to write go.mod: %s", err)
 			return nil, nil, err
 		}
-		if err := ioutil.WriteFile(r.getHashPath(), hash, 0666); err != nil {
+		if err := writeFileAtomic(r.getHashPath(), hash); err != nil {
 			log.Errorf("Failed to write module hash: %s", err)
 			return nil, nil, err
 		}
+		r.setResolvedMod(modBytes, hash)
 		return mod, hash, nil
 	}
 	modFile, err := modfile.Parse(modPath, modBytes, nil)
@@ -131,23 +334,85 @@ func (r *Resolver) Resolve() (*modfile.File, Hash, error) {
 		log.Errorf("Failed to parse go.mod: %s", err)
 		return nil, nil, err
 	}
+	r.setResolvedMod(modBytes, hashBytes)
 	return modFile, hashBytes, nil
 }
 
-func (r *Resolver) fetchMod() (*modfile.File, Hash, error) {
-	target, replace := r.Config.Target, r.Config.Replace
+// Fetch fetches the target modules without resolving them
+// Fetch checks that the targets can be fetched, e.g. from the module proxy or the module cache, without
+// replacing the module resolved in the resolver's path.
+func (r *Resolver) Fetch() error {
+	_, _, err := r.fetchMods()
+	return err
+}
+
+// GetModCacheDir returns the Go module cache directory from which target modules are fetched
+func (r *Resolver) GetModCacheDir() (string, error) {
+	return r.getGoModCacheDir()
+}
+
+// getTargets returns the configured target modules
+func (r *Resolver) getTargets() []TargetConfig {
+	var targets []TargetConfig
+	if r.Config.Target != "" || len(r.Config.Targets) == 0 {
+		targets = append(targets, TargetConfig{
+			Target:  r.Config.Target,
+			Replace: r.Config.Replace,
+		})
+	}
+	return append(targets, r.Config.Targets...)
+}
+
+// fetchMods fetches all the target modules and merges their requirements
+func (r *Resolver) fetchMods() (*modfile.File, Hash, error) {
+	targets := r.getTargets()
+	mods := make([]*modfile.File, len(targets))
+	hashes := make([]Hash, len(targets))
+	for i, target := range targets {
+		mod, hash, err := r.fetchMod(target.Target, target.Replace)
+		if err != nil {
+			return nil, nil, err
+		}
+		mods[i] = mod
+		hashes[i] = hash
+	}
+	if len(mods) == 1 {
+		return mods[0], hashes[0], nil
+	}
+	mod, err := mergeMods(mods)
+	if err != nil {
+		log.Errorf("Failed to merge target modules: %s", err)
+		return nil, nil, err
+	}
+	return mod, combineHashes(hashes), nil
+}
+
+func (r *Resolver) fetchMod(target, replace string) (*modfile.File, Hash, error) {
 	if target == "" {
 		err := errors.NewInvalid("no target module configured")
-		log.Errorf("Failed to fetch module '%s': %s", r.Config.Target, err)
+		log.Errorf("Failed to fetch module '%s': %s", target, err)
 		return nil, nil, err
 	}
 
+	// Vendored modules are read from the vendor directory without fetching them
+	if r.Config.VendorDir != "" {
+		return r.fetchVendorMod(target, replace)
+	}
+
 	targetPath, _ := splitModPathVersion(target)
 
+	// Offline modules can only be fetched from the module cache
+	if r.Config.Offline {
+		if err := r.checkModCache(target, replace); err != nil {
+			log.Errorf("Failed to fetch module '%s': %s", target, err)
+			return nil, nil, err
+		}
+	}
+
 	log.Infof("Fetching module '%s'", target)
 	fakeModDir, err := ioutil.TempDir("", "config-plugin-target")
 	if err != nil {
-		log.Errorf("Failed to fetch module '%s': %s", r.Config.Target, err)
+		log.Errorf("Failed to fetch module '%s': %s", target, err)
 		return nil, nil, err
 	}
 	defer os.RemoveAll(fakeModDir)
@@ -156,33 +421,39 @@ func (r *Resolver) fetchMod() (*modfile.File, Hash, error) {
 	fakeMod := []byte("module m\n")
 	if replace != "" {
 		replacePath, replaceVersion := splitModPathVersion(replace)
+		if err := r.validateReplace(fakeModDir, replacePath, replaceVersion); err != nil {
+			log.Errorf("Failed to fetch module '%s': %s", target, err)
+			return nil, nil, err
+		}
 		fakeMod = append(fakeMod, []byte(fmt.Sprintf("replace %s => %s %s\n", targetPath, replacePath, replaceVersion))...)
 	}
 
 	// Write the temporary module file
 	fakeModPath := filepath.Join(fakeModDir, modFile)
 	if err := ioutil.WriteFile(fakeModPath, fakeMod, 0666); err != nil {
-		log.Errorf("Failed to fetch module '%s': %s", r.Config.Target, err)
+		log.Errorf("Failed to fetch module '%s': %s", target, err)
 		return nil, nil, err
 	}
+	r.report(ModuleCreatedPhase, target, fmt.Sprintf("Created temporary module '%s'", fakeModDir))
 
 	// Add the target dependency to the temporary module and download the target module
-	if _, err := r.exec(fakeModDir, "go", "get", "-d", target); err != nil {
-		log.Errorf("Failed to fetch module '%s': %s", r.Config.Target, err)
+	if err := r.getMod(fakeModDir, target); err != nil {
+		log.Errorf("Failed to fetch module '%s': %s", target, err)
 		return nil, nil, err
 	}
+	r.report(ModuleFetchedPhase, target, fmt.Sprintf("Fetched module '%s'", target))
 
 	// Read the updated go.mod for the temporary module
 	fakeMod, err = ioutil.ReadFile(fakeModPath)
 	if err != nil {
-		log.Errorf("Failed to fetch module '%s': %s", r.Config.Target, err)
+		log.Errorf("Failed to fetch module '%s': %s", target, err)
 		return nil, nil, err
 	}
 
 	// Parse the updated go.mod for the temporary module
 	tmpModFile, err := modfile.Parse(fakeModPath, fakeMod, nil)
 	if err != nil {
-		log.Errorf("Failed to fetch module '%s': %s", r.Config.Target, err)
+		log.Errorf("Failed to fetch module '%s': %s", target, err)
 		return nil, nil, err
 	}
 
@@ -210,7 +481,7 @@ func (r *Resolver) fetchMod() (*modfile.File, Hash, error) {
 	// Encode the target dependency path
 	encPath, err := module.EncodePath(modPath)
 	if err != nil {
-		log.Errorf("Failed to fetch module '%s': %s", r.Config.Target, err)
+		log.Errorf("Failed to fetch module '%s': %s", target, err)
 		return nil, nil, err
 	}
 	modPath = encPath
@@ -218,7 +489,7 @@ func (r *Resolver) fetchMod() (*modfile.File, Hash, error) {
 	// Lookup the Go cache from the environment
 	modCache, err := r.getGoModCacheDir()
 	if err != nil {
-		log.Errorf("Failed to fetch module '%s': %s", r.Config.Target, err)
+		log.Errorf("Failed to fetch module '%s': %s", target, err)
 		return nil, nil, err
 	}
 
@@ -226,14 +497,14 @@ func (r *Resolver) fetchMod() (*modfile.File, Hash, error) {
 	cacheModPath := filepath.Join(modCache, "cache", "download", modPath, "@v", modVersion+".mod")
 	modBytes, err := ioutil.ReadFile(cacheModPath)
 	if err != nil {
-		log.Errorf("Failed to fetch module '%s': %s", r.Config.Target, err)
+		log.Errorf("Failed to fetch module '%s': %s", target, err)
 		return nil, nil, err
 	}
 
 	// Parse the target go.mod
 	targetModFile, err := modfile.Parse(cacheModPath, modBytes, nil)
 	if err != nil {
-		log.Errorf("Failed to fetch module '%s': %s", r.Config.Target, err)
+		log.Errorf("Failed to fetch module '%s': %s", target, err)
 		return nil, nil, err
 	}
 
@@ -241,12 +512,155 @@ func (r *Resolver) fetchMod() (*modfile.File, Hash, error) {
 	hashPath := filepath.Join(modCache, "cache", "download", modPath, "@v", modVersion+".ziphash")
 	hashBytes, err := ioutil.ReadFile(hashPath)
 	if err != nil {
-		log.Errorf("Failed to fetch module '%s' hash: %s", r.Config.Target, err)
+		log.Errorf("Failed to fetch module '%s' hash: %s", target, err)
 		return nil, nil, err
 	}
+	r.report(CacheReadPhase, target, fmt.Sprintf("Read module '%s%s%s' from the module cache '%s'", modPath, modVersionSep, modVersion, modCache))
 	return targetModFile, hashBytes, nil
 }
 
+// validateReplace verifies the replace module exists before it's used to fetch the target module
+// getMod adds the target module to the module in the given directory with 'go get'
+// Transient failures are retried with exponential backoff up to the configured number of retries.
+func (r *Resolver) getMod(dir string, target string) error {
+	delay := r.Config.FetchRetryDelay
+	if delay == 0 {
+		delay = defaultFetchRetryDelay
+	}
+	for attempt := 1; ; attempt++ {
+		_, err := r.exec(dir, "go", "get", "-d", target)
+		if err == nil {
+			return nil
+		}
+		if attempt > r.Config.FetchRetries || !isRetryableFetchError(err) {
+			return err
+		}
+		log.Warnf("Fetching module '%s' failed (attempt %d of %d); retrying in %s: %s", target, attempt, r.Config.FetchRetries+1, delay, err)
+		if err := r.sleep(fmt.Sprintf("fetching module '%s'", target), delay); err != nil {
+			return err
+		}
+		delay *= 2
+	}
+}
+
+// isRetryableFetchError returns whether the given 'go get' failure may be caused by a transient network or proxy error
+// Any other failure, e.g. an unknown module or revision, is treated as permanent.
+func isRetryableFetchError(err error) bool {
+	message := strings.ToLower(err.Error())
+	for _, pattern := range retryableFetchErrors {
+		if strings.Contains(message, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Resolver) validateReplace(dir string, replacePath, replaceVersion string) error {
+	if isLocalPath(replacePath) {
+		if _, err := os.Stat(filepath.Join(replacePath, modFile)); err != nil {
+			return errors.NewNotFound("replace target %s not found", replacePath)
+		}
+		return nil
+	}
+	if replaceVersion == "" {
+		return errors.NewInvalid("replace target %s must specify a version", replacePath)
+	}
+	if _, err := r.exec(dir, "go", "list", "-m", "-json", replacePath+modVersionSep+replaceVersion); err != nil {
+		// Other failures, e.g. an unreachable proxy, don't show the version is missing
+		if isMissingVersionError(err) {
+			return errors.NewNotFound("replace target %s%s%s not found", replacePath, modVersionSep, replaceVersion)
+		}
+		return err
+	}
+	return nil
+}
+
+// isMissingVersionError returns whether the given go command error reports that a module version doesn't exist
+func isMissingVersionError(err error) bool {
+	message := strings.ToLower(err.Error())
+	for _, pattern := range missingVersionErrors {
+		if strings.Contains(message, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkModCache verifies the module fetched for the given target and replace modules is in the module cache
+func (r *Resolver) checkModCache(target, replace string) error {
+	mod := target
+	if replace != "" {
+		// Local replace modules are read from the file system
+		if replacePath, _ := splitModPathVersion(replace); isLocalPath(replacePath) {
+			return nil
+		}
+		mod = replace
+	}
+	modPath, modVersion := splitModPathVersion(mod)
+	if !semver.IsValid(modVersion) {
+		return errors.NewInvalid("module '%s' must be pinned to a version to be resolved offline", mod)
+	}
+	encPath, err := module.EncodePath(modPath)
+	if err != nil {
+		return errors.NewInvalid("invalid module path '%s': %s", modPath, err)
+	}
+	modCache, err := r.getGoModCacheDir()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(modCache, "cache", "download", encPath, "@v", modVersion+".mod")); err != nil {
+		return errors.NewNotFound("module '%s' is not in the module cache '%s' and cannot be downloaded offline", mod, modCache)
+	}
+	return nil
+}
+
+// mergeMods merges the requirements of the given modules into the first module
+// When modules require different versions of the same dependency, the highest version is used.
+func mergeMods(mods []*modfile.File) (*modfile.File, error) {
+	merged := mods[0]
+	for _, mod := range mods[1:] {
+		for _, require := range mod.Require {
+			version := require.Mod.Version
+			for _, existing := range merged.Require {
+				if existing.Mod.Path == require.Mod.Path && existing.Mod.Version != version {
+					log.Warnf("Conflicting versions %s and %s required for module '%s'", existing.Mod.Version, version, require.Mod.Path)
+					version = semver.Max(existing.Mod.Version, version)
+				}
+			}
+			if err := merged.AddRequire(require.Mod.Path, version); err != nil {
+				return nil, err
+			}
+		}
+		for _, replace := range mod.Replace {
+			conflict := false
+			for _, existing := range merged.Replace {
+				if existing.Old.Path == replace.Old.Path && existing.Old.Version == replace.Old.Version {
+					if existing.New != replace.New {
+						log.Warnf("Conflicting replacements %s and %s for module '%s'; using %s", existing.New, replace.New, replace.Old.Path, existing.New)
+					}
+					conflict = true
+				}
+			}
+			if !conflict {
+				if err := merged.AddReplace(replace.Old.Path, replace.Old.Version, replace.New.Path, replace.New.Version); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	merged.Cleanup()
+	return merged, nil
+}
+
+// combineHashes combines the hashes of multiple modules into a single hash
+func combineHashes(hashes []Hash) Hash {
+	hash := sha256.New()
+	for _, h := range hashes {
+		hash.Write(h)
+	}
+	return hash.Sum(nil)
+}
+
 func (r *Resolver) getModPath() string {
 	return filepath.Join(r.Config.Path, modFile)
 }
@@ -262,10 +676,35 @@ func splitModPathVersion(mod string) (string, string) {
 	return mod, ""
 }
 
+// writeFileAtomic writes the given file by renaming a temporary file in the same directory
+func writeFileAtomic(path string, data []byte) error {
+	file, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(file.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// isLocalPath returns whether the given module path refers to a directory on the local file system
+func isLocalPath(path string) bool {
+	return filepath.IsAbs(path) || strings.HasPrefix(path, "./") || strings.HasPrefix(path, "../")
+}
+
 func ensureDir(dir string) {
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
 		log.Debugf("Creating '%s'", dir)
-		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil && !os.IsExist(err) {
 			log.Errorf("Creating '%s' failed: %s", dir, err)
 		}
 	}
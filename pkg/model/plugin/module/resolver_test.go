@@ -0,0 +1,326 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package pluginmodule
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/rogpeppe/go-internal/modfile"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+const fooMod = `module example.com/foo
+
+require (
+	github.com/openconfig/goyang v0.2.0
+	github.com/openconfig/ygot v0.10.0
+	google.golang.org/grpc v1.38.0
+)
+
+replace github.com/openconfig/gnmi => github.com/example/gnmi v0.1.0
+`
+
+const barMod = `module example.com/bar
+
+require (
+	github.com/openconfig/ygot v0.12.4
+	github.com/spf13/cobra v1.2.1
+	google.golang.org/grpc v1.33.0
+)
+
+replace (
+	github.com/openconfig/gnmi => github.com/other/gnmi v0.2.0
+	github.com/openconfig/goyang => github.com/example/goyang v0.3.0
+)
+`
+
+func TestMergeMods(t *testing.T) {
+	foo, err := modfile.Parse("foo/go.mod", []byte(fooMod), nil)
+	assert.NoError(t, err)
+	bar, err := modfile.Parse("bar/go.mod", []byte(barMod), nil)
+	assert.NoError(t, err)
+
+	mod, err := mergeMods([]*modfile.File{foo, bar})
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com/foo", mod.Module.Mod.Path)
+
+	requires := make(map[string]string)
+	for _, require := range mod.Require {
+		requires[require.Mod.Path] = require.Mod.Version
+	}
+	assert.Equal(t, map[string]string{
+		"github.com/openconfig/goyang": "v0.2.0",
+		"github.com/openconfig/ygot":   "v0.12.4",
+		"github.com/spf13/cobra":       "v1.2.1",
+		"google.golang.org/grpc":       "v1.38.0",
+	}, requires)
+
+	replaces := make(map[string]string)
+	for _, replace := range mod.Replace {
+		replaces[replace.Old.Path] = replace.New.Path
+	}
+	assert.Equal(t, map[string]string{
+		"github.com/openconfig/gnmi":   "github.com/example/gnmi",
+		"github.com/openconfig/goyang": "github.com/example/goyang",
+	}, replaces)
+}
+
+func TestGetTargets(t *testing.T) {
+	resolver := &Resolver{Config: ResolverConfig{
+		Target:  "github.com/onosproject/onos-config@master",
+		Targets: []TargetConfig{{Target: "example.com/vendor@v1.0.0"}},
+	}}
+	assert.Equal(t, []TargetConfig{
+		{Target: "github.com/onosproject/onos-config@master"},
+		{Target: "example.com/vendor@v1.0.0"},
+	}, resolver.getTargets())
+
+	assert.Equal(t, combineHashes([]Hash{Hash("a"), Hash("b")}), combineHashes([]Hash{Hash("a"), Hash("b")}))
+	assert.NotEqual(t, combineHashes([]Hash{Hash("a"), Hash("b")}), combineHashes([]Hash{Hash("b"), Hash("a")}))
+}
+
+func TestResolveBogusReplace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-model-mod")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// The proxy lists the bogus module without the replace version
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/example.com/bogus/onos-config/@v/list" {
+			_, _ = w.Write([]byte("v0.0.2\n"))
+			return
+		}
+		http.Error(w, "not found: "+r.URL.Path, http.StatusNotFound)
+	}))
+	defer proxy.Close()
+	setEnv(t, "GOPROXY", proxy.URL)
+	setEnv(t, "GOSUMDB", "off")
+
+	resolver := NewResolver(ResolverConfig{
+		Path:    dir,
+		Target:  "github.com/onosproject/onos-config@master",
+		Replace: "example.com/bogus/onos-config@v0.0.1",
+	})
+	_, _, err = resolver.Resolve()
+	assert.Error(t, err)
+	assert.True(t, errors.IsNotFound(err))
+	assert.Equal(t, "replace target example.com/bogus/onos-config@v0.0.1 not found", err.Error())
+
+	// Failures to look up the module don't show the version is missing
+	setEnv(t, "GOPROXY", "off")
+	resolver = NewResolver(ResolverConfig{
+		Path:    dir,
+		Target:  "github.com/onosproject/onos-config@master",
+		Replace: "example.com/bogus/onos-config@v0.0.1",
+	})
+	_, _, err = resolver.Resolve()
+	assert.Error(t, err)
+	assert.False(t, errors.IsNotFound(err))
+	assert.Contains(t, err.Error(), "GOPROXY=off")
+
+	resolver = NewResolver(ResolverConfig{
+		Path:    dir,
+		Target:  "github.com/onosproject/onos-config@master",
+		Replace: "/bogus/onos-config",
+	})
+	_, _, err = resolver.Resolve()
+	assert.True(t, errors.IsNotFound(err))
+	assert.Contains(t, err.Error(), "replace target /bogus/onos-config not found")
+}
+
+// setEnv sets an environment variable for the duration of the test
+func setEnv(t *testing.T, key, value string) {
+	previous, ok := os.LookupEnv(key)
+	assert.NoError(t, os.Setenv(key, value))
+	t.Cleanup(func() {
+		if ok {
+			_ = os.Setenv(key, previous)
+		} else {
+			_ = os.Unsetenv(key)
+		}
+	})
+}
+
+func TestParseTargets(t *testing.T) {
+	targets, err := ParseTargets([]string{"github.com/onosproject/onos-config@master", "example.com/vendor@v1.0.0"}, []string{"", "example.com/fork@v1.0.1"})
+	assert.NoError(t, err)
+	assert.Equal(t, []TargetConfig{
+		{Target: "github.com/onosproject/onos-config@master"},
+		{Target: "example.com/vendor@v1.0.0", Replace: "example.com/fork@v1.0.1"},
+	}, targets)
+
+	// Trailing replaces may be omitted
+	targets, err = ParseTargets([]string{"github.com/onosproject/onos-config@master", "example.com/vendor@v1.0.0"}, []string{"../onos-config"})
+	assert.NoError(t, err)
+	assert.Equal(t, []TargetConfig{
+		{Target: "github.com/onosproject/onos-config@master", Replace: "../onos-config"},
+		{Target: "example.com/vendor@v1.0.0"},
+	}, targets)
+
+	_, err = ParseTargets([]string{"github.com/onosproject/onos-config@master"}, []string{"../onos-config", "../vendor"})
+	assert.True(t, errors.IsInvalid(err))
+
+	// The targets are resolved in order
+	resolver := &Resolver{Config: ResolverConfig{Targets: targets}}
+	assert.Equal(t, targets, resolver.getTargets())
+}
+
+func TestResolveOffline(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-model-mod")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// Use an empty module cache
+	modCache, ok := os.LookupEnv("GOMODCACHE")
+	assert.NoError(t, os.Setenv("GOMODCACHE", dir))
+	defer func() {
+		if ok {
+			_ = os.Setenv("GOMODCACHE", modCache)
+		} else {
+			_ = os.Unsetenv("GOMODCACHE")
+		}
+	}()
+
+	resolver := NewResolver(ResolverConfig{
+		Path:    dir,
+		Target:  "github.com/onosproject/onos-config@v0.9.0",
+		Offline: true,
+	})
+	_, _, err = resolver.Resolve()
+	assert.True(t, errors.IsNotFound(err))
+	assert.Contains(t, err.Error(), "module 'github.com/onosproject/onos-config@v0.9.0' is not in the module cache")
+
+	// Offline targets cannot be resolved from branches
+	resolver = NewResolver(ResolverConfig{
+		Path:    dir,
+		Target:  "github.com/onosproject/onos-config@master",
+		Offline: true,
+	})
+	_, _, err = resolver.Resolve()
+	assert.True(t, errors.IsInvalid(err))
+
+	// Replace modules are fetched in place of the target
+	resolver = NewResolver(ResolverConfig{
+		Path:    dir,
+		Target:  "github.com/onosproject/onos-config@master",
+		Replace: "example.com/fork/onos-config@v0.0.1",
+		Offline: true,
+	})
+	_, _, err = resolver.Resolve()
+	assert.True(t, errors.IsNotFound(err))
+	assert.Contains(t, err.Error(), "example.com/fork/onos-config@v0.0.1")
+}
+
+func TestGetEnv(t *testing.T) {
+	env := GetEnv(false, nil)
+	assert.Contains(t, env, "GO111MODULE=on")
+	assert.NotContains(t, env, "GOPROXY=off")
+
+	env = GetEnv(true, nil)
+	for _, value := range DefaultOfflineEnv {
+		assert.Contains(t, env, value)
+	}
+
+	env = GetEnv(true, []string{"GOPROXY=file:///mirror"})
+	assert.Contains(t, env, "GOPROXY=file:///mirror")
+	assert.NotContains(t, env, "GOPROXY=off")
+}
+
+func TestResolveMemoized(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-model-mod")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	config := ResolverConfig{
+		Path:   dir,
+		Target: "github.com/onosproject/onos-config@v0.9.0",
+	}
+	resolver := NewResolver(config)
+	defer resolver.Invalidate()
+	assert.NoError(t, ioutil.WriteFile(resolver.getModPath(), []byte("module github.com/onosproject/onos-config\n"), 0644))
+	assert.NoError(t, ioutil.WriteFile(resolver.getHashPath(), []byte("hash1"), 0644))
+
+	mod, hash, err := resolver.Resolve()
+	assert.NoError(t, err)
+	assert.Equal(t, "github.com/onosproject/onos-config", mod.Module.Mod.Path)
+	assert.Equal(t, Hash("hash1"), hash)
+
+	// Modifying the returned module does not modify the module returned to other callers
+	assert.NoError(t, mod.AddModuleStmt("example.com/modified"))
+
+	// Other resolvers with the same configuration share the resolved module while the files are unchanged
+	info, err := os.Stat(resolver.getHashPath())
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(resolver.getHashPath(), []byte("hash2"), 0644))
+	assert.NoError(t, os.Chtimes(resolver.getHashPath(), info.ModTime(), info.ModTime()))
+	mod, hash, err = NewResolver(config).Resolve()
+	assert.NoError(t, err)
+	assert.Equal(t, "github.com/onosproject/onos-config", mod.Module.Mod.Path)
+	assert.Equal(t, Hash("hash1"), hash)
+
+	// Invalidating the resolved module reads the files again
+	resolver.Invalidate()
+	_, hash, err = NewResolver(config).Resolve()
+	assert.NoError(t, err)
+	assert.Equal(t, Hash("hash2"), hash)
+
+	// Modified files are read again
+	assert.NoError(t, ioutil.WriteFile(resolver.getHashPath(), []byte("hash3"), 0644))
+	future := time.Now().Add(time.Hour)
+	assert.NoError(t, os.Chtimes(resolver.getHashPath(), future, future))
+	_, hash, err = resolver.Resolve()
+	assert.NoError(t, err)
+	assert.Equal(t, Hash("hash3"), hash)
+}
+
+func TestGetModRetries(t *testing.T) {
+	var calls int
+	fake := func(errs ...error) commandFunc {
+		calls = 0
+		return func(dir string, name string, args ...string) (string, error) {
+			assert.Equal(t, "go", name)
+			assert.Equal(t, []string{"get", "-d", "example.com/foo@v1.0.0"}, args)
+			calls++
+			if calls <= len(errs) {
+				return "", errs[calls-1]
+			}
+			return "", nil
+		}
+	}
+
+	resolver := NewResolver(ResolverConfig{
+		Path:            t.TempDir(),
+		FetchRetries:    3,
+		FetchRetryDelay: time.Millisecond,
+	})
+
+	// Transient failures are retried until the fetch succeeds
+	proxyErr := errors.NewUnavailable("reading https://proxy.golang.org/example.com/foo/@v/v1.0.0.info: 502 Bad Gateway")
+	resolver.command = fake(proxyErr, proxyErr)
+	assert.NoError(t, resolver.getMod(resolver.Config.Path, "example.com/foo@v1.0.0"))
+	assert.Equal(t, 3, calls)
+
+	// Permanent failures are not retried
+	resolver.command = fake(errors.NewNotFound("example.com/foo@v1.0.0: invalid version: unknown revision v1.0.0"))
+	assert.Error(t, resolver.getMod(resolver.Config.Path, "example.com/foo@v1.0.0"))
+	assert.Equal(t, 1, calls)
+
+	// Transient failures fail once the retries are exhausted
+	networkErr := errors.NewUnavailable("dial tcp: lookup proxy.golang.org: i/o timeout")
+	resolver.command = fake(networkErr, networkErr, networkErr, networkErr, networkErr)
+	assert.Error(t, resolver.getMod(resolver.Config.Path, "example.com/foo@v1.0.0"))
+	assert.Equal(t, 4, calls)
+
+	// Failures are not retried by default
+	resolver.Config.FetchRetries = 0
+	resolver.command = fake(proxyErr)
+	assert.Error(t, resolver.getMod(resolver.Config.Path, "example.com/foo@v1.0.0"))
+	assert.Equal(t, 1, calls)
+}
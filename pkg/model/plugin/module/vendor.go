@@ -0,0 +1,156 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package pluginmodule
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/rogpeppe/go-internal/modfile"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// vendorModulesFile is the name of the file listing the modules in a vendor directory
+const vendorModulesFile = "modules.txt"
+
+// vendoredMod is a module listed in a vendor directory's modules.txt
+type vendoredMod struct {
+	path           string
+	version        string
+	replacePath    string
+	replaceVersion string
+	// entry is the module's section of modules.txt, i.e. its header and the packages vendored from it
+	entry []byte
+}
+
+// fetchVendorMod reads the target module from the configured vendor directory
+// The target's go.mod is read from the vendor directory rather than the module cache, and the hash is
+// computed from the module's entry in modules.txt and its go.mod, so it changes when the vendored
+// module is updated.
+func (r *Resolver) fetchVendorMod(target, replace string) (*modfile.File, Hash, error) {
+	targetPath, targetVersion := splitModPathVersion(target)
+	log.Infof("Reading module '%s' from vendor directory '%s'", target, r.Config.VendorDir)
+
+	modulesPath := filepath.Join(r.Config.VendorDir, vendorModulesFile)
+	modulesBytes, err := ioutil.ReadFile(modulesPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = errors.NewNotFound("vendor directory '%s' has no %s", r.Config.VendorDir, vendorModulesFile)
+		}
+		log.Errorf("Failed to fetch module '%s': %s", target, err)
+		return nil, nil, err
+	}
+	mod, ok := parseVendorModules(modulesBytes)[targetPath]
+	if !ok {
+		err := errors.NewNotFound("module '%s' is not vendored in '%s'", targetPath, r.Config.VendorDir)
+		log.Errorf("Failed to fetch module '%s': %s", target, err)
+		return nil, nil, err
+	}
+	if err := mod.validate(targetVersion, replace); err != nil {
+		log.Errorf("Failed to fetch module '%s': %s", target, err)
+		return nil, nil, err
+	}
+
+	// Read the target go.mod from the vendor directory
+	vendorModPath := filepath.Join(r.Config.VendorDir, filepath.FromSlash(targetPath), modFile)
+	modBytes, err := ioutil.ReadFile(vendorModPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = errors.NewNotFound("%s for module '%s' not found in vendor directory '%s'", modFile, targetPath, r.Config.VendorDir)
+		}
+		log.Errorf("Failed to fetch module '%s': %s", target, err)
+		return nil, nil, err
+	}
+
+	// Parse the target go.mod
+	targetModFile, err := modfile.Parse(vendorModPath, modBytes, nil)
+	if err != nil {
+		log.Errorf("Failed to fetch module '%s': %s", target, err)
+		return nil, nil, err
+	}
+
+	hash := sha256.New()
+	hash.Write(mod.entry)
+	hash.Write(modBytes)
+	return targetModFile, hash.Sum(nil), nil
+}
+
+// validate verifies the vendored module matches the given target version and replace module
+func (m *vendoredMod) validate(version, replace string) error {
+	if version != "" && version != m.version {
+		return errors.NewInvalid("module '%s' is vendored at version '%s', not '%s'", m.path, m.version, version)
+	}
+	if replace == "" {
+		return nil
+	}
+	replacePath, replaceVersion := splitModPathVersion(replace)
+	if m.replacePath == "" || filepath.Clean(replacePath) != filepath.Clean(m.replacePath) ||
+		replaceVersion != "" && replaceVersion != m.replaceVersion {
+		return errors.NewInvalid("vendored module '%s' is not replaced with '%s'", m.path, replace)
+	}
+	return nil
+}
+
+// parseVendorModules parses the modules listed in a vendor directory's modules.txt, indexed by module path
+// Each module is introduced by a '# path version [=> path [version]]' header, followed by '##' annotations
+// and the packages vendored from it.
+func parseVendorModules(data []byte) map[string]*vendoredMod {
+	mods := make(map[string]*vendoredMod)
+	var mod *vendoredMod
+	var entry bytes.Buffer
+	flush := func() {
+		if mod != nil {
+			mod.entry = append([]byte(nil), entry.Bytes()...)
+			mods[mod.path] = mod
+		}
+		entry.Reset()
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "# ") {
+			flush()
+			mod = parseVendorModuleHeader(strings.TrimPrefix(line, "# "))
+		}
+		if mod != nil {
+			entry.WriteString(line)
+			entry.WriteByte('\n')
+		}
+	}
+	flush()
+	return mods
+}
+
+// parseVendorModuleHeader parses a module header line in modules.txt
+func parseVendorModuleHeader(header string) *vendoredMod {
+	var replace []string
+	fields := strings.Fields(header)
+	for i, field := range fields {
+		if field == "=>" {
+			replace = fields[i+1:]
+			fields = fields[:i]
+			break
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	mod := &vendoredMod{path: fields[0]}
+	if len(fields) > 1 {
+		mod.version = fields[1]
+	}
+	if len(replace) > 0 {
+		mod.replacePath = replace[0]
+	}
+	if len(replace) > 1 {
+		mod.replaceVersion = replace[1]
+	}
+	return mod
+}
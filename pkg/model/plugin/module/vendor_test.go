@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package pluginmodule
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const vendorModules = `# example.com/foo v1.0.0
+## explicit; go 1.16
+example.com/foo/api
+# example.com/bar v0.2.0 => example.com/fork/bar v0.2.1
+## explicit
+example.com/bar
+# github.com/openconfig/ygot v0.10.0
+github.com/openconfig/ygot/ygot
+`
+
+func TestResolveVendor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-model-mod")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	vendorDir := filepath.Join(dir, "vendor")
+	assert.NoError(t, os.MkdirAll(filepath.Join(vendorDir, "example.com", "foo"), 0755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(vendorDir, "example.com", "bar"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(vendorDir, "modules.txt"), []byte(vendorModules), 0666))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(vendorDir, "example.com", "foo", "go.mod"), []byte(fooMod), 0666))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(vendorDir, "example.com", "bar", "go.mod"), []byte(barMod), 0666))
+
+	// No go commands are run to resolve vendored modules
+	newResolver := func(target, replace string) *Resolver {
+		resolver := NewResolver(ResolverConfig{
+			Path:      filepath.Join(dir, "mod"),
+			Target:    target,
+			Replace:   replace,
+			VendorDir: vendorDir,
+		})
+		resolver.command = func(dir string, name string, args ...string) (string, error) {
+			t.Fatalf("unexpected command %s %v", name, args)
+			return "", nil
+		}
+		return resolver
+	}
+
+	mod, fooHash, err := newResolver("example.com/foo@v1.0.0", "").fetchMods()
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com/foo", mod.Module.Mod.Path)
+	assert.Len(t, mod.Require, 3)
+	assert.NotEmpty(t, fooHash)
+
+	// The version may be omitted
+	_, hash, err := newResolver("example.com/foo", "").fetchMods()
+	assert.NoError(t, err)
+	assert.Equal(t, fooHash, hash)
+
+	// The vendored version must match the target version
+	_, _, err = newResolver("example.com/foo@v1.1.0", "").fetchMods()
+	assert.True(t, errors.IsInvalid(err))
+
+	// Replaced modules must be vendored with the same replacement
+	_, barHash, err := newResolver("example.com/bar@v0.2.0", "example.com/fork/bar@v0.2.1").fetchMods()
+	assert.NoError(t, err)
+	assert.NotEqual(t, fooHash, barHash)
+	_, _, err = newResolver("example.com/bar@v0.2.0", "example.com/other/bar@v0.2.1").fetchMods()
+	assert.True(t, errors.IsInvalid(err))
+	_, _, err = newResolver("example.com/foo@v1.0.0", "example.com/fork/foo@v1.0.0").fetchMods()
+	assert.True(t, errors.IsInvalid(err))
+
+	// Modules must be vendored with their go.mod
+	_, _, err = newResolver("example.com/baz@v1.0.0", "").fetchMods()
+	assert.True(t, errors.IsNotFound(err))
+	_, _, err = newResolver("github.com/openconfig/ygot@v0.10.0", "").fetchMods()
+	assert.True(t, errors.IsNotFound(err))
+
+	// The hash changes when the vendored module is updated
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(vendorDir, "example.com", "foo", "go.mod"), []byte(barMod), 0666))
+	_, hash, err = newResolver("example.com/foo@v1.0.0", "").fetchMods()
+	assert.NoError(t, err)
+	assert.NotEqual(t, fooHash, hash)
+}
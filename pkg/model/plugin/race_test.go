@@ -0,0 +1,10 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build race
+// +build race
+
+package modelplugin
+
+const raceEnabled = true
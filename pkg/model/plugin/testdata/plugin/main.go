@@ -0,0 +1,30 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+)
+
+type plugin struct{}
+
+func (p plugin) Model() configmodel.ConfigModel {
+	return model{}
+}
+
+type model struct {
+	configmodel.ConfigModel
+}
+
+func (m model) Info() configmodel.ModelInfo {
+	return configmodel.ModelInfo{
+		Name:     "test",
+		Version:  configmodel.Version(version),
+		Features: []string{"feature-a"},
+	}
+}
+
+// ConfigModelPlugin is the test plugin
+var ConfigModelPlugin plugin
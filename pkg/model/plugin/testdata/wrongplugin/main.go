@@ -0,0 +1,15 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+type wrongPlugin struct{}
+
+// Model returns the name of the model rather than the model
+func (p wrongPlugin) Model() string {
+	return "test"
+}
+
+// ConfigModelPlugin is a test plugin exporting a symbol of the wrong type
+var ConfigModelPlugin wrongPlugin
@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelplugin
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"io/ioutil"
+	"path/filepath"
+	"plugin"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+const (
+	// PluginVersionSymbol is the symbol for the version of onos-config-model with which a plugin was compiled
+	PluginVersionSymbol = "PluginVersion"
+	modulePath          = "github.com/onosproject/onos-config-model"
+	versionFile         = "VERSION"
+	develVersion        = "(devel)"
+	// versionMismatchMessage is the error reported when a plugin was compiled with a different module version
+	versionMismatchMessage = "was compiled with onos-config-model"
+)
+
+var (
+	_, b, _, _    = runtime.Caller(0)
+	moduleRoot    = filepath.Dir(filepath.Dir(filepath.Dir(filepath.Dir(b))))
+	moduleVersion = readModuleVersion()
+)
+
+// GetModuleVersion returns the version of onos-config-model in the running binary
+// The version is empty if it cannot be determined, in which case plugin versions are not checked.
+func GetModuleVersion() string {
+	return moduleVersion
+}
+
+// readModuleVersion reads the version of onos-config-model in the running binary
+// Binaries that depend on a released version of the module record the version in their build info. Otherwise
+// the version is read from the module's VERSION file, from which the compiler also versions the plugins it builds.
+func readModuleVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path == modulePath && dep.Replace == nil && dep.Version != "" && dep.Version != develVersion {
+				return dep.Version
+			}
+		}
+	}
+	bytes, err := ioutil.ReadFile(filepath.Join(moduleRoot, versionFile))
+	if err != nil {
+		return ""
+	}
+	return "v" + strings.TrimSpace(string(bytes))
+}
+
+// checkPluginVersion checks that the given plugin was compiled with the running version of onos-config-model
+// Plugins compiled before versions were embedded have no version symbol and are not checked.
+func checkPluginVersion(path string, module *plugin.Plugin) error {
+	symbol, err := module.Lookup(PluginVersionSymbol)
+	if err != nil {
+		return nil
+	}
+	version, ok := symbol.(*string)
+	if !ok {
+		return errors.NewInvalid("symbol %s loaded from module %s is a %T rather than a string", PluginVersionSymbol, filepath.Base(path), symbol)
+	}
+	return checkVersion(filepath.Base(path), *version, GetModuleVersion())
+}
+
+// checkVersion checks that the plugin version matches the running version, if both are known
+func checkVersion(name string, pluginVersion string, runningVersion string) error {
+	if pluginVersion == "" || runningVersion == "" || pluginVersion == runningVersion {
+		return nil
+	}
+	return errors.NewInvalid("plugin %s %s %s, but the running binary uses %s; the plugin must be recompiled", name, versionMismatchMessage, pluginVersion, runningVersion)
+}
@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	"encoding/json"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// BuildInfoKey is the GetModel response header containing the JSON encoded build info for the model's plugin
+// The header is only sent once the plugin has been compiled.
+const BuildInfoKey = "config-model-build-info"
+
+// GetBuildInfo gets the environment in which the plugin for the given model was built
+// If the plugin has not been compiled by the registry, nil is returned.
+func GetBuildInfo(ctx context.Context, client configmodelapi.ConfigModelRegistryServiceClient, name configmodel.Name, version configmodel.Version) (*configmodel.BuildInfo, error) {
+	var header metadata.MD
+	request := &configmodelapi.GetModelRequest{
+		Name:    string(name),
+		Version: string(version),
+	}
+	if _, err := client.GetModel(ctx, request, grpc.Header(&header)); err != nil {
+		return nil, err
+	}
+	values := header.Get(BuildInfoKey)
+	if len(values) == 0 {
+		return nil, nil
+	}
+	info := &configmodel.BuildInfo{}
+	if err := json.Unmarshal([]byte(values[0]), info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// recordBuildInfo records the environment in which the plugin at the given path was built in the model descriptor
+func (s *Server) recordBuildInfo(modelInfo configmodel.ModelInfo, path string) {
+	if s.registry.IsReadOnly() {
+		return
+	}
+	info, err := s.compiler.GetBuildInfo(path)
+	if err != nil {
+		log.Warnf("Failed to read build info for model '%s': %s", modelInfo, err)
+		return
+	}
+	if err := s.registry.SetBuildInfo(modelInfo.Name, modelInfo.Version, info); err != nil {
+		log.Warnf("Failed to record build info for model '%s': %s", modelInfo, err)
+	}
+}
+
+// sendBuildInfo sends the build info for the given model in the response headers
+func sendBuildInfo(ctx context.Context, modelInfo configmodel.ModelInfo) {
+	if modelInfo.Build == nil {
+		return
+	}
+	bytes, err := json.Marshal(modelInfo.Build)
+	if err != nil {
+		log.Warnf("Failed to encode build info for model '%s': %s", modelInfo, err)
+		return
+	}
+	if err := grpc.SetHeader(ctx, metadata.Pairs(BuildInfoKey, string(bytes))); err != nil {
+		log.Debugf("Failed to send build info: %s", err)
+	}
+}
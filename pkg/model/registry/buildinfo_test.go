@@ -0,0 +1,38 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"runtime"
+	"testing"
+)
+
+func TestBuildInfo(t *testing.T) {
+	server := newTestServer(t)
+	client := newTestClient(t, server)
+	assert.NoError(t, server.registry.AddModel(configmodel.ModelInfo{Name: "test", Version: "1.0.0"}))
+
+	info, err := GetBuildInfo(context.Background(), client, "test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Nil(t, info)
+
+	// Record the build info for the test binary in place of a compiled plugin
+	server.recordBuildInfo(configmodel.ModelInfo{Name: "test", Version: "1.0.0"}, os.Args[0])
+	model, err := server.registry.GetModel("test", "1.0.0")
+	assert.NoError(t, err)
+	assert.NotNil(t, model.Build)
+
+	info, err = GetBuildInfo(context.Background(), client, "test", "1.0.0")
+	assert.NoError(t, err)
+	assert.NotNil(t, info)
+	assert.Equal(t, runtime.Version(), info.GoVersion)
+	assert.Equal(t, runtime.GOOS, info.GOOS)
+	assert.Equal(t, runtime.GOARCH, info.GOARCH)
+	assert.Contains(t, info.Dependencies, "github.com/openconfig/ygot")
+}
@@ -0,0 +1,151 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// CapabilitiesKey is the response header in which the server advertises its capabilities
+// The registry API has no method for capability negotiation, so the capabilities are sent
+// in the headers of every response.
+const CapabilitiesKey = "config-model-capabilities"
+
+// Capability is a feature supported by the registry server
+type Capability string
+
+const (
+	// SkipCompileCapability indicates the server supports registering models with prebuilt plugins
+	SkipCompileCapability Capability = "skip-compile"
+	// TestConfigsCapability indicates the server supports validating sample configs on push
+	TestConfigsCapability Capability = "test-configs"
+	// ForceCapability indicates the server supports forcing the deletion of pinned models
+	ForceCapability Capability = "force"
+	// AutoRecompileCapability indicates the server recompiles plugins with an incompatible ABI
+	AutoRecompileCapability Capability = "auto-recompile"
+	// CompressionCapability indicates the server stores YANG files compressed
+	CompressionCapability Capability = "compression"
+	// PaginationCapability indicates the server supports listing models in pages
+	PaginationCapability Capability = "pagination"
+	// BuildInfoCapability indicates the server returns the build info for compiled plugins
+	BuildInfoCapability Capability = "build-info"
+	// ValidateCapability indicates the server supports validating models without adding them
+	ValidateCapability Capability = "validate"
+	// TryoutCapability indicates the server supports compiling and loading models without adding them
+	TryoutCapability Capability = "tryout"
+	// ChecksumCapability indicates the server returns the checksums of model definitions
+	ChecksumCapability Capability = "checksum"
+	// DryRunCapability indicates the server supports previewing the files removed by deleting a model,
+	// and generating pushed models without building their plugins
+	DryRunCapability Capability = "dry-run"
+	// ReadOnlyCapability indicates the server or its registry is read-only, so models cannot be pushed or deleted
+	ReadOnlyCapability Capability = "read-only"
+	// PushStreamCapability indicates the server supports streaming the progress of pushed models' compilation
+	PushStreamCapability Capability = "push-stream"
+	// ReplaceCapability indicates the server supports replacing existing models by forcing a push
+	ReplaceCapability Capability = "replace"
+	// PriorityCapability indicates the server supports prioritizing the compilation of pushed models
+	PriorityCapability Capability = "priority"
+	// LabelsCapability indicates the server supports labeling models and listing models by label selectors
+	LabelsCapability Capability = "labels"
+	// ModelDataCapability indicates the server supports returning models with their YANG files
+	ModelDataCapability Capability = "model-data"
+	// PlatformsCapability indicates the server supports storing plugins for multiple platforms per model
+	PlatformsCapability Capability = "platforms"
+	// ModulesCapability indicates the server supports listing the distinct modules referenced by models
+	ModulesCapability Capability = "modules"
+	// WatchCapability indicates the server supports watching the models added to and removed from the registry
+	WatchCapability Capability = "watch"
+	// FileEncodingCapability indicates the server supports pushing and returning gzip encoded YANG files
+	FileEncodingCapability Capability = "file-encoding"
+	// ValidateConfigCapability indicates the server supports validating configurations against the models' plugins
+	ValidateConfigCapability Capability = "validate-config"
+	// VerifyCapability indicates the server supports verifying and repairing the models' cached plugins
+	VerifyCapability Capability = "verify"
+	// HistoryCapability indicates the server supports getting the compile history of models
+	HistoryCapability Capability = "history"
+)
+
+// Capabilities is a set of capabilities supported by the registry server
+type Capabilities []Capability
+
+// Has returns whether the given capability is supported
+func (c Capabilities) Has(capability Capability) bool {
+	for _, value := range c {
+		if value == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// GetCapabilities gets the capabilities of the registry server
+func GetCapabilities(ctx context.Context, client configmodelapi.ConfigModelRegistryServiceClient) (Capabilities, error) {
+	var header metadata.MD
+	if _, err := client.ListModels(ctx, &configmodelapi.ListModelsRequest{}, grpc.Header(&header)); err != nil {
+		return nil, err
+	}
+	return getCapabilities(header), nil
+}
+
+func getCapabilities(md metadata.MD) Capabilities {
+	var capabilities Capabilities
+	for _, value := range md.Get(CapabilitiesKey) {
+		capabilities = append(capabilities, Capability(value))
+	}
+	return capabilities
+}
+
+// Capabilities returns the capabilities supported by the server
+func (s *Server) Capabilities() Capabilities {
+	capabilities := Capabilities{
+		SkipCompileCapability,
+		TestConfigsCapability,
+		ForceCapability,
+		PaginationCapability,
+		BuildInfoCapability,
+		ValidateCapability,
+		TryoutCapability,
+		ChecksumCapability,
+		DryRunCapability,
+		PushStreamCapability,
+		ReplaceCapability,
+		PriorityCapability,
+		LabelsCapability,
+		ModelDataCapability,
+		PlatformsCapability,
+		ModulesCapability,
+		WatchCapability,
+		FileEncodingCapability,
+		ValidateConfigCapability,
+		VerifyCapability,
+		HistoryCapability,
+	}
+	if s.config.AutoRecompileOnABIMismatch {
+		capabilities = append(capabilities, AutoRecompileCapability)
+	}
+	if registry, ok := s.registry.(*ConfigModelRegistry); ok && registry.Config.CompressStorage {
+		capabilities = append(capabilities, CompressionCapability)
+	}
+	if s.config.ReadOnly || s.registry.IsReadOnly() {
+		capabilities = append(capabilities, ReadOnlyCapability)
+	}
+	return capabilities
+}
+
+// sendCapabilities sends the server capabilities in the response headers
+func (s *Server) sendCapabilities(ctx context.Context) {
+	capabilities := s.Capabilities()
+	values := make([]string, len(capabilities))
+	for i, capability := range capabilities {
+		values[i] = string(capability)
+	}
+	if err := grpc.SetHeader(ctx, metadata.MD{CapabilitiesKey: values}); err != nil {
+		log.Debugf("Failed to send capabilities: %s", err)
+	}
+}
@@ -0,0 +1,28 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCapabilities(t *testing.T) {
+	server := newTestServer(t)
+	capabilities := server.Capabilities()
+	assert.True(t, capabilities.Has(SkipCompileCapability))
+	assert.False(t, capabilities.Has(AutoRecompileCapability))
+	assert.False(t, capabilities.Has(CompressionCapability))
+
+	server.config.AutoRecompileOnABIMismatch = true
+	server.registry.(*ConfigModelRegistry).Config.CompressStorage = true
+
+	capabilities, err := GetCapabilities(context.Background(), newTestClient(t, server))
+	assert.NoError(t, err)
+	assert.True(t, capabilities.Has(SkipCompileCapability))
+	assert.True(t, capabilities.Has(AutoRecompileCapability))
+	assert.True(t, capabilities.Has(CompressionCapability))
+}
@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	"encoding/json"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// ChecksumKey is the GetModel response header containing the checksum of the model definition
+	ChecksumKey = "config-model-checksum"
+	// ChecksumsKey is the ListModels response header containing the JSON encoded checksums of the listed
+	// models, keyed by name@version
+	ChecksumsKey = "config-model-checksums"
+)
+
+// GetChecksum gets the checksum of the given model's definition
+// Clients can compare checksums to detect changes to a model without fetching the model or its plugin.
+func GetChecksum(ctx context.Context, client configmodelapi.ConfigModelRegistryServiceClient, name configmodel.Name, version configmodel.Version) (string, error) {
+	var header metadata.MD
+	request := &configmodelapi.GetModelRequest{
+		Name:    string(name),
+		Version: string(version),
+	}
+	if _, err := client.GetModel(ctx, request, grpc.Header(&header)); err != nil {
+		return "", err
+	}
+	values := header.Get(ChecksumKey)
+	if len(values) == 0 {
+		return "", nil
+	}
+	return values[0], nil
+}
+
+// ListChecksums lists the checksums of the definitions of all models in the registry, keyed by name@version
+func ListChecksums(ctx context.Context, client configmodelapi.ConfigModelRegistryServiceClient) (map[string]string, error) {
+	var header metadata.MD
+	if _, err := client.ListModels(ctx, &configmodelapi.ListModelsRequest{}, grpc.Header(&header)); err != nil {
+		return nil, err
+	}
+	checksums := make(map[string]string)
+	if values := header.Get(ChecksumsKey); len(values) > 0 {
+		if err := json.Unmarshal([]byte(values[0]), &checksums); err != nil {
+			return nil, err
+		}
+	}
+	return checksums, nil
+}
+
+// sendChecksum sends the checksum of the given model in the response headers
+func sendChecksum(ctx context.Context, modelInfo configmodel.ModelInfo) {
+	if err := grpc.SetHeader(ctx, metadata.Pairs(ChecksumKey, modelInfo.Checksum)); err != nil {
+		log.Debugf("Failed to send checksum: %s", err)
+	}
+}
+
+// sendChecksums sends the checksums of the given models in the response headers
+func sendChecksums(ctx context.Context, modelInfos []configmodel.ModelInfo) {
+	checksums := make(map[string]string)
+	for _, modelInfo := range modelInfos {
+		checksums[modelInfo.String()] = modelInfo.Checksum
+	}
+	bytes, err := json.Marshal(checksums)
+	if err != nil {
+		log.Warnf("Failed to encode checksums: %s", err)
+		return
+	}
+	if err := grpc.SetHeader(ctx, metadata.Pairs(ChecksumsKey, string(bytes))); err != nil {
+		log.Debugf("Failed to send checksums: %s", err)
+	}
+}
@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newChecksumTestModel() configmodel.ModelInfo {
+	return configmodel.ModelInfo{
+		Name:    "test",
+		Version: "1.0.0",
+		Files: []configmodel.FileInfo{
+			{Path: "a.yang", Data: []byte("module a {}")},
+			{Path: "b.yang", Data: []byte("module b {}")},
+		},
+		Modules: []configmodel.ModuleInfo{
+			{Name: "a", File: "a.yang"},
+			{Name: "b", File: "b.yang"},
+		},
+		Plugin: configmodel.PluginInfo{
+			Name:    "test",
+			Version: "1.0.0",
+		},
+	}
+}
+
+func TestModelChecksum(t *testing.T) {
+	model := newChecksumTestModel()
+	checksum := model.ComputeChecksum()
+	assert.Len(t, checksum, 64)
+
+	// The checksum does not depend on the order of files and modules
+	reordered := newChecksumTestModel()
+	reordered.Files[0], reordered.Files[1] = reordered.Files[1], reordered.Files[0]
+	reordered.Modules[0], reordered.Modules[1] = reordered.Modules[1], reordered.Modules[0]
+	assert.Equal(t, checksum, reordered.ComputeChecksum())
+
+	// Registry metadata is not part of the model definition
+	reordered.Pinned = true
+	reordered.Build = &configmodel.BuildInfo{GoVersion: "go1.16"}
+	assert.Equal(t, checksum, reordered.ComputeChecksum())
+
+	changed := newChecksumTestModel()
+	changed.Files[0].Data = []byte("module a { }")
+	assert.NotEqual(t, checksum, changed.ComputeChecksum())
+}
+
+func TestModelChecksumRegistry(t *testing.T) {
+	server := newTestServer(t)
+	server.registry.(*ConfigModelRegistry).Config.CompressStorage = true
+	client := newTestClient(t, server)
+
+	model := newChecksumTestModel()
+	assert.NoError(t, server.registry.AddModel(model))
+	stored, err := server.registry.GetModel("test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, model.ComputeChecksum(), stored.Checksum)
+
+	// Recording build info does not change the checksum
+	assert.NoError(t, server.registry.SetBuildInfo("test", "1.0.0", configmodel.BuildInfo{GoVersion: "go1.16"}))
+	checksum, err := GetChecksum(context.Background(), client, "test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, stored.Checksum, checksum)
+
+	checksums, err := ListChecksums(context.Background(), client)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"test@1.0.0": checksum}, checksums)
+}
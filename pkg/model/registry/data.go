@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"sort"
+)
+
+// GetModelData gets the YANG files of the given model, ordered by path
+// The files are returned as they were pushed, so clients can generate bindings for the model themselves.
+// Files are transferred gzip compressed by servers that support it, and decompressed transparently.
+func GetModelData(ctx context.Context, client configmodelapi.ConfigModelRegistryServiceClient, name configmodel.Name, version configmodel.Version) ([]configmodel.FileInfo, error) {
+	request := &configmodelapi.GetModelRequest{
+		Name:    string(name),
+		Version: string(version),
+	}
+	var header metadata.MD
+	ctx = WithFileEncoding(WithIncludeFiles(ctx), configmodel.GzipEncoding)
+	response, err := client.GetModel(ctx, request, grpc.Header(&header))
+	if err != nil {
+		return nil, err
+	}
+	encoding, err := getResponseFileEncoding(header)
+	if err != nil {
+		return nil, err
+	}
+	data, err := DecodeFiles(response.Model.Files, encoding, 0)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]configmodel.FileInfo, 0, len(data))
+	for path, data := range data {
+		files = append(files, configmodel.FileInfo{
+			Path: path,
+			Data: []byte(data),
+		})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	return files, nil
+}
+
+// newAPIFiles returns the YANG files of the given model keyed by path, as they're pushed to the registry
+func newAPIFiles(modelInfo configmodel.ModelInfo) (map[string]string, error) {
+	files := make(map[string]string)
+	for _, file := range modelInfo.Files {
+		file, err := file.Decompress()
+		if err != nil {
+			return nil, errors.NewInternal("failed to decompress '%s' for model '%s': %s", file.Path, modelInfo, err)
+		}
+		files[file.Path] = string(file.Data)
+	}
+	return files, nil
+}
@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io/ioutil"
+	"testing"
+)
+
+func TestGetModelData(t *testing.T) {
+	server := newTestServer(t)
+	server.registry.(*ConfigModelRegistry).Config.CompressStorage = true
+	client := newTestClient(t, server)
+
+	entry := server.cache.Entry("test", "1.0.0")
+	assert.NoError(t, ioutil.WriteFile(entry.Path, []byte("plugin"), 0666))
+	_, err := client.PushModel(WithSkipCompile(context.Background()), &configmodelapi.PushModelRequest{
+		Model: &configmodelapi.ConfigModel{
+			Name:    "test",
+			Version: "1.0.0",
+			Modules: []*configmodelapi.ConfigModule{
+				{Name: "b", File: "b.yang"},
+				{Name: "a", File: "a.yang"},
+			},
+			Files: map[string]string{
+				"b.yang": "module b {}",
+				"a.yang": "module a {}",
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	// Files are only returned when requested
+	response, err := client.GetModel(context.Background(), &configmodelapi.GetModelRequest{Name: "test", Version: "1.0.0"})
+	assert.NoError(t, err)
+	assert.Empty(t, response.Model.Files)
+
+	// Compressed files are returned as they were pushed
+	files, err := GetModelData(context.Background(), client, "test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, []configmodel.FileInfo{
+		{Path: "a.yang", Data: []byte("module a {}")},
+		{Path: "b.yang", Data: []byte("module b {}")},
+	}, files)
+
+	_, err = GetModelData(context.Background(), client, "missing", "1.0.0")
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
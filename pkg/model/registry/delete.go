@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	"encoding/json"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	plugincache "github.com/onosproject/onos-config-model/pkg/model/plugin/cache"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"os"
+	"strconv"
+)
+
+const (
+	// DryRunKey is the metadata key indicating a deleted model should not actually be removed, or a
+	// pushed model should only be generated
+	// The files that would be removed by a delete are returned in the response headers. The plugin module
+	// and YANG bindings of a pushed model are generated to catch schema errors, but the plugin is not built
+	// and the model is not added to the registry.
+	DryRunKey = "config-model-dry-run"
+	// DeletedFilesKey is the DeleteModel response header containing the JSON encoded list of files
+	// removed for the model, or that would be removed for a dry run
+	DeletedFilesKey = "config-model-deleted-files"
+)
+
+// WithDryRun returns a context requesting that a deleted model not actually be removed, or that a
+// pushed model only be generated
+func WithDryRun(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, DryRunKey, strconv.FormatBool(true))
+}
+
+// DeleteModel deletes the given model and its plugin, returning the files that were removed
+// If the context was created WithDryRun, the files that would be removed are returned without removing them.
+func DeleteModel(ctx context.Context, client configmodelapi.ConfigModelRegistryServiceClient, name configmodel.Name, version configmodel.Version) ([]string, error) {
+	var header metadata.MD
+	request := &configmodelapi.DeleteModelRequest{
+		Name:    string(name),
+		Version: string(version),
+	}
+	if _, err := client.DeleteModel(ctx, request, grpc.Header(&header)); err != nil {
+		return nil, err
+	}
+	values := header.Get(DeletedFilesKey)
+	if len(values) == 0 {
+		return nil, nil
+	}
+	var files []string
+	if err := json.Unmarshal([]byte(values[0]), &files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// getModelFiles returns the registry and cache files for the given model, including its plugins for all platforms
+func (s *Server) getModelFiles(name configmodel.Name, version configmodel.Version) []string {
+	files := s.registry.GetModelFiles(name, version)
+	entries := []*plugincache.PluginEntry{s.cache.Entry(name, version)}
+	if modelInfo, err := s.registry.GetModel(name, version); err == nil {
+		if platformEntries, err := s.getPlatformEntries(modelInfo); err == nil {
+			entries = append(entries, platformEntries...)
+		}
+	}
+	for _, entry := range entries {
+		if _, err := os.Stat(entry.Path); err == nil {
+			files = append(files, entry.Path)
+		}
+	}
+	return files
+}
+
+// sendDeletedFiles sends the files removed for a deleted model in the response headers
+func sendDeletedFiles(ctx context.Context, files []string) {
+	if files == nil {
+		files = []string{}
+	}
+	bytes, err := json.Marshal(files)
+	if err != nil {
+		log.Warnf("Failed to encode deleted files: %s", err)
+		return
+	}
+	if err := grpc.SetHeader(ctx, metadata.Pairs(DeletedFilesKey, string(bytes))); err != nil {
+		log.Debugf("Failed to send deleted files: %s", err)
+	}
+}
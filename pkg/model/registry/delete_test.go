@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDeleteModelDryRun(t *testing.T) {
+	server := newTestServer(t)
+	client := newTestClient(t, server)
+
+	assert.NoError(t, server.registry.AddModel(configmodel.ModelInfo{Name: "test", Version: "1.0.0"}))
+	assert.NoError(t, server.registry.AddCompileAttempt("test", "1.0.0", CompileAttempt{Success: true}))
+	entry := server.cache.Entry("test", "1.0.0")
+	assert.NoError(t, ioutil.WriteFile(entry.Path, []byte("plugin"), 0666))
+	expected := []string{
+		server.registry.(*ConfigModelRegistry).getDescriptorFile("test", "1.0.0"),
+		server.registry.(*ConfigModelRegistry).getHistoryFile("test", "1.0.0"),
+		entry.Path,
+	}
+
+	// A dry run lists the files without removing them
+	files, err := DeleteModel(WithDryRun(context.Background()), client, "test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, files)
+	for _, file := range expected {
+		_, err := os.Stat(file)
+		assert.NoError(t, err)
+	}
+
+	// Deleting the model removes both the descriptor and the plugin
+	files, err = DeleteModel(context.Background(), client, "test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, files)
+	for _, file := range expected {
+		_, err := os.Stat(file)
+		assert.True(t, os.IsNotExist(err))
+	}
+	assert.False(t, entry.IsLocked())
+
+	files, err = DeleteModel(WithDryRun(context.Background()), client, "test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestDeleteModelRestoresPlugin(t *testing.T) {
+	server := newTestServer(t)
+	client := newTestClient(t, server)
+
+	// A descriptor that can't be removed fails the deletion
+	descriptor := server.registry.(*ConfigModelRegistry).getDescriptorFile("test", "1.0.0")
+	assert.NoError(t, os.MkdirAll(filepath.Join(descriptor, "file"), os.ModePerm))
+	entry := server.cache.Entry("test", "1.0.0")
+	assert.NoError(t, ioutil.WriteFile(entry.Path, []byte("plugin"), 0666))
+	_, err := DeleteModel(context.Background(), client, "test", "1.0.0")
+	assert.Error(t, err)
+
+	// The plugin is restored for the model that's still in the registry
+	data, err := ioutil.ReadFile(entry.Path)
+	assert.NoError(t, err)
+	assert.Equal(t, "plugin", string(data))
+	assert.False(t, entry.IsLocked())
+}
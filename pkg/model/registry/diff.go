@@ -0,0 +1,220 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"fmt"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/openconfig/goyang/pkg/yang"
+	"sort"
+	"strings"
+)
+
+// ModelDiff is the set of schema differences between two versions of a model
+type ModelDiff struct {
+	// From is the model from which the diff is computed
+	From string `json:"from"`
+	// To is the model to which the diff is computed
+	To string `json:"to"`
+	// Added is the list of paths present only in the 'to' model
+	Added []SchemaPath `json:"added"`
+	// Removed is the list of paths present only in the 'from' model
+	Removed []SchemaPath `json:"removed"`
+	// Changed is the list of paths present in both models whose schema differs
+	Changed []SchemaChange `json:"changed"`
+}
+
+// SchemaPath is a path in a model's schema
+type SchemaPath struct {
+	Path string     `json:"path"`
+	Node SchemaNode `json:"node"`
+}
+
+// SchemaChange is a change to the schema of a path
+type SchemaChange struct {
+	Path string     `json:"path"`
+	From SchemaNode `json:"from"`
+	To   SchemaNode `json:"to"`
+}
+
+// SchemaNode describes the schema of a node
+type SchemaNode struct {
+	// Kind is the kind of node, e.g. 'container', 'list' or 'leaf'
+	Kind string `json:"kind"`
+	// Type is the type of a leaf or leaf-list node
+	Type string `json:"type,omitempty"`
+	// Keys is the list of keys of a list node
+	Keys []string `json:"keys,omitempty"`
+	// ReadOnly indicates whether the node is state (config false)
+	ReadOnly bool `json:"readOnly,omitempty"`
+}
+
+func (n SchemaNode) String() string {
+	var s strings.Builder
+	s.WriteString(n.Kind)
+	if n.Type != "" {
+		s.WriteString(" ")
+		s.WriteString(n.Type)
+	}
+	if len(n.Keys) > 0 {
+		fmt.Fprintf(&s, " [%s]", strings.Join(n.Keys, " "))
+	}
+	if n.ReadOnly {
+		s.WriteString(" (read-only)")
+	}
+	return s.String()
+}
+
+func (n SchemaNode) equal(other SchemaNode) bool {
+	return n.String() == other.String()
+}
+
+// GetModelDiff returns the schema differences between two versions of the given model
+func (r *ConfigModelRegistry) GetModelDiff(name configmodel.Name, fromVersion, toVersion configmodel.Version) (ModelDiff, error) {
+	from, err := r.GetModel(name, fromVersion)
+	if err != nil {
+		return ModelDiff{}, err
+	}
+	to, err := r.GetModel(name, toVersion)
+	if err != nil {
+		return ModelDiff{}, err
+	}
+	return DiffModels(from, to)
+}
+
+// DiffModels returns the schema differences between two models from their YANG files
+// The schemas are built from the persisted YANG files rather than the models' plugins, which can't safely
+// be loaded into the same process if they were built from conflicting dependencies.
+func DiffModels(from, to configmodel.ModelInfo) (ModelDiff, error) {
+	diff := ModelDiff{
+		From:    from.String(),
+		To:      to.String(),
+		Added:   []SchemaPath{},
+		Removed: []SchemaPath{},
+		Changed: []SchemaChange{},
+	}
+
+	fromNodes, err := getSchemaNodes(from)
+	if err != nil {
+		return diff, err
+	}
+	toNodes, err := getSchemaNodes(to)
+	if err != nil {
+		return diff, err
+	}
+
+	for path, fromNode := range fromNodes {
+		toNode, ok := toNodes[path]
+		if !ok {
+			diff.Removed = append(diff.Removed, SchemaPath{Path: path, Node: fromNode})
+		} else if !fromNode.equal(toNode) {
+			diff.Changed = append(diff.Changed, SchemaChange{Path: path, From: fromNode, To: toNode})
+		}
+	}
+	for path, toNode := range toNodes {
+		if _, ok := fromNodes[path]; !ok {
+			diff.Added = append(diff.Added, SchemaPath{Path: path, Node: toNode})
+		}
+	}
+
+	sort.Slice(diff.Added, func(i, j int) bool {
+		return diff.Added[i].Path < diff.Added[j].Path
+	})
+	sort.Slice(diff.Removed, func(i, j int) bool {
+		return diff.Removed[i].Path < diff.Removed[j].Path
+	})
+	sort.Slice(diff.Changed, func(i, j int) bool {
+		return diff.Changed[i].Path < diff.Changed[j].Path
+	})
+	return diff, nil
+}
+
+// getSchemaNodes returns the schema nodes of the given model indexed by path
+// List keys are not included in paths, so a change to a list's keys is reported as a change to the list node.
+func getSchemaNodes(model configmodel.ModelInfo) (map[string]SchemaNode, error) {
+	modules, diagnostics := parseModules(model)
+	if len(diagnostics) > 0 {
+		return nil, errors.NewInvalid("model '%s' is not valid: %s", model, diagnostics[0])
+	}
+
+	nodes := make(map[string]SchemaNode)
+	for _, module := range model.Modules {
+		if module.IsSubmodule() {
+			continue
+		}
+		entry, errs := modules.GetModule(string(module.Name))
+		if len(errs) > 0 {
+			return nil, errors.NewInvalid("failed to load module '%s': %s", module.Name, errs[0])
+		}
+		addSchemaNodes(nodes, entry, "")
+	}
+	return nodes, nil
+}
+
+// addSchemaNodes adds the schema nodes under the given entry
+func addSchemaNodes(nodes map[string]SchemaNode, entry *yang.Entry, path string) {
+	for _, child := range entry.Dir {
+		// Choice and case nodes do not appear in data paths
+		if child.IsChoice() || child.IsCase() {
+			addSchemaNodes(nodes, child, path)
+			continue
+		}
+
+		childPath := fmt.Sprintf("%s/%s", path, child.Name)
+		nodes[childPath] = newSchemaNode(child)
+		if child.IsDir() {
+			addSchemaNodes(nodes, child, childPath)
+		}
+	}
+}
+
+// newSchemaNode returns the schema node describing the given entry
+func newSchemaNode(entry *yang.Entry) SchemaNode {
+	node := SchemaNode{
+		ReadOnly: entry.ReadOnly(),
+	}
+	switch {
+	case entry.IsList():
+		node.Kind = "list"
+		node.Keys = strings.Fields(entry.Key)
+	case entry.IsDir():
+		node.Kind = "container"
+	case entry.IsLeafList():
+		node.Kind = "leaf-list"
+		node.Type = getTypeName(entry.Type)
+	default:
+		node.Kind = "leaf"
+		node.Type = getTypeName(entry.Type)
+	}
+	return node
+}
+
+// getTypeName returns a description of the given leaf type
+// Enumerations and unions include their values and member types, so changes to them are reported.
+func getTypeName(t *yang.YangType) string {
+	if t == nil {
+		return ""
+	}
+	switch t.Kind {
+	case yang.Yenum:
+		if t.Enum != nil {
+			return fmt.Sprintf("%s{%s}", t.Name, strings.Join(t.Enum.Names(), ","))
+		}
+	case yang.Yunion:
+		types := make([]string, 0, len(t.Type))
+		for _, member := range t.Type {
+			types = append(types, getTypeName(member))
+		}
+		return fmt.Sprintf("%s{%s}", t.Name, strings.Join(types, "|"))
+	case yang.Yidentityref:
+		if t.IdentityBase != nil {
+			return fmt.Sprintf("%s{%s}", t.Name, t.IdentityBase.Name)
+		}
+	case yang.Yleafref:
+		return fmt.Sprintf("%s{%s}", t.Name, t.Path)
+	}
+	return t.Name
+}
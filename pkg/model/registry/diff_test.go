@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+const diffFromYang = `module diff {
+  namespace "http://opennetworking.org/test/diff";
+  prefix df;
+
+  container system {
+    leaf hostname {
+      type string;
+    }
+    leaf domain {
+      type string;
+    }
+    leaf mode {
+      type enumeration {
+        enum a;
+        enum b;
+      }
+    }
+    list interface {
+      key "name";
+      leaf name {
+        type string;
+      }
+      leaf mtu {
+        type uint16;
+      }
+    }
+  }
+}
+`
+
+const diffToYang = `module diff {
+  namespace "http://opennetworking.org/test/diff";
+  prefix df;
+
+  container system {
+    leaf hostname {
+      type string;
+    }
+    leaf mode {
+      type enumeration {
+        enum a;
+        enum b;
+        enum c;
+      }
+    }
+    list interface {
+      key "name";
+      leaf name {
+        type string;
+      }
+      leaf mtu {
+        type uint32;
+      }
+      leaf oper-status {
+        type string;
+        config false;
+      }
+    }
+  }
+}
+`
+
+func newDiffModel(version configmodel.Version, yang string) configmodel.ModelInfo {
+	return configmodel.ModelInfo{
+		Name:    "diff",
+		Version: version,
+		Modules: []configmodel.ModuleInfo{
+			{
+				Name: "diff",
+				File: "diff.yang",
+			},
+		},
+		Files: []configmodel.FileInfo{
+			{
+				Path: "diff.yang",
+				Data: []byte(yang),
+			},
+		},
+	}
+}
+
+func TestGetModelDiff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-model-registry")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	registry := NewConfigModelRegistry(Config{
+		Path: dir,
+	})
+	assert.NoError(t, registry.AddModel(newDiffModel("1.0.0", diffFromYang)))
+	assert.NoError(t, registry.AddModel(newDiffModel("2.0.0", diffToYang)))
+
+	diff, err := registry.GetModelDiff("diff", "1.0.0", "2.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "diff@1.0.0", diff.From)
+	assert.Equal(t, "diff@2.0.0", diff.To)
+	assert.Equal(t, []SchemaPath{
+		{
+			Path: "/system/interface/oper-status",
+			Node: SchemaNode{Kind: "leaf", Type: "string", ReadOnly: true},
+		},
+	}, diff.Added)
+	assert.Equal(t, []SchemaPath{
+		{
+			Path: "/system/domain",
+			Node: SchemaNode{Kind: "leaf", Type: "string"},
+		},
+	}, diff.Removed)
+	assert.Equal(t, []SchemaChange{
+		{
+			Path: "/system/interface/mtu",
+			From: SchemaNode{Kind: "leaf", Type: "uint16"},
+			To:   SchemaNode{Kind: "leaf", Type: "uint32"},
+		},
+		{
+			Path: "/system/mode",
+			From: SchemaNode{Kind: "leaf", Type: "enumeration{a,b}"},
+			To:   SchemaNode{Kind: "leaf", Type: "enumeration{a,b,c}"},
+		},
+	}, diff.Changed)
+
+	// A model is identical to itself
+	diff, err = registry.GetModelDiff("diff", "1.0.0", "1.0.0")
+	assert.NoError(t, err)
+	assert.Empty(t, diff.Added)
+	assert.Empty(t, diff.Removed)
+	assert.Empty(t, diff.Changed)
+
+	_, err = registry.GetModelDiff("diff", "1.0.0", "3.0.0")
+	assert.Error(t, err)
+}
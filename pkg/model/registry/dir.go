@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const yangExt = ".yang"
+
+// ReadModelDir reads the YANG files in the given directory tree, inferring the modules they define
+// Each file's module name, organization and newest revision are read from its module or submodule
+// statement. Files are identified by their base names, so the tree must not contain two files with
+// the same name. Files that cannot be parsed are reported together, one per line.
+func ReadModelDir(dir string) ([]configmodel.ModuleInfo, []configmodel.FileInfo, error) {
+	var modules []configmodel.ModuleInfo
+	var files []configmodel.FileInfo
+	var problems []string
+	paths := make(map[string]string)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != yangExt {
+			return nil
+		}
+		name := filepath.Base(path)
+		if other, ok := paths[name]; ok {
+			return errors.NewInvalid("'%s' and '%s' have the same file name", other, path)
+		}
+		paths[name] = path
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		module, err := configmodel.ParseModuleInfo(path, data)
+		if err != nil {
+			problems = append(problems, err.Error())
+			return nil
+		}
+		module.File = name
+		modules = append(modules, module)
+		files = append(files, configmodel.FileInfo{
+			Path: name,
+			Data: data,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(problems) > 0 {
+		return nil, nil, errors.NewInvalid("failed to parse YANG files in '%s':\n%s", dir, strings.Join(problems, "\n"))
+	}
+	if len(files) == 0 {
+		return nil, nil, errors.NewNotFound("no YANG files found in '%s'", dir)
+	}
+	return modules, files, nil
+}
@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeYangFile(t *testing.T, path string, data string) {
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	assert.NoError(t, ioutil.WriteFile(path, []byte(data), 0644))
+}
+
+func TestReadModelDir(t *testing.T) {
+	dir := t.TempDir()
+	writeYangFile(t, filepath.Join(dir, "revisions.yang"), `module revisions {
+  namespace "http://opennetworking.org/test/revisions";
+  prefix rev;
+  organization "ONF";
+
+  revision 2020-01-01;
+  revision 2021-06-01;
+}
+`)
+	writeYangFile(t, filepath.Join(dir, "sub", "child.yang"), `submodule child {
+  belongs-to parent { prefix p; }
+}
+`)
+	writeYangFile(t, filepath.Join(dir, "README.md"), "not YANG")
+
+	modules, files, err := ReadModelDir(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []configmodel.ModuleInfo{
+		{
+			Name:         "revisions",
+			File:         "revisions.yang",
+			Organization: "ONF",
+			Revision:     "2021-06-01",
+		},
+		{
+			Name: "child",
+			File: "child.yang",
+		},
+	}, modules)
+	assert.Len(t, files, 2)
+	assert.Equal(t, "revisions.yang", files[0].Path)
+	assert.Equal(t, "child.yang", files[1].Path)
+
+	// Each file that fails to parse is reported
+	writeYangFile(t, filepath.Join(dir, "broken.yang"), "module broken {\n")
+	writeYangFile(t, filepath.Join(dir, "sub", "empty.yang"), "")
+	_, _, err = ReadModelDir(dir)
+	assert.True(t, errors.IsInvalid(err))
+	assert.Contains(t, err.Error(), "broken.yang")
+	assert.Contains(t, err.Error(), filepath.Join("sub", "empty.yang")+": no module or submodule statement")
+
+	// Files are identified by name, so names must be unique
+	dir = t.TempDir()
+	writeYangFile(t, filepath.Join(dir, "a", "test.yang"), "module test {}")
+	writeYangFile(t, filepath.Join(dir, "b", "test.yang"), "module test {}")
+	_, _, err = ReadModelDir(dir)
+	assert.True(t, errors.IsInvalid(err))
+
+	_, _, err = ReadModelDir(t.TempDir())
+	assert.True(t, errors.IsNotFound(err))
+}
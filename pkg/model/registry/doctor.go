@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"fmt"
+	plugincompiler "github.com/onosproject/onos-config-model/pkg/model/plugin/compiler"
+	pluginmodule "github.com/onosproject/onos-config-model/pkg/model/plugin/module"
+)
+
+// DoctorConfig is the configuration of the paths checked by Doctor
+type DoctorConfig struct {
+	RegistryPath string
+	CachePath    string
+}
+
+// DoctorCheck is the result of a check of the registry host
+type DoctorCheck struct {
+	// Name describes what was checked
+	Name string `json:"name"`
+	// Error is the reason the check failed, if it failed
+	Error string `json:"error,omitempty"`
+}
+
+// Passed returns whether the check passed
+func (c DoctorCheck) Passed() bool {
+	return c.Error == ""
+}
+
+// Doctor checks that the registry host can build, fetch the dependencies of, and store model plugins
+// Each check is run even if a previous check failed, so all the problems with the host are reported at once.
+func Doctor(compiler *plugincompiler.PluginCompiler, resolver *pluginmodule.Resolver, config DoctorConfig) []DoctorCheck {
+	var checks []DoctorCheck
+	check := func(name string, f func() error) {
+		result := DoctorCheck{Name: name}
+		if err := f(); err != nil {
+			result.Error = err.Error()
+		}
+		checks = append(checks, result)
+	}
+
+	check("go is available and matches the running Go version", compiler.CheckGoVersion)
+	check("cgo can build plugins", compiler.SmokeTest)
+	check("module cache is reachable", func() error {
+		dir, err := resolver.GetModCacheDir()
+		if err != nil {
+			return err
+		}
+		// Offline modules are only read from the module cache, which may be mounted read-only
+		if resolver.Config.Offline {
+			return checkReadable(dir)
+		}
+		return checkWritable(dir)
+	})
+	check("target modules can be fetched", resolver.Fetch)
+	check(fmt.Sprintf("registry path '%s' is writable", config.RegistryPath), func() error {
+		return checkWritable(config.RegistryPath)
+	})
+	check(fmt.Sprintf("cache path '%s' is writable", config.CachePath), func() error {
+		return checkWritable(config.CachePath)
+	})
+	check(fmt.Sprintf("build path '%s' is writable", compiler.Config.BuildPath), func() error {
+		return checkWritable(compiler.Config.BuildPath)
+	})
+	check(fmt.Sprintf("module path '%s' is writable", resolver.Config.Path), func() error {
+		return checkWritable(resolver.Config.Path)
+	})
+	return checks
+}
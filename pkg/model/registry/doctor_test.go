@@ -0,0 +1,44 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	plugincompiler "github.com/onosproject/onos-config-model/pkg/model/plugin/compiler"
+	pluginmodule "github.com/onosproject/onos-config-model/pkg/model/plugin/module"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+)
+
+func TestDoctor(t *testing.T) {
+	dir := t.TempDir()
+	resolver := pluginmodule.NewResolver(pluginmodule.ResolverConfig{
+		Path: filepath.Join(dir, "mod"),
+	})
+	compiler := plugincompiler.NewPluginCompiler(plugincompiler.CompilerConfig{
+		BuildPath: filepath.Join(dir, "build"),
+		GoBinary:  filepath.Join(dir, "missing"),
+	}, resolver)
+
+	checks := Doctor(compiler, resolver, DoctorConfig{
+		RegistryPath: filepath.Join(dir, "registry"),
+		CachePath:    filepath.Join(dir, "cache"),
+	})
+
+	// Every check is run even if previous checks fail
+	failed := make(map[string]bool)
+	for _, check := range checks {
+		failed[check.Name] = !check.Passed()
+	}
+	assert.Len(t, checks, 8)
+	assert.True(t, failed["go is available and matches the running Go version"])
+	assert.True(t, failed["cgo can build plugins"])
+	assert.True(t, failed["target modules can be fetched"])
+	assert.False(t, failed["module cache is reachable"])
+	assert.False(t, failed["registry path '"+filepath.Join(dir, "registry")+"' is writable"])
+	assert.False(t, failed["cache path '"+filepath.Join(dir, "cache")+"' is writable"])
+	assert.False(t, failed["build path '"+filepath.Join(dir, "build")+"' is writable"])
+	assert.False(t, failed["module path '"+filepath.Join(dir, "mod")+"' is writable"])
+}
@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"io/ioutil"
+	"os"
+)
+
+// DryRunModel generates the plugin module and YANG bindings for the given model without building its plugin
+// Dry runs catch schema errors without the cost of building the plugin. The model is not added to the registry,
+// and the generated files are removed since they're only on the server. Dry runs share the MaxTryouts limit.
+func (s *Server) DryRunModel(ctx context.Context, modelInfo configmodel.ModelInfo) error {
+	select {
+	case s.tryouts <- struct{}{}:
+		defer func() {
+			<-s.tryouts
+		}()
+	case <-ctx.Done():
+		return errors.NewTimeout("timed out waiting to generate model '%s'", modelInfo)
+	}
+
+	dir, err := ioutil.TempDir("", "config-model-dry-run")
+	if err != nil {
+		return errors.NewInternal(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	compiler := *s.compiler
+	compiler.Config.BuildPath = dir
+	compiler.Config.DryRun = true
+	if err := compiler.WithContext(ctx).CompilePlugin(modelInfo, ""); err != nil {
+		return errors.NewInvalid("failed to generate model '%s': %s", modelInfo, err)
+	}
+	return nil
+}
+
+// dryRunModel generates a pushed model without building its plugin or adding it to the registry
+func (s *Server) dryRunModel(ctx context.Context, request *configmodelapi.PushModelRequest) (*configmodelapi.PushModelResponse, error) {
+	if err := validateModules(request.Model); err != nil {
+		log.Warnf("PushModelRequest '%s@%s' failed: %s", request.Model.Name, request.Model.Version, err)
+		return nil, errors.Status(err).Err()
+	}
+	modelInfo := newModelInfo(request.Model)
+	if err := setFeatures(ctx, &modelInfo); err != nil {
+		log.Warnf("PushModelRequest '%s' failed: %s", modelInfo, err)
+		return nil, errors.Status(err).Err()
+	}
+	if err := s.DryRunModel(ctx, modelInfo); err != nil {
+		log.Warnf("PushModelRequest '%s' failed: %s", modelInfo, err)
+		return nil, errors.Status(err).Err()
+	}
+	response := &configmodelapi.PushModelResponse{}
+	log.Debugf("Sending PushModelResponse %+v", response)
+	return response, nil
+}
@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPushModelDryRun(t *testing.T) {
+	if testing.Short() {
+		t.Skip("generating plugins is slow")
+	}
+
+	moduleRoot, err := filepath.Abs(filepath.Join("..", "..", ".."))
+	assert.NoError(t, err)
+	server := newTestServer(t)
+	server.compiler.Config.ModFile = writeTryoutModFile(t, t.TempDir(), moduleRoot)
+	server.compiler.Config.SumFile = filepath.Join(moduleRoot, "go.sum")
+	client := newTestClient(t, server)
+
+	yang, err := ioutil.ReadFile(filepath.Join(moduleRoot, "test", "test@2020-11-18.yang"))
+	assert.NoError(t, err)
+	model := &configmodelapi.ConfigModel{
+		Name:         "test",
+		Version:      "1.0.0",
+		GetStateMode: configmodelapi.GetStateMode_NONE,
+		Modules: []*configmodelapi.ConfigModule{
+			{
+				Name:     "test",
+				Revision: "2020-11-18",
+				File:     "test@2020-11-18.yang",
+			},
+		},
+		Files: map[string]string{
+			"test@2020-11-18.yang": string(yang),
+		},
+	}
+
+	// The model is generated, but neither its plugin nor the model is added
+	_, err = client.PushModel(WithDryRun(context.Background()), &configmodelapi.PushModelRequest{Model: model})
+	assert.NoError(t, err)
+	_, err = server.registry.GetModel("test", "1.0.0")
+	assert.True(t, errors.IsNotFound(err))
+	_, err = os.Stat(server.cache.Entry("test", "1.0.0").Path)
+	assert.True(t, os.IsNotExist(err))
+
+	// Schema errors fail the push
+	model.Files["test@2020-11-18.yang"] = "module test { leaf value { type bogus; } }"
+	_, err = client.PushModel(WithDryRun(context.Background()), &configmodelapi.PushModelRequest{Model: model})
+	assert.True(t, errors.IsInvalid(errors.FromGRPC(err)))
+	_, err = server.registry.GetModel("test", "1.0.0")
+	assert.True(t, errors.IsNotFound(err))
+}
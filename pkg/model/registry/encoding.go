@@ -0,0 +1,173 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// EncodeFiles encodes the YANG files of a model for the wire, keyed by path
+// Gzip encoded files are base64 encoded, as the API's files are strings. Models pushed with encoded files
+// must be pushed WithFileEncoding.
+func EncodeFiles(files map[string]string, encoding configmodel.FileEncoding) (map[string]string, error) {
+	if encoding == configmodel.IdentityEncoding {
+		return files, nil
+	}
+	encoded := make(map[string]string, len(files))
+	for path, data := range files {
+		file, err := configmodel.FileInfo{Path: path, Data: []byte(data)}.Compress()
+		if err != nil {
+			return nil, errors.NewInternal("failed to compress '%s': %s", path, err)
+		}
+		encoded[path] = base64.StdEncoding.EncodeToString(file.Data)
+	}
+	return encoded, nil
+}
+
+// DecodeFiles decodes the YANG files of a model encoded for the wire, keyed by path
+// Files larger than maxBytes once decompressed are rejected. If maxBytes is zero, files are not limited.
+func DecodeFiles(files map[string]string, encoding configmodel.FileEncoding, maxBytes int64) (map[string]string, error) {
+	if encoding == configmodel.IdentityEncoding {
+		return files, nil
+	}
+	decoded := make(map[string]string, len(files))
+	for path, data := range files {
+		compressed, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return nil, errors.NewInvalid("file '%s' is not base64 encoded: %s", path, err)
+		}
+		reader, err := gzip.NewReader(bytes.NewReader(compressed))
+		if err != nil {
+			return nil, errors.NewInvalid("file '%s' is not gzip compressed: %s", path, err)
+		}
+		var limited io.Reader = reader
+		if maxBytes > 0 {
+			limited = io.LimitReader(reader, maxBytes+1)
+		}
+		bytes, err := ioutil.ReadAll(limited)
+		_ = reader.Close()
+		if err != nil {
+			return nil, errors.NewInvalid("failed to decompress file '%s': %s", path, err)
+		}
+		if maxBytes > 0 && int64(len(bytes)) > maxBytes {
+			return nil, errors.NewInvalid("file '%s' exceeds the maximum size of %d bytes when decompressed", path, maxBytes)
+		}
+		decoded[path] = string(bytes)
+	}
+	return decoded, nil
+}
+
+// getFileEncoding returns the file encoding in the incoming metadata
+func getFileEncoding(ctx context.Context) (configmodel.FileEncoding, error) {
+	return configmodel.ParseFileEncoding(getStringMetadata(ctx, FileEncodingKey))
+}
+
+// decodeModelFiles decodes the files of a pushed model in place according to the incoming metadata
+// Files are decoded before anything else is done with the model, so the rest of the push sees the YANG
+// files as if they were pushed verbatim.
+func (s *Server) decodeModelFiles(ctx context.Context, model *configmodelapi.ConfigModel) error {
+	encoding, err := getFileEncoding(ctx)
+	if err != nil || encoding == configmodel.IdentityEncoding || model == nil {
+		return err
+	}
+	files, err := DecodeFiles(model.Files, encoding, s.getMaxFileBytes())
+	if err != nil {
+		return err
+	}
+	model.Files = files
+	return nil
+}
+
+// encodeModelFiles encodes the files of a returned model in the encoding requested in the incoming metadata
+func encodeModelFiles(ctx context.Context, files map[string]string) (map[string]string, error) {
+	encoding, err := getFileEncoding(ctx)
+	if err != nil || encoding == configmodel.IdentityEncoding {
+		return files, err
+	}
+	files, err = EncodeFiles(files, encoding)
+	if err != nil {
+		return nil, err
+	}
+	if err := grpc.SetHeader(ctx, metadata.Pairs(FileEncodingKey, string(encoding))); err != nil {
+		log.Debugf("Failed to send file encoding: %s", err)
+	}
+	return files, nil
+}
+
+// getResponseFileEncoding returns the encoding of the files in a response from its headers
+// Servers that don't support file encodings ignore the requested encoding and send no header.
+func getResponseFileEncoding(header metadata.MD) (configmodel.FileEncoding, error) {
+	values := header.Get(FileEncodingKey)
+	if len(values) == 0 {
+		return configmodel.IdentityEncoding, nil
+	}
+	return configmodel.ParseFileEncoding(values[0])
+}
+
+// TranscodeModels compresses the YANG files of models stored uncompressed in a registry that compresses storage
+// Descriptors written before compression was enabled, or before file encodings were recorded, are rewritten
+// with compressed files. Registries that don't compress storage still read compressed descriptors, so they're
+// not rewritten. Returns the number of rewritten descriptors.
+func (r *ConfigModelRegistry) TranscodeModels() (int, error) {
+	if !r.Config.CompressStorage || r.IsReadOnly() {
+		return 0, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	paths, err := filepath.Glob(filepath.Join(r.Config.Path, "*"+jsonExt))
+	if err != nil {
+		return 0, errors.NewInternal(err.Error())
+	}
+	count := 0
+	for _, path := range paths {
+		bytes, err := ioutil.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return count, errors.NewInternal("failed reading model definition '%s': %s", path, err)
+		}
+		var stored configmodel.ModelInfo
+		if err := json.Unmarshal(bytes, &stored); err != nil || !isLegacyEncoding(stored) {
+			continue
+		}
+		model, err := loadModel(path)
+		if err != nil {
+			log.Warnf("Failed to transcode model definition '%s': %s", path, err)
+			continue
+		}
+		if err := r.writeModel(model); err != nil {
+			return count, errors.NewInternal("failed to transcode model definition '%s': %s", path, err)
+		}
+		log.Infof("Compressed the files of model definition '%s'", strings.TrimPrefix(path, r.Config.Path+string(filepath.Separator)))
+		count++
+	}
+	return count, nil
+}
+
+// isLegacyEncoding returns whether any file of a stored model has no recorded gzip encoding
+func isLegacyEncoding(model configmodel.ModelInfo) bool {
+	for _, file := range model.Files {
+		if file.Encoding != configmodel.GzipEncoding {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	"encoding/json"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestEncodeFiles(t *testing.T) {
+	files := map[string]string{
+		"a.yang": "module a {}",
+		"b.yang": "module b {}",
+	}
+	encoded, err := EncodeFiles(files, configmodel.GzipEncoding)
+	assert.NoError(t, err)
+	assert.NotEqual(t, files, encoded)
+	decoded, err := DecodeFiles(encoded, configmodel.GzipEncoding, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, files, decoded)
+
+	// Identity encoded files are sent as they are
+	encoded, err = EncodeFiles(files, configmodel.IdentityEncoding)
+	assert.NoError(t, err)
+	assert.Equal(t, files, encoded)
+
+	_, err = DecodeFiles(map[string]string{"a.yang": "module a {}"}, configmodel.GzipEncoding, 0)
+	assert.Error(t, err)
+
+	// Decompressed files are limited
+	encoded, err = EncodeFiles(map[string]string{"a.yang": strings.Repeat("a", 1024)}, configmodel.GzipEncoding)
+	assert.NoError(t, err)
+	_, err = DecodeFiles(encoded, configmodel.GzipEncoding, 1023)
+	assert.Error(t, err)
+	_, err = DecodeFiles(encoded, configmodel.GzipEncoding, 1024)
+	assert.NoError(t, err)
+}
+
+func TestPushModelGzipEncoding(t *testing.T) {
+	server := newTestServer(t)
+	server.registry.(*ConfigModelRegistry).Config.CompressStorage = true
+	client := newTestClient(t, server)
+
+	files := map[string]string{
+		"a.yang": "module a {}",
+		"b.yang": "module b {}",
+	}
+	encoded, err := EncodeFiles(files, configmodel.GzipEncoding)
+	assert.NoError(t, err)
+
+	entry := server.cache.Entry("test", "1.0.0")
+	assert.NoError(t, ioutil.WriteFile(entry.Path, []byte("plugin"), 0666))
+	ctx := WithFileEncoding(WithSkipCompile(context.Background()), configmodel.GzipEncoding)
+	_, err = client.PushModel(ctx, &configmodelapi.PushModelRequest{
+		Model: &configmodelapi.ConfigModel{
+			Name:    "test",
+			Version: "1.0.0",
+			Modules: []*configmodelapi.ConfigModule{
+				{Name: "a", File: "a.yang"},
+				{Name: "b", File: "b.yang"},
+			},
+			Files: encoded,
+		},
+	})
+	assert.NoError(t, err)
+
+	// Files are stored compressed
+	bytes, err := ioutil.ReadFile(server.registry.(*ConfigModelRegistry).getDescriptorFile("test", "1.0.0"))
+	assert.NoError(t, err)
+	var stored configmodel.ModelInfo
+	assert.NoError(t, json.Unmarshal(bytes, &stored))
+	for _, file := range stored.Files {
+		assert.Equal(t, configmodel.GzipEncoding, file.Encoding)
+		assert.True(t, file.IsCompressed())
+	}
+
+	// Files are returned decoded unless an encoding is requested
+	response, err := client.GetModel(WithIncludeFiles(context.Background()), &configmodelapi.GetModelRequest{Name: "test", Version: "1.0.0"})
+	assert.NoError(t, err)
+	assert.Equal(t, files, response.Model.Files)
+
+	var header metadata.MD
+	ctx = WithFileEncoding(WithIncludeFiles(context.Background()), configmodel.GzipEncoding)
+	response, err = client.GetModel(ctx, &configmodelapi.GetModelRequest{Name: "test", Version: "1.0.0"}, grpc.Header(&header))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{string(configmodel.GzipEncoding)}, header.Get(FileEncodingKey))
+	decoded, err := DecodeFiles(response.Model.Files, configmodel.GzipEncoding, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, files, decoded)
+
+	data, err := GetModelData(context.Background(), client, "test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, []configmodel.FileInfo{
+		{Path: "a.yang", Data: []byte("module a {}")},
+		{Path: "b.yang", Data: []byte("module b {}")},
+	}, data)
+
+	// Files that aren't encoded as declared are rejected
+	ctx = WithFileEncoding(WithSkipCompile(context.Background()), configmodel.GzipEncoding)
+	_, err = client.PushModel(ctx, &configmodelapi.PushModelRequest{
+		Model: &configmodelapi.ConfigModel{
+			Name:    "test",
+			Version: "2.0.0",
+			Modules: []*configmodelapi.ConfigModule{{Name: "a", File: "a.yang"}},
+			Files:   map[string]string{"a.yang": "module a {}"},
+		},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	ctx = WithFileEncoding(WithSkipCompile(context.Background()), "zip")
+	_, err = client.PushModel(ctx, &configmodelapi.PushModelRequest{
+		Model: &configmodelapi.ConfigModel{
+			Name:    "test",
+			Version: "2.0.0",
+			Modules: []*configmodelapi.ConfigModule{{Name: "a", File: "a.yang"}},
+			Files:   encoded,
+		},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestTranscodeModels(t *testing.T) {
+	server := newTestServer(t)
+	registry := server.registry.(*ConfigModelRegistry)
+
+	// Models are written uncompressed before compression is enabled
+	model := newChecksumTestModel()
+	assert.NoError(t, registry.AddModel(model))
+	count, err := registry.TranscodeModels()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	registry.Config.CompressStorage = true
+	count, err = registry.TranscodeModels()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	bytes, err := ioutil.ReadFile(registry.getDescriptorFile("test", "1.0.0"))
+	assert.NoError(t, err)
+	var stored configmodel.ModelInfo
+	assert.NoError(t, json.Unmarshal(bytes, &stored))
+	for _, file := range stored.Files {
+		assert.Equal(t, configmodel.GzipEncoding, file.Encoding)
+	}
+
+	// Transcoded models are read as they were written
+	transcoded, err := registry.GetModel("test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, model.Files, transcoded.Files)
+	assert.Equal(t, model.ComputeChecksum(), transcoded.Checksum)
+
+	count, err = registry.TranscodeModels()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
@@ -0,0 +1,32 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	plugincache "github.com/onosproject/onos-config-model/pkg/model/plugin/cache"
+)
+
+// EvictPlugins evicts the least recently loaded plugins from the cache until it is within its configured limits
+// The plugins for pinned models are never evicted. Returns the paths of the evicted plugins.
+func EvictPlugins(registry Registry, cache *plugincache.PluginCache) ([]string, error) {
+	if cache.Config.MaxSizeBytes == 0 && cache.Config.MaxEntries == 0 {
+		return nil, nil
+	}
+
+	models, err := registry.ListModels()
+	if err != nil {
+		return nil, err
+	}
+
+	pinned := make(map[string]bool)
+	for _, model := range models {
+		if model.Pinned {
+			pinned[cache.Entry(model.Name, model.Version).Path] = true
+		}
+	}
+	return cache.Evict(func(path string) bool {
+		return pinned[path]
+	})
+}
@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	modelplugin "github.com/onosproject/onos-config-model/pkg/model/plugin"
+	plugincache "github.com/onosproject/onos-config-model/pkg/model/plugin/cache"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEvictPinnedPlugin(t *testing.T) {
+	server := newTestServer(t)
+	now := time.Now()
+	for i, name := range []configmodel.Name{"pinned", "unpinned", "latest"} {
+		assert.NoError(t, server.registry.AddModel(configmodel.ModelInfo{
+			Name:    name,
+			Version: "1.0.0",
+		}))
+		path := server.cache.Entry(name, "1.0.0").Path
+		assert.NoError(t, ioutil.WriteFile(path, []byte(name), 0666))
+		modTime := now.Add(time.Duration(i-3) * time.Hour)
+		assert.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	assert.NoError(t, server.registry.PinModel("pinned", "1.0.0"))
+
+	evicted, err := EvictPlugins(server.registry, server.cache)
+	assert.NoError(t, err)
+	assert.Empty(t, evicted)
+
+	// The least recently used plugin survives eviction when it's pinned
+	server.cache.Config.MaxEntries = 1
+	evicted, err = EvictPlugins(server.registry, server.cache)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		server.cache.Entry("unpinned", "1.0.0").Path,
+		server.cache.Entry("latest", "1.0.0").Path,
+	}, evicted)
+	_, err = os.Stat(server.cache.Entry("pinned", "1.0.0").Path)
+	assert.NoError(t, err)
+}
+
+func TestLoadEvictedPlugin(t *testing.T) {
+	if testing.Short() || raceEnabled {
+		t.Skip("building plugins is slow and they cannot be loaded by binaries built with -race")
+	}
+
+	moduleRoot, err := filepath.Abs(filepath.Join("..", "..", ".."))
+	assert.NoError(t, err)
+	server := newTestServer(t)
+	server.compiler.Config.ModFile = writeTryoutModFile(t, t.TempDir(), moduleRoot)
+	server.compiler.Config.SumFile = filepath.Join(moduleRoot, "go.sum")
+
+	yang, err := ioutil.ReadFile(filepath.Join(moduleRoot, "test", "test@2020-11-18.yang"))
+	assert.NoError(t, err)
+	assert.NoError(t, server.registry.AddModel(configmodel.ModelInfo{
+		Name:         "test",
+		Version:      "1.0.0",
+		GetStateMode: configmodel.GetStateNone,
+		Modules: []configmodel.ModuleInfo{
+			{
+				Name:     "test",
+				Revision: "2020-11-18",
+				File:     "test@2020-11-18.yang",
+			},
+		},
+		Files: []configmodel.FileInfo{
+			{
+				Path: "test@2020-11-18.yang",
+				Data: yang,
+			},
+		},
+		Plugin: configmodel.PluginInfo{
+			Name:    "test",
+			Version: "1.0.0",
+		},
+	}))
+	assert.NoError(t, server.registry.AddModel(configmodel.ModelInfo{Name: "latest", Version: "1.0.0"}))
+	entry := server.cache.Entry("test", "1.0.0")
+	assert.NoError(t, ioutil.WriteFile(entry.Path, []byte("plugin"), 0666))
+	modTime := time.Now().Add(-time.Hour)
+	assert.NoError(t, os.Chtimes(entry.Path, modTime, modTime))
+	assert.NoError(t, ioutil.WriteFile(server.cache.Entry("latest", "1.0.0").Path, []byte("plugin"), 0666))
+
+	server.cache.Config.MaxEntries = 1
+	evicted, err := EvictPlugins(server.registry, server.cache)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{entry.Path}, evicted)
+
+	// The evicted plugin is recompiled from the model's YANG files when it's loaded
+	server.load = func(entry *plugincache.PluginEntry) (modelplugin.ConfigModelPlugin, error) {
+		_, err := os.Stat(entry.Path)
+		return testModelPlugin{model: testModel{}}, err
+	}
+	_, err = server.LoadPlugin(context.Background(), "test", "1.0.0")
+	assert.NoError(t, err)
+	history, err := server.registry.GetCompileHistory("test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+	assert.True(t, history[0].Success)
+	model, err := server.registry.GetModel("test", "1.0.0")
+	assert.NoError(t, err)
+	assert.NotNil(t, model.Build)
+	assert.False(t, entry.IsLocked())
+}
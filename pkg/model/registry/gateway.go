@@ -0,0 +1,208 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"bytes"
+	"crypto/tls"
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	"github.com/onosproject/onos-lib-go/pkg/certs"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"net/http"
+	"strings"
+)
+
+const (
+	gatewayModelsPath = "/v1/models"
+	// gatewayMetadataPrefix prefixes the response metadata of the registry API in HTTP headers, as grpc-gateway does
+	gatewayMetadataPrefix = "Grpc-Metadata-"
+)
+
+// gatewayQueryKeys maps the query parameters accepted by the gateway to the request metadata keys
+var gatewayQueryKeys = map[string]string{
+	"selector":   SelectorKey,
+	"page_size":  PageSizeKey,
+	"page_token": PageTokenKey,
+	"files":      IncludeFilesKey,
+	"encoding":   FileEncodingKey,
+	"force":      ForceKey,
+	"dry_run":    DryRunKey,
+}
+
+// NewGateway returns an HTTP handler serving the registry API of the given server as JSON
+// GET /v1/models lists the models, and GET and DELETE /v1/models/{name}/{version} get and delete a model.
+// Requests are mapped to the server's RPCs, so query parameters set the request metadata, the response
+// metadata is returned in Grpc-Metadata- headers, and errors are returned with the HTTP status of their type.
+func NewGateway(server configmodelapi.ConfigModelRegistryServiceServer) http.Handler {
+	return &gateway{server: server}
+}
+
+// gateway is an HTTP handler for the registry API
+type gateway struct {
+	server configmodelapi.ConfigModelRegistryServiceServer
+}
+
+func (g *gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+	elems := strings.Split(path, "/")
+	if !strings.HasPrefix("/"+path+"/", gatewayModelsPath+"/") || (len(elems) != 2 && len(elems) != 4) {
+		writeGatewayError(w, errors.NewNotFound("unknown path '%s'", r.URL.Path))
+		return
+	}
+
+	stream := &gatewayStream{header: metadata.MD{}}
+	ctx := grpc.NewContextWithServerTransportStream(metadata.NewIncomingContext(r.Context(), getGatewayMetadata(r)), stream)
+	var response proto.Message
+	var err error
+	switch {
+	case len(elems) == 2 && r.Method == http.MethodGet:
+		response, err = g.server.ListModels(ctx, &configmodelapi.ListModelsRequest{})
+	case len(elems) == 4 && r.Method == http.MethodGet:
+		response, err = g.server.GetModel(ctx, &configmodelapi.GetModelRequest{Name: elems[2], Version: elems[3]})
+	case len(elems) == 4 && r.Method == http.MethodDelete:
+		response, err = g.server.DeleteModel(ctx, &configmodelapi.DeleteModelRequest{Name: elems[2], Version: elems[3]})
+	default:
+		w.Header().Set("Allow", getGatewayAllowedMethods(len(elems)))
+		writeGatewayStatus(w, http.StatusMethodNotAllowed, errors.NewNotSupported("method %s is not supported for '%s'", r.Method, r.URL.Path))
+		return
+	}
+	if err != nil {
+		writeGatewayError(w, errors.FromGRPC(err))
+		return
+	}
+
+	var body bytes.Buffer
+	if err := (&jsonpb.Marshaler{}).Marshal(&body, response); err != nil {
+		writeGatewayError(w, errors.NewInternal(err.Error()))
+		return
+	}
+	for key, values := range stream.header {
+		for _, value := range values {
+			w.Header().Add(gatewayMetadataPrefix+key, value)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body.Bytes())
+}
+
+// getGatewayMetadata returns the request metadata set by the query parameters of the given request
+func getGatewayMetadata(r *http.Request) metadata.MD {
+	md := metadata.MD{}
+	for param, values := range r.URL.Query() {
+		if key, ok := gatewayQueryKeys[param]; ok {
+			md.Append(key, values...)
+		}
+	}
+	return md
+}
+
+// getGatewayAllowedMethods returns the methods allowed for a path with the given number of elements
+func getGatewayAllowedMethods(elems int) string {
+	if elems == 2 {
+		return http.MethodGet
+	}
+	return strings.Join([]string{http.MethodGet, http.MethodDelete}, ", ")
+}
+
+// writeGatewayError writes the given error with the HTTP status of its type
+func writeGatewayError(w http.ResponseWriter, err error) {
+	writeGatewayStatus(w, getHTTPStatus(err), err)
+}
+
+// writeGatewayStatus writes the given error with the given HTTP status
+func writeGatewayStatus(w http.ResponseWriter, status int, err error) {
+	http.Error(w, err.Error(), status)
+}
+
+// getHTTPStatus returns the HTTP status for the type of the given error
+func getHTTPStatus(err error) int {
+	if isQueueFull(err) {
+		return http.StatusTooManyRequests
+	}
+	switch errors.TypeOf(err) {
+	case errors.NotFound:
+		return http.StatusNotFound
+	case errors.AlreadyExists, errors.Conflict:
+		return http.StatusConflict
+	case errors.Invalid:
+		return http.StatusBadRequest
+	case errors.Unauthorized:
+		return http.StatusUnauthorized
+	case errors.Forbidden:
+		return http.StatusForbidden
+	case errors.Unavailable:
+		return http.StatusServiceUnavailable
+	case errors.NotSupported:
+		return http.StatusNotImplemented
+	case errors.Timeout:
+		return http.StatusGatewayTimeout
+	case errors.Canceled:
+		return http.StatusRequestTimeout
+	}
+	return http.StatusInternalServerError
+}
+
+// gatewayStream records the response metadata set by the registry server for a gateway request
+type gatewayStream struct {
+	header metadata.MD
+}
+
+func (s *gatewayStream) Method() string {
+	return ""
+}
+
+func (s *gatewayStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *gatewayStream) SendHeader(md metadata.MD) error {
+	return s.SetHeader(md)
+}
+
+func (s *gatewayStream) SetTrailer(md metadata.MD) error {
+	return nil
+}
+
+var _ grpc.ServerTransportStream = &gatewayStream{}
+
+// NewGatewayTLSConfig returns the TLS config with which the gateway is served
+// The config matches that with which the northbound server serves the gRPC API: the given certificate and key
+// are served, or the default localhost certificate if none is given, and client certificates are requested
+// and verified against the given CA, or the default CA if none is given, but not required.
+func NewGatewayTLSConfig(caPath, certPath, keyPath string) (*tls.Config, error) {
+	var cert tls.Certificate
+	var err error
+	if certPath == "" && keyPath == "" {
+		cert, err = tls.X509KeyPair([]byte(certs.DefaultLocalhostCrt), []byte(certs.DefaultLocalhostKey))
+	} else {
+		cert, err = tls.LoadX509KeyPair(certPath, keyPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequestClientCert,
+	}
+	if caPath == "" {
+		config.ClientCAs, err = certs.GetCertPoolDefault()
+	} else {
+		config.ClientCAs, err = certs.GetCertPool(caPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Gateway returns an HTTP handler serving the registry API of the service as JSON
+func (s *Service) Gateway() http.Handler {
+	return NewGateway(s.server)
+}
@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"github.com/gogo/protobuf/jsonpb"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGateway(t *testing.T) {
+	server := newTestServer(t)
+	assert.NoError(t, server.registry.AddModel(configmodel.ModelInfo{
+		Name:    "foo",
+		Version: "1.0.0",
+		Modules: []configmodel.ModuleInfo{{Name: "foo", File: "foo.yang"}},
+	}))
+	assert.NoError(t, server.registry.AddModel(configmodel.ModelInfo{Name: "bar", Version: "1.0.0"}))
+	gateway := httptest.NewServer(NewGateway(server))
+	defer gateway.Close()
+
+	response, err := http.Get(gateway.URL + "/v1/models")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "application/json", response.Header.Get("Content-Type"))
+	list := &configmodelapi.ListModelsResponse{}
+	assert.NoError(t, jsonpb.Unmarshal(response.Body, list))
+	response.Body.Close()
+	assert.Len(t, list.Models, 2)
+
+	// Query parameters set the request metadata, and the response metadata is returned in headers
+	response, err = http.Get(gateway.URL + "/v1/models?page_size=1")
+	assert.NoError(t, err)
+	list = &configmodelapi.ListModelsResponse{}
+	assert.NoError(t, jsonpb.Unmarshal(response.Body, list))
+	response.Body.Close()
+	assert.Len(t, list.Models, 1)
+	assert.NotEmpty(t, response.Header.Get(gatewayMetadataPrefix+NextPageTokenKey))
+
+	response, err = http.Get(gateway.URL + "/v1/models/foo/1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	get := &configmodelapi.GetModelResponse{}
+	assert.NoError(t, jsonpb.Unmarshal(response.Body, get))
+	response.Body.Close()
+	assert.Equal(t, "foo", get.Model.Name)
+	assert.Equal(t, "foo.yang", get.Model.Modules[0].File)
+
+	// Errors are returned with the HTTP status of their type
+	response, err = http.Get(gateway.URL + "/v1/models/baz/1.0.0")
+	assert.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+
+	response, err = http.Get(gateway.URL + "/v1/modules")
+	assert.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+
+	response, err = http.Post(gateway.URL+"/v1/models", "application/json", nil)
+	assert.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, response.StatusCode)
+	assert.Equal(t, http.MethodGet, response.Header.Get("Allow"))
+
+	request, err := http.NewRequest(http.MethodDelete, gateway.URL+"/v1/models/foo/1.0.0", nil)
+	assert.NoError(t, err)
+	response, err = http.DefaultClient.Do(request)
+	assert.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	_, err = server.registry.GetModel("foo", "1.0.0")
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestGetHTTPStatus(t *testing.T) {
+	assert.Equal(t, http.StatusNotFound, getHTTPStatus(errors.NewNotFound("not found")))
+	assert.Equal(t, http.StatusConflict, getHTTPStatus(errors.NewAlreadyExists("exists")))
+	assert.Equal(t, http.StatusBadRequest, getHTTPStatus(errors.NewInvalid("invalid")))
+	assert.Equal(t, http.StatusForbidden, getHTTPStatus(errors.NewForbidden("pinned")))
+	assert.Equal(t, http.StatusServiceUnavailable, getHTTPStatus(errors.NewUnavailable("unavailable")))
+	assert.Equal(t, http.StatusInternalServerError, getHTTPStatus(errors.NewInternal("internal")))
+}
+
+func TestNewGatewayTLSConfig(t *testing.T) {
+	config, err := NewGatewayTLSConfig("", "", "")
+	assert.NoError(t, err)
+	assert.Len(t, config.Certificates, 1)
+	assert.NotNil(t, config.ClientCAs)
+
+	_, err = NewGatewayTLSConfig("", "missing.crt", "missing.key")
+	assert.Error(t, err)
+}
@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// registryServiceName is the name of the registry service reported by the health service
+const registryServiceName = "onos.configmodel.ConfigModelRegistryService"
+
+// healthWatchInterval is the interval at which the health of the server is checked for watchers
+const healthWatchInterval = 5 * time.Second
+
+// newHealthServer creates a health service for the given registry server
+func newHealthServer(server *Server) *healthServer {
+	return &healthServer{
+		server:   server,
+		interval: healthWatchInterval,
+	}
+}
+
+// healthServer is a gRPC health service reporting whether the registry server can serve requests
+// The server is SERVING only when the registry path is readable and the compiler's build path is writable.
+type healthServer struct {
+	server   *Server
+	interval time.Duration
+}
+
+// Check :
+func (h *healthServer) Check(ctx context.Context, request *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	status, err := h.getStatus(request.Service)
+	if err != nil {
+		return nil, errors.Status(err).Err()
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: status}, nil
+}
+
+// Watch :
+func (h *healthServer) Watch(request *grpc_health_v1.HealthCheckRequest, stream grpc_health_v1.Health_WatchServer) error {
+	status, err := h.getStatus(request.Service)
+	if err != nil {
+		status = grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN
+	}
+	if err := stream.Send(&grpc_health_v1.HealthCheckResponse{Status: status}); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			next, err := h.getStatus(request.Service)
+			if err != nil {
+				next = grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN
+			}
+			if next == status {
+				continue
+			}
+			status = next
+			if err := stream.Send(&grpc_health_v1.HealthCheckResponse{Status: status}); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+// getStatus returns the serving status of the given service
+func (h *healthServer) getStatus(service string) (grpc_health_v1.HealthCheckResponse_ServingStatus, error) {
+	if service != "" && service != registryServiceName {
+		return grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN, errors.NewNotFound("unknown service '%s'", service)
+	}
+	if registry, ok := h.server.registry.(*ConfigModelRegistry); ok {
+		if err := checkReadable(registry.Config.Path); err != nil {
+			log.Warnf("Registry path '%s' is not readable: %s", registry.Config.Path, err)
+			return grpc_health_v1.HealthCheckResponse_NOT_SERVING, nil
+		}
+	}
+	if err := checkWritable(h.server.compiler.Config.BuildPath); err != nil {
+		log.Warnf("Build path '%s' is not writable: %s", h.server.compiler.Config.BuildPath, err)
+		return grpc_health_v1.HealthCheckResponse_NOT_SERVING, nil
+	}
+	return grpc_health_v1.HealthCheckResponse_SERVING, nil
+}
+
+// checkReadable checks that the directory at the given path can be listed
+func checkReadable(path string) error {
+	_, err := ioutil.ReadDir(path)
+	return err
+}
+
+// checkWritable checks that files can be created in the directory at the given path
+func checkWritable(path string) error {
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		return err
+	}
+	file, err := ioutil.TempFile(path, ".health")
+	if err != nil {
+		return err
+	}
+	_ = file.Close()
+	return os.Remove(file.Name())
+}
@@ -0,0 +1,106 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+func newTestHealthClient(t *testing.T, server *Server) grpc_health_v1.HealthClient {
+	lis := bufconn.Listen(1024 * 1024)
+	s := grpc.NewServer()
+	health := newHealthServer(server)
+	health.interval = 10 * time.Millisecond
+	grpc_health_v1.RegisterHealthServer(s, health)
+	go func() {
+		_ = s.Serve(lis)
+	}()
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) {
+		return lis.Dial()
+	}))
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return grpc_health_v1.NewHealthClient(conn)
+}
+
+func TestRegisterServices(t *testing.T) {
+	s := grpc.NewServer()
+	service := &Service{server: newTestServer(t)}
+	service.Register(s)
+	services := s.GetServiceInfo()
+	assert.Contains(t, services, registryServiceName)
+	assert.Contains(t, services, "grpc.health.v1.Health")
+	assert.Contains(t, services, "grpc.reflection.v1alpha.ServerReflection")
+}
+
+func TestHealthCheck(t *testing.T) {
+	server := newTestServer(t)
+	client := newTestHealthClient(t, server)
+
+	response, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, response.Status)
+	response, err = client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: registryServiceName})
+	assert.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, response.Status)
+
+	_, err = client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// The server is not serving while the build path cannot be written
+	buildPath := server.compiler.Config.BuildPath
+	assert.NoError(t, os.RemoveAll(buildPath))
+	assert.NoError(t, ioutil.WriteFile(buildPath, []byte{}, 0666))
+	response, err = client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, response.Status)
+	assert.NoError(t, os.Remove(buildPath))
+
+	// The server is not serving while the registry path cannot be read
+	assert.NoError(t, os.RemoveAll(server.registry.(*ConfigModelRegistry).Config.Path))
+	response, err = client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, response.Status)
+}
+
+func TestHealthWatch(t *testing.T) {
+	server := newTestServer(t)
+	client := newTestHealthClient(t, server)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.Watch(ctx, &grpc_health_v1.HealthCheckRequest{})
+	assert.NoError(t, err)
+	response, err := stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, response.Status)
+
+	// Changes in the serving status are sent to watchers
+	registryPath := server.registry.(*ConfigModelRegistry).Config.Path
+	assert.NoError(t, os.RemoveAll(registryPath))
+	response, err = stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, response.Status)
+
+	assert.NoError(t, os.MkdirAll(registryPath, os.ModePerm))
+	response, err = stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, response.Status)
+}
@@ -0,0 +1,110 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	"encoding/json"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// The registry API has no method for getting the compile history of models, so GetCompileHistory is provided
+// by a separate service. The service reuses the API's GetModelRequest, and returns the JSON encoded compile
+// attempts as a string value.
+const (
+	historyServiceName      = "onos.configmodel.ConfigModelRegistryHistoryService"
+	getCompileHistoryMethod = "GetCompileHistory"
+)
+
+// historyServer is the server API for the history service
+type historyServer interface {
+	GetCompileHistory(ctx context.Context, request *configmodelapi.GetModelRequest) (*wrapperspb.StringValue, error)
+}
+
+var historyServiceDesc = grpc.ServiceDesc{
+	ServiceName: historyServiceName,
+	HandlerType: (*historyServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: getCompileHistoryMethod,
+			Handler:    getCompileHistoryHandler,
+		},
+	},
+}
+
+func getCompileHistoryHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	request := &configmodelapi.GetModelRequest{}
+	if err := dec(request); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(historyServer).GetCompileHistory(ctx, request)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/" + historyServiceName + "/" + getCompileHistoryMethod,
+	}
+	handler := func(ctx context.Context, request interface{}) (interface{}, error) {
+		return srv.(historyServer).GetCompileHistory(ctx, request.(*configmodelapi.GetModelRequest))
+	}
+	return interceptor(ctx, request, info, handler)
+}
+
+// registerHistoryService registers the history service for the given server
+func registerHistoryService(r *grpc.Server, server *Server) {
+	r.RegisterService(&historyServiceDesc, server)
+}
+
+// GetCompileHistoryRemote gets the recent compile attempts for a model from the registry server, oldest first
+// The version may be a constraint such as 'latest', which is resolved to the highest matching version of the model.
+func GetCompileHistoryRemote(ctx context.Context, conn grpc.ClientConnInterface, name configmodel.Name, version configmodel.Version) ([]CompileAttempt, error) {
+	request := &configmodelapi.GetModelRequest{
+		Name:    string(name),
+		Version: string(version),
+	}
+	response := &wrapperspb.StringValue{}
+	if err := conn.Invoke(ctx, "/"+historyServiceName+"/"+getCompileHistoryMethod, request, response); err != nil {
+		return nil, err
+	}
+	var history []CompileAttempt
+	if err := json.Unmarshal([]byte(response.Value), &history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// GetCompileHistory :
+func (s *Server) GetCompileHistory(ctx context.Context, request *configmodelapi.GetModelRequest) (*wrapperspb.StringValue, error) {
+	log.Debugf("Received GetCompileHistoryRequest %+v", request)
+	s.sendCapabilities(ctx)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	modelInfo, err := s.registry.GetModel(configmodel.Name(request.Name), configmodel.Version(request.Version))
+	if err != nil {
+		log.Warnf("GetCompileHistoryRequest %+v failed: %v", request, err)
+		return nil, errors.Status(err).Err()
+	}
+	history, err := s.registry.GetCompileHistory(modelInfo.Name, modelInfo.Version)
+	if err != nil {
+		log.Warnf("GetCompileHistoryRequest %+v failed: %v", request, err)
+		return nil, errors.Status(err).Err()
+	}
+	if history == nil {
+		history = []CompileAttempt{}
+	}
+	bytes, err := json.Marshal(history)
+	if err != nil {
+		log.Warnf("GetCompileHistoryRequest %+v failed: %v", request, err)
+		return nil, errors.Status(errors.NewInternal(err.Error())).Err()
+	}
+	response := wrapperspb.String(string(bytes))
+	log.Debugf("Sending GetCompileHistoryResponse %+v", response)
+	return response, nil
+}
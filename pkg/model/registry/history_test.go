@@ -0,0 +1,42 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestGetCompileHistoryRemote(t *testing.T) {
+	server := newTestServer(t)
+	conn := newTestConn(t, server)
+	ctx := context.Background()
+
+	assert.NoError(t, server.registry.AddModel(configmodel.ModelInfo{Name: "test", Version: "1.0.0"}))
+	history, err := GetCompileHistoryRemote(ctx, conn, "test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Empty(t, history)
+
+	assert.NoError(t, server.registry.AddCompileAttempt("test", "1.0.0", CompileAttempt{Time: time.Unix(1, 0), Error: "failed"}))
+	assert.NoError(t, server.registry.AddCompileAttempt("test", "1.0.0", CompileAttempt{Time: time.Unix(2, 0), Success: true}))
+	history, err = GetCompileHistoryRemote(ctx, conn, "test", "1.0.0")
+	assert.NoError(t, err)
+	if assert.Len(t, history, 2) {
+		assert.Equal(t, "failed", history[0].Error)
+		assert.True(t, history[1].Success)
+	}
+
+	// Version constraints are resolved to the model's version
+	history, err = GetCompileHistoryRemote(ctx, conn, "test", "latest")
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+
+	_, err = GetCompileHistoryRemote(ctx, conn, "bogus", "1.0.0")
+	assert.True(t, errors.IsNotFound(errors.FromGRPC(err)))
+}
@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// ImportResult is the result of importing a directory of model descriptors
+type ImportResult struct {
+	// Imported are the models pushed to the registry, formatted as name@version
+	Imported []string `json:"imported,omitempty"`
+	// Skipped are the models that already exist in the registry, formatted as name@version
+	Skipped []string `json:"skipped,omitempty"`
+	// Failed are the errors importing models or reading descriptors, keyed by name@version or descriptor path
+	Failed map[string]string `json:"failed,omitempty"`
+}
+
+// ExportModels writes the descriptor of each model in the given registry to the given directory
+// Descriptors are written as the registry writes them when models are added, so the directory can be
+// imported with ImportModels or served as a registry. The paths of the written files are returned.
+func ExportModels(registry Registry, dir string) ([]string, error) {
+	models, err := registry.ListModels()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, errors.NewInternal("failed to create '%s': %s", dir, err)
+	}
+
+	export := NewConfigModelRegistry(Config{Path: dir})
+	var paths []string
+	for _, model := range models {
+		if err := export.AddModel(model); err != nil {
+			return paths, errors.NewInternal("failed to export model '%s': %s", model, err)
+		}
+		paths = append(paths, export.getDescriptorFile(model.Name, model.Version))
+	}
+	return paths, nil
+}
+
+// ReadDescriptors reads the model descriptors in the given directory
+// Descriptors that cannot be read are returned as errors keyed by path, so one bad file doesn't prevent
+// the others from being read.
+func ReadDescriptors(dir string) ([]configmodel.ModelInfo, map[string]error, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+jsonExt))
+	if err != nil {
+		return nil, nil, errors.NewInvalid(err.Error())
+	}
+	sort.Strings(paths)
+
+	var models []configmodel.ModelInfo
+	failures := make(map[string]error)
+	for _, path := range paths {
+		model, err := loadModel(path)
+		if err != nil {
+			failures[path] = err
+			continue
+		}
+		models = append(models, model)
+	}
+	return models, failures, nil
+}
+
+// ImportModels pushes the models described by the descriptors in the given directory to the registry
+// Models that already exist in the registry are skipped unless overwrite is set, in which case they're
+// replaced. The models' plugins are compiled by the registry as if the models were pushed one at a time.
+func ImportModels(ctx context.Context, client configmodelapi.ConfigModelRegistryServiceClient, dir string, overwrite bool) (ImportResult, error) {
+	models, failures, err := ReadDescriptors(dir)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	result := ImportResult{
+		Failed: make(map[string]string),
+	}
+	for path, err := range failures {
+		result.Failed[path] = err.Error()
+	}
+	for _, model := range models {
+		err := importModel(ctx, client, model, overwrite)
+		if err == nil {
+			result.Imported = append(result.Imported, model.String())
+		} else if errors.IsAlreadyExists(errors.FromGRPC(err)) {
+			result.Skipped = append(result.Skipped, model.String())
+		} else {
+			result.Failed[model.String()] = errors.FromGRPC(err).Error()
+		}
+	}
+	return result, nil
+}
+
+// importModel pushes the given model as it was pushed when it was added to a registry
+func importModel(ctx context.Context, client configmodelapi.ConfigModelRegistryServiceClient, modelInfo configmodel.ModelInfo, overwrite bool) error {
+	files, err := newAPIFiles(modelInfo)
+	if err != nil {
+		return err
+	}
+
+	// Deviation modules are pushed as modules of the model and named in the metadata
+	model := &configmodelapi.ConfigModel{
+		Name:         string(modelInfo.Name),
+		Version:      string(modelInfo.Version),
+		GetStateMode: newAPIGetStateMode(modelInfo.GetStateMode),
+		Files:        files,
+	}
+	var deviations []string
+	for _, moduleInfo := range append(modelInfo.Modules, modelInfo.Deviations...) {
+		model.Modules = append(model.Modules, &configmodelapi.ConfigModule{
+			Name:         string(moduleInfo.Name),
+			Organization: moduleInfo.Organization,
+			Revision:     string(moduleInfo.Revision),
+			File:         moduleInfo.File,
+		})
+	}
+	for _, moduleInfo := range modelInfo.Deviations {
+		deviations = append(deviations, string(moduleInfo.Name))
+	}
+
+	ctx = WithFeatures(ctx, modelInfo.Features...)
+	ctx = WithDeviations(ctx, deviations...)
+	if len(modelInfo.Labels) > 0 {
+		ctx = WithLabels(ctx, modelInfo.Labels)
+	}
+	if overwrite {
+		ctx = WithForce(ctx)
+	}
+	_, err = client.PushModel(ctx, &configmodelapi.PushModelRequest{Model: model})
+	return err
+}
@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestImportExportModels(t *testing.T) {
+	source := NewConfigModelRegistry(Config{Path: filepath.Join(t.TempDir(), "source")})
+	model := configmodel.ModelInfo{
+		Name:         "test",
+		Version:      "1.0.0",
+		GetStateMode: configmodel.GetStateOpState,
+		Modules:      []configmodel.ModuleInfo{{Name: "state", File: "state.yang", Revision: "2020-11-18"}},
+		Deviations:   []configmodel.ModuleInfo{{Name: "state-deviations", File: "state-deviations.yang"}},
+		Files: []configmodel.FileInfo{
+			{Path: "state.yang", Data: []byte(stateYang)},
+			{Path: "state-deviations.yang", Data: []byte("module state-deviations {}")},
+		},
+		Features: []string{"foo"},
+		Labels:   map[string]string{"vendor": "onf"},
+	}
+	assert.NoError(t, source.AddModel(model))
+
+	dir := filepath.Join(t.TempDir(), "export")
+	paths, err := ExportModels(source, dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "test-1.0.0.json")}, paths)
+
+	// Unreadable descriptors are reported as failures
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "bad-1.0.0.json"), []byte("{"), 0666))
+
+	server := newTestServer(t)
+	client := newTestClient(t, server)
+	entry := server.cache.Entry("test", "1.0.0")
+	assert.NoError(t, ioutil.WriteFile(entry.Path, []byte("plugin"), 0666))
+	ctx := WithSkipCompile(context.Background())
+	result, err := ImportModels(ctx, client, dir, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"test@1.0.0"}, result.Imported)
+	assert.Empty(t, result.Skipped)
+	assert.Contains(t, result.Failed, filepath.Join(dir, "bad-1.0.0.json"))
+
+	imported, err := server.registry.GetModel("test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, model.GetStateMode, imported.GetStateMode)
+	assert.Equal(t, model.Modules, imported.Modules)
+	assert.Equal(t, model.Deviations, imported.Deviations)
+	assert.Equal(t, model.Features, imported.Features)
+	assert.Equal(t, model.Labels, imported.Labels)
+	assert.Len(t, imported.Files, 2)
+
+	// Existing models are skipped unless overwritten
+	result, err = ImportModels(ctx, client, dir, false)
+	assert.NoError(t, err)
+	assert.Empty(t, result.Imported)
+	assert.Equal(t, []string{"test@1.0.0"}, result.Skipped)
+
+	result, err = ImportModels(ctx, client, dir, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"test@1.0.0"}, result.Imported)
+	assert.Empty(t, result.Skipped)
+}
@@ -0,0 +1,216 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	"encoding/json"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// ModelLabelsKey is the GetModel response header containing the JSON encoded labels of the model
+	// The registry API's model message has no field for labels.
+	ModelLabelsKey = "config-model-model-labels"
+	// ListLabelsKey is the ListModels response header containing the JSON encoded labels of the listed
+	// models that have labels, keyed by name@version
+	ListLabelsKey = "config-model-list-labels"
+)
+
+// labelKeyPattern matches valid label keys, which may be prefixed like Kubernetes label keys
+var labelKeyPattern = regexp.MustCompile(`^[a-zA-Z0-9]([-a-zA-Z0-9_./]*[a-zA-Z0-9])?$`)
+
+// labelValuePattern matches valid label values, which may be empty
+var labelValuePattern = regexp.MustCompile(`^([a-zA-Z0-9]([-a-zA-Z0-9_.]*[a-zA-Z0-9])?)?$`)
+
+// ValidateLabels checks that the given label keys and values can be used in label selectors
+func ValidateLabels(labels map[string]string) error {
+	for key, value := range labels {
+		if !labelKeyPattern.MatchString(key) {
+			return errors.NewInvalid("label key '%s' is not valid", key)
+		}
+		if !labelValuePattern.MatchString(value) {
+			return errors.NewInvalid("value '%s' of label '%s' is not valid", value, key)
+		}
+	}
+	return nil
+}
+
+// LabelSelector selects models by their labels
+type LabelSelector []labelRequirement
+
+// labelRequirement is a requirement that a label equals or does not equal a value
+type labelRequirement struct {
+	key   string
+	value string
+	equal bool
+}
+
+// ParseLabelSelector parses a comma separated list of key=value or key!=value label requirements
+// Models are selected if they meet every requirement. A label that is not set does not equal any value.
+// An empty selector selects all models.
+func ParseLabelSelector(selector string) (LabelSelector, error) {
+	var requirements LabelSelector
+	if strings.TrimSpace(selector) == "" {
+		return requirements, nil
+	}
+	for _, term := range strings.Split(selector, ",") {
+		term = strings.TrimSpace(term)
+		requirement := labelRequirement{equal: true}
+		var key, value string
+		if i := strings.Index(term, "!="); i >= 0 {
+			key, value = term[:i], term[i+2:]
+			requirement.equal = false
+		} else if i := strings.Index(term, "=="); i >= 0 {
+			key, value = term[:i], term[i+2:]
+		} else if i := strings.Index(term, "="); i >= 0 {
+			key, value = term[:i], term[i+1:]
+		} else {
+			return nil, errors.NewInvalid("label selector requirement '%s' is not in the key=value or key!=value format", term)
+		}
+		requirement.key, requirement.value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if err := ValidateLabels(map[string]string{requirement.key: requirement.value}); err != nil {
+			return nil, errors.NewInvalid("invalid label selector '%s': %s", selector, err)
+		}
+		requirements = append(requirements, requirement)
+	}
+	return requirements, nil
+}
+
+// Matches returns whether the given labels meet the selector's requirements
+func (s LabelSelector) Matches(labels map[string]string) bool {
+	for _, requirement := range s {
+		value, ok := labels[requirement.key]
+		if (ok && value == requirement.value) != requirement.equal {
+			return false
+		}
+	}
+	return true
+}
+
+// GetLabels gets the labels of the given model
+func GetLabels(ctx context.Context, client configmodelapi.ConfigModelRegistryServiceClient, name configmodel.Name, version configmodel.Version) (map[string]string, error) {
+	var header metadata.MD
+	request := &configmodelapi.GetModelRequest{
+		Name:    string(name),
+		Version: string(version),
+	}
+	if _, err := client.GetModel(ctx, request, grpc.Header(&header)); err != nil {
+		return nil, err
+	}
+	values := header.Get(ModelLabelsKey)
+	if len(values) == 0 {
+		return nil, nil
+	}
+	var labels map[string]string
+	if err := json.Unmarshal([]byte(values[0]), &labels); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+// ListLabels lists the labels of the models in the registry that have labels, keyed by name@version
+// If the context has a label selector, only the labels of the selected models are listed.
+func ListLabels(ctx context.Context, client configmodelapi.ConfigModelRegistryServiceClient) (map[string]map[string]string, error) {
+	var header metadata.MD
+	if _, err := client.ListModels(ctx, &configmodelapi.ListModelsRequest{}, grpc.Header(&header)); err != nil {
+		return nil, err
+	}
+	labels := make(map[string]map[string]string)
+	if values := header.Get(ListLabelsKey); len(values) > 0 {
+		if err := json.Unmarshal([]byte(values[0]), &labels); err != nil {
+			return nil, err
+		}
+	}
+	return labels, nil
+}
+
+// getLabels returns the labels of a pushed model from the incoming metadata
+func getLabels(ctx context.Context) (map[string]string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, nil
+	}
+	values := md.Get(LabelsKey)
+	if len(values) == 0 {
+		return nil, nil
+	}
+	labels := make(map[string]string)
+	for _, value := range values {
+		i := strings.Index(value, "=")
+		if i < 0 {
+			return nil, errors.NewInvalid("label '%s' is not in the key=value format", value)
+		}
+		labels[value[:i]] = value[i+1:]
+	}
+	if err := ValidateLabels(labels); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+// selectModels returns the models selected by the given selector
+func selectModels(modelInfos []configmodel.ModelInfo, selector LabelSelector) []configmodel.ModelInfo {
+	var selected []configmodel.ModelInfo
+	for _, modelInfo := range modelInfos {
+		if selector.Matches(modelInfo.Labels) {
+			selected = append(selected, modelInfo)
+		}
+	}
+	return selected
+}
+
+// sendModelLabels sends the labels of the given model in the response headers
+func sendModelLabels(ctx context.Context, modelInfo configmodel.ModelInfo) {
+	if len(modelInfo.Labels) == 0 {
+		return
+	}
+	bytes, err := json.Marshal(modelInfo.Labels)
+	if err != nil {
+		log.Warnf("Failed to encode labels for model '%s': %s", modelInfo, err)
+		return
+	}
+	if err := grpc.SetHeader(ctx, metadata.Pairs(ModelLabelsKey, string(bytes))); err != nil {
+		log.Debugf("Failed to send labels: %s", err)
+	}
+}
+
+// sendListLabels sends the labels of the given models in the response headers
+func sendListLabels(ctx context.Context, modelInfos []configmodel.ModelInfo) {
+	labels := make(map[string]map[string]string)
+	for _, modelInfo := range modelInfos {
+		if len(modelInfo.Labels) > 0 {
+			labels[modelInfo.String()] = modelInfo.Labels
+		}
+	}
+	if len(labels) == 0 {
+		return
+	}
+	bytes, err := json.Marshal(labels)
+	if err != nil {
+		log.Warnf("Failed to encode labels: %s", err)
+		return
+	}
+	if err := grpc.SetHeader(ctx, metadata.Pairs(ListLabelsKey, string(bytes))); err != nil {
+		log.Debugf("Failed to send labels: %s", err)
+	}
+}
+
+// formatLabels formats the given labels as sorted key=value pairs for request metadata
+func formatLabels(labels map[string]string) []string {
+	pairs := make([]string, 0, len(labels))
+	for key, value := range labels {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return pairs
+}
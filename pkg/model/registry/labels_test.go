@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io/ioutil"
+	"testing"
+)
+
+func TestParseLabelSelector(t *testing.T) {
+	labels := map[string]string{
+		"vendor": "cisco",
+		"env":    "prod",
+	}
+	tests := []struct {
+		selector string
+		matches  bool
+	}{
+		{"", true},
+		{"vendor=cisco", true},
+		{"vendor==cisco", true},
+		{"vendor=cisco,env=prod", true},
+		{" vendor = cisco , env = prod ", true},
+		{"vendor=cisco,env=dev", false},
+		{"vendor!=juniper", true},
+		{"vendor!=cisco", false},
+		{"family=router", false},
+		{"family!=router", true},
+	}
+	for _, test := range tests {
+		selector, err := ParseLabelSelector(test.selector)
+		assert.NoError(t, err, test.selector)
+		assert.Equal(t, test.matches, selector.Matches(labels), test.selector)
+	}
+
+	for _, selector := range []string{"vendor", "vendor=cisco,", "=cisco", "vendor=cis co"} {
+		_, err := ParseLabelSelector(selector)
+		assert.True(t, errors.IsInvalid(err), selector)
+	}
+}
+
+func TestModelLabels(t *testing.T) {
+	server := newTestServer(t)
+	client := newTestClient(t, server)
+
+	push := func(name string, labels map[string]string) error {
+		entry := server.cache.Entry(configmodel.Name(name), "1.0.0")
+		assert.NoError(t, ioutil.WriteFile(entry.Path, []byte("plugin"), 0666))
+		ctx := WithLabels(WithSkipCompile(context.Background()), labels)
+		_, err := client.PushModel(ctx, &configmodelapi.PushModelRequest{
+			Model: &configmodelapi.ConfigModel{
+				Name:    name,
+				Version: "1.0.0",
+			},
+		})
+		return err
+	}
+	assert.NoError(t, push("model1", map[string]string{"vendor": "cisco", "env": "prod"}))
+	assert.NoError(t, push("model2", map[string]string{"vendor": "cisco", "env": "dev"}))
+	assert.NoError(t, push("model3", map[string]string{"vendor": "juniper", "env": "prod"}))
+	assert.NoError(t, push("model4", nil))
+
+	// Labels are persisted with the model
+	model, err := server.registry.GetModel("model1", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"vendor": "cisco", "env": "prod"}, model.Labels)
+
+	labels, err := GetLabels(context.Background(), client, "model2", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"vendor": "cisco", "env": "dev"}, labels)
+	labels, err = GetLabels(context.Background(), client, "model4", "1.0.0")
+	assert.NoError(t, err)
+	assert.Empty(t, labels)
+
+	// An empty selector lists all models
+	response, err := client.ListModels(context.Background(), &configmodelapi.ListModelsRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, response.Models, 4)
+
+	ctx := WithSelector(context.Background(), "vendor=cisco,env=prod")
+	response, err = client.ListModels(ctx, &configmodelapi.ListModelsRequest{})
+	assert.NoError(t, err)
+	if assert.Len(t, response.Models, 1) {
+		assert.Equal(t, "model1", response.Models[0].Name)
+	}
+	listLabels, err := ListLabels(ctx, client)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{
+		"model1@1.0.0": {"vendor": "cisco", "env": "prod"},
+	}, listLabels)
+
+	// Pages are filled with selected models
+	ctx = WithSelector(context.Background(), "env=prod")
+	page, token, err := ListModelsPage(ctx, client, 1, "")
+	assert.NoError(t, err)
+	if assert.Len(t, page, 1) {
+		assert.Equal(t, "model1", page[0].Name)
+	}
+	page, token, err = ListModelsPage(ctx, client, 1, token)
+	assert.NoError(t, err)
+	if assert.Len(t, page, 1) {
+		assert.Equal(t, "model3", page[0].Name)
+	}
+	assert.Empty(t, token)
+
+	// Invalid labels and selectors are rejected
+	err = push("model5", map[string]string{"vendor": "cis co"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.ListModels(WithSelector(context.Background(), "vendor"), &configmodelapi.ListModelsRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+)
+
+const (
+	defaultMaxModelBytes = 64 * 1024 * 1024
+	defaultMaxModules    = 1000
+	defaultMaxFileBytes  = 16 * 1024 * 1024
+)
+
+// getMaxModelBytes returns the maximum total size of the YANG files of a pushed model
+func (s *Server) getMaxModelBytes() int64 {
+	if s.config.MaxModelBytes <= 0 {
+		return defaultMaxModelBytes
+	}
+	return s.config.MaxModelBytes
+}
+
+// getMaxModules returns the maximum number of modules in a pushed model
+func (s *Server) getMaxModules() int {
+	if s.config.MaxModules <= 0 {
+		return defaultMaxModules
+	}
+	return s.config.MaxModules
+}
+
+// getMaxFileBytes returns the maximum size of each YANG file of a pushed model
+func (s *Server) getMaxFileBytes() int64 {
+	if s.config.MaxFileBytes <= 0 {
+		return defaultMaxFileBytes
+	}
+	return s.config.MaxFileBytes
+}
+
+// checkLimits checks that a pushed model is within the size limits of the server
+// Pushed models are written to the registry and compiled, so oversized models are rejected before
+// they consume disk space or compile workers.
+func (s *Server) checkLimits(model *configmodelapi.ConfigModel) error {
+	if modules := len(model.GetModules()); modules > s.getMaxModules() {
+		return errors.NewInvalid("model '%s@%s' has %d modules, exceeding the limit of %d", model.GetName(), model.GetVersion(), modules, s.getMaxModules())
+	}
+	var total int64
+	for path, data := range model.GetFiles() {
+		size := int64(len(data))
+		if size > s.getMaxFileBytes() {
+			return errors.NewInvalid("file '%s' of model '%s@%s' is %d bytes, exceeding the limit of %d bytes", path, model.GetName(), model.GetVersion(), size, s.getMaxFileBytes())
+		}
+		total += size
+	}
+	if total > s.getMaxModelBytes() {
+		return errors.NewInvalid("files of model '%s@%s' total %d bytes, exceeding the limit of %d bytes", model.GetName(), model.GetVersion(), total, s.getMaxModelBytes())
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func newLimitsTestModel() *configmodelapi.ConfigModel {
+	return &configmodelapi.ConfigModel{
+		Name:    "test",
+		Version: "1.0.0",
+		Modules: []*configmodelapi.ConfigModule{
+			{Name: "foo", File: "foo.yang"},
+			{Name: "bar", File: "bar.yang"},
+		},
+		Files: map[string]string{
+			"foo.yang": strings.Repeat("a", 60),
+			"bar.yang": strings.Repeat("b", 60),
+		},
+	}
+}
+
+func assertNothingWritten(t *testing.T, server *Server) {
+	models, err := server.registry.ListModels()
+	assert.NoError(t, err)
+	assert.Len(t, models, 0)
+	paths, err := server.cache.List()
+	assert.NoError(t, err)
+	assert.Len(t, paths, 0)
+}
+
+func TestMaxModules(t *testing.T) {
+	server := newTestServer(t)
+	server.config.MaxModules = 1
+	client := newTestClient(t, server)
+
+	_, err := client.PushModel(context.Background(), &configmodelapi.PushModelRequest{Model: newLimitsTestModel()})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), "2 modules, exceeding the limit of 1")
+
+	// Limits also apply to validated, tried out and streamed pushes
+	_, err = ValidateModelRemote(context.Background(), newTestConn(t, server), newLimitsTestModel())
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.PushModel(WithTryout(context.Background()), &configmodelapi.PushModelRequest{Model: newLimitsTestModel()})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	err = PushModelStream(context.Background(), newTestConn(t, server), newLimitsTestModel(), func(PushEvent) {})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assertNothingWritten(t, server)
+}
+
+func TestMaxFileBytes(t *testing.T) {
+	server := newTestServer(t)
+	server.config.MaxFileBytes = 50
+	client := newTestClient(t, server)
+
+	_, err := client.PushModel(context.Background(), &configmodelapi.PushModelRequest{Model: newLimitsTestModel()})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), "60 bytes, exceeding the limit of 50 bytes")
+	assertNothingWritten(t, server)
+}
+
+func TestMaxModelBytes(t *testing.T) {
+	server := newTestServer(t)
+	server.config.MaxModelBytes = 100
+	client := newTestClient(t, server)
+
+	_, err := client.PushModel(context.Background(), &configmodelapi.PushModelRequest{Model: newLimitsTestModel()})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), "total 120 bytes, exceeding the limit of 100 bytes")
+	assertNothingWritten(t, server)
+
+	// Models within the limits are pushed
+	server.config.MaxModelBytes = 120
+	assert.NoError(t, ioutil.WriteFile(server.cache.Entry("test", "1.0.0").Path, []byte("plugin"), 0666))
+	_, err = client.PushModel(WithSkipCompile(context.Background()), &configmodelapi.PushModelRequest{Model: newLimitsTestModel()})
+	assert.NoError(t, err)
+}
+
+func TestDefaultLimits(t *testing.T) {
+	server := newTestServer(t)
+	assert.Equal(t, int64(defaultMaxModelBytes), server.getMaxModelBytes())
+	assert.Equal(t, defaultMaxModules, server.getMaxModules())
+	assert.Equal(t, int64(defaultMaxFileBytes), server.getMaxFileBytes())
+	assert.NoError(t, server.checkLimits(newLimitsTestModel()))
+}
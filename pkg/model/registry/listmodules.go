@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	"encoding/json"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"sort"
+)
+
+// The registry API has no method for listing modules, so ListModules is provided by a separate service.
+// The service returns the JSON encoded ModuleUsages as a string value.
+const (
+	moduleServiceName = "onos.configmodel.ConfigModelRegistryModuleService"
+	listModulesMethod = "ListModules"
+)
+
+// ModuleUsage is a distinct YANG module revision and the models in the registry that reference it
+type ModuleUsage struct {
+	Name         configmodel.Name     `json:"name"`
+	Revision     configmodel.Revision `json:"revision,omitempty"`
+	Organization string               `json:"organization,omitempty"`
+	Namespace    string               `json:"namespace,omitempty"`
+	// Models are the models referencing the module, formatted as name@version
+	Models []string `json:"models"`
+}
+
+func (m ModuleUsage) String() string {
+	return getModuleKey(m.Name, m.Revision)
+}
+
+// moduleServer is the server API for the module service
+type moduleServer interface {
+	ListModules(ctx context.Context, request *emptypb.Empty) (*wrapperspb.StringValue, error)
+}
+
+var moduleServiceDesc = grpc.ServiceDesc{
+	ServiceName: moduleServiceName,
+	HandlerType: (*moduleServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: listModulesMethod,
+			Handler:    listModulesHandler,
+		},
+	},
+}
+
+func listModulesHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	request := &emptypb.Empty{}
+	if err := dec(request); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(moduleServer).ListModules(ctx, request)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/" + moduleServiceName + "/" + listModulesMethod,
+	}
+	handler := func(ctx context.Context, request interface{}) (interface{}, error) {
+		return srv.(moduleServer).ListModules(ctx, request.(*emptypb.Empty))
+	}
+	return interceptor(ctx, request, info, handler)
+}
+
+// registerModuleService registers the module service for the given server
+func registerModuleService(r *grpc.Server, server *Server) {
+	r.RegisterService(&moduleServiceDesc, server)
+}
+
+// ListModules lists the distinct module revisions referenced by the models in the registry
+// Models can be selected by their labels by passing a context created WithSelector.
+func ListModules(ctx context.Context, conn grpc.ClientConnInterface) ([]ModuleUsage, error) {
+	response := &wrapperspb.StringValue{}
+	if err := conn.Invoke(ctx, "/"+moduleServiceName+"/"+listModulesMethod, &emptypb.Empty{}, response); err != nil {
+		return nil, err
+	}
+	var modules []ModuleUsage
+	if err := json.Unmarshal([]byte(response.Value), &modules); err != nil {
+		return nil, err
+	}
+	return modules, nil
+}
+
+// ListModules :
+func (s *Server) ListModules(ctx context.Context, request *emptypb.Empty) (*wrapperspb.StringValue, error) {
+	log.Debugf("Received ListModulesRequest %+v", request)
+	s.sendCapabilities(ctx)
+
+	selector, err := ParseLabelSelector(getStringMetadata(ctx, SelectorKey))
+	if err != nil {
+		log.Warnf("ListModulesRequest %+v failed: %v", request, err)
+		return nil, errors.Status(err).Err()
+	}
+
+	s.mu.RLock()
+	modelInfos, err := s.registry.ListModels()
+	s.mu.RUnlock()
+	if err != nil {
+		log.Warnf("ListModulesRequest %+v failed: %v", request, err)
+		return nil, errors.Status(err).Err()
+	}
+
+	bytes, err := json.Marshal(getModuleUsages(selectModels(modelInfos, selector)))
+	if err != nil {
+		log.Warnf("ListModulesRequest %+v failed: %v", request, err)
+		return nil, errors.Status(errors.NewInternal(err.Error())).Err()
+	}
+	response := wrapperspb.String(string(bytes))
+	log.Debugf("Sending ListModulesResponse %+v", response)
+	return response, nil
+}
+
+// getModuleUsages returns the distinct module revisions referenced by the given models, sorted by name and revision
+// Modules are identified by name and revision, so the same revision of a module referenced by different
+// files is listed once. Deviation modules are listed along with the modules they deviate.
+func getModuleUsages(modelInfos []configmodel.ModelInfo) []ModuleUsage {
+	usages := make(map[string]*ModuleUsage)
+	for _, modelInfo := range modelInfos {
+		referenced := make(map[string]bool)
+		for _, module := range append(append([]configmodel.ModuleInfo{}, modelInfo.Modules...), modelInfo.Deviations...) {
+			key := getModuleKey(module.Name, module.Revision)
+			usage, ok := usages[key]
+			if !ok {
+				usage = &ModuleUsage{
+					Name:     module.Name,
+					Revision: module.Revision,
+					Models:   []string{},
+				}
+				usages[key] = usage
+			}
+			if usage.Organization == "" {
+				usage.Organization = module.Organization
+			}
+			if usage.Namespace == "" {
+				usage.Namespace = module.Namespace
+			}
+			if !referenced[key] {
+				referenced[key] = true
+				usage.Models = append(usage.Models, modelInfo.String())
+			}
+		}
+	}
+
+	modules := make([]ModuleUsage, 0, len(usages))
+	for _, usage := range usages {
+		sort.Strings(usage.Models)
+		modules = append(modules, *usage)
+	}
+	sort.Slice(modules, func(i, j int) bool {
+		if modules[i].Name != modules[j].Name {
+			return modules[i].Name < modules[j].Name
+		}
+		return modules[i].Revision < modules[j].Revision
+	})
+	return modules
+}
+
+// getModuleKey returns the key identifying a module revision, formatted as name@revision
+func getModuleKey(name configmodel.Name, revision configmodel.Revision) string {
+	if revision == "" {
+		return string(name)
+	}
+	return string(name) + "@" + string(revision)
+}
@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestListModules(t *testing.T) {
+	server := newTestServer(t)
+	conn := newTestConn(t, server)
+
+	modules, err := ListModules(context.Background(), conn)
+	assert.NoError(t, err)
+	assert.Empty(t, modules)
+
+	assert.NoError(t, server.registry.AddModel(configmodel.ModelInfo{
+		Name:    "foo",
+		Version: "1.0.0",
+		Modules: []configmodel.ModuleInfo{
+			{Name: "interfaces", File: "interfaces.yang", Organization: "ONF", Revision: "2020-01-01", Namespace: "urn:interfaces"},
+			{Name: "system", File: "system.yang", Revision: "2020-01-01"},
+		},
+		Deviations: []configmodel.ModuleInfo{
+			{Name: "foo-deviations", File: "foo-deviations.yang"},
+		},
+		Labels: map[string]string{"vendor": "foo"},
+	}))
+	assert.NoError(t, server.registry.AddModel(configmodel.ModelInfo{
+		Name:    "bar",
+		Version: "2.0.0",
+		Modules: []configmodel.ModuleInfo{
+			{Name: "interfaces", File: "bar/interfaces.yang", Revision: "2020-01-01"},
+			{Name: "system", File: "system.yang", Revision: "2021-01-01"},
+		},
+		Labels: map[string]string{"vendor": "bar"},
+	}))
+
+	// Modules are listed once per revision with the models referencing them
+	modules, err = ListModules(context.Background(), conn)
+	assert.NoError(t, err)
+	assert.Equal(t, []ModuleUsage{
+		{Name: "foo-deviations", Models: []string{"foo@1.0.0"}},
+		{Name: "interfaces", Revision: "2020-01-01", Organization: "ONF", Namespace: "urn:interfaces", Models: []string{"bar@2.0.0", "foo@1.0.0"}},
+		{Name: "system", Revision: "2020-01-01", Models: []string{"foo@1.0.0"}},
+		{Name: "system", Revision: "2021-01-01", Models: []string{"bar@2.0.0"}},
+	}, modules)
+	assert.Equal(t, "interfaces@2020-01-01", modules[1].String())
+
+	// Models can be selected by label
+	modules, err = ListModules(WithSelector(context.Background(), "vendor=bar"), conn)
+	assert.NoError(t, err)
+	assert.Len(t, modules, 2)
+	_, err = ListModules(WithSelector(context.Background(), "vendor"), conn)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
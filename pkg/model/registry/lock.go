@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"fmt"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// lockFile is the name of the file locked by the process serving a registry
+const lockFile = ".lock"
+
+// lockTakeoverTimeout is the time for which a forced lock waits for the lock holder to release the lock
+var lockTakeoverTimeout = 10 * time.Second
+
+// lockTakeoverInterval is the interval at which a forced lock retries acquiring the lock
+const lockTakeoverInterval = 100 * time.Millisecond
+
+// Lock acquires an advisory lock on the registry path, failing if another process holds it
+// Only one process may modify a registry at a time, so servers lock writable registries before serving them.
+// The lock is released when the process exits. If force is set, the lock file is kept and its lock is taken over
+// once released, waiting for a shutting down server rather than failing fast. Since unlinking the lock file would
+// leave the holder locking an orphaned inode, a lock that is still held once the wait is over can't be broken.
+func (r *ConfigModelRegistry) Lock(force bool) error {
+	if r.IsReadOnly() {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.lock != nil {
+		return nil
+	}
+
+	path := filepath.Join(r.Config.Path, lockFile)
+	fh, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0666)
+	if err != nil {
+		return errors.NewInternal("failed to open registry lock '%s': %s", path, err)
+	}
+	err = syscall.Flock(int(fh.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err == syscall.EWOULDBLOCK && force {
+		log.Warnf("Waiting up to %s to take over the lock on registry '%s'%s", lockTakeoverTimeout, r.Config.Path, getLockOwner(path))
+		deadline := time.Now().Add(lockTakeoverTimeout)
+		for err == syscall.EWOULDBLOCK && time.Now().Before(deadline) {
+			time.Sleep(lockTakeoverInterval)
+			err = syscall.Flock(int(fh.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		}
+		if err == syscall.EWOULDBLOCK {
+			_ = fh.Close()
+			return errors.NewConflict("registry '%s' is still locked by a running process%s after %s; the lock can't be taken over until that process stops", r.Config.Path, getLockOwner(path), lockTakeoverTimeout)
+		}
+	}
+	if err != nil {
+		_ = fh.Close()
+		if err == syscall.EWOULDBLOCK {
+			return errors.NewConflict("registry '%s' is locked by another process%s; stop the other server or wait for it to release the lock with force", r.Config.Path, getLockOwner(path))
+		}
+		return errors.NewInternal("failed to acquire registry lock '%s': %s", path, err)
+	}
+
+	// Record the pid of the lock owner to identify it to processes that fail to acquire the lock
+	if err := fh.Truncate(0); err == nil {
+		_, err = fh.WriteAt([]byte(fmt.Sprintf("%d\n", os.Getpid())), 0)
+		if err != nil {
+			log.Warnf("Failed to record registry lock owner: %s", err)
+		}
+	}
+	r.lock = fh
+	return nil
+}
+
+// Unlock releases the advisory lock on the registry path
+func (r *ConfigModelRegistry) Unlock() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.lock == nil {
+		return nil
+	}
+	fh := r.lock
+	r.lock = nil
+	if err := syscall.Flock(int(fh.Fd()), syscall.LOCK_UN); err != nil {
+		_ = fh.Close()
+		return errors.NewInternal("failed to release registry lock: %s", err)
+	}
+	return fh.Close()
+}
+
+// getLockOwner returns a description of the process holding the given lock file, if known
+func getLockOwner(path string) string {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	pid := strings.TrimSpace(string(bytes))
+	if pid == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (pid %s)", pid)
+}
@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"fmt"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRegistryLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-model-registry")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	registry1 := NewConfigModelRegistry(Config{Path: dir})
+	assert.NoError(t, registry1.Lock(false))
+	assert.NoError(t, registry1.Lock(false))
+
+	// Another registry at the same path fails fast, identifying the lock owner
+	registry2 := NewConfigModelRegistry(Config{Path: dir})
+	err = registry2.Lock(false)
+	assert.True(t, errors.IsConflict(err))
+	assert.Contains(t, err.Error(), fmt.Sprintf("pid %d", os.Getpid()))
+
+	// The lock file doesn't affect the models in the registry
+	assert.NoError(t, registry1.AddModel(configmodel.ModelInfo{Name: "test", Version: "1.0.0"}))
+	models, err := registry2.ListModels()
+	assert.NoError(t, err)
+	assert.Len(t, models, 1)
+
+	// Released locks can be acquired
+	assert.NoError(t, registry1.Unlock())
+	assert.NoError(t, registry2.Lock(false))
+
+	// Forced locks take over the existing lock file once it's released
+	info, err := os.Stat(filepath.Join(dir, lockFile))
+	assert.NoError(t, err)
+	assert.True(t, errors.IsConflict(registry1.Lock(false)))
+	time.AfterFunc(50*time.Millisecond, func() {
+		assert.NoError(t, registry2.Unlock())
+	})
+	assert.NoError(t, registry1.Lock(true))
+	takenOver, err := os.Stat(filepath.Join(dir, lockFile))
+	assert.NoError(t, err)
+	assert.True(t, os.SameFile(info, takenOver))
+
+	// Forced locks fail with a clear error if the lock isn't released in time
+	timeout := lockTakeoverTimeout
+	lockTakeoverTimeout = 200 * time.Millisecond
+	defer func() {
+		lockTakeoverTimeout = timeout
+	}()
+	err = registry2.Lock(true)
+	assert.True(t, errors.IsConflict(err))
+	assert.Contains(t, err.Error(), "still locked")
+	assert.NoError(t, registry1.Unlock())
+
+	// Read-only registries aren't locked
+	readOnly := NewConfigModelRegistry(Config{Path: dir, ReadOnly: true})
+	assert.NoError(t, readOnly.Lock(false))
+	assert.NoError(t, registry1.Lock(false))
+	assert.NoError(t, readOnly.Lock(false))
+	assert.NoError(t, registry1.Unlock())
+}
@@ -0,0 +1,192 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"encoding/json"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	configMapAPIVersion = "v1"
+	configMapKind       = "ConfigMap"
+	// manifestDescriptorKey is the ConfigMap key of the model descriptor
+	manifestDescriptorKey = "model.json"
+	// manifestNameAnnotation and manifestVersionAnnotation identify the model of a manifest, since the
+	// characters allowed in Kubernetes resource names and label values are more restricted than model names
+	manifestNameAnnotation    = "config-model.onosproject.org/name"
+	manifestVersionAnnotation = "config-model.onosproject.org/version"
+	manifestManagedByLabel    = "app.kubernetes.io/managed-by"
+	manifestManagedBy         = "config-model"
+	maxResourceNameLen        = 253
+	yamlExt                   = ".yaml"
+)
+
+// resourceNameInvalidChars matches the characters that are not allowed in Kubernetes resource names
+var resourceNameInvalidChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// configMapKeyInvalidChars matches the characters that are not allowed in ConfigMap keys
+var configMapKeyInvalidChars = regexp.MustCompile(`[^-._a-zA-Z0-9]+`)
+
+// ManifestConfig is the configuration of the Kubernetes manifests exported for models
+type ManifestConfig struct {
+	// APIVersion is the API version of the resources, defaulting to v1 for ConfigMaps
+	APIVersion string
+	// Kind is the kind of the resources, defaulting to ConfigMap
+	// Models are exported to the data of ConfigMaps and to the spec of any other kind of resource.
+	Kind string
+	// Namespace is the namespace of the resources, which is omitted if empty
+	Namespace string
+	// IncludeFiles indicates whether the models' YANG files are included in the resources
+	IncludeFiles bool
+}
+
+// Manifest is a Kubernetes resource manifest for a model
+type Manifest struct {
+	APIVersion string                 `yaml:"apiVersion"`
+	Kind       string                 `yaml:"kind"`
+	Metadata   ManifestMetadata       `yaml:"metadata"`
+	Data       map[string]string      `yaml:"data,omitempty"`
+	Spec       map[string]interface{} `yaml:"spec,omitempty"`
+}
+
+// ManifestMetadata is the metadata of a Kubernetes resource manifest
+type ManifestMetadata struct {
+	Name        string            `yaml:"name"`
+	Namespace   string            `yaml:"namespace,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// NewManifest returns a Kubernetes resource manifest for the given model
+// The model's descriptor is stored without its files, which are stored separately as text if they're included.
+// ConfigMaps store the descriptor as JSON with each file under a key derived from its path, and other kinds
+// of resources store the descriptor's fields in their spec with the files mapped by path.
+func NewManifest(model configmodel.ModelInfo, config ManifestConfig) (*Manifest, error) {
+	kind := config.Kind
+	if kind == "" {
+		kind = configMapKind
+	}
+	apiVersion := config.APIVersion
+	if apiVersion == "" {
+		if kind != configMapKind {
+			return nil, errors.NewInvalid("no API version configured for kind '%s'", kind)
+		}
+		apiVersion = configMapAPIVersion
+	}
+
+	name := getResourceName(model)
+	if name == "" {
+		return nil, errors.NewInvalid("model '%s' has no valid resource name", model)
+	}
+
+	files := make(map[string]string)
+	if config.IncludeFiles {
+		for _, file := range model.Files {
+			file, err := file.Decompress()
+			if err != nil {
+				return nil, errors.NewInternal("failed to decompress '%s' for model '%s': %s", file.Path, model, err)
+			}
+			files[file.Path] = string(file.Data)
+		}
+	}
+	model.Files = nil
+	descriptor, err := json.MarshalIndent(model, "", "  ")
+	if err != nil {
+		return nil, errors.NewInternal("failed to encode model '%s': %s", model, err)
+	}
+
+	manifest := &Manifest{
+		APIVersion: apiVersion,
+		Kind:       kind,
+		Metadata: ManifestMetadata{
+			Name:      name,
+			Namespace: config.Namespace,
+			Labels: map[string]string{
+				manifestManagedByLabel: manifestManagedBy,
+			},
+			Annotations: map[string]string{
+				manifestNameAnnotation:    string(model.Name),
+				manifestVersionAnnotation: string(model.Version),
+			},
+		},
+	}
+
+	if kind == configMapKind {
+		manifest.Data = map[string]string{
+			manifestDescriptorKey: string(descriptor),
+		}
+		for path, data := range files {
+			key := configMapKeyInvalidChars.ReplaceAllString(path, "_")
+			if _, ok := manifest.Data[key]; ok {
+				return nil, errors.NewInvalid("file '%s' of model '%s' conflicts with another ConfigMap key '%s'", path, model, key)
+			}
+			manifest.Data[key] = data
+		}
+		return manifest, nil
+	}
+
+	if err := json.Unmarshal(descriptor, &manifest.Spec); err != nil {
+		return nil, errors.NewInternal("failed to encode model '%s': %s", model, err)
+	}
+	delete(manifest.Spec, "files")
+	if len(files) > 0 {
+		manifest.Spec["files"] = files
+	}
+	return manifest, nil
+}
+
+// ExportManifests writes a Kubernetes resource manifest for each model in the given registry to the given directory
+// Each manifest is written to a file named for its resource. The paths of the written files are returned.
+func ExportManifests(registry Registry, dir string, config ManifestConfig) ([]string, error) {
+	models, err := registry.ListModels()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, errors.NewInternal("failed to create '%s': %s", dir, err)
+	}
+
+	var paths []string
+	names := make(map[string]configmodel.ModelInfo)
+	for _, model := range models {
+		manifest, err := NewManifest(model, config)
+		if err != nil {
+			return paths, err
+		}
+		if other, ok := names[manifest.Metadata.Name]; ok {
+			return paths, errors.NewInvalid("models '%s' and '%s' have the same resource name '%s'", other, model, manifest.Metadata.Name)
+		}
+		names[manifest.Metadata.Name] = model
+		bytes, err := yaml.Marshal(manifest)
+		if err != nil {
+			return paths, errors.NewInternal("failed to encode manifest for model '%s': %s", model, err)
+		}
+		path := filepath.Join(dir, manifest.Metadata.Name+yamlExt)
+		if err := ioutil.WriteFile(path, bytes, 0644); err != nil {
+			return paths, errors.NewInternal("failed to write manifest for model '%s': %s", model, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// getResourceName returns a Kubernetes resource name for the given model
+// Resource names are lowercase DNS subdomains, so other characters are replaced with dashes.
+func getResourceName(model configmodel.ModelInfo) string {
+	name := strings.ToLower(string(model.Name) + "-" + string(model.Version))
+	name = resourceNameInvalidChars.ReplaceAllString(name, "-")
+	if len(name) > maxResourceNameLen {
+		name = name[:maxResourceNameLen]
+	}
+	return strings.Trim(name, ".-")
+}
@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"encoding/json"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestExportManifests(t *testing.T) {
+	registry := NewConfigModelRegistry(Config{
+		Path:            filepath.Join(t.TempDir(), "registry"),
+		CompressStorage: true,
+	})
+	assert.NoError(t, registry.AddModel(configmodel.ModelInfo{
+		Name:         "Test_Model",
+		Version:      "1.0.0",
+		GetStateMode: configmodel.GetStateOpState,
+		Modules:      []configmodel.ModuleInfo{{Name: "test", File: "test@2020-11-18.yang", Revision: "2020-11-18"}},
+		Files:        []configmodel.FileInfo{{Path: "test@2020-11-18.yang", Data: []byte(stateYang)}},
+	}))
+
+	// Models are exported to ConfigMaps by default
+	dir := filepath.Join(t.TempDir(), "manifests")
+	paths, err := ExportManifests(registry, dir, ManifestConfig{Namespace: "onos", IncludeFiles: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "test-model-1.0.0.yaml")}, paths)
+
+	bytes, err := ioutil.ReadFile(paths[0])
+	assert.NoError(t, err)
+	manifest := &Manifest{}
+	assert.NoError(t, yaml.UnmarshalStrict(bytes, manifest))
+	assert.Equal(t, "v1", manifest.APIVersion)
+	assert.Equal(t, "ConfigMap", manifest.Kind)
+	assert.Equal(t, "test-model-1.0.0", manifest.Metadata.Name)
+	assert.Equal(t, "onos", manifest.Metadata.Namespace)
+	assert.Equal(t, "Test_Model", manifest.Metadata.Annotations[manifestNameAnnotation])
+	assert.Nil(t, manifest.Spec)
+
+	// YANG files are decompressed under valid ConfigMap keys
+	assert.Equal(t, stateYang, manifest.Data["test_2020-11-18.yang"])
+	model := configmodel.ModelInfo{}
+	assert.NoError(t, json.Unmarshal([]byte(manifest.Data[manifestDescriptorKey]), &model))
+	assert.Equal(t, configmodel.Name("Test_Model"), model.Name)
+	assert.Equal(t, configmodel.GetStateOpState, model.GetStateMode)
+	assert.Empty(t, model.Files)
+
+	// Other kinds of resources are exported with the model in their spec
+	paths, err = ExportManifests(registry, dir, ManifestConfig{
+		APIVersion: "config.onosproject.org/v1beta1",
+		Kind:       "ConfigModel",
+	})
+	assert.NoError(t, err)
+	bytes, err = ioutil.ReadFile(paths[0])
+	assert.NoError(t, err)
+	manifest = &Manifest{}
+	assert.NoError(t, yaml.UnmarshalStrict(bytes, manifest))
+	assert.Equal(t, "config.onosproject.org/v1beta1", manifest.APIVersion)
+	assert.Equal(t, "ConfigModel", manifest.Kind)
+	assert.Empty(t, manifest.Metadata.Namespace)
+	assert.Nil(t, manifest.Data)
+	assert.Equal(t, "Test_Model", manifest.Spec["name"])
+	assert.Equal(t, "GetStateOpState", manifest.Spec["getStateMode"])
+	assert.Len(t, manifest.Spec["modules"], 1)
+	assert.NotContains(t, manifest.Spec, "files")
+
+	// Custom kinds must have an API version
+	_, err = ExportManifests(registry, dir, ManifestConfig{Kind: "ConfigModel"})
+	assert.True(t, errors.IsInvalid(err))
+}
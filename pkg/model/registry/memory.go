@@ -0,0 +1,211 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"encoding/json"
+	"github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-config-model/pkg/model/logging"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"sort"
+	"sync"
+)
+
+// NewMemoryRegistry creates a new registry that keeps models in memory
+// Models in a memory registry are lost when the registry is discarded, so it's intended for
+// tests and ephemeral deployments.
+func NewMemoryRegistry() Registry {
+	return &memRegistry{
+		models:            make(map[string]configmodel.ModelInfo),
+		history:           make(map[string][]CompileAttempt),
+		maxCompileHistory: defaultMaxCompileHistory,
+	}
+}
+
+// memRegistry is a registry of config models stored in memory
+type memRegistry struct {
+	models            map[string]configmodel.ModelInfo
+	history           map[string][]CompileAttempt
+	maxCompileHistory int
+	publisher         modelPublisher
+	mu                sync.RWMutex
+}
+
+var _ Registry = &memRegistry{}
+
+func (r *memRegistry) String() string {
+	return "memory"
+}
+
+func (r *memRegistry) GetModel(name configmodel.Name, version configmodel.Version) (configmodel.ModelInfo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	model, ok := r.models[getModelKey(name, version)]
+	if !ok && IsVersionConstraint(version) {
+		resolved, err := r.resolveModelVersion(name, version)
+		if err != nil {
+			return configmodel.ModelInfo{}, err
+		}
+		model, ok = r.models[getModelKey(name, resolved)]
+	}
+	if !ok {
+		return configmodel.ModelInfo{}, errors.NewNotFound("Model '%s/%s' not found", name, version)
+	}
+	return copyModel(model)
+}
+
+func (r *memRegistry) ListModels() ([]configmodel.ModelInfo, error) {
+	models, _, err := r.ListModelsPage("", 0)
+	return models, err
+}
+
+// ListModelsPage lists a page of models in the registry ordered by name and version
+func (r *memRegistry) ListModelsPage(cursor string, limit int) ([]configmodel.ModelInfo, string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.listModelsPage(cursor, limit)
+}
+
+func (r *memRegistry) listModelsPage(cursor string, limit int) ([]configmodel.ModelInfo, string, error) {
+	keys := make([]string, 0, len(r.models))
+	for key := range r.models {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	keys, next := getKeysPage(keys, cursor, limit)
+
+	var models []configmodel.ModelInfo
+	for _, key := range keys {
+		model, err := copyModel(r.models[key])
+		if err != nil {
+			return nil, "", err
+		}
+		models = append(models, model)
+	}
+	return models, next, nil
+}
+
+func (r *memRegistry) AddModel(model configmodel.ModelInfo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	model.Checksum = model.ComputeChecksum()
+	stored, err := copyModel(model)
+	if err != nil {
+		log.Errorf("Adding model '%s/%s' failed: %v", model.Name, model.Version, err)
+		return err
+	}
+	r.models[getModelKey(model.Name, model.Version)] = stored
+	log.Infow("Model added to registry", modellogging.ModelField, model.Name, modellogging.VersionField, model.Version, "registry", r.String())
+	r.publisher.publish(newModelEvent(ModelAdded, model))
+	return nil
+}
+
+// Watch returns the models in the registry and a watcher receiving the models subsequently added and removed
+func (r *memRegistry) Watch() ([]configmodel.ModelInfo, *ModelWatcher, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	models, _, err := r.listModelsPage("", 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	return models, r.publisher.subscribe(), nil
+}
+
+func (r *memRegistry) RemoveModel(name configmodel.Name, version configmodel.Version) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := getModelKey(name, version)
+	removed, ok := r.models[key]
+	delete(r.models, key)
+	delete(r.history, key)
+	log.Infow("Model deleted from registry", modellogging.ModelField, name, modellogging.VersionField, version, "registry", r.String())
+	if ok {
+		r.publisher.publish(newModelEvent(ModelRemoved, removed))
+	}
+	return nil
+}
+
+func (r *memRegistry) PinModel(name configmodel.Name, version configmodel.Version) error {
+	return r.updateModel(name, version, func(model *configmodel.ModelInfo) {
+		model.Pinned = true
+	})
+}
+
+func (r *memRegistry) UnpinModel(name configmodel.Name, version configmodel.Version) error {
+	return r.updateModel(name, version, func(model *configmodel.ModelInfo) {
+		model.Pinned = false
+	})
+}
+
+func (r *memRegistry) SetBuildInfo(name configmodel.Name, version configmodel.Version, info configmodel.BuildInfo) error {
+	return r.updateModel(name, version, func(model *configmodel.ModelInfo) {
+		model.Build = &info
+	})
+}
+
+func (r *memRegistry) SetPluginArtifact(name configmodel.Name, version configmodel.Version, artifact configmodel.PluginArtifact) error {
+	return r.updateModel(name, version, func(model *configmodel.ModelInfo) {
+		model.Plugin.SetArtifact(artifact)
+	})
+}
+
+func (r *memRegistry) updateModel(name configmodel.Name, version configmodel.Version, f func(*configmodel.ModelInfo)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := getModelKey(name, version)
+	model, ok := r.models[key]
+	if !ok {
+		return errors.NewNotFound("Model '%s/%s' not found", name, version)
+	}
+	f(&model)
+	model.Checksum = model.ComputeChecksum()
+	r.models[key] = model
+	return nil
+}
+
+// GetModelFiles returns no files as the memory registry does not store models in files
+func (r *memRegistry) GetModelFiles(name configmodel.Name, version configmodel.Version) []string {
+	return nil
+}
+
+func (r *memRegistry) GetCompileHistory(name configmodel.Name, version configmodel.Version) ([]CompileAttempt, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	history := r.history[getModelKey(name, version)]
+	return append([]CompileAttempt(nil), history...), nil
+}
+
+func (r *memRegistry) AddCompileAttempt(name configmodel.Name, version configmodel.Version, attempt CompileAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := getModelKey(name, version)
+	history := append(r.history[key], attempt)
+	if len(history) > r.maxCompileHistory {
+		history = history[len(history)-r.maxCompileHistory:]
+	}
+	r.history[key] = history
+	return nil
+}
+
+func (r *memRegistry) IsReadOnly() bool {
+	return false
+}
+
+func getModelKey(name configmodel.Name, version configmodel.Version) string {
+	return configmodel.GetFileName(name, version)
+}
+
+// copyModel returns a deep copy of the given model so callers can't modify the stored model
+func copyModel(model configmodel.ModelInfo) (configmodel.ModelInfo, error) {
+	var result configmodel.ModelInfo
+	bytes, err := json.Marshal(model)
+	if err != nil {
+		return result, errors.NewInternal(err.Error())
+	}
+	if err := json.Unmarshal(bytes, &result); err != nil {
+		return result, errors.NewInternal(err.Error())
+	}
+	return result, nil
+}
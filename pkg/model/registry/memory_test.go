@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	"github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMemoryRegistry(t *testing.T) {
+	registry := NewMemoryRegistry()
+	assert.False(t, registry.IsReadOnly())
+
+	_, err := registry.GetModel("foo", "1.0.0")
+	assert.True(t, errors.IsNotFound(err))
+	assert.True(t, errors.IsNotFound(registry.PinModel("foo", "1.0.0")))
+
+	models, err := registry.ListModels()
+	assert.NoError(t, err)
+	assert.Len(t, models, 0)
+
+	model := configmodel.ModelInfo{
+		Name:    "foo",
+		Version: "1.0.0",
+		Modules: []configmodel.ModuleInfo{
+			{
+				Name:         "bar",
+				Organization: "ONF",
+				Revision:     "0.1.0",
+				File:         "bar",
+			},
+		},
+	}
+	assert.NoError(t, registry.AddModel(model))
+	assert.NoError(t, registry.AddModel(configmodel.ModelInfo{Name: "bar", Version: "1.0.0"}))
+	assert.NoError(t, registry.AddModel(configmodel.ModelInfo{Name: "baz", Version: "1.0.0"}))
+
+	model, err = registry.GetModel("foo", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, configmodel.Name("bar"), model.Modules[0].Name)
+	assert.Equal(t, model.ComputeChecksum(), model.Checksum)
+	assert.Empty(t, registry.GetModelFiles("foo", "1.0.0"))
+
+	// Modifying a returned model does not modify the stored model
+	model.Modules[0].Name = "changed"
+	model, err = registry.GetModel("foo", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, configmodel.Name("bar"), model.Modules[0].Name)
+
+	assert.NoError(t, registry.PinModel("foo", "1.0.0"))
+	assert.NoError(t, registry.SetBuildInfo("foo", "1.0.0", configmodel.BuildInfo{GoVersion: "go1.16"}))
+	model, err = registry.GetModel("foo", "1.0.0")
+	assert.NoError(t, err)
+	assert.True(t, model.Pinned)
+	assert.Equal(t, "go1.16", model.Build.GoVersion)
+	assert.NoError(t, registry.UnpinModel("foo", "1.0.0"))
+	model, err = registry.GetModel("foo", "1.0.0")
+	assert.NoError(t, err)
+	assert.False(t, model.Pinned)
+
+	models, next, err := registry.ListModelsPage("", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, getModelKey("baz", "1.0.0"), next)
+	assert.Len(t, models, 2)
+	assert.Equal(t, configmodel.Name("bar"), models[0].Name)
+	assert.Equal(t, configmodel.Name("baz"), models[1].Name)
+	models, next, err = registry.ListModelsPage(next, 2)
+	assert.NoError(t, err)
+	assert.Empty(t, next)
+	assert.Len(t, models, 1)
+	assert.Equal(t, configmodel.Name("foo"), models[0].Name)
+
+	for i := 0; i < defaultMaxCompileHistory+2; i++ {
+		assert.NoError(t, registry.AddCompileAttempt("foo", "1.0.0", CompileAttempt{Time: time.Unix(int64(i), 0)}))
+	}
+	history, err := registry.GetCompileHistory("foo", "1.0.0")
+	assert.NoError(t, err)
+	assert.Len(t, history, defaultMaxCompileHistory)
+	assert.Equal(t, time.Unix(2, 0), history[0].Time)
+
+	assert.NoError(t, registry.RemoveModel("foo", "1.0.0"))
+	_, err = registry.GetModel("foo", "1.0.0")
+	assert.True(t, errors.IsNotFound(err))
+	history, err = registry.GetCompileHistory("foo", "1.0.0")
+	assert.NoError(t, err)
+	assert.Len(t, history, 0)
+	models, err = registry.ListModels()
+	assert.NoError(t, err)
+	assert.Len(t, models, 2)
+}
+
+func TestServeMemoryRegistry(t *testing.T) {
+	server := newTestServer(t)
+	server.registry = NewMemoryRegistry()
+	assert.False(t, server.Capabilities().Has(CompressionCapability))
+	assert.NoError(t, server.registry.AddModel(configmodel.ModelInfo{Name: "test", Version: "1.0.0"}))
+	client := newTestClient(t, server)
+
+	response, err := client.GetModel(context.Background(), &configmodelapi.GetModelRequest{Name: "test", Version: "1.0.0"})
+	assert.NoError(t, err)
+	assert.Equal(t, "test", response.Model.Name)
+
+	list, err := client.ListModels(context.Background(), &configmodelapi.ListModelsRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, list.Models, 1)
+
+	_, err = client.DeleteModel(context.Background(), &configmodelapi.DeleteModelRequest{Name: "test", Version: "1.0.0"})
+	assert.NoError(t, err)
+	list, err = client.ListModels(context.Background(), &configmodelapi.ListModelsRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, list.Models, 0)
+}
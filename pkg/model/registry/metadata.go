@@ -0,0 +1,177 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-config-model/pkg/model/plugin/module"
+	"google.golang.org/grpc/metadata"
+	"strconv"
+)
+
+// Request options not covered by the registry API messages are passed as gRPC metadata
+const (
+	// SkipCompileKey is the metadata key indicating the plugin should not be compiled on push.
+	// When set, the model is only registered if its plugin is already present in the cache, and the plugin
+	// was compiled from the pushed model and can be loaded by the registry.
+	SkipCompileKey = "config-model-skip-compile"
+	// ForceKey is the metadata key indicating a request should override model protections
+	// Forced deletes remove pinned models, and forced pushes replace existing models.
+	ForceKey = "config-model-force"
+	// TryoutKey is the metadata key indicating a pushed model should be tried out
+	// The model's plugin is compiled and loaded in a temporary directory and then discarded without adding
+	// the model to the registry or the plugin to the cache.
+	TryoutKey = "config-model-tryout"
+	// PriorityKey is the metadata key for the priority of a pushed model's compilation
+	// Compilations default to normal priority.
+	PriorityKey = "config-model-priority"
+	// LabelsKey is the metadata key for the labels of a pushed model, each formatted as key=value
+	LabelsKey = "config-model-labels"
+	// SelectorKey is the metadata key for the label selector of the models to list
+	// Selectors are formatted as comma separated key=value or key!=value requirements.
+	SelectorKey = "config-model-selector"
+	// IncludeFilesKey is the metadata key indicating a requested model should include its YANG files
+	IncludeFilesKey = "config-model-include-files"
+	// PlatformKey is the metadata key for the platform of a pushed model's plugin, formatted as goos/goarch
+	// Plugins for platforms other than the registry's must be built externally and pushed with SkipCompileKey.
+	PlatformKey = "config-model-platform"
+	// PageSizeKey is the metadata key for the maximum number of models to list
+	PageSizeKey = "config-model-page-size"
+	// PageTokenKey is the metadata key for the token of the page of models to list
+	PageTokenKey = "config-model-page-token"
+	// NextPageTokenKey is the response header containing the token of the next page of models
+	// The header is only sent when more models remain to be listed.
+	NextPageTokenKey = "config-model-next-page-token"
+	// FeaturesKey is the metadata key for the YANG features enabled in a pushed model
+	// Nodes guarded by features that are not enabled are omitted from the model's plugin. All features
+	// are enabled if none are given. It's also the GetModel response header containing the model's features.
+	FeaturesKey = "config-model-features"
+	// DeviationsKey is the metadata key for the names of a pushed model's modules that are deviation modules
+	// It's also the GetModel response header naming the returned model's deviation modules.
+	DeviationsKey = "config-model-deviations"
+	// FileEncodingKey is the metadata key for the encoding of the YANG files of a pushed model, or the
+	// requested encoding of the files of a model returned WithIncludeFiles. It's also the response header
+	// indicating the encoding of returned files. The API's files are strings, so gzip encoded files are
+	// base64 encoded on the wire.
+	FileEncodingKey = "config-model-file-encoding"
+)
+
+// WithSkipCompile returns a context requesting that a pushed model not be compiled
+func WithSkipCompile(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, SkipCompileKey, strconv.FormatBool(true))
+}
+
+// WithForce returns a context requesting that model protections be overridden
+func WithForce(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, ForceKey, strconv.FormatBool(true))
+}
+
+// WithTryout returns a context requesting that a pushed model only be tried out
+func WithTryout(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, TryoutKey, strconv.FormatBool(true))
+}
+
+// WithPriority returns a context requesting that a pushed model be compiled with the given priority
+func WithPriority(ctx context.Context, priority Priority) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, PriorityKey, string(priority))
+}
+
+// WithLabels returns a context providing the labels of a pushed model
+func WithLabels(ctx context.Context, labels map[string]string) context.Context {
+	for _, pair := range formatLabels(labels) {
+		ctx = metadata.AppendToOutgoingContext(ctx, LabelsKey, pair)
+	}
+	return ctx
+}
+
+// WithFeatures returns a context enabling the given YANG features in a pushed model
+func WithFeatures(ctx context.Context, features ...string) context.Context {
+	for _, feature := range features {
+		ctx = metadata.AppendToOutgoingContext(ctx, FeaturesKey, feature)
+	}
+	return ctx
+}
+
+// WithDeviations returns a context marking the given modules of a pushed model as deviation modules
+func WithDeviations(ctx context.Context, modules ...string) context.Context {
+	for _, module := range modules {
+		ctx = metadata.AppendToOutgoingContext(ctx, DeviationsKey, module)
+	}
+	return ctx
+}
+
+// WithSelector returns a context requesting that only the models matching the given label selector be listed
+func WithSelector(ctx context.Context, selector string) context.Context {
+	if selector == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, SelectorKey, selector)
+}
+
+// WithIncludeFiles returns a context requesting that a model be returned with its YANG files
+func WithIncludeFiles(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, IncludeFilesKey, strconv.FormatBool(true))
+}
+
+// WithFileEncoding returns a context indicating the encoding of a pushed model's files, or requesting
+// that the files of a model returned WithIncludeFiles be encoded
+func WithFileEncoding(ctx context.Context, encoding configmodel.FileEncoding) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, FileEncodingKey, string(encoding))
+}
+
+// WithPlatform returns a context registering a pushed model's plugin for the given platform
+func WithPlatform(ctx context.Context, platform pluginmodule.Platform) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, PlatformKey, platform.String())
+}
+
+// WithPage returns a context requesting a page of models with the given size and token
+// An empty token requests the first page.
+func WithPage(ctx context.Context, size int, token string) context.Context {
+	ctx = metadata.AppendToOutgoingContext(ctx, PageSizeKey, strconv.Itoa(size))
+	if token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, PageTokenKey, token)
+	}
+	return ctx
+}
+
+// getStringMetadata returns the string value of the given incoming metadata key
+func getStringMetadata(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// getStringsMetadata returns the string values of the given incoming metadata key
+func getStringsMetadata(ctx context.Context, key string) []string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil
+	}
+	return md.Get(key)
+}
+
+// getBoolMetadata returns the boolean value of the given incoming metadata key
+func getBoolMetadata(ctx context.Context, key string) bool {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return false
+	}
+	values := md.Get(key)
+	if len(values) == 0 {
+		return false
+	}
+	value, err := strconv.ParseBool(values[0])
+	if err != nil {
+		return false
+	}
+	return value
+}
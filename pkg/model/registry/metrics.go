@@ -0,0 +1,212 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"fmt"
+	plugincache "github.com/onosproject/onos-config-model/pkg/model/plugin/cache"
+	"github.com/onosproject/onos-lib-go/pkg/prom"
+	"github.com/prometheus/client_golang/prometheus"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	defaultStatsInterval = 30 * time.Second
+	metricsNamespace     = "onos"
+	metricsSubsystem     = "config_model_registry"
+)
+
+var builder = prom.NewBuilder(metricsNamespace, metricsSubsystem, map[string]string{})
+
+var (
+	modelsDesc       = builder.NewMetricDesc("models", "The number of models in the registry", nil, map[string]string{})
+	modulesDesc      = builder.NewMetricDesc("modules", "The number of distinct modules in the registry", nil, map[string]string{})
+	yangBytesDesc    = builder.NewMetricDesc("yang_bytes", "The total size of the YANG files in the registry", nil, map[string]string{})
+	pluginsDesc      = builder.NewMetricDesc("cached_plugins", "The number of plugins in the cache", nil, map[string]string{})
+	pluginsBytesDesc = builder.NewMetricDesc("cached_plugins_bytes", "The disk usage of the plugins in the cache", nil, map[string]string{})
+)
+
+// NewCollector creates a new Prometheus collector for the registry and cache contents
+// The registry contents are recomputed at most once per interval.
+func NewCollector(registry Registry, cache *plugincache.PluginCache, interval time.Duration) prom.Collector {
+	if interval == 0 {
+		interval = defaultStatsInterval
+	}
+	return &registryCollector{
+		registry: registry,
+		cache:    cache,
+		interval: interval,
+	}
+}
+
+// registryStats is a snapshot of the registry and cache contents
+type registryStats struct {
+	models       int
+	modules      int
+	yangBytes    int
+	plugins      int
+	pluginsBytes int64
+}
+
+// registryCollector is a Prometheus collector for the registry and cache contents
+type registryCollector struct {
+	registry Registry
+	cache    *plugincache.PluginCache
+	interval time.Duration
+	stats    registryStats
+	updated  time.Time
+	mu       sync.Mutex
+}
+
+// Retrieve sends the registry metrics to the given channel
+func (c *registryCollector) Retrieve(ch chan<- prometheus.Metric) error {
+	stats, err := c.getStats()
+	if err != nil {
+		log.Warnf("Failed to compute registry metrics: %s", err)
+		return err
+	}
+	ch <- builder.MustNewConstMetric(modelsDesc, prometheus.GaugeValue, float64(stats.models))
+	ch <- builder.MustNewConstMetric(modulesDesc, prometheus.GaugeValue, float64(stats.modules))
+	ch <- builder.MustNewConstMetric(yangBytesDesc, prometheus.GaugeValue, float64(stats.yangBytes))
+	ch <- builder.MustNewConstMetric(pluginsDesc, prometheus.GaugeValue, float64(stats.plugins))
+	ch <- builder.MustNewConstMetric(pluginsBytesDesc, prometheus.GaugeValue, float64(stats.pluginsBytes))
+	return nil
+}
+
+func (c *registryCollector) getStats() (registryStats, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.updated.IsZero() && time.Since(c.updated) < c.interval {
+		return c.stats, nil
+	}
+
+	var stats registryStats
+	models, err := c.registry.ListModels()
+	if err != nil {
+		return stats, err
+	}
+	modules := make(map[string]bool)
+	for _, model := range models {
+		stats.models++
+		for _, module := range model.Modules {
+			modules[fmt.Sprintf("%s@%s", module.Name, module.Revision)] = true
+		}
+		for _, file := range model.Files {
+			stats.yangBytes += len(file.Data)
+		}
+	}
+	stats.modules = len(modules)
+
+	plugins, err := c.cache.List()
+	if err != nil {
+		return stats, err
+	}
+	for _, plugin := range plugins {
+		info, err := os.Stat(plugin)
+		if err != nil {
+			continue
+		}
+		stats.plugins++
+		stats.pluginsBytes += info.Size()
+	}
+
+	c.stats = stats
+	c.updated = time.Now()
+	return stats, nil
+}
+
+// compileDurationBuckets are the bounds in seconds of the compile duration histogram buckets
+// Compiling a plugin typically takes tens of seconds to minutes.
+var compileDurationBuckets = []float64{5, 10, 20, 30, 60, 120, 300, 600}
+
+// newServerMetrics creates the metrics recorded by a registry server
+// The metrics are not registered with the default Prometheus registry, so each server has its own metrics,
+// and they're exported by the collector returned by the server's Metrics method.
+func newServerMetrics() *serverMetrics {
+	return &serverMetrics{
+		pushes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "push_total",
+			Help:      "The number of models pushed to the registry",
+		}),
+		pushFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "push_failures_total",
+			Help:      "The number of pushed models that were rejected",
+		}),
+		compileDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "compile_duration_seconds",
+			Help:      "The duration of plugin compilations",
+			Buckets:   compileDurationBuckets,
+		}),
+		compileFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "compile_failures_total",
+			Help:      "The number of plugin compilations that failed",
+		}),
+		cacheHits: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "cache_hits_total",
+			Help:      "The number of pushed models whose plugin was already cached",
+		}),
+		cacheMisses: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "cache_misses_total",
+			Help:      "The number of pushed models whose plugin was not cached",
+		}),
+	}
+}
+
+// serverMetrics are the metrics recorded by a registry server
+type serverMetrics struct {
+	pushes          prometheus.Counter
+	pushFailures    prometheus.Counter
+	compileDuration prometheus.Histogram
+	compileFailures prometheus.Counter
+	cacheHits       prometheus.Counter
+	cacheMisses     prometheus.Counter
+}
+
+// Retrieve sends the server metrics to the given channel
+func (m *serverMetrics) Retrieve(ch chan<- prometheus.Metric) error {
+	m.pushes.Collect(ch)
+	m.pushFailures.Collect(ch)
+	m.compileDuration.Collect(ch)
+	m.compileFailures.Collect(ch)
+	m.cacheHits.Collect(ch)
+	m.cacheMisses.Collect(ch)
+	return nil
+}
+
+// recordCompile records the duration and result of a plugin compilation
+func (m *serverMetrics) recordCompile(duration time.Duration, err error) {
+	m.compileDuration.Observe(duration.Seconds())
+	if err != nil {
+		m.compileFailures.Inc()
+	}
+}
+
+// recordCached records whether a pushed model's plugin was found in the cache
+func (m *serverMetrics) recordCached(cached bool) {
+	if cached {
+		m.cacheHits.Inc()
+	} else {
+		m.cacheMisses.Inc()
+	}
+}
+
+// Metrics returns a Prometheus collector for the push, compile and cache metrics recorded by the service
+func (s *Service) Metrics() prom.Collector {
+	return s.server.metrics
+}
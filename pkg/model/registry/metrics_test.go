@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestCollector(t *testing.T) {
+	server := newTestServer(t)
+
+	for _, version := range []configmodel.Version{"1.0.0", "1.1.0"} {
+		err := server.registry.AddModel(configmodel.ModelInfo{
+			Name:    "test",
+			Version: version,
+			Modules: []configmodel.ModuleInfo{
+				{Name: "test", Revision: "2020-11-18"},
+				{Name: configmodel.Name("test-" + version), Revision: "2020-11-18"},
+			},
+			Files: []configmodel.FileInfo{
+				{Path: "test.yang", Data: []byte("module test {}")},
+			},
+		})
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, ioutil.WriteFile(server.cache.Entry("test", "1.0.0").Path, []byte("plugin"), 0666))
+
+	collector := NewCollector(server.registry, server.cache, time.Minute)
+	ch := make(chan prometheus.Metric, 10)
+	assert.NoError(t, collector.Retrieve(ch))
+	close(ch)
+
+	values := make(map[string]float64)
+	for metric := range ch {
+		m := &dto.Metric{}
+		assert.NoError(t, metric.Write(m))
+		values[metric.Desc().String()] = m.GetGauge().GetValue()
+	}
+	assert.Equal(t, float64(2), values[modelsDesc.String()])
+	assert.Equal(t, float64(3), values[modulesDesc.String()])
+	assert.Equal(t, float64(28), values[yangBytesDesc.String()])
+	assert.Equal(t, float64(1), values[pluginsDesc.String()])
+	assert.Equal(t, float64(6), values[pluginsBytesDesc.String()])
+
+	// The stats are cached until the interval elapses
+	assert.NoError(t, server.registry.RemoveModel("test", "1.1.0"))
+	stats, err := collector.(*registryCollector).getStats()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stats.models)
+}
+
+func TestServerMetrics(t *testing.T) {
+	server := newTestServer(t)
+	client := newTestClient(t, server)
+
+	push := func(name string) error {
+		_, err := client.PushModel(WithSkipCompile(context.Background()), &configmodelapi.PushModelRequest{
+			Model: &configmodelapi.ConfigModel{
+				Name:    name,
+				Version: "1.0.0",
+			},
+		})
+		return err
+	}
+	assert.NoError(t, ioutil.WriteFile(server.cache.Entry("cached", "1.0.0").Path, []byte("plugin"), 0666))
+	assert.NoError(t, push("cached"))
+	assert.Error(t, push("cached"))
+	assert.Error(t, push("missing"))
+
+	// Compilation fails because the model has no modules from which to generate bindings
+	model := configmodel.ModelInfo{Name: "test", Version: "1.0.0"}
+	assert.Error(t, server.compilePlugin(context.Background(), model, server.cache.Entry("test", "1.0.0").Path))
+
+	ch := make(chan prometheus.Metric, 10)
+	assert.NoError(t, server.metrics.Retrieve(ch))
+	close(ch)
+
+	counters := make(map[string]float64)
+	var compiles uint64
+	for metric := range ch {
+		m := &dto.Metric{}
+		assert.NoError(t, metric.Write(m))
+		if m.Histogram != nil {
+			compiles = m.GetHistogram().GetSampleCount()
+		} else {
+			counters[metric.Desc().String()] = m.GetCounter().GetValue()
+		}
+	}
+	assert.Equal(t, float64(3), counters[server.metrics.pushes.Desc().String()])
+	assert.Equal(t, float64(2), counters[server.metrics.pushFailures.Desc().String()])
+	assert.Equal(t, float64(1), counters[server.metrics.cacheHits.Desc().String()])
+	assert.Equal(t, float64(1), counters[server.metrics.cacheMisses.Desc().String()])
+	assert.Equal(t, float64(1), counters[server.metrics.compileFailures.Desc().String()])
+	assert.Equal(t, uint64(1), compiles)
+}
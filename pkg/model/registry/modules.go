@@ -0,0 +1,224 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/openconfig/goyang/pkg/yang"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const revisionFormat = "2006-01-02"
+
+// identifierPattern matches YANG identifiers
+var identifierPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_.-]*$`)
+
+// revisionPattern matches YANG revision dates
+var revisionPattern = regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}$`)
+
+// ModulesKey is the GetModel response header containing the JSON encoded modules for the model
+// The registry API's module message has no fields for the YANG namespace and prefix of a module.
+const ModulesKey = "config-model-modules"
+
+// GetModules gets the modules for the given model, including each module's namespace and prefix
+func GetModules(ctx context.Context, client configmodelapi.ConfigModelRegistryServiceClient, name configmodel.Name, version configmodel.Version) ([]configmodel.ModuleInfo, error) {
+	var header metadata.MD
+	request := &configmodelapi.GetModelRequest{
+		Name:    string(name),
+		Version: string(version),
+	}
+	if _, err := client.GetModel(ctx, request, grpc.Header(&header)); err != nil {
+		return nil, err
+	}
+	values := header.Get(ModulesKey)
+	if len(values) == 0 {
+		return nil, nil
+	}
+	var modules []configmodel.ModuleInfo
+	if err := json.Unmarshal([]byte(values[0]), &modules); err != nil {
+		return nil, err
+	}
+	return modules, nil
+}
+
+// GetFeatures gets the features and the names of the deviation modules for the given model
+func GetFeatures(ctx context.Context, client configmodelapi.ConfigModelRegistryServiceClient, name configmodel.Name, version configmodel.Version) ([]string, []string, error) {
+	var header metadata.MD
+	request := &configmodelapi.GetModelRequest{
+		Name:    string(name),
+		Version: string(version),
+	}
+	if _, err := client.GetModel(ctx, request, grpc.Header(&header)); err != nil {
+		return nil, nil, err
+	}
+	return header.Get(FeaturesKey), header.Get(DeviationsKey), nil
+}
+
+// validateModules checks that the modules of a pushed model have valid names and revisions
+// Module names and revisions are used in file and package names, so malformed values would
+// otherwise fail deep in the compiler. Each module may only be listed once, since the generated bindings
+// for multiple revisions of the same module are undefined.
+func validateModules(model *configmodelapi.ConfigModel) error {
+	revisions := make(map[string]string)
+	for i, module := range model.Modules {
+		if module.Name == "" {
+			return errors.NewInvalid("module %d of model '%s@%s' has no name", i, model.Name, model.Version)
+		}
+		if !identifierPattern.MatchString(module.Name) {
+			return errors.NewInvalid("module name '%s' is not a valid YANG identifier", module.Name)
+		}
+		if revision, ok := revisions[module.Name]; ok {
+			return errors.NewInvalid("module '%s' is listed more than once in model '%s@%s', with revisions '%s' and '%s'", module.Name, model.Name, model.Version, revision, module.Revision)
+		}
+		revisions[module.Name] = module.Revision
+		if module.Revision == "" {
+			continue
+		}
+		if !revisionPattern.MatchString(module.Revision) {
+			return errors.NewInvalid("revision '%s' of module '%s' is not in the YYYY-MM-DD format", module.Revision, module.Name)
+		}
+		if _, err := time.Parse(revisionFormat, module.Revision); err != nil {
+			return errors.NewInvalid("revision '%s' of module '%s' is not a valid date", module.Revision, module.Name)
+		}
+	}
+	return nil
+}
+
+// inferModuleIdentity sets the namespace, prefix, organization and contact of the given model's modules from its YANG files
+// Submodules take the namespace and prefix of the module they belong to, but declare their own organization
+// and contact. Modules that cannot be found in the files are left unchanged.
+func inferModuleIdentity(model *configmodel.ModelInfo) {
+	modules := yang.NewModules()
+	for _, file := range model.Files {
+		file, err := file.Decompress()
+		if err != nil {
+			continue
+		}
+		if err := modules.Parse(string(file.Data), file.Path); err != nil {
+			log.Debugf("Failed to parse '%s' for model '%s': %s", file.Path, model, err)
+		}
+	}
+
+	for i, moduleInfo := range model.Modules {
+		name := string(moduleInfo.Name)
+		if moduleInfo.Revision != "" {
+			name = fmt.Sprintf("%s@%s", moduleInfo.Name, moduleInfo.Revision)
+		}
+		module, ok := modules.Modules[name]
+		if !ok {
+			submodule, ok := modules.SubModules[name]
+			if !ok || submodule.BelongsTo == nil {
+				continue
+			}
+			setModuleMetadata(model, i, submodule)
+			model.Modules[i].BelongsTo = configmodel.Name(submodule.BelongsTo.Name)
+			if submodule.BelongsTo.Prefix != nil {
+				model.Modules[i].Prefix = submodule.BelongsTo.Prefix.Name
+			}
+			if parent, ok := modules.Modules[submodule.BelongsTo.Name]; ok && parent.Namespace != nil {
+				model.Modules[i].Namespace = parent.Namespace.Name
+			}
+			continue
+		}
+		setModuleMetadata(model, i, module)
+		if module.Namespace != nil {
+			model.Modules[i].Namespace = module.Namespace.Name
+		}
+		if module.Prefix != nil {
+			model.Modules[i].Prefix = module.Prefix.Name
+		}
+	}
+}
+
+// setModuleMetadata sets the organization and contact of the given model's i'th module from its YANG statements
+// Organizations given by clients are often missing or mistyped, so the YANG organization takes precedence
+// and a conflicting organization given by the client is replaced with a warning.
+func setModuleMetadata(model *configmodel.ModelInfo, i int, module *yang.Module) {
+	if module.Organization != nil {
+		organization := module.Organization.Name
+		if given := model.Modules[i].Organization; given != "" && given != organization {
+			log.Warnf("Organization '%s' of module '%s' in model '%s' does not match its YANG organization '%s'; using the YANG organization",
+				given, model.Modules[i].Name, model, organization)
+		}
+		model.Modules[i].Organization = organization
+	}
+	if module.Contact != nil {
+		model.Modules[i].Contact = module.Contact.Name
+	}
+}
+
+// sendModules sends the modules for the given model in the response headers
+func sendModules(ctx context.Context, modelInfo configmodel.ModelInfo) {
+	bytes, err := json.Marshal(modelInfo.Modules)
+	if err != nil {
+		log.Warnf("Failed to encode modules for model '%s': %s", modelInfo, err)
+		return
+	}
+	if err := grpc.SetHeader(ctx, metadata.Pairs(ModulesKey, string(bytes))); err != nil {
+		log.Debugf("Failed to send modules: %s", err)
+	}
+}
+
+// sendFeatures sends the features and the names of the deviation modules of the given model in the response
+// headers, in the format in which they're pushed, so a returned model can be pushed to another registry
+func sendFeatures(ctx context.Context, modelInfo configmodel.ModelInfo) {
+	md := metadata.MD{}
+	for _, feature := range modelInfo.Features {
+		md.Append(FeaturesKey, feature)
+	}
+	for _, deviation := range modelInfo.Deviations {
+		md.Append(DeviationsKey, string(deviation.Name))
+	}
+	if md.Len() == 0 {
+		return
+	}
+	if err := grpc.SetHeader(ctx, md); err != nil {
+		log.Debugf("Failed to send features: %s", err)
+	}
+}
+
+// setFeatures sets the features and deviation modules of a pushed model from the incoming metadata
+// The registry API has no fields for features or deviations, so deviation modules are pushed as modules
+// and named in the metadata to move them to the model's deviations.
+func setFeatures(ctx context.Context, model *configmodel.ModelInfo) error {
+	features := getStringsMetadata(ctx, FeaturesKey)
+	for _, feature := range features {
+		name := feature
+		if i := strings.Index(feature, ":"); i >= 0 {
+			name = feature[i+1:]
+		}
+		if !identifierPattern.MatchString(name) {
+			return errors.NewInvalid("feature '%s' is not a valid YANG identifier", feature)
+		}
+	}
+	model.Features = features
+
+	for _, deviation := range getStringsMetadata(ctx, DeviationsKey) {
+		found := false
+		modules := model.Modules[:0]
+		for _, module := range model.Modules {
+			if string(module.Name) == deviation {
+				model.Deviations = append(model.Deviations, module)
+				found = true
+			} else {
+				modules = append(modules, module)
+			}
+		}
+		if !found {
+			return errors.NewInvalid("deviation module '%s' is not a module of model '%s'", deviation, model)
+		}
+		model.Modules = modules
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestModuleIdentity(t *testing.T) {
+	server := newTestServer(t)
+	client := newTestClient(t, server)
+
+	yang, err := ioutil.ReadFile(filepath.Join("..", "..", "..", "test", "test@2020-11-18.yang"))
+	assert.NoError(t, err)
+	entry := server.cache.Entry("test", "1.0.0")
+	assert.NoError(t, ioutil.WriteFile(entry.Path, []byte("plugin"), 0666))
+
+	ctx := WithSkipCompile(context.Background())
+	_, err = client.PushModel(ctx, &configmodelapi.PushModelRequest{
+		Model: &configmodelapi.ConfigModel{
+			Name:    "test",
+			Version: "1.0.0",
+			Modules: []*configmodelapi.ConfigModule{
+				{
+					Name:     "test",
+					Revision: "2020-11-18",
+					File:     "test@2020-11-18.yang",
+				},
+				{
+					Name: "missing",
+					File: "missing.yang",
+				},
+			},
+			Files: map[string]string{
+				"test@2020-11-18.yang": string(yang),
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	// The namespace, prefix, organization and contact are inferred from the YANG files and persisted
+	model, err := server.registry.GetModel("test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://opennetworking.org/oran/test", model.Modules[0].Namespace)
+	assert.Equal(t, "t1", model.Modules[0].Prefix)
+
+	modules, err := GetModules(context.Background(), client, "test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, []configmodel.ModuleInfo{
+		{
+			Name:         "test",
+			File:         "test@2020-11-18.yang",
+			Organization: "Open Networking Foundation.",
+			Contact:      "Adib Rastegarnia",
+			Revision:     "2020-11-18",
+			Namespace:    "http://opennetworking.org/oran/test",
+			Prefix:       "t1",
+		},
+		{
+			Name: "missing",
+			File: "missing.yang",
+		},
+	}, modules)
+}
+
+func TestSubmoduleIdentity(t *testing.T) {
+	model := newModelInfo(&configmodelapi.ConfigModel{
+		Name:    "parent",
+		Version: "1.0.0",
+		Modules: []*configmodelapi.ConfigModule{
+			{
+				Name: "parent",
+				File: "parent.yang",
+			},
+			{
+				Name: "child",
+				File: "child.yang",
+			},
+		},
+		Files: map[string]string{
+			"parent.yang": `module parent {
+  namespace "http://opennetworking.org/test/parent";
+  prefix p;
+  include child;
+  container parent {
+    leaf name { type string; }
+  }
+}
+`,
+			"child.yang": `submodule child {
+  belongs-to parent { prefix p; }
+  container child {
+    leaf value { type string; }
+  }
+}
+`,
+		},
+	})
+
+	// Submodules take the identity of the module they belong to
+	assert.False(t, model.Modules[0].IsSubmodule())
+	assert.Equal(t, "http://opennetworking.org/test/parent", model.Modules[0].Namespace)
+	assert.True(t, model.Modules[1].IsSubmodule())
+	assert.Equal(t, configmodel.Name("parent"), model.Modules[1].BelongsTo)
+	assert.Equal(t, "http://opennetworking.org/test/parent", model.Modules[1].Namespace)
+	assert.Equal(t, "p", model.Modules[1].Prefix)
+
+	assert.Empty(t, ValidateModel(model))
+	paths, err := GetStatePaths(model)
+	assert.NoError(t, err)
+	assert.Empty(t, paths.Paths)
+}
+
+func TestModuleMetadata(t *testing.T) {
+	model := newModelInfo(&configmodelapi.ConfigModel{
+		Name:    "parent",
+		Version: "1.0.0",
+		Modules: []*configmodelapi.ConfigModule{
+			{
+				Name:         "parent",
+				Organization: "Open Networking Fondation",
+				File:         "parent.yang",
+			},
+			{
+				Name: "child",
+				File: "child.yang",
+			},
+			{
+				Name:         "other",
+				Organization: "Example Inc.",
+				File:         "other.yang",
+			},
+		},
+		Files: map[string]string{
+			"parent.yang": `module parent {
+  namespace "http://opennetworking.org/test/parent";
+  prefix p;
+  organization "Open Networking Foundation";
+  contact "onos-dev@onosproject.org";
+  include child;
+}
+`,
+			"child.yang": `submodule child {
+  belongs-to parent { prefix p; }
+  organization "ONF";
+}
+`,
+			"other.yang": `module other {
+  namespace "http://example.com/other";
+  prefix o;
+}
+`,
+		},
+	})
+
+	// The organization and contact declared in the YANG take precedence over those given by the client
+	assert.Equal(t, "Open Networking Foundation", model.Modules[0].Organization)
+	assert.Equal(t, "onos-dev@onosproject.org", model.Modules[0].Contact)
+
+	// Submodules declare their own organization and contact
+	assert.Equal(t, "ONF", model.Modules[1].Organization)
+	assert.Empty(t, model.Modules[1].Contact)
+
+	// The client's organization is kept for modules that declare none
+	assert.Equal(t, "Example Inc.", model.Modules[2].Organization)
+	assert.Empty(t, model.Modules[2].Contact)
+}
+
+func TestValidateModules(t *testing.T) {
+	tests := []struct {
+		name     string
+		module   *configmodelapi.ConfigModule
+		expected string
+	}{
+		{"valid", &configmodelapi.ConfigModule{Name: "test", Revision: "2020-11-18"}, ""},
+		{"no revision", &configmodelapi.ConfigModule{Name: "openconfig-interfaces"}, ""},
+		{"dotted name", &configmodelapi.ConfigModule{Name: "_test.v1", Revision: "2020-11-18"}, ""},
+		{"empty name", &configmodelapi.ConfigModule{Revision: "2020-11-18"}, "module 0 of model 'test@1.0.0' has no name"},
+		{"name with slash", &configmodelapi.ConfigModule{Name: "foo/bar"}, "module name 'foo/bar' is not a valid YANG identifier"},
+		{"name with space", &configmodelapi.ConfigModule{Name: "foo bar"}, "module name 'foo bar' is not a valid YANG identifier"},
+		{"name with leading digit", &configmodelapi.ConfigModule{Name: "1test"}, "module name '1test' is not a valid YANG identifier"},
+		{"revision with slash", &configmodelapi.ConfigModule{Name: "test", Revision: "2020/11/18"}, "revision '2020/11/18' of module 'test' is not in the YYYY-MM-DD format"},
+		{"semantic version revision", &configmodelapi.ConfigModule{Name: "test", Revision: "1.0.0"}, "revision '1.0.0' of module 'test' is not in the YYYY-MM-DD format"},
+		{"short revision", &configmodelapi.ConfigModule{Name: "test", Revision: "2020-1-18"}, "revision '2020-1-18' of module 'test' is not in the YYYY-MM-DD format"},
+		{"invalid date", &configmodelapi.ConfigModule{Name: "test", Revision: "2020-13-40"}, "revision '2020-13-40' of module 'test' is not a valid date"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateModules(&configmodelapi.ConfigModel{
+				Name:    "test",
+				Version: "1.0.0",
+				Modules: []*configmodelapi.ConfigModule{test.module},
+			})
+			if test.expected == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.IsInvalid(err))
+				assert.EqualError(t, err, test.expected)
+			}
+		})
+	}
+}
+
+func TestValidateDuplicateModules(t *testing.T) {
+	model := &configmodelapi.ConfigModel{
+		Name:    "test",
+		Version: "1.0.0",
+		Modules: []*configmodelapi.ConfigModule{
+			{Name: "test", Revision: "2020-11-18"},
+			{Name: "other", Revision: "2020-11-18"},
+			{Name: "test", Revision: "2021-01-01"},
+		},
+	}
+	err := validateModules(model)
+	assert.True(t, errors.IsInvalid(err))
+	assert.EqualError(t, err, "module 'test' is listed more than once in model 'test@1.0.0', with revisions '2020-11-18' and '2021-01-01'")
+
+	// Modules listed twice with the same revision are duplicates too
+	model.Modules[2].Revision = "2020-11-18"
+	assert.True(t, errors.IsInvalid(validateModules(model)))
+}
+
+func TestPushInvalidModules(t *testing.T) {
+	server := newTestServer(t)
+	client := newTestClient(t, server)
+	_, err := client.PushModel(context.Background(), &configmodelapi.PushModelRequest{
+		Model: &configmodelapi.ConfigModel{
+			Name:    "test",
+			Version: "1.0.0",
+			Modules: []*configmodelapi.ConfigModule{{Name: "test", Revision: "2020/11/18"}},
+		},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), "revision '2020/11/18' of module 'test'")
+
+	// Models listing a module more than once are rejected
+	_, err = client.PushModel(context.Background(), &configmodelapi.PushModelRequest{
+		Model: &configmodelapi.ConfigModel{
+			Name:    "test",
+			Version: "1.0.0",
+			Modules: []*configmodelapi.ConfigModule{
+				{Name: "test", Revision: "2020-11-18", File: "test@2020-11-18.yang"},
+				{Name: "test", Revision: "2021-01-01", File: "test@2021-01-01.yang"},
+			},
+		},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), "revisions '2020-11-18' and '2021-01-01'")
+
+	// Nothing is written to the registry or the cache
+	models, err := server.registry.ListModels()
+	assert.NoError(t, err)
+	assert.Len(t, models, 0)
+	paths, err := server.cache.List()
+	assert.NoError(t, err)
+	assert.Len(t, paths, 0)
+}
+
+func TestPushFeatures(t *testing.T) {
+	server := newTestServer(t)
+	client := newTestClient(t, server)
+	entry := server.cache.Entry("test", "1.0.0")
+	assert.NoError(t, ioutil.WriteFile(entry.Path, []byte("plugin"), 0666))
+
+	request := &configmodelapi.PushModelRequest{
+		Model: &configmodelapi.ConfigModel{
+			Name:    "test",
+			Version: "1.0.0",
+			Modules: []*configmodelapi.ConfigModule{
+				{Name: "test", File: "test.yang"},
+				{Name: "test-deviations", File: "test-deviations.yang"},
+			},
+		},
+	}
+
+	// Features must be YANG identifiers and deviations must be modules of the model
+	_, err := client.PushModel(WithFeatures(WithSkipCompile(context.Background()), "not a feature"), request)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.PushModel(WithDeviations(WithSkipCompile(context.Background()), "missing"), request)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Features and deviations are persisted in the model descriptor
+	ctx := WithDeviations(WithFeatures(WithSkipCompile(context.Background()), "feature-a", "t:feature-b"), "test-deviations")
+	_, err = client.PushModel(ctx, request)
+	assert.NoError(t, err)
+	model, err := server.registry.GetModel("test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"feature-a", "t:feature-b"}, model.Features)
+	assert.Equal(t, []configmodel.ModuleInfo{{Name: "test", File: "test.yang"}}, model.Modules)
+	assert.Equal(t, []configmodel.ModuleInfo{{Name: "test-deviations", File: "test-deviations.yang"}}, model.Deviations)
+
+	// Features and deviations are returned in the format in which they're pushed
+	features, deviations, err := GetFeatures(context.Background(), client, "test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"feature-a", "t:feature-b"}, features)
+	assert.Equal(t, []string{"test-deviations"}, deviations)
+	response, err := client.GetModel(context.Background(), &configmodelapi.GetModelRequest{Name: "test", Version: "1.0.0"})
+	assert.NoError(t, err)
+	assert.Len(t, response.Model.Modules, 2)
+	assert.Equal(t, "test-deviations", response.Model.Modules[1].Name)
+}
@@ -0,0 +1,42 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	modelopenapi "github.com/onosproject/onos-config-model/pkg/model/openapi"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+// GetModelOpenAPI returns an OpenAPI document describing the config tree of the given model
+func (r *ConfigModelRegistry) GetModelOpenAPI(name configmodel.Name, version configmodel.Version) (*modelopenapi.Spec, error) {
+	model, err := r.GetModel(name, version)
+	if err != nil {
+		return nil, err
+	}
+	return GetOpenAPI(model)
+}
+
+// GetOpenAPI returns an OpenAPI document describing the config tree of the given model from its YANG files
+func GetOpenAPI(model configmodel.ModelInfo) (*modelopenapi.Spec, error) {
+	modules, diagnostics := parseModules(model)
+	if len(diagnostics) > 0 {
+		return nil, errors.NewInvalid("model '%s' is not valid: %s", model, diagnostics[0])
+	}
+
+	var roots []*yang.Entry
+	for _, module := range model.Modules {
+		if module.IsSubmodule() {
+			continue
+		}
+		entry, errs := modules.GetModule(string(module.Name))
+		if len(errs) > 0 {
+			return nil, errors.NewInvalid("failed to load module '%s': %s", module.Name, errs[0])
+		}
+		roots = append(roots, entry)
+	}
+	return modelopenapi.NewSpec(model, roots...), nil
+}
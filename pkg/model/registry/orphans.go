@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	plugincache "github.com/onosproject/onos-config-model/pkg/model/plugin/cache"
+	"os"
+)
+
+// Orphans is a report of mismatches between the registry and the plugin cache
+type Orphans struct {
+	// Plugins is the list of cached plugin files with no model descriptor
+	Plugins []string `json:"plugins"`
+	// Models is the list of models with no cached plugin and no YANG files from which to rebuild it
+	Models []string `json:"models"`
+}
+
+// GetOrphans returns the cached plugins and model descriptors that have no counterpart
+func GetOrphans(registry Registry, cache *plugincache.PluginCache) (Orphans, error) {
+	orphans := Orphans{
+		Plugins: []string{},
+		Models:  []string{},
+	}
+
+	models, err := registry.ListModels()
+	if err != nil {
+		return orphans, err
+	}
+
+	plugins := make(map[string]bool)
+	for _, model := range models {
+		path := cache.Entry(model.Name, model.Version).Path
+		plugins[path] = true
+		if _, err := os.Stat(path); os.IsNotExist(err) && len(model.Files) == 0 {
+			orphans.Models = append(orphans.Models, model.String())
+		}
+	}
+
+	paths, err := cache.List()
+	if err != nil {
+		return orphans, err
+	}
+	for _, path := range paths {
+		if !plugins[path] {
+			orphans.Plugins = append(orphans.Plugins, path)
+		}
+	}
+	return orphans, nil
+}
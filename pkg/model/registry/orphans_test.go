@@ -0,0 +1,39 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"testing"
+)
+
+func TestGetOrphans(t *testing.T) {
+	server := newTestServer(t)
+
+	// A model with a cached plugin
+	assert.NoError(t, server.registry.AddModel(configmodel.ModelInfo{Name: "foo", Version: "1.0.0"}))
+	assert.NoError(t, ioutil.WriteFile(server.cache.Entry("foo", "1.0.0").Path, []byte("plugin"), 0666))
+
+	// A model with no cached plugin but YANG files from which to rebuild it
+	assert.NoError(t, server.registry.AddModel(configmodel.ModelInfo{
+		Name:    "bar",
+		Version: "1.0.0",
+		Files:   []configmodel.FileInfo{{Path: "bar.yang", Data: []byte("module bar {}")}},
+	}))
+
+	// A model with no cached plugin and no YANG files
+	assert.NoError(t, server.registry.AddModel(configmodel.ModelInfo{Name: "baz", Version: "1.0.0"}))
+
+	// A cached plugin with no model
+	orphan := server.cache.Entry("qux", "1.0.0").Path
+	assert.NoError(t, ioutil.WriteFile(orphan, []byte("plugin"), 0666))
+
+	orphans, err := GetOrphans(server.registry, server.cache)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"baz@1.0.0"}, orphans.Models)
+	assert.Equal(t, []string{orphan}, orphans.Plugins)
+}
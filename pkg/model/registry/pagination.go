@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	"encoding/base64"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"sort"
+	"strconv"
+)
+
+// ListModelsPage lists a page of models from the registry server
+// The returned token is the token of the next page, or empty if there are no more models.
+func ListModelsPage(ctx context.Context, client configmodelapi.ConfigModelRegistryServiceClient, size int, token string) ([]*configmodelapi.ConfigModel, string, error) {
+	var header metadata.MD
+	response, err := client.ListModels(WithPage(ctx, size, token), &configmodelapi.ListModelsRequest{}, grpc.Header(&header))
+	if err != nil {
+		return nil, "", err
+	}
+	var next string
+	if values := header.Get(NextPageTokenKey); len(values) > 0 {
+		next = values[0]
+	}
+	return response.Models, next, nil
+}
+
+// encodePageToken encodes the given cursor as an opaque page token
+func encodePageToken(cursor string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursor))
+}
+
+// decodePageToken decodes the cursor from the given page token
+func decodePageToken(token string) (string, error) {
+	if token == "" {
+		return "", nil
+	}
+	bytes, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(bytes) == 0 {
+		return "", errors.NewInvalid("invalid page token '%s'", token)
+	}
+	return string(bytes), nil
+}
+
+// getPage returns the cursor and size of the requested page
+func getPage(ctx context.Context) (string, int, error) {
+	cursor, err := decodePageToken(getStringMetadata(ctx, PageTokenKey))
+	if err != nil {
+		return "", 0, err
+	}
+	var size int
+	if value := getStringMetadata(ctx, PageSizeKey); value != "" {
+		size, err = strconv.Atoi(value)
+		if err != nil || size < 0 {
+			return "", 0, errors.NewInvalid("invalid page size '%s'", value)
+		}
+	}
+	return cursor, size, nil
+}
+
+// sendNextPageToken sends the token for the page after the given cursor in the response headers
+func sendNextPageToken(ctx context.Context, cursor string) {
+	if err := grpc.SetHeader(ctx, metadata.Pairs(NextPageTokenKey, encodePageToken(cursor))); err != nil {
+		log.Debugf("Failed to send next page token: %s", err)
+	}
+}
+
+// getKeysPage returns the page of the given sorted keys after the given cursor, returning the cursor of the next page
+// The cursor is the last key of the previous page, so models added or removed between pages don't shift later pages.
+// If the limit is zero, all keys after the cursor are returned. The returned cursor is empty if there are no more keys.
+func getKeysPage(keys []string, cursor string, limit int) ([]string, string) {
+	i := sort.SearchStrings(keys, cursor)
+	if i < len(keys) && keys[i] == cursor {
+		i++
+	}
+	keys = keys[i:]
+	if limit > 0 && limit < len(keys) {
+		keys = keys[:limit]
+		return keys, keys[limit-1]
+	}
+	return keys, ""
+}
+
+// getModelsPage returns a page of the given models, returning the cursor of the next page
+// Models that are filtered before they're paged are paged the same way as models listed from a registry.
+func getModelsPage(modelInfos []configmodel.ModelInfo, cursor string, limit int) ([]configmodel.ModelInfo, string) {
+	keys := make([]string, 0, len(modelInfos))
+	models := make(map[string]configmodel.ModelInfo, len(modelInfos))
+	for _, modelInfo := range modelInfos {
+		key := getModelKey(modelInfo.Name, modelInfo.Version)
+		keys = append(keys, key)
+		models[key] = modelInfo
+	}
+	sort.Strings(keys)
+	keys, next := getKeysPage(keys, cursor, limit)
+	page := make([]configmodel.ModelInfo, 0, len(keys))
+	for _, key := range keys {
+		page = append(page, models[key])
+	}
+	return page, next
+}
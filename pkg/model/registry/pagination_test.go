@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	"fmt"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestListModelsPage(t *testing.T) {
+	server := newTestServer(t)
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, server.registry.AddModel(configmodel.ModelInfo{
+			Name:    configmodel.Name(fmt.Sprintf("model%d", i)),
+			Version: "1.0.0",
+		}))
+	}
+
+	models, next, err := server.registry.ListModelsPage("", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, getModelKey("model1", "1.0.0"), next)
+	assert.Len(t, models, 2)
+	assert.Equal(t, configmodel.Name("model0"), models[0].Name)
+	assert.Equal(t, configmodel.Name("model1"), models[1].Name)
+
+	models, next, err = server.registry.ListModelsPage(getModelKey("model3", "1.0.0"), 2)
+	assert.NoError(t, err)
+	assert.Empty(t, next)
+	assert.Len(t, models, 1)
+
+	models, next, err = server.registry.ListModelsPage(getModelKey("model9", "1.0.0"), 2)
+	assert.NoError(t, err)
+	assert.Empty(t, next)
+	assert.Len(t, models, 0)
+
+	client := newTestClient(t, server)
+	ctx := context.Background()
+
+	// An empty token returns the first page
+	page, token, err := ListModelsPage(ctx, client, 2, "")
+	assert.NoError(t, err)
+	assert.Len(t, page, 2)
+	assert.Equal(t, "model0", page[0].Name)
+	assert.NotEmpty(t, token)
+
+	// Deleting models while iterating must neither fail the listing nor skip models
+	assert.NoError(t, server.registry.RemoveModel("model0", "1.0.0"))
+	assert.NoError(t, server.registry.RemoveModel("model4", "1.0.0"))
+
+	page, token, err = ListModelsPage(ctx, client, 2, token)
+	assert.NoError(t, err)
+	if assert.Len(t, page, 2) {
+		assert.Equal(t, "model2", page[0].Name)
+		assert.Equal(t, "model3", page[1].Name)
+	}
+	assert.Empty(t, token)
+
+	_, _, err = ListModelsPage(ctx, client, 2, "bogus")
+	assert.True(t, errors.IsInvalid(errors.FromGRPC(err)))
+
+	// Models are not paged unless a page size is requested
+	page, token, err = ListModelsPage(ctx, client, 0, "")
+	assert.NoError(t, err)
+	assert.Len(t, page, 3)
+	assert.Empty(t, token)
+}
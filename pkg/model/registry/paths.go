@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"fmt"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/openconfig/goyang/pkg/yang"
+	"sort"
+	"strings"
+)
+
+// StatePaths is the set of read-only paths in a model
+type StatePaths struct {
+	// Paths is the list of read-only paths with no wildcards
+	Paths []string `json:"paths"`
+	// WildcardPaths is the list of read-only paths under a list, with wildcards for the list keys
+	WildcardPaths []string `json:"wildcardPaths"`
+}
+
+// GetModelStatePaths returns the read-only (config false) paths for the given model
+func (r *ConfigModelRegistry) GetModelStatePaths(name configmodel.Name, version configmodel.Version) (StatePaths, error) {
+	model, err := r.GetModel(name, version)
+	if err != nil {
+		return StatePaths{}, err
+	}
+	return GetStatePaths(model)
+}
+
+// GetStatePaths returns the read-only (config false) paths for the given model from its YANG files
+func GetStatePaths(model configmodel.ModelInfo) (StatePaths, error) {
+	paths := StatePaths{
+		Paths:         []string{},
+		WildcardPaths: []string{},
+	}
+
+	modules, diagnostics := parseModules(model)
+	if len(diagnostics) > 0 {
+		return paths, errors.NewInvalid("model '%s' is not valid: %s", model, diagnostics[0])
+	}
+
+	for _, module := range model.Modules {
+		if module.IsSubmodule() {
+			continue
+		}
+		entry, errs := modules.GetModule(string(module.Name))
+		if len(errs) > 0 {
+			return paths, errors.NewInvalid("failed to load module '%s': %s", module.Name, errs[0])
+		}
+		addStatePaths(&paths, entry, "", false)
+	}
+	sort.Strings(paths.Paths)
+	sort.Strings(paths.WildcardPaths)
+	return paths, nil
+}
+
+// addStatePaths adds the read-only leaf paths under the given entry
+func addStatePaths(paths *StatePaths, entry *yang.Entry, path string, wildcard bool) {
+	names := make([]string, 0, len(entry.Dir))
+	for name := range entry.Dir {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		child := entry.Dir[name]
+
+		// Choice and case nodes do not appear in data paths
+		if child.IsChoice() || child.IsCase() {
+			addStatePaths(paths, child, path, wildcard)
+			continue
+		}
+
+		childPath := fmt.Sprintf("%s/%s", path, child.Name)
+		childWildcard := wildcard
+		if child.IsList() {
+			for _, key := range strings.Fields(child.Key) {
+				childPath = fmt.Sprintf("%s[%s=*]", childPath, key)
+				childWildcard = true
+			}
+		}
+
+		if child.IsDir() {
+			addStatePaths(paths, child, childPath, childWildcard)
+		} else if child.ReadOnly() {
+			if childWildcard {
+				paths.WildcardPaths = append(paths.WildcardPaths, childPath)
+			} else {
+				paths.Paths = append(paths.Paths, childPath)
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+const stateYang = `module state {
+  namespace "http://opennetworking.org/test/state";
+  prefix st;
+
+  container system {
+    leaf hostname {
+      type string;
+    }
+    leaf uptime {
+      type uint64;
+      config false;
+    }
+    container counters {
+      config false;
+      leaf in-octets {
+        type uint64;
+      }
+    }
+    list interface {
+      key "name";
+      leaf name {
+        type string;
+      }
+      leaf mtu {
+        type uint16;
+      }
+      leaf oper-status {
+        type string;
+        config false;
+      }
+      list neighbor {
+        key "ip port";
+        config false;
+        leaf ip {
+          type string;
+        }
+        leaf port {
+          type uint16;
+        }
+      }
+    }
+  }
+}
+`
+
+func TestGetStatePaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-model-registry")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	registry := NewConfigModelRegistry(Config{
+		Path:            dir,
+		CompressStorage: true,
+	})
+	err = registry.AddModel(configmodel.ModelInfo{
+		Name:    "state",
+		Version: "1.0.0",
+		Modules: []configmodel.ModuleInfo{
+			{
+				Name: "state",
+				File: "state.yang",
+			},
+		},
+		Files: []configmodel.FileInfo{
+			{
+				Path: "state.yang",
+				Data: []byte(stateYang),
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	paths, err := registry.GetModelStatePaths("state", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"/system/counters/in-octets",
+		"/system/uptime",
+	}, paths.Paths)
+	assert.Equal(t, []string{
+		"/system/interface[name=*]/neighbor[ip=*][port=*]/ip",
+		"/system/interface[name=*]/neighbor[ip=*][port=*]/port",
+		"/system/interface[name=*]/oper-status",
+	}, paths.WildcardPaths)
+}
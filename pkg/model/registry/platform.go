@@ -0,0 +1,211 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	"encoding/json"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	modelplugin "github.com/onosproject/onos-config-model/pkg/model/plugin"
+	plugincache "github.com/onosproject/onos-config-model/pkg/model/plugin/cache"
+	pluginmodule "github.com/onosproject/onos-config-model/pkg/model/plugin/module"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// PluginArtifactsKey is the GetModel response header containing the JSON encoded platforms for which
+// plugins are stored for the model
+const PluginArtifactsKey = "config-model-plugin-artifacts"
+
+// GetPluginArtifacts gets the plugin binaries stored for the given model, one per platform
+func GetPluginArtifacts(ctx context.Context, client configmodelapi.ConfigModelRegistryServiceClient, name configmodel.Name, version configmodel.Version) ([]configmodel.PluginArtifact, error) {
+	var header metadata.MD
+	request := &configmodelapi.GetModelRequest{
+		Name:    string(name),
+		Version: string(version),
+	}
+	if _, err := client.GetModel(ctx, request, grpc.Header(&header)); err != nil {
+		return nil, err
+	}
+	values := header.Get(PluginArtifactsKey)
+	if len(values) == 0 {
+		return nil, nil
+	}
+	var artifacts []configmodel.PluginArtifact
+	if err := json.Unmarshal([]byte(values[0]), &artifacts); err != nil {
+		return nil, err
+	}
+	return artifacts, nil
+}
+
+// newPluginArtifact returns the artifact for a plugin built for the given platform
+func newPluginArtifact(platform pluginmodule.Platform) configmodel.PluginArtifact {
+	return configmodel.PluginArtifact{
+		GOOS:   platform.GOOS,
+		GOARCH: platform.GOARCH,
+	}
+}
+
+// getPlatform returns the plugin platform requested in the incoming metadata, defaulting to the cache's platform
+func (s *Server) getPlatform(ctx context.Context) (pluginmodule.Platform, error) {
+	value := getStringMetadata(ctx, PlatformKey)
+	if value == "" {
+		return s.cache.Platform(), nil
+	}
+	platform, err := pluginmodule.ParsePlatform(value)
+	if err != nil {
+		return platform, err
+	}
+	return platform, platform.Validate()
+}
+
+// pushPlatformPlugin registers a plugin built externally for the given platform
+// The model is added if it's not in the registry. Otherwise the plugin is added to the existing model's
+// artifacts, and the pushed model definition is ignored. Existing artifacts are only replaced if forced.
+func (s *Server) pushPlatformPlugin(ctx context.Context, modelInfo configmodel.ModelInfo, platform pluginmodule.Platform) (<-chan error, error) {
+	if !getBoolMetadata(ctx, SkipCompileKey) {
+		err := errors.NewInvalid("plugins for platform '%s' cannot be compiled by a registry compiling for '%s'; push a prebuilt plugin with compilation skipped", platform, s.cache.Platform())
+		log.Warnf("PushModelRequest '%s' failed: %s", modelInfo, err)
+		return nil, errors.Status(err).Err()
+	}
+
+	entry, err := s.cache.PlatformEntry(modelInfo.Name, modelInfo.Version, platform)
+	if err != nil {
+		log.Warnf("PushModelRequest '%s' failed: %s", modelInfo, err)
+		return nil, errors.Status(err).Err()
+	}
+	if err := entry.RLock(ctx); err != nil {
+		log.Errorf("Failed to acquire cache lock: %s", err)
+		return nil, errors.Status(err).Err()
+	}
+	cached, err := entry.Cached()
+	if err := entry.RUnlock(ctx); err != nil {
+		log.Errorf("Failed to release cache lock: %s", err)
+	}
+	if err == nil && !cached {
+		err = errors.NewNotFound("plugin for model '%s' not found for platform '%s' at '%s'", modelInfo, platform, entry.Path)
+	}
+	if err != nil {
+		log.Warnf("PushModelRequest '%s' failed: %s", modelInfo, err)
+		return nil, errors.Status(err).Err()
+	}
+
+	artifact := newPluginArtifact(platform)
+	added := false
+	existing, err := s.registry.GetModel(modelInfo.Name, modelInfo.Version)
+	if errors.IsNotFound(err) {
+		modelInfo.Plugin.SetArtifact(artifact)
+		err = s.registry.AddModel(modelInfo)
+		added = err == nil
+	} else if err == nil {
+		if _, ok := existing.Plugin.GetArtifact(platform.GOOS, platform.GOARCH); ok && !getBoolMetadata(ctx, ForceKey) {
+			err = errors.NewAlreadyExists("plugin for model '%s' already exists for platform '%s'", modelInfo, platform)
+		} else {
+			err = s.registry.SetPluginArtifact(modelInfo.Name, modelInfo.Version, artifact)
+		}
+	}
+	if err != nil {
+		log.Warnf("PushModelRequest '%s' failed: %s", modelInfo, err)
+		return nil, errors.Status(err).Err()
+	}
+	log.Infof("Registered plugin for model '%s' for platform '%s'", modelInfo, platform)
+	if added {
+		s.notifyModelEvent(ModelAdded, modelInfo)
+	}
+
+	done := make(chan error, 1)
+	done <- nil
+	return done, nil
+}
+
+// loadPlatformPlugin loads the plugin stored for the given model for a platform other than the registry's
+// Plugins for other platforms are only built externally, so they're never compiled on load.
+func (s *Server) loadPlatformPlugin(ctx context.Context, name configmodel.Name, version configmodel.Version, platform pluginmodule.Platform) (modelplugin.ConfigModelPlugin, error) {
+	modelInfo, err := s.registry.GetModel(name, version)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := modelInfo.Plugin.GetArtifact(platform.GOOS, platform.GOARCH); !ok {
+		return nil, errors.NewNotFound("no plugin for model '%s' is stored for platform '%s'", modelInfo, platform)
+	}
+	entry, err := s.cache.PlatformEntry(name, version, platform)
+	if err != nil {
+		return nil, err
+	}
+	if err := entry.RLock(ctx); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := entry.RUnlock(ctx); err != nil {
+			log.Errorf("Failed to release cache lock: %s", err)
+		}
+	}()
+	return s.load(entry)
+}
+
+// recordPluginArtifact records the plugin compiled by the registry for the given model in the model descriptor
+func (s *Server) recordPluginArtifact(modelInfo configmodel.ModelInfo) {
+	if s.registry.IsReadOnly() {
+		return
+	}
+	if err := s.registry.SetPluginArtifact(modelInfo.Name, modelInfo.Version, newPluginArtifact(s.cache.Platform())); err != nil {
+		log.Warnf("Failed to record plugin artifact for model '%s': %s", modelInfo, err)
+	}
+}
+
+// getPlatformEntries returns the cache entries of the given model's plugins for platforms other than the registry's
+func (s *Server) getPlatformEntries(modelInfo configmodel.ModelInfo) ([]*plugincache.PluginEntry, error) {
+	var entries []*plugincache.PluginEntry
+	for _, artifact := range modelInfo.Plugin.Artifacts {
+		platform := pluginmodule.NewPlatform(artifact.GOOS, artifact.GOARCH)
+		if platform == s.cache.Platform() {
+			continue
+		}
+		entry, err := s.cache.PlatformEntry(modelInfo.Name, modelInfo.Version, platform)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// removePlatformPlugins removes the given model's plugins for platforms other than the registry's
+func (s *Server) removePlatformPlugins(ctx context.Context, modelInfo configmodel.ModelInfo) error {
+	entries, err := s.getPlatformEntries(modelInfo)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := entry.Lock(ctx); err != nil {
+			return err
+		}
+		err := entry.Remove()
+		if err := entry.Unlock(context.Background()); err != nil {
+			log.Errorf("Failed to release cache lock: %s", err)
+		}
+		if err != nil {
+			return errors.NewInternal("failed to remove plugin '%s' for model '%s': %s", entry.Path, modelInfo, err)
+		}
+	}
+	return nil
+}
+
+// sendPluginArtifacts sends the plugin artifacts stored for the given model in the response headers
+func sendPluginArtifacts(ctx context.Context, modelInfo configmodel.ModelInfo) {
+	if len(modelInfo.Plugin.Artifacts) == 0 {
+		return
+	}
+	bytes, err := json.Marshal(modelInfo.Plugin.Artifacts)
+	if err != nil {
+		log.Warnf("Failed to encode plugin artifacts for model '%s': %s", modelInfo, err)
+		return
+	}
+	if err := grpc.SetHeader(ctx, metadata.Pairs(PluginArtifactsKey, string(bytes))); err != nil {
+		log.Debugf("Failed to send plugin artifacts: %s", err)
+	}
+}
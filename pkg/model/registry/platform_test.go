@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	modelplugin "github.com/onosproject/onos-config-model/pkg/model/plugin"
+	plugincache "github.com/onosproject/onos-config-model/pkg/model/plugin/cache"
+	pluginmodule "github.com/onosproject/onos-config-model/pkg/model/plugin/module"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"testing"
+)
+
+func TestPluginPlatforms(t *testing.T) {
+	server := newTestServer(t)
+	client := newTestClient(t, server)
+
+	goarch := "arm64"
+	if runtime.GOARCH == goarch {
+		goarch = "amd64"
+	}
+	host := server.cache.Platform()
+	other := pluginmodule.NewPlatform("linux", goarch)
+	push := func(ctx context.Context) error {
+		_, err := client.PushModel(WithSkipCompile(ctx), &configmodelapi.PushModelRequest{
+			Model: &configmodelapi.ConfigModel{
+				Name:    "test",
+				Version: "1.0.0",
+			},
+		})
+		return err
+	}
+
+	// Plugins for other platforms must be built externally
+	_, err := client.PushModel(WithPlatform(context.Background(), other), &configmodelapi.PushModelRequest{
+		Model: &configmodelapi.ConfigModel{
+			Name:    "test",
+			Version: "1.0.0",
+		},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	err = push(WithPlatform(context.Background(), other))
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// A registry populated by external builds can store only plugins for other platforms
+	otherEntry, err := server.cache.PlatformEntry("test", "1.0.0", other)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(otherEntry.Path, []byte("plugin"), 0666))
+	assert.NoError(t, push(WithPlatform(context.Background(), other)))
+	artifacts, err := GetPluginArtifacts(context.Background(), client, "test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, []configmodel.PluginArtifact{newPluginArtifact(other)}, artifacts)
+	err = push(WithPlatform(context.Background(), other))
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	assert.NoError(t, push(WithForce(WithPlatform(context.Background(), other))))
+
+	// The plugin for the registry's platform is added to the same model
+	hostEntry := server.cache.Entry("test", "1.0.0")
+	assert.NoError(t, ioutil.WriteFile(hostEntry.Path, []byte("plugin"), 0666))
+	assert.NoError(t, push(WithPlatform(context.Background(), host)))
+	artifacts, err = GetPluginArtifacts(context.Background(), client, "test", "1.0.0")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []configmodel.PluginArtifact{newPluginArtifact(host), newPluginArtifact(other)}, artifacts)
+
+	// Plugins are loaded by platform
+	var loaded string
+	server.load = func(entry *plugincache.PluginEntry) (modelplugin.ConfigModelPlugin, error) {
+		loaded = entry.Path
+		return nil, nil
+	}
+	_, err = server.loadPlatformPlugin(context.Background(), "test", "1.0.0", other)
+	assert.NoError(t, err)
+	assert.Equal(t, otherEntry.Path, loaded)
+	_, err = server.loadPlatformPlugin(context.Background(), "test", "1.0.0", pluginmodule.NewPlatform("linux", "s390x"))
+	assert.Error(t, err)
+	_, err = server.LoadPlugin(context.Background(), "test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, hostEntry.Path, loaded)
+
+	// Deleting the model removes the plugins for all platforms
+	files, err := DeleteModel(context.Background(), client, "test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Contains(t, files, hostEntry.Path)
+	assert.Contains(t, files, otherEntry.Path)
+	for _, path := range []string{hostEntry.Path, otherEntry.Path} {
+		_, err := os.Stat(path)
+		assert.True(t, os.IsNotExist(err))
+	}
+
+	err = push(WithPlatform(context.Background(), pluginmodule.NewPlatform("windows", "amd64")))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"strconv"
+)
+
+// Priority is the priority of a pushed model's compilation
+// Compilations with a higher priority are run ahead of queued compilations with a lower priority.
+type Priority string
+
+const (
+	// PriorityLow is the priority of compilations that can wait for other work, e.g. bulk imports
+	PriorityLow Priority = "low"
+	// PriorityNormal is the default priority of compilations
+	PriorityNormal Priority = "normal"
+	// PriorityHigh is the priority of urgent compilations, e.g. production hotfixes
+	PriorityHigh Priority = "high"
+)
+
+// rank returns the order of the priority, with higher priorities having higher ranks
+func (p Priority) rank() int {
+	switch p {
+	case PriorityLow:
+		return -1
+	case PriorityHigh:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// QueuePositionKey is the GetModel response header containing the position of the model's compilation in the queue
+// Positions start at 1 for the next compilation to run. The header is only sent while the compilation is queued.
+const QueuePositionKey = "config-model-queue-position"
+
+// GetQueuePosition gets the position in the compile queue of the plugin for the given model
+// If the plugin is not waiting to be compiled, 0 is returned.
+func GetQueuePosition(ctx context.Context, client configmodelapi.ConfigModelRegistryServiceClient, name configmodel.Name, version configmodel.Version) (int, error) {
+	var header metadata.MD
+	request := &configmodelapi.GetModelRequest{
+		Name:    string(name),
+		Version: string(version),
+	}
+	if _, err := client.GetModel(ctx, request, grpc.Header(&header)); err != nil {
+		return 0, err
+	}
+	values := header.Get(QueuePositionKey)
+	if len(values) == 0 {
+		return 0, nil
+	}
+	return strconv.Atoi(values[0])
+}
+
+// getPriority returns the priority requested in the incoming metadata, defaulting to normal
+func getPriority(ctx context.Context) (Priority, error) {
+	switch priority := Priority(getStringMetadata(ctx, PriorityKey)); priority {
+	case "":
+		return PriorityNormal, nil
+	case PriorityLow, PriorityNormal, PriorityHigh:
+		return priority, nil
+	default:
+		return "", errors.NewInvalid("unknown priority '%s'", priority)
+	}
+}
+
+// sendQueuePosition sends the position of the given model's compilation in the response headers
+func (s *Server) sendQueuePosition(ctx context.Context, modelInfo configmodel.ModelInfo) {
+	position := s.workers.position(modelInfo.String())
+	if position == 0 {
+		return
+	}
+	if err := grpc.SetHeader(ctx, metadata.Pairs(QueuePositionKey, strconv.Itoa(position))); err != nil {
+		log.Debugf("Failed to send queue position: %s", err)
+	}
+}
@@ -0,0 +1,189 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	modelplugin "github.com/onosproject/onos-config-model/pkg/model/plugin"
+	plugincache "github.com/onosproject/onos-config-model/pkg/model/plugin/cache"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newReadOnlyTestServer creates a test server with a read-only registry staged with the given models
+func newReadOnlyTestServer(t *testing.T, models ...configmodel.ModelInfo) *Server {
+	server := newTestServer(t)
+	for _, model := range models {
+		assert.NoError(t, server.registry.AddModel(model))
+	}
+	path := server.registry.(*ConfigModelRegistry).Config.Path
+	assert.NoError(t, os.Chmod(path, 0555))
+	t.Cleanup(func() {
+		_ = os.Chmod(path, 0755)
+	})
+	server.registry = NewConfigModelRegistry(Config{
+		Path:     path,
+		ReadOnly: true,
+	})
+	return server
+}
+
+func TestReadOnlyRegistry(t *testing.T) {
+	server := newReadOnlyTestServer(t, configmodel.ModelInfo{Name: "test", Version: "1.0.0"})
+	client := newTestClient(t, server)
+	assert.True(t, server.registry.IsReadOnly())
+	assert.True(t, server.Capabilities().Has(ReadOnlyCapability))
+	files, err := ioutil.ReadDir(server.registry.(*ConfigModelRegistry).Config.Path)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	// Staged models are served
+	response, err := client.GetModel(context.Background(), &configmodelapi.GetModelRequest{Name: "test", Version: "1.0.0"})
+	assert.NoError(t, err)
+	assert.Equal(t, "test", response.Model.Name)
+
+	// Models cannot be added, updated, or removed
+	_, err = client.PushModel(context.Background(), &configmodelapi.PushModelRequest{
+		Model: &configmodelapi.ConfigModel{Name: "foo", Version: "1.0.0"},
+	})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = client.DeleteModel(context.Background(), &configmodelapi.DeleteModelRequest{Name: "test", Version: "1.0.0"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.True(t, errors.IsForbidden(server.registry.PinModel("test", "1.0.0")))
+	_, err = DeleteModel(WithDryRun(context.Background()), client, "test", "1.0.0")
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// Plugins missing from the cache are compiled on load (which fails here without the templates)
+	loads := 0
+	server.load = func(entry *plugincache.PluginEntry) (modelplugin.ConfigModelPlugin, error) {
+		loads++
+		return nil, nil
+	}
+	_, err = server.LoadPlugin(context.Background(), "test", "1.0.0")
+	assert.Error(t, err)
+	assert.Equal(t, 0, loads)
+	assert.False(t, server.cache.Entry("test", "1.0.0").IsLocked())
+
+	// Cached plugins are loaded without compiling them
+	assert.NoError(t, ioutil.WriteFile(server.cache.Entry("test", "1.0.0").Path, []byte("plugin"), 0666))
+	_, err = server.LoadPlugin(context.Background(), "test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, loads)
+
+	// The registry is not modified
+	files, err = ioutil.ReadDir(server.registry.(*ConfigModelRegistry).Config.Path)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+func TestReadOnlyService(t *testing.T) {
+	server := newTestServer(t)
+	server.config.ReadOnly = true
+	client := newTestClient(t, server)
+	assert.NoError(t, server.registry.AddModel(configmodel.ModelInfo{Name: "test", Version: "1.0.0"}))
+	assert.False(t, server.registry.IsReadOnly())
+	assert.True(t, server.Capabilities().Has(ReadOnlyCapability))
+
+	// Models are served from the writable registry
+	response, err := client.GetModel(context.Background(), &configmodelapi.GetModelRequest{Name: "test", Version: "1.0.0"})
+	assert.NoError(t, err)
+	assert.Equal(t, "test", response.Model.Name)
+	list, err := client.ListModels(context.Background(), &configmodelapi.ListModelsRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, list.Models, 1)
+
+	// Pushes and deletes are rejected before the request is processed, even for dry runs
+	_, err = client.PushModel(context.Background(), &configmodelapi.PushModelRequest{
+		Model: &configmodelapi.ConfigModel{Name: "foo", Version: "1.0.0"},
+	})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = client.DeleteModel(context.Background(), &configmodelapi.DeleteModelRequest{Name: "test", Version: "1.0.0"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = DeleteModel(WithDryRun(context.Background()), client, "test", "1.0.0")
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = server.registry.GetModel("test", "1.0.0")
+	assert.NoError(t, err)
+}
+
+func TestDetectReadOnlyRegistry(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("file permissions are not enforced for root")
+	}
+	dir, err := ioutil.TempDir("", "config-model-registry")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.False(t, NewConfigModelRegistry(Config{Path: dir}).IsReadOnly())
+	assert.NoError(t, os.Chmod(dir, 0555))
+	defer os.Chmod(dir, 0755)
+	assert.True(t, NewConfigModelRegistry(Config{Path: dir}).IsReadOnly())
+}
+
+func TestCompileFromReadOnlyRegistry(t *testing.T) {
+	if testing.Short() || raceEnabled {
+		t.Skip("building plugins is slow and they cannot be loaded by binaries built with -race")
+	}
+
+	moduleRoot, err := filepath.Abs(filepath.Join("..", "..", ".."))
+	assert.NoError(t, err)
+	dir, err := ioutil.TempDir("", "config-model-readonly-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	yang, err := ioutil.ReadFile(filepath.Join(moduleRoot, "test", "test@2020-11-18.yang"))
+	assert.NoError(t, err)
+	server := newReadOnlyTestServer(t, configmodel.ModelInfo{
+		Name:         "readonly",
+		Version:      "1.0.0",
+		GetStateMode: configmodel.GetStateNone,
+		Modules: []configmodel.ModuleInfo{
+			{
+				Name:     "test",
+				Revision: "2020-11-18",
+				File:     "test@2020-11-18.yang",
+			},
+		},
+		Files: []configmodel.FileInfo{
+			{
+				Path: "test@2020-11-18.yang",
+				Data: yang,
+			},
+		},
+		Plugin: configmodel.PluginInfo{
+			Name:    "readonly",
+			Version: "1.0.0",
+		},
+	})
+	server.compiler.Config.TemplatePath = filepath.Join(moduleRoot, "pkg", "model", "plugin", "compiler", "templates")
+	server.compiler.Config.ModFile = writeTryoutModFile(t, dir, moduleRoot)
+	server.compiler.Config.SumFile = filepath.Join(moduleRoot, "go.sum")
+
+	client := newTestClient(t, server)
+
+	// The plugin is compiled from the YANG files in the read-only registry into the writable cache once it's served
+	_, err = client.GetModel(context.Background(), &configmodelapi.GetModelRequest{Name: "readonly", Version: "1.0.0"})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(server.cache.Entry("readonly", "1.0.0").Path)
+		return err == nil
+	}, time.Minute, 100*time.Millisecond)
+
+	plugin, err := server.LoadPlugin(context.Background(), "readonly", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, configmodel.Name("readonly"), plugin.Model().Info().Name)
+
+	model, err := server.registry.GetModel("readonly", "1.0.0")
+	assert.NoError(t, err)
+	assert.Nil(t, model.Build)
+}
@@ -8,28 +8,82 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-config-model/pkg/model/logging"
 	"github.com/onosproject/onos-lib-go/pkg/errors"
-	"github.com/onosproject/onos-lib-go/pkg/logging"
 	"github.com/rogpeppe/go-internal/module"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 )
 
-const jsonExt = ".json"
+const (
+	jsonExt    = ".json"
+	historyExt = ".history"
+	tmpExt     = ".tmp"
+)
 
 const (
-	defaultPath   = "/etc/onos/registry"
-	defaultTarget = "github.com/onosproject/onos-config"
+	defaultPath              = "/etc/onos/registry"
+	defaultTarget            = "github.com/onosproject/onos-config"
+	defaultMaxCompileHistory = 10
 )
 
-var log = logging.GetLogger("config-model", "registry")
+var log = modellogging.GetLogger("config-model", "registry")
 
 // Config is a model plugin registry config
 type Config struct {
-	Path string `yaml:"path" json:"path"`
+	Path              string `yaml:"path" json:"path"`
+	MaxCompileHistory int    `yaml:"maxCompileHistory" json:"maxCompileHistory"`
+	CompressStorage   bool   `yaml:"compressStorage" json:"compressStorage"`
+	// ReadOnly indicates the registry path must not be modified
+	// Registries whose path cannot be written, e.g. read-only volumes, are detected as read-only
+	// even if ReadOnly is not set.
+	ReadOnly bool `yaml:"readOnly" json:"readOnly"`
+}
+
+// Registry is a store of config models
+type Registry interface {
+	fmt.Stringer
+	// GetModel gets a model by name and version
+	GetModel(name configmodel.Name, version configmodel.Version) (configmodel.ModelInfo, error)
+	// ListModels lists models in the registry
+	ListModels() ([]configmodel.ModelInfo, error)
+	// ListModelsPage lists a page of models in the registry after the given cursor, returning the cursor of the next page
+	ListModelsPage(cursor string, limit int) ([]configmodel.ModelInfo, string, error)
+	// AddModel adds a model to the registry
+	AddModel(model configmodel.ModelInfo) error
+	// RemoveModel removes a model from the registry
+	RemoveModel(name configmodel.Name, version configmodel.Version) error
+	// PinModel pins a model to protect it from deletion and eviction
+	PinModel(name configmodel.Name, version configmodel.Version) error
+	// UnpinModel unpins a model
+	UnpinModel(name configmodel.Name, version configmodel.Version) error
+	// SetBuildInfo records the environment in which the plugin for a model was built
+	SetBuildInfo(name configmodel.Name, version configmodel.Version, info configmodel.BuildInfo) error
+	// SetPluginArtifact records a plugin binary built for a model, replacing any artifact for the same platform
+	SetPluginArtifact(name configmodel.Name, version configmodel.Version, artifact configmodel.PluginArtifact) error
+	// GetModelFiles returns the files stored in the registry for a model
+	GetModelFiles(name configmodel.Name, version configmodel.Version) []string
+	// GetCompileHistory gets the recent compile attempts for a model, oldest first
+	GetCompileHistory(name configmodel.Name, version configmodel.Version) ([]CompileAttempt, error)
+	// AddCompileAttempt records a compile attempt for a model
+	AddCompileAttempt(name configmodel.Name, version configmodel.Version, attempt CompileAttempt) error
+	// IsReadOnly returns whether the registry is read-only
+	IsReadOnly() bool
+	// Watch returns the models in the registry and a watcher receiving the models subsequently added and removed
+	Watch() ([]configmodel.ModelInfo, *ModelWatcher, error)
+}
+
+// checkMutable returns a Forbidden error if the registry is read-only
+func checkMutable(registry Registry) error {
+	if registry.IsReadOnly() {
+		return errors.NewForbidden("registry '%s' is read-only", registry)
+	}
+	return nil
 }
 
 // NewConfigModelRegistry creates a new config model registry
@@ -37,66 +91,128 @@ func NewConfigModelRegistry(config Config) *ConfigModelRegistry {
 	if config.Path == "" {
 		config.Path = defaultPath
 	}
-	if _, err := os.Stat(config.Path); os.IsNotExist(err) {
+	if config.MaxCompileHistory == 0 {
+		config.MaxCompileHistory = defaultMaxCompileHistory
+	}
+	if _, err := os.Stat(config.Path); os.IsNotExist(err) && !config.ReadOnly {
 		err = os.MkdirAll(config.Path, os.ModePerm)
 		if err != nil {
 			log.Error(err)
 		}
 	}
+	readOnly := config.ReadOnly
+	if !readOnly {
+		if err := checkWritable(config.Path); err != nil {
+			log.Warnf("Registry path '%s' is not writable; serving models read-only: %s", config.Path, err)
+			readOnly = true
+		}
+	}
 	return &ConfigModelRegistry{
-		Config: config,
+		Config:   config,
+		readOnly: readOnly,
 	}
 }
 
-// ConfigModelRegistry is a registry of config models
+// ConfigModelRegistry is a registry of config models stored in the filesystem
 type ConfigModelRegistry struct {
-	Config Config
-	mu     sync.RWMutex
+	Config    Config
+	readOnly  bool
+	lock      *os.File
+	publisher modelPublisher
+	mu        sync.RWMutex
+}
+
+var _ Registry = &ConfigModelRegistry{}
+
+func (r *ConfigModelRegistry) String() string {
+	return r.Config.Path
+}
+
+// IsReadOnly returns whether the registry is read-only
+// Models cannot be added to, updated in, or removed from a read-only registry.
+func (r *ConfigModelRegistry) IsReadOnly() bool {
+	return r.readOnly
+}
+
+// CompileAttempt is a record of an attempt to compile a model plugin
+type CompileAttempt struct {
+	Time     time.Time     `json:"time"`
+	Duration time.Duration `json:"duration"`
+	Success  bool          `json:"success"`
+	Error    string        `json:"error,omitempty"`
 }
 
 // GetModel gets a model by name and version
+// The version may be a constraint such as '^1.0.0', '~1.2' or 'latest', which is resolved to the highest
+// matching version of the model. See IsVersionConstraint for the supported constraints.
 func (r *ConfigModelRegistry) GetModel(name configmodel.Name, version configmodel.Version) (configmodel.ModelInfo, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	path := r.getDescriptorFile(name, version)
 	log.Debugf("Loading model definition '%s'", path)
 	model, err := loadModel(path)
+	// Versions are resolved as constraints only if there's no model with the exact version
+	if errors.IsNotFound(err) && IsVersionConstraint(version) {
+		var resolved configmodel.Version
+		if resolved, err = r.resolveModelVersion(name, version); err == nil {
+			path = r.getDescriptorFile(name, resolved)
+			log.Debugf("Resolved version '%s' of model '%s' to '%s'", version, name, resolved)
+			model, err = loadModel(path)
+		}
+	}
 	if err != nil {
 		log.Warnf("Failed loading model definition '%s': %v", path, err)
 		return configmodel.ModelInfo{}, err
 	}
-	log.Infof("Loaded model definition '%s': %s", path, model)
+	log.Debugw("Loaded model definition", modellogging.ModelField, model.Name, modellogging.VersionField, model.Version, "path", path)
 	return model, nil
 }
 
 // ListModels lists models in the registry
 func (r *ConfigModelRegistry) ListModels() ([]configmodel.ModelInfo, error) {
+	models, _, err := r.ListModelsPage("", 0)
+	return models, err
+}
+
+// ListModelsPage lists a page of models in the registry ordered by descriptor file name
+// Pages start after the descriptor named by the cursor. If the limit is zero, all models after the cursor
+// are returned. The returned cursor is the cursor of the next page, or empty if there are no more models.
+func (r *ConfigModelRegistry) ListModelsPage(cursor string, limit int) ([]configmodel.ModelInfo, string, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
+	return r.listModelsPage(cursor, limit)
+}
+
+func (r *ConfigModelRegistry) listModelsPage(cursor string, limit int) ([]configmodel.ModelInfo, string, error) {
 	log.Debugf("Loading models from '%s'", r.Config.Path)
-	var modelFiles []string
+	var names []string
 	err := filepath.Walk(r.Config.Path, func(file string, info os.FileInfo, err error) error {
 		if err == nil && strings.HasSuffix(file, jsonExt) {
-			modelFiles = append(modelFiles, file)
+			if name, err := filepath.Rel(r.Config.Path, file); err == nil {
+				names = append(names, strings.TrimSuffix(name, jsonExt))
+			}
 		}
 		return nil
 	})
 	if err != nil {
-		return nil, errors.NewInternal(err.Error())
+		return nil, "", errors.NewInternal(err.Error())
 	}
+	sort.Strings(names)
+	names, next := getKeysPage(names, cursor, limit)
 
 	var models []configmodel.ModelInfo
-	for _, file := range modelFiles {
+	for _, name := range names {
+		file := filepath.Join(r.Config.Path, name+jsonExt)
 		log.Debugf("Loading model definition '%s'", file)
 		model, err := loadModel(file)
 		if err != nil {
 			log.Warnf("Failed loading model definition '%s': %v", file, err)
 		} else {
-			log.Infof("Loaded model definition '%s': %s", file, model)
+			log.Debugw("Loaded model definition", modellogging.ModelField, model.Name, modellogging.VersionField, model.Version, "path", file)
 			models = append(models, model)
 		}
 	}
-	return models, nil
+	return models, next, nil
 }
 
 // AddModel adds a model to the registry
@@ -104,17 +220,116 @@ func (r *ConfigModelRegistry) AddModel(model configmodel.ModelInfo) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	log.Debugf("Adding model '%s/%s' to registry '%s'", model.Name, model.Version, r.Config.Path)
+	if err := checkMutable(r); err != nil {
+		log.Warnf("Adding model '%s/%s' failed: %v", model.Name, model.Version, err)
+		return err
+	}
+	model.Checksum = model.ComputeChecksum()
+	if err := r.writeModel(model); err != nil {
+		log.Errorf("Adding model '%s/%s' failed: %v", model.Name, model.Version, err)
+		return err
+	}
+	log.Infow("Model added to registry", modellogging.ModelField, model.Name, modellogging.VersionField, model.Version, "registry", r.Config.Path)
+	r.publisher.publish(newModelEvent(ModelAdded, model))
+	return nil
+}
+
+// Watch returns the models in the registry and a watcher receiving the models subsequently added and removed
+// The watcher is subscribed while the registry is locked, so no change is missing from both the returned
+// models and the watcher's events.
+func (r *ConfigModelRegistry) Watch() ([]configmodel.ModelInfo, *ModelWatcher, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	models, _, err := r.listModelsPage("", 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	return models, r.publisher.subscribe(), nil
+}
+
+// PinModel pins a model to protect it from deletion and eviction
+func (r *ConfigModelRegistry) PinModel(name configmodel.Name, version configmodel.Version) error {
+	return r.setPinned(name, version, true)
+}
+
+// UnpinModel unpins a model
+func (r *ConfigModelRegistry) UnpinModel(name configmodel.Name, version configmodel.Version) error {
+	return r.setPinned(name, version, false)
+}
+
+func (r *ConfigModelRegistry) setPinned(name configmodel.Name, version configmodel.Version, pinned bool) error {
+	err := r.updateModel(name, version, func(model *configmodel.ModelInfo) {
+		model.Pinned = pinned
+	})
+	if err != nil {
+		return err
+	}
+	log.Infof("Model '%s/%s' pinned: %t", name, version, pinned)
+	return nil
+}
+
+// SetBuildInfo records the environment in which the plugin for a model was built
+func (r *ConfigModelRegistry) SetBuildInfo(name configmodel.Name, version configmodel.Version, info configmodel.BuildInfo) error {
+	return r.updateModel(name, version, func(model *configmodel.ModelInfo) {
+		model.Build = &info
+	})
+}
+
+// SetPluginArtifact records a plugin binary built for a model, replacing any artifact for the same platform
+func (r *ConfigModelRegistry) SetPluginArtifact(name configmodel.Name, version configmodel.Version, artifact configmodel.PluginArtifact) error {
+	return r.updateModel(name, version, func(model *configmodel.ModelInfo) {
+		model.Plugin.SetArtifact(artifact)
+	})
+}
+
+func (r *ConfigModelRegistry) updateModel(name configmodel.Name, version configmodel.Version, f func(*configmodel.ModelInfo)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := checkMutable(r); err != nil {
+		log.Warnf("Updating model '%s/%s' failed: %v", name, version, err)
+		return err
+	}
+	model, err := loadModel(r.getDescriptorFile(name, version))
+	if err != nil {
+		log.Warnf("Updating model '%s/%s' failed: %v", name, version, err)
+		return err
+	}
+	f(&model)
+	if err := r.writeModel(model); err != nil {
+		log.Errorf("Updating model '%s/%s' failed: %v", name, version, err)
+		return err
+	}
+	return nil
+}
+
+func (r *ConfigModelRegistry) writeModel(model configmodel.ModelInfo) error {
+	model.Checksum = model.ComputeChecksum()
+	if r.Config.CompressStorage {
+		files := make([]configmodel.FileInfo, len(model.Files))
+		for i, file := range model.Files {
+			compressed, err := file.Compress()
+			if err != nil {
+				return err
+			}
+			files[i] = compressed
+		}
+		model.Files = files
+	}
 	bytes, err := json.MarshalIndent(model, "", "  ")
 	if err != nil {
-		log.Errorf("Adding model '%s/%s' failed: %v", model.Name, model.Version, err)
 		return err
 	}
+
+	// Descriptors are written to a temporary file and renamed so a replaced model is never partially written
 	path := r.getDescriptorFile(model.Name, model.Version)
-	if err := ioutil.WriteFile(path, bytes, 0666); err != nil {
-		log.Errorf("Adding model '%s/%s' failed: %v", model.Name, model.Version, err)
+	tmpPath := path + tmpExt
+	if err := ioutil.WriteFile(tmpPath, bytes, 0666); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
 		return err
 	}
-	log.Infof("Model '%s/%s' added to registry '%s'", model.Name, model.Version, r.Config.Path)
 	return nil
 }
 
@@ -123,19 +338,122 @@ func (r *ConfigModelRegistry) RemoveModel(name configmodel.Name, version configm
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	log.Debugf("Deleting model '%s/%s' from registry '%s'", name, version, r.Config.Path)
+	if err := checkMutable(r); err != nil {
+		log.Warnf("Deleting model '%s/%s' failed: %v", name, version, err)
+		return err
+	}
 	path := r.getDescriptorFile(name, version)
+	// The removed model is loaded to publish its checksum to watchers
+	removed, loadErr := loadModel(path)
 	if _, err := os.Stat(path); !os.IsNotExist(err) {
 		if err := os.Remove(path); err != nil {
 			log.Errorf("Deleting model '%s/%s' failed: %v", name, version, err)
 			return err
 		}
 	}
-	log.Infof("Model '%s/%s' deleted from registry '%s'", name, version, r.Config.Path)
+	historyPath := r.getHistoryFile(name, version)
+	if _, err := os.Stat(historyPath); !os.IsNotExist(err) {
+		if err := os.Remove(historyPath); err != nil {
+			log.Errorf("Deleting model '%s/%s' failed: %v", name, version, err)
+			return err
+		}
+	}
+	log.Infow("Model deleted from registry", modellogging.ModelField, name, modellogging.VersionField, version, "registry", r.Config.Path)
+	if loadErr == nil {
+		r.publisher.publish(newModelEvent(ModelRemoved, removed))
+	}
+	return nil
+}
+
+// GetModelFiles returns the files stored in the registry for a model
+func (r *ConfigModelRegistry) GetModelFiles(name configmodel.Name, version configmodel.Version) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var files []string
+	for _, path := range []string{r.getDescriptorFile(name, version), r.getHistoryFile(name, version)} {
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// GetCompileHistory gets the recent compile attempts for a model, oldest first
+func (r *ConfigModelRegistry) GetCompileHistory(name configmodel.Name, version configmodel.Version) ([]CompileAttempt, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return loadHistory(r.getHistoryFile(name, version))
+}
+
+// AddCompileAttempt records a compile attempt for a model
+func (r *ConfigModelRegistry) AddCompileAttempt(name configmodel.Name, version configmodel.Version, attempt CompileAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := checkMutable(r); err != nil {
+		return err
+	}
+	path := r.getHistoryFile(name, version)
+	history, err := loadHistory(path)
+	if err != nil {
+		log.Errorf("Recording compile attempt for model '%s/%s' failed: %v", name, version, err)
+		return err
+	}
+	history = append(history, attempt)
+	if len(history) > r.Config.MaxCompileHistory {
+		history = history[len(history)-r.Config.MaxCompileHistory:]
+	}
+	bytes, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		log.Errorf("Recording compile attempt for model '%s/%s' failed: %v", name, version, err)
+		return err
+	}
+	if err := ioutil.WriteFile(path, bytes, 0666); err != nil {
+		log.Errorf("Recording compile attempt for model '%s/%s' failed: %v", name, version, err)
+		return err
+	}
 	return nil
 }
 
 func (r *ConfigModelRegistry) getDescriptorFile(name configmodel.Name, version configmodel.Version) string {
-	return filepath.Join(r.Config.Path, fmt.Sprintf("%s-%s.json", name, version))
+	return r.getModelFile(name, version, jsonExt)
+}
+
+func (r *ConfigModelRegistry) getHistoryFile(name configmodel.Name, version configmodel.Version) string {
+	return r.getModelFile(name, version, historyExt)
+}
+
+// getModelFile returns the path of the file with the given extension stored for a model
+// Files are named by configmodel.GetFileName. Files stored before names were escaped keep their unescaped
+// names, so they're used if the model's descriptor was stored under its unescaped name.
+func (r *ConfigModelRegistry) getModelFile(name configmodel.Name, version configmodel.Version, ext string) string {
+	fileName := configmodel.GetFileName(name, version)
+	if legacyName := fmt.Sprintf("%s-%s", name, version); r.isLegacyModel(name, version, fileName, legacyName) {
+		fileName = legacyName
+	}
+	return filepath.Join(r.Config.Path, fileName+ext)
+}
+
+// isLegacyModel returns whether the descriptor for a model is stored under its unescaped name
+// Unescaped names are ambiguous, so the descriptor must describe the model.
+func (r *ConfigModelRegistry) isLegacyModel(name configmodel.Name, version configmodel.Version, fileName, legacyName string) bool {
+	if legacyName == fileName || filepath.Base(legacyName) != legacyName {
+		return false
+	}
+	if _, err := os.Stat(filepath.Join(r.Config.Path, fileName+jsonExt)); !os.IsNotExist(err) {
+		return false
+	}
+	bytes, err := ioutil.ReadFile(filepath.Join(r.Config.Path, legacyName+jsonExt))
+	if err != nil {
+		return false
+	}
+	var descriptor struct {
+		Name    configmodel.Name    `json:"name"`
+		Version configmodel.Version `json:"version"`
+	}
+	if err := json.Unmarshal(bytes, &descriptor); err != nil {
+		return false
+	}
+	return descriptor.Name == name && descriptor.Version == version
 }
 
 func loadModel(path string) (configmodel.ModelInfo, error) {
@@ -145,7 +463,7 @@ func loadModel(path string) (configmodel.ModelInfo, error) {
 		if os.IsNotExist(err) {
 			return model, errors.NewNotFound("Model definition '%s' not found", path)
 		}
-		return model, errors.NewUnknown(err.Error())
+		return model, errors.NewInternal("failed reading model definition '%s': %s", path, err)
 	}
 	err = json.Unmarshal(bytes, &model)
 	if err != nil {
@@ -154,9 +472,36 @@ func loadModel(path string) (configmodel.ModelInfo, error) {
 	if model.Name == "" || model.Version == "" {
 		return model, errors.NewInvalid("'%s' is not a valid model descriptor", path)
 	}
+	for i, file := range model.Files {
+		decompressed, err := file.Decompress()
+		if err != nil {
+			return model, errors.NewInvalid("'%s' contains invalid compressed file '%s': %s", path, file.Path, err)
+		}
+		model.Files[i] = decompressed
+	}
+
+	// Descriptors written before checksums were recorded are checksummed on load
+	if model.Checksum == "" {
+		model.Checksum = model.ComputeChecksum()
+	}
 	return model, nil
 }
 
+func loadHistory(path string) ([]CompileAttempt, error) {
+	var history []CompileAttempt
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return history, nil
+		}
+		return nil, errors.NewInternal("failed reading compile history '%s': %s", path, err)
+	}
+	if err := json.Unmarshal(bytes, &history); err != nil {
+		return nil, errors.NewInvalid(err.Error())
+	}
+	return history, nil
+}
+
 // GetPath :
 func GetPath(dir, target, replace string) (string, error) {
 	if dir == "" {
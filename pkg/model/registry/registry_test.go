@@ -5,11 +5,15 @@
 package modelregistry
 
 import (
+	"encoding/json"
 	"github.com/onosproject/onos-config-model/pkg/model"
 	"github.com/onosproject/onos-lib-go/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestRegistry(t *testing.T) {
@@ -63,3 +67,176 @@ func TestRegistry(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, models, 0)
 }
+
+func TestCompileHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-model-registry")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	registry := NewConfigModelRegistry(Config{
+		Path:              dir,
+		MaxCompileHistory: 3,
+	})
+
+	history, err := registry.GetCompileHistory("foo", "1.0.0")
+	assert.NoError(t, err)
+	assert.Len(t, history, 0)
+
+	for i := 0; i < 5; i++ {
+		err = registry.AddCompileAttempt("foo", "1.0.0", CompileAttempt{
+			Time:     time.Unix(int64(i), 0),
+			Duration: time.Second,
+			Success:  i%2 == 0,
+		})
+		assert.NoError(t, err)
+	}
+
+	history, err = registry.GetCompileHistory("foo", "1.0.0")
+	assert.NoError(t, err)
+	assert.Len(t, history, 3)
+	assert.Equal(t, int64(2), history[0].Time.Unix())
+	assert.Equal(t, int64(4), history[2].Time.Unix())
+	assert.True(t, history[2].Success)
+
+	models, err := registry.ListModels()
+	assert.NoError(t, err)
+	assert.Len(t, models, 0)
+
+	err = registry.RemoveModel("foo", "1.0.0")
+	assert.NoError(t, err)
+	history, err = registry.GetCompileHistory("foo", "1.0.0")
+	assert.NoError(t, err)
+	assert.Len(t, history, 0)
+}
+
+func TestCompressStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-model-registry")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	registry := NewConfigModelRegistry(Config{
+		Path:            dir,
+		CompressStorage: true,
+	})
+
+	data, err := ioutil.ReadFile(filepath.Join("..", "..", "..", "test", "test@2020-11-18.yang"))
+	assert.NoError(t, err)
+	err = registry.AddModel(configmodel.ModelInfo{
+		Name:    "foo",
+		Version: "1.0.0",
+		Files: []configmodel.FileInfo{
+			{
+				Path: "test@2020-11-18.yang",
+				Data: data,
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	// The YANG file is stored compressed
+	bytes, err := ioutil.ReadFile(registry.getDescriptorFile("foo", "1.0.0"))
+	assert.NoError(t, err)
+	var stored configmodel.ModelInfo
+	assert.NoError(t, json.Unmarshal(bytes, &stored))
+	assert.True(t, stored.Files[0].IsCompressed())
+	assert.Less(t, len(stored.Files[0].Data), len(data))
+
+	// The YANG file is decompressed on read
+	model, err := registry.GetModel("foo", "1.0.0")
+	assert.NoError(t, err)
+	assert.False(t, model.Files[0].IsCompressed())
+	assert.Equal(t, data, model.Files[0].Data)
+}
+
+func TestModelFeatures(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-model-registry")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	registry := NewConfigModelRegistry(Config{Path: dir})
+	err = registry.AddModel(configmodel.ModelInfo{
+		Name:     "foo",
+		Version:  "1.0.0",
+		Features: []string{"feature-a"},
+		Deviations: []configmodel.ModuleInfo{
+			{Name: "foo-deviations", File: "foo-deviations.yang", Revision: "2020-11-18"},
+		},
+	})
+	assert.NoError(t, err)
+
+	model, err := registry.GetModel("foo", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"feature-a"}, model.Features)
+	assert.Len(t, model.Deviations, 1)
+	assert.Equal(t, configmodel.Name("foo-deviations"), model.Deviations[0].Name)
+}
+
+func TestGetModelErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-model-registry")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	registry := NewConfigModelRegistry(Config{
+		Path: dir,
+	})
+
+	_, err = registry.GetModel("missing", "1.0.0")
+	assert.True(t, errors.IsNotFound(err))
+
+	// A descriptor that cannot be read is an internal error rather than a missing model
+	assert.NoError(t, os.Mkdir(registry.getDescriptorFile("unreadable", "1.0.0"), os.ModePerm))
+	_, err = registry.GetModel("unreadable", "1.0.0")
+	assert.True(t, errors.IsInternal(err))
+
+	if os.Geteuid() == 0 {
+		t.Skip("permissions are not enforced for root")
+	}
+	assert.NoError(t, registry.AddModel(configmodel.ModelInfo{
+		Name:    "denied",
+		Version: "1.0.0",
+	}))
+	assert.NoError(t, os.Chmod(registry.getDescriptorFile("denied", "1.0.0"), 0))
+	_, err = registry.GetModel("denied", "1.0.0")
+	assert.True(t, errors.IsInternal(err))
+}
+
+func TestModelFileNames(t *testing.T) {
+	dir := t.TempDir()
+	registry := NewConfigModelRegistry(Config{Path: dir})
+
+	// Models whose names and versions would collide if joined with a dash are stored separately
+	assert.NoError(t, registry.AddModel(configmodel.ModelInfo{Name: "openconfig-interfaces", Version: "1.0.0"}))
+	assert.NoError(t, registry.AddModel(configmodel.ModelInfo{Name: "openconfig", Version: "interfaces-1.0.0"}))
+	assert.NoError(t, registry.AddModel(configmodel.ModelInfo{Name: "Open/Config", Version: "1.0.0"}))
+	_, err := os.Stat(filepath.Join(dir, "openconfig-interfaces-1.0.0.json"))
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "openconfig-interfaces%2D1.0.0.json"))
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "!open%2F!config-1.0.0.json"))
+	assert.NoError(t, err)
+
+	models, err := registry.ListModels()
+	assert.NoError(t, err)
+	assert.Len(t, models, 3)
+	model, err := registry.GetModel("openconfig", "interfaces-1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, configmodel.Version("interfaces-1.0.0"), model.Version)
+	model, err = registry.GetModel("Open/Config", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, configmodel.Name("Open/Config"), model.Name)
+
+	// Descriptors stored before names were escaped are still found by their unescaped names
+	bytes, err := json.Marshal(configmodel.ModelInfo{Name: "Legacy", Version: "1.0.0-rc1"})
+	assert.NoError(t, err)
+	legacyPath := filepath.Join(dir, "Legacy-1.0.0-rc1.json")
+	assert.NoError(t, ioutil.WriteFile(legacyPath, bytes, 0666))
+	model, err = registry.GetModel("Legacy", "1.0.0-rc1")
+	assert.NoError(t, err)
+	assert.Equal(t, configmodel.Name("Legacy"), model.Name)
+	assert.NoError(t, registry.PinModel("Legacy", "1.0.0-rc1"))
+	model, err = registry.GetModel("Legacy", "1.0.0-rc1")
+	assert.NoError(t, err)
+	assert.True(t, model.Pinned)
+	assert.NoError(t, registry.RemoveModel("Legacy", "1.0.0-rc1"))
+	_, err = os.Stat(legacyPath)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,202 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/onosproject/onos-lib-go/pkg/logging"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const goProxyEnv = "GOPROXY"
+
+var logLevels = []logging.Level{
+	logging.DebugLevel,
+	logging.InfoLevel,
+	logging.WarnLevel,
+	logging.ErrorLevel,
+	logging.FatalLevel,
+	logging.PanicLevel,
+	logging.DPanicLevel,
+}
+
+// ServerConfig is the registry server configuration file
+// Fields that are not set in the file are left unchanged. Fields set in the file are applied even if
+// they're set to a zero value, so reloading the file can reset a setting.
+type ServerConfig struct {
+	ServiceConfig `yaml:",inline"`
+	// RegistryPath is the path in which to store the registry models
+	RegistryPath string `yaml:"registryPath"`
+	// CachePath is the path in which to store compiled plugins
+	CachePath string `yaml:"cachePath"`
+	// BuildPath is the path in which to build plugins
+	BuildPath string `yaml:"buildPath"`
+	// CompileTimeout is the maximum duration of each plugin compilation phase
+	CompileTimeout time.Duration `yaml:"compileTimeout"`
+	// LogLevel is the log level
+	LogLevel string `yaml:"logLevel"`
+	// GoProxy is the GOPROXY used to fetch plugin dependencies
+	GoProxy string `yaml:"goProxy"`
+	// set is the names of the settings in the file from which the configuration was loaded
+	set map[string]bool
+}
+
+// isSet returns whether the setting with the given name is set in the configuration
+// Settings loaded from a file are set if the file contains them, whatever their value. Settings of
+// configurations that were not loaded from a file are set if they're not zero.
+func (c ServerConfig) isSet(name string) bool {
+	if c.set != nil {
+		return c.set[name]
+	}
+	value, ok := getConfigField(reflect.ValueOf(c), name)
+	return ok && !value.IsZero()
+}
+
+// getConfigField returns the field of the given configuration struct with the given YAML name
+func getConfigField(config reflect.Value, name string) (reflect.Value, bool) {
+	for i := 0; i < config.NumField(); i++ {
+		tag := strings.Split(config.Type().Field(i).Tag.Get("yaml"), ",")
+		if len(tag) > 1 && tag[1] == "inline" {
+			if value, ok := getConfigField(config.Field(i), name); ok {
+				return value, true
+			}
+		} else if tag[0] == name {
+			return config.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// LoadServerConfig loads the registry server configuration from the given file
+func LoadServerConfig(path string) (ServerConfig, error) {
+	var config ServerConfig
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return config, errors.NewNotFound("config file '%s' not found", path)
+		}
+		return config, errors.NewInternal("failed reading config file '%s': %s", path, err)
+	}
+	if err := yaml.UnmarshalStrict(bytes, &config); err != nil {
+		return config, errors.NewInvalid("'%s' is not a valid config file: %s", path, err)
+	}
+	var settings map[string]interface{}
+	if err := yaml.Unmarshal(bytes, &settings); err != nil {
+		return config, errors.NewInvalid("'%s' is not a valid config file: %s", path, err)
+	}
+	config.set = make(map[string]bool)
+	for name := range settings {
+		config.set[name] = true
+	}
+	if config.isSet("logLevel") {
+		if _, err := parseLogLevel(config.LogLevel); err != nil {
+			return config, err
+		}
+	}
+	return config, nil
+}
+
+// Reload applies the runtime configurable subset of the given configuration to the service
+// The names of changed settings that require a restart are returned.
+func (s *Service) Reload(config ServerConfig) ([]string, error) {
+	return s.server.Reload(config)
+}
+
+// Reload applies the runtime configurable subset of the given configuration to the server
+// Compile concurrency, timeouts, the log level and the Go proxy are applied without interrupting
+// in-flight builds. Changed settings that require a restart are logged, and their names are returned.
+func (s *Server) Reload(config ServerConfig) ([]string, error) {
+	var level logging.Level
+	if config.isSet("logLevel") {
+		l, err := parseLogLevel(config.LogLevel)
+		if err != nil {
+			return nil, err
+		}
+		level = l
+	}
+
+	var ignored []string
+	if config.isSet("registryPath") {
+		if registry, ok := s.registry.(*ConfigModelRegistry); !ok || config.RegistryPath != registry.Config.Path {
+			ignored = append(ignored, "registryPath")
+		}
+	}
+	// The cache path is qualified by the hash of the target module
+	if config.isSet("cachePath") && filepath.Clean(config.CachePath) != filepath.Dir(s.cache.Config.Path) {
+		ignored = append(ignored, "cachePath")
+	}
+	if config.isSet("buildPath") && config.BuildPath != s.compiler.Config.BuildPath {
+		ignored = append(ignored, "buildPath")
+	}
+	if config.isSet("compileQueueSize") && config.CompileQueueSize != s.config.CompileQueueSize {
+		ignored = append(ignored, "compileQueueSize")
+	}
+	if config.isSet("compileWebhook") && config.CompileWebhook != s.config.CompileWebhook {
+		ignored = append(ignored, "compileWebhook")
+	}
+	if config.isSet("webhookURL") && config.WebhookURL != s.config.WebhookURL {
+		ignored = append(ignored, "webhookURL")
+	}
+	if config.isSet("pluginGracePeriod") && config.PluginGracePeriod != s.config.PluginGracePeriod {
+		ignored = append(ignored, "pluginGracePeriod")
+	}
+	if config.isSet("upstreamAddress") && config.UpstreamAddress != s.config.UpstreamAddress {
+		ignored = append(ignored, "upstreamAddress")
+	}
+	if config.isSet("maxModelBytes") && config.MaxModelBytes != s.config.MaxModelBytes {
+		ignored = append(ignored, "maxModelBytes")
+	}
+	if config.isSet("maxModules") && config.MaxModules != s.config.MaxModules {
+		ignored = append(ignored, "maxModules")
+	}
+	if config.isSet("maxFileBytes") && config.MaxFileBytes != s.config.MaxFileBytes {
+		ignored = append(ignored, "maxFileBytes")
+	}
+
+	if config.isSet("compileWorkers") || config.isSet("compileWorkerIdleTimeout") {
+		size, idleTimeout := s.workers.limits()
+		if config.isSet("compileWorkers") {
+			size = config.CompileWorkers
+		}
+		if config.isSet("compileWorkerIdleTimeout") {
+			idleTimeout = config.CompileWorkerIdleTimeout
+		}
+		log.Infof("Resizing compile workers to %d (idle timeout %s)", size, idleTimeout)
+		s.workers.resize(size, idleTimeout)
+	}
+	if config.isSet("compileTimeout") {
+		log.Infof("Setting compile timeout to %s", config.CompileTimeout)
+		s.compiler.SetTimeout(config.CompileTimeout)
+	}
+	if config.isSet("logLevel") {
+		log.Infof("Setting log level to %s", level)
+		logging.SetLevel(level)
+	}
+	if config.isSet("goProxy") && config.GoProxy != os.Getenv(goProxyEnv) {
+		log.Infof("Setting %s to '%s'", goProxyEnv, config.GoProxy)
+		if err := os.Setenv(goProxyEnv, config.GoProxy); err != nil {
+			return ignored, errors.NewInternal(err.Error())
+		}
+	}
+	for _, name := range ignored {
+		log.Warnf("Ignoring change to '%s': a restart is required", name)
+	}
+	return ignored, nil
+}
+
+func parseLogLevel(name string) (logging.Level, error) {
+	for _, level := range logLevels {
+		if strings.EqualFold(level.String(), name) {
+			return level, nil
+		}
+	}
+	return logging.InfoLevel, errors.NewInvalid("unknown log level '%s'", name)
+}
@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReloadCompileWorkers(t *testing.T) {
+	server := newTestServer(t)
+	server.workers.resize(1, 0)
+
+	// The second task waits for the only worker
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{}, 2)
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, server.workers.submit("", PriorityNormal, func() {
+			started <- struct{}{}
+			<-release
+		}))
+	}
+	<-started
+	select {
+	case <-started:
+		t.Fatal("task started beyond the concurrency limit")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Raising the limit starts the queued task without waiting for the in-flight one
+	ignored, err := server.Reload(ServerConfig{
+		ServiceConfig: ServiceConfig{
+			CompileWorkers: 2,
+		},
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, ignored)
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("queued task was not started")
+	}
+	assert.Equal(t, 2, server.workers.numWorkers())
+}
+
+func TestReloadIgnoresPaths(t *testing.T) {
+	server := newTestServer(t)
+	ignored, err := server.Reload(ServerConfig{
+		RegistryPath:   server.registry.(*ConfigModelRegistry).Config.Path,
+		CachePath:      filepath.Dir(server.cache.Config.Path),
+		BuildPath:      "/tmp/other",
+		CompileTimeout: time.Minute,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"buildPath"}, ignored)
+	assert.Equal(t, time.Minute, server.compiler.GetTimeout())
+
+	_, err = server.Reload(ServerConfig{
+		LogLevel: "verbose",
+	})
+	assert.True(t, errors.IsInvalid(err))
+}
+
+func TestReloadZeroValues(t *testing.T) {
+	server := newTestServer(t)
+	server.compiler.SetTimeout(time.Minute)
+	server.workers.resize(1, time.Minute)
+
+	// Settings in a reloaded file are applied even if they're zero
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("compileTimeout: 0\ncompileWorkerIdleTimeout: 0s\n"), 0666))
+	config, err := LoadServerConfig(path)
+	assert.NoError(t, err)
+	ignored, err := server.Reload(config)
+	assert.NoError(t, err)
+	assert.Empty(t, ignored)
+	assert.Equal(t, time.Duration(0), server.compiler.GetTimeout())
+	size, idleTimeout := server.workers.limits()
+	assert.Equal(t, 1, size)
+	assert.Equal(t, time.Duration(0), idleTimeout)
+
+	// Settings that are not in the file are left unchanged, and settings that require a restart are reported
+	assert.NoError(t, ioutil.WriteFile(path, []byte("maxModules: 0\n"), 0666))
+	config, err = LoadServerConfig(path)
+	assert.NoError(t, err)
+	server.compiler.SetTimeout(time.Minute)
+	server.config.MaxModules = 10
+	ignored, err = server.Reload(config)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, server.compiler.GetTimeout())
+	assert.Equal(t, []string{"maxModules"}, ignored)
+}
+
+func TestLoadServerConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-model-config")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.yaml")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`
+compileWorkers: 4
+compileWorkerIdleTimeout: 1m
+compileTimeout: 10m
+logLevel: debug
+registryPath: /etc/onos/registry
+`), 0666))
+	config, err := LoadServerConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, config.CompileWorkers)
+	assert.Equal(t, time.Minute, config.CompileWorkerIdleTimeout)
+	assert.Equal(t, 10*time.Minute, config.CompileTimeout)
+	assert.Equal(t, "debug", config.LogLevel)
+	assert.Equal(t, "/etc/onos/registry", config.RegistryPath)
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte("compileWorker: 4\n"), 0666))
+	_, err = LoadServerConfig(path)
+	assert.True(t, errors.IsInvalid(err))
+
+	_, err = LoadServerConfig(filepath.Join(dir, "missing.yaml"))
+	assert.True(t, errors.IsNotFound(err))
+}
@@ -6,67 +6,180 @@ package modelregistry
 
 import (
 	"context"
+	"fmt"
 	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
 	"github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-config-model/pkg/model/logging"
+	"github.com/onosproject/onos-config-model/pkg/model/plugin"
 	"github.com/onosproject/onos-config-model/pkg/model/plugin/cache"
 	"github.com/onosproject/onos-config-model/pkg/model/plugin/compiler"
+	"github.com/onosproject/onos-config-model/pkg/model/plugin/module"
 	"github.com/onosproject/onos-lib-go/pkg/errors"
 	"github.com/onosproject/onos-lib-go/pkg/northbound"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"os"
+	"runtime"
+	"strings"
 	"sync"
+	"time"
 )
 
+// ServiceConfig is a registry service configuration
+type ServiceConfig struct {
+	// AutoRecompileOnABIMismatch indicates whether to recompile a plugin that was built
+	// with an incompatible version of Go or its dependencies when it's loaded
+	// If enabled, plugins are also loaded when their model is requested with GetModel.
+	AutoRecompileOnABIMismatch bool `yaml:"autoRecompileOnABIMismatch" json:"autoRecompileOnABIMismatch"`
+	// CompileWorkers is the maximum number of plugins compiled concurrently
+	// If zero, the number of CPUs is divided among workers by the compiler's build parallelism.
+	CompileWorkers int `yaml:"compileWorkers" json:"compileWorkers"`
+	// CompileQueueSize is the maximum number of compilations waiting for a worker, defaulting to 100
+	// Pushes that would compile a plugin while the queue is full fail with ResourceExhausted.
+	CompileQueueSize int `yaml:"compileQueueSize" json:"compileQueueSize"`
+	// CompileWorkerIdleTimeout is the time after which an idle compile worker exits
+	// Workers are recreated on demand. If zero, idle workers are never shut down.
+	CompileWorkerIdleTimeout time.Duration `yaml:"compileWorkerIdleTimeout" json:"compileWorkerIdleTimeout"`
+	// MaxTryouts is the maximum number of models tried out concurrently
+	MaxTryouts int `yaml:"maxTryouts" json:"maxTryouts"`
+	// CompileWebhook is a URL to which the result of each compile is posted
+	CompileWebhook string `yaml:"compileWebhook" json:"compileWebhook"`
+	// WebhookURL is a URL to which an event is posted when a model is added to or removed from the registry
+	WebhookURL string `yaml:"webhookURL" json:"webhookURL"`
+	// PluginGracePeriod is the time for which a plugin replaced by a forced push is kept for its consumers
+	// If zero, replaced plugins are not removed.
+	PluginGracePeriod time.Duration `yaml:"pluginGracePeriod" json:"pluginGracePeriod"`
+	// UpstreamAddress is the address of a registry from which models that are not found are fetched
+	// Fetched models are added to the registry and their plugins compiled into the cache. If empty,
+	// models are only served from the registry.
+	UpstreamAddress string `yaml:"upstreamAddress" json:"upstreamAddress"`
+	// MaxModelBytes is the maximum total size of the YANG files of a pushed model
+	// If zero, the size defaults to 64MiB.
+	MaxModelBytes int64 `yaml:"maxModelBytes" json:"maxModelBytes"`
+	// MaxModules is the maximum number of modules in a pushed model
+	// If zero, the number defaults to 1000.
+	MaxModules int `yaml:"maxModules" json:"maxModules"`
+	// MaxFileBytes is the maximum size of each YANG file of a pushed model
+	// If zero, the size defaults to 16MiB.
+	MaxFileBytes int64 `yaml:"maxFileBytes" json:"maxFileBytes"`
+	// ReadOnly indicates whether the service rejects pushes and deletes, e.g. for read replicas of a registry
+	// Models are served as they are from a read-only registry, but unlike a read-only registry, the registry
+	// path may be writable, e.g. when it's shared with the registry that accepts pushes.
+	ReadOnly bool `yaml:"readOnly" json:"readOnly"`
+}
+
 // NewService :
-func NewService(registry *ConfigModelRegistry, cache *plugincache.PluginCache, compiler *plugincompiler.PluginCompiler) northbound.Service {
+func NewService(config ServiceConfig, registry Registry, cache *plugincache.PluginCache, compiler *plugincompiler.PluginCompiler) *Service {
 	return &Service{
+		config:   config,
 		registry: registry,
 		cache:    cache,
 		compiler: compiler,
+		server:   NewServer(config, registry, cache, compiler),
 	}
 }
 
 // Service :
 type Service struct {
-	registry *ConfigModelRegistry
+	config   ServiceConfig
+	registry Registry
 	cache    *plugincache.PluginCache
 	compiler *plugincompiler.PluginCompiler
+	server   *Server
 }
 
 // Register :
 func (s *Service) Register(r *grpc.Server) {
-	server := &Server{
-		registry: s.registry,
-		cache:    s.cache,
-		compiler: s.compiler,
-	}
-	configmodelapi.RegisterConfigModelRegistryServiceServer(r, server)
+	configmodelapi.RegisterConfigModelRegistryServiceServer(r, s.server)
+	grpc_health_v1.RegisterHealthServer(r, newHealthServer(s.server))
+	registerPushStream(r, s.server)
+	registerModuleService(r, s.server)
+	registerWatchService(r, s.server)
+	registerConfigService(r, s.server)
+	registerValidationService(r, s.server)
+	registerHistoryService(r, s.server)
+	registerMaintenanceService(r, s.server)
+	reflection.Register(r)
 }
 
 var _ northbound.Service = &Service{}
 
+// NewServer creates a new registry server
+func NewServer(config ServiceConfig, registry Registry, cache *plugincache.PluginCache, compiler *plugincompiler.PluginCompiler) *Server {
+	if config.CompileWorkers <= 0 && compiler != nil {
+		config.CompileWorkers = getDefaultCompileWorkers(runtime.NumCPU(), compiler.GetBuildParallelism())
+	}
+	s := &Server{
+		config:       config,
+		registry:     registry,
+		cache:        cache,
+		compiler:     compiler,
+		workers:      newWorkerPool(config.CompileWorkers, config.CompileQueueSize, config.CompileWorkerIdleTimeout),
+		tryouts:      newTryoutLimiter(config.MaxTryouts),
+		webhook:      newWebhook(config.CompileWebhook),
+		modelWebhook: newWebhook(config.WebhookURL),
+		metrics:      newServerMetrics(),
+		upstream:     newUpstream(config.UpstreamAddress),
+		load: func(entry *plugincache.PluginEntry) (modelplugin.ConfigModelPlugin, error) {
+			return entry.Load()
+		},
+		inspect:   execInspectPlugin,
+		scheduled: make(map[string]bool),
+		testing:   make(map[string]bool),
+	}
+	s.verifyPrebuilt = s.verifyPrebuiltPlugin
+	return s
+}
+
 // Server is a registry server
 type Server struct {
-	registry *ConfigModelRegistry
-	cache    *plugincache.PluginCache
-	compiler *plugincompiler.PluginCompiler
-	mu       sync.RWMutex
+	config       ServiceConfig
+	registry     Registry
+	cache        *plugincache.PluginCache
+	compiler     *plugincompiler.PluginCompiler
+	workers      *workerPool
+	tryouts      chan struct{}
+	webhook      *webhook
+	modelWebhook *webhook
+	metrics      *serverMetrics
+	upstream     *upstream
+	load         func(entry *plugincache.PluginEntry) (modelplugin.ConfigModelPlugin, error)
+	inspect      func(ctx context.Context, path string) (TryoutResult, error)
+	// verifyPrebuilt verifies a plugin placed in the cache for a model pushed without compiling its plugin
+	verifyPrebuilt func(ctx context.Context, modelInfo configmodel.ModelInfo, path string) error
+	// scheduled is the models whose missing plugins have been submitted for compilation as they're served
+	scheduled   map[string]bool
+	scheduledMu sync.Mutex
+	// testing is the pushed models whose test configs are being validated before they're added, guarded by mu
+	testing map[string]bool
+	mu      sync.RWMutex
 }
 
 // GetModel :
 func (s *Server) GetModel(ctx context.Context, request *configmodelapi.GetModelRequest) (*configmodelapi.GetModelResponse, error) {
 	log.Debugf("Received GetModelRequest %+v", request)
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	s.sendCapabilities(ctx)
 
 	name, version := configmodel.Name(request.Name), configmodel.Version(request.Version)
-	modelInfo, err := s.registry.GetModel(name, version)
+	modelInfo, err := s.getModel(name, version)
 	if err != nil {
 		log.Warnf("GetModelRequest %+v failed: %v", request, err)
 		return nil, errors.Status(err).Err()
 	}
-
+	sendBuildInfo(ctx, modelInfo)
+	sendModules(ctx, modelInfo)
+	sendFeatures(ctx, modelInfo)
+	sendChecksum(ctx, modelInfo)
+	sendModelLabels(ctx, modelInfo)
+	sendPluginArtifacts(ctx, modelInfo)
+	s.sendQueuePosition(ctx, modelInfo)
+	s.compileMissingPlugins(modelInfo)
+	s.checkPlugin(ctx, modelInfo)
+
+	// Deviation modules are pushed as modules, so they're returned as modules and named in the headers
 	var modules []*configmodelapi.ConfigModule
-	for _, moduleInfo := range modelInfo.Modules {
+	for _, moduleInfo := range append(modelInfo.Modules, modelInfo.Deviations...) {
 		modules = append(modules, &configmodelapi.ConfigModule{
 			Name:         string(moduleInfo.Name),
 			Organization: moduleInfo.Organization,
@@ -76,27 +189,58 @@ func (s *Server) GetModel(ctx context.Context, request *configmodelapi.GetModelR
 	}
 	response := &configmodelapi.GetModelResponse{
 		Model: &configmodelapi.ConfigModel{
-			Name:    string(modelInfo.Name),
-			Version: string(modelInfo.Version),
-			Modules: modules,
+			Name:         string(modelInfo.Name),
+			Version:      string(modelInfo.Version),
+			Modules:      modules,
+			GetStateMode: newAPIGetStateMode(modelInfo.GetStateMode),
 		},
 	}
-	log.Debugf("Sending GetModelResponse %+v", response)
+	if getBoolMetadata(ctx, IncludeFilesKey) {
+		files, err := newAPIFiles(modelInfo)
+		if err == nil {
+			files, err = encodeModelFiles(ctx, files)
+		}
+		if err != nil {
+			log.Warnf("GetModelRequest %+v failed: %v", request, err)
+			return nil, errors.Status(err).Err()
+		}
+		response.Model.Files = files
+	}
+	log.Debugw("Sending GetModelResponse", modellogging.ModelField, response.Model.Name, modellogging.VersionField, response.Model.Version, "files", len(response.Model.Files))
 	return response, nil
 }
 
 // ListModels :
 func (s *Server) ListModels(ctx context.Context, request *configmodelapi.ListModelsRequest) (*configmodelapi.ListModelsResponse, error) {
 	log.Debugf("Received ListModelsRequest %+v", request)
+	s.sendCapabilities(ctx)
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	modelInfos, err := s.registry.ListModels()
+	cursor, size, err := getPage(ctx)
 	if err != nil {
 		log.Warnf("ListModelsRequest %+v failed: %v", request, err)
 		return nil, errors.Status(err).Err()
 	}
 
+	selector, err := ParseLabelSelector(getStringMetadata(ctx, SelectorKey))
+	if err != nil {
+		log.Warnf("ListModelsRequest %+v failed: %v", request, err)
+		return nil, errors.Status(err).Err()
+	}
+
+	modelInfos, next, err := s.listModels(selector, cursor, size)
+	if err != nil {
+		log.Warnf("ListModelsRequest %+v failed: %v", request, err)
+		return nil, errors.Status(err).Err()
+	}
+	if next != "" {
+		sendNextPageToken(ctx, next)
+	}
+	sendChecksums(ctx, modelInfos)
+	sendListLabels(ctx, modelInfos)
+	s.compileMissingPlugins(modelInfos...)
+
 	var models []*configmodelapi.ConfigModel
 	for _, modelInfo := range modelInfos {
 		var modules []*configmodelapi.ConfigModule
@@ -109,9 +253,10 @@ func (s *Server) ListModels(ctx context.Context, request *configmodelapi.ListMod
 			})
 		}
 		models = append(models, &configmodelapi.ConfigModel{
-			Name:    string(modelInfo.Name),
-			Version: string(modelInfo.Version),
-			Modules: modules,
+			Name:         string(modelInfo.Name),
+			Version:      string(modelInfo.Version),
+			Modules:      modules,
+			GetStateMode: newAPIGetStateMode(modelInfo.GetStateMode),
 		})
 	}
 
@@ -124,65 +269,171 @@ func (s *Server) ListModels(ctx context.Context, request *configmodelapi.ListMod
 
 // PushModel :
 func (s *Server) PushModel(ctx context.Context, request *configmodelapi.PushModelRequest) (*configmodelapi.PushModelResponse, error) {
-	log.Debugf("Received PushModelRequest %+v", request)
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	log.Debugw("Received PushModelRequest", modellogging.ModelField, request.Model.GetName(), modellogging.VersionField, request.Model.GetVersion(), "files", len(request.Model.GetFiles()))
+	s.sendCapabilities(ctx)
 
-	name, version := configmodel.Name(request.Model.Name), configmodel.Version(request.Model.Version)
+	// Limits are enforced on the decoded files before anything is written for the model
+	if err := s.decodeModelFiles(ctx, request.Model); err != nil {
+		log.Warnf("PushModelRequest '%s@%s' failed: %s", request.Model.GetName(), request.Model.GetVersion(), err)
+		return nil, errors.Status(err).Err()
+	}
+	if err := s.checkLimits(request.Model); err != nil {
+		log.Warnf("PushModelRequest '%s@%s' failed: %s", request.Model.GetName(), request.Model.GetVersion(), err)
+		return nil, errors.Status(err).Err()
+	}
 
-	// First check the registry for the model
-	_, err := s.registry.GetModel(name, version)
-	if err == nil {
-		err = errors.NewAlreadyExists("model '%s@%s' already exists", request.Model.Name, request.Model.Version)
+	// Models that are tried out are compiled and loaded, but not added to the registry
+	if getBoolMetadata(ctx, TryoutKey) {
+		return s.tryModel(ctx, request)
 	}
-	if err != nil && !errors.IsNotFound(err) {
+
+	// Dry runs generate the model's plugin module without building the plugin or adding the model to the registry
+	if getBoolMetadata(ctx, DryRunKey) {
+		return s.dryRunModel(ctx, request)
+	}
+
+	done, err := s.pushModel(ctx, request, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pushes with test configs fail if the configs are not valid for the model's plugin
+	if len(getTestConfigs(request.Model)) > 0 {
+		if err := <-done; err != nil {
+			log.Warnf("PushModelRequest '%s@%s' failed: %s", request.Model.Name, request.Model.Version, err)
+			return nil, errors.Status(err).Err()
+		}
+	}
+	response := &configmodelapi.PushModelResponse{}
+	log.Debugf("Sending PushModelResponse %+v", response)
+	return response, nil
+}
+
+// listModels lists a page of the models selected by the given selector
+// Models are selected before they're paged, so pages are filled with selected models.
+func (s *Server) listModels(selector LabelSelector, cursor string, size int) ([]configmodel.ModelInfo, string, error) {
+	if len(selector) == 0 {
+		return s.registry.ListModelsPage(cursor, size)
+	}
+	modelInfos, err := s.registry.ListModels()
+	if err != nil {
+		return nil, "", err
+	}
+	modelInfos, next := getModelsPage(selectModels(modelInfos, selector), cursor, size)
+	return modelInfos, next, nil
+}
+
+// pushModel adds a pushed model to the registry, compiling its plugin asynchronously if it's not cached
+// The returned channel receives the result of the compilation, or nil if the plugin was already cached.
+// If a progress function or test configs are provided, the compilation is aborted if the context is canceled,
+// since the caller is waiting for it, and the progress function is called with the progress of the compilation.
+// Otherwise the compilation outlives the request.
+func (s *Server) pushModel(ctx context.Context, request *configmodelapi.PushModelRequest, progress plugincompiler.ProgressFunc) (<-chan error, error) {
+	start := time.Now()
+	s.metrics.pushes.Inc()
+	done, err := s.addModel(ctx, request, progress)
+	if err != nil {
+		s.metrics.pushFailures.Inc()
+		return done, err
+	}
+	log.Infow("Pushed model", modellogging.ModelField, request.Model.Name, modellogging.VersionField, request.Model.Version, modellogging.DurationField, time.Since(start))
+	return done, nil
+}
+
+// checkMutable returns a Forbidden error if the service or its registry is read-only
+func (s *Server) checkMutable() error {
+	if s.config.ReadOnly {
+		return errors.NewForbidden("registry service is read-only")
+	}
+	return checkMutable(s.registry)
+}
+
+// addModel validates a pushed model and adds it to the registry
+func (s *Server) addModel(ctx context.Context, request *configmodelapi.PushModelRequest, progress plugincompiler.ProgressFunc) (<-chan error, error) {
+	if err := s.checkMutable(); err != nil {
 		log.Warnf("PushModelRequest '%s@%s' failed: %s", request.Model.Name, request.Model.Version, err)
 		return nil, errors.Status(err).Err()
 	}
-
-	// Add the model if it's not already present in the registry
-	fileInfos := make([]configmodel.FileInfo, 0, len(request.Model.Files))
-	for path, data := range request.Model.Files {
-		fileInfos = append(fileInfos, configmodel.FileInfo{
-			Path: path,
-			Data: []byte(data),
-		})
+	if err := validateModules(request.Model); err != nil {
+		log.Warnf("PushModelRequest '%s@%s' failed: %s", request.Model.Name, request.Model.Version, err)
+		return nil, errors.Status(err).Err()
+	}
+	priority, err := getPriority(ctx)
+	if err != nil {
+		log.Warnf("PushModelRequest '%s@%s' failed: %s", request.Model.Name, request.Model.Version, err)
+		return nil, errors.Status(err).Err()
+	}
+	labels, err := getLabels(ctx)
+	if err != nil {
+		log.Warnf("PushModelRequest '%s@%s' failed: %s", request.Model.Name, request.Model.Version, err)
+		return nil, errors.Status(err).Err()
+	}
+	platform, err := s.getPlatform(ctx)
+	if err != nil {
+		log.Warnf("PushModelRequest '%s@%s' failed: %s", request.Model.Name, request.Model.Version, err)
+		return nil, errors.Status(err).Err()
+	}
+	modelInfo := newModelInfo(request.Model)
+	modelInfo.Labels = labels
+	if err := setFeatures(ctx, &modelInfo); err != nil {
+		log.Warnf("PushModelRequest '%s' failed: %s", modelInfo, err)
+		return nil, errors.Status(err).Err()
 	}
 
-	moduleInfos := make([]configmodel.ModuleInfo, len(request.Model.Modules))
-	for i, module := range request.Model.Modules {
-		moduleInfos[i] = configmodel.ModuleInfo{
-			Name:         configmodel.Name(module.Name),
-			File:         module.File,
-			Organization: module.Organization,
-			Revision:     configmodel.Revision(module.Revision),
+	testConfigs := getTestConfigs(request.Model)
+	// Plugins placed in the cache out of band must have been built from the pushed model for the running binary
+	if getBoolMetadata(ctx, SkipCompileKey) && platform == s.cache.Platform() {
+		if err := s.verifyPrebuiltPlugins(ctx, modelInfo); err != nil {
+			log.Warnf("PushModelRequest '%s' failed: %s", modelInfo, err)
+			return nil, errors.Status(err).Err()
 		}
 	}
 
-	var getStateMode configmodel.GetStateMode
-	switch request.Model.GetStateMode {
-	case configmodelapi.GetStateMode_NONE:
-		getStateMode = configmodel.GetStateNone
-	case configmodelapi.GetStateMode_OP_STATE:
-		getStateMode = configmodel.GetStateOpState
-	case configmodelapi.GetStateMode_EXPLICIT_RO_PATHS:
-		getStateMode = configmodel.GetStateExplicitRoPaths
-	case configmodelapi.GetStateMode_EXPLICIT_RO_PATHS_EXPAND_WILDCARDS:
-		getStateMode = configmodel.GetStateExplicitRoPathsExpandWildcards
+	compileCtx := context.Background()
+	if progress != nil || len(testConfigs) > 0 {
+		compileCtx = ctx
 	}
 
-	modelInfo := configmodel.ModelInfo{
-		Name:         configmodel.Name(request.Model.Name),
-		Version:      configmodel.Version(request.Model.Version),
-		GetStateMode: getStateMode,
-		Files:        fileInfos,
-		Modules:      moduleInfos,
-		Plugin: configmodel.PluginInfo{
-			Name:    configmodel.Name(request.Model.Name),
-			Version: configmodel.Version(request.Model.Version),
-		},
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Plugins for other platforms are added to the model's artifacts rather than compiled
+	if platform != s.cache.Platform() {
+		return s.pushPlatformPlugin(ctx, modelInfo, platform)
+	}
+
+	name, version := configmodel.Name(request.Model.Name), configmodel.Version(request.Model.Version)
+
+	// First check the registry for the model, which is only replaced if forced
+	existing, err := s.registry.GetModel(name, version)
+	if err == nil {
+		if getBoolMetadata(ctx, ForceKey) {
+			return s.replaceModel(compileCtx, modelInfo, replaceOptions{
+				priority:    priority,
+				progress:    progress,
+				skipCompile: getBoolMetadata(ctx, SkipCompileKey),
+				testConfigs: testConfigs,
+			})
+		}
+		// A prebuilt plugin can be added to a model registered with plugins for other platforms only
+		if _, ok := existing.Plugin.GetArtifact(platform.GOOS, platform.GOARCH); !ok && len(existing.Plugin.Artifacts) > 0 && getBoolMetadata(ctx, SkipCompileKey) {
+			return s.pushPlatformPlugin(ctx, modelInfo, platform)
+		}
+		err = errors.NewAlreadyExists("model '%s@%s' already exists", request.Model.Name, request.Model.Version)
+	}
+	if err != nil && !errors.IsNotFound(err) {
+		log.Warnf("PushModelRequest '%s@%s' failed: %s", request.Model.Name, request.Model.Version, err)
+		return nil, errors.Status(err).Err()
+	}
+
+	// Models being tested are not in the registry yet, but their cache entries are locked until they're added
+	if s.testing[modelInfo.String()] {
+		err = errors.NewConflict("model '%s@%s' is already being pushed", request.Model.Name, request.Model.Version)
+		log.Warnf("PushModelRequest '%s@%s' failed: %s", request.Model.Name, request.Model.Version, err)
+		return nil, errors.Status(err).Err()
 	}
 
+	// Add the model if it's not already present in the registry
 	// Acquire a lock on the cache before adding it to the registry to ensure subsequent
 	// requests to load the same plugin will be blocked until compilation is complete.
 	entry := s.cache.Entry(name, version)
@@ -197,26 +448,67 @@ func (s *Server) PushModel(ctx context.Context, request *configmodelapi.PushMode
 		}
 	}()
 
-	// Add the model to the registry
-	err = s.registry.AddModel(modelInfo)
+	// Look for the plugin in the cache
+	cached, err := entry.Cached()
 	if err != nil {
+		_ = entry.Unlock(context.Background())
+		log.Errorf("Failed to compile plugin for model '%s@%s': %s", request.Model.Name, request.Model.Version, err)
+		return nil, errors.Status(err).Err()
+	}
+	s.metrics.recordCached(cached)
+
+	// If compilation was skipped, the plugin must already be present in the cache
+	if !cached && getBoolMetadata(ctx, SkipCompileKey) {
+		_ = entry.Unlock(context.Background())
+		err = errors.NewNotFound("plugin for model '%s@%s' not found in cache '%s'", request.Model.Name, request.Model.Version, s.cache.Config.Path)
 		log.Warnf("PushModelRequest '%s@%s' failed: %s", request.Model.Name, request.Model.Version, err)
 		return nil, errors.Status(err).Err()
 	}
 
-	// Look for the plugin in the cache
-	cached, err := entry.Cached()
+	// If test configs were provided, the model is only added once they've been validated against the plugin
+	if len(testConfigs) > 0 {
+		return s.addTestedModel(compileCtx, modelInfo, entry, cached, testConfigs, priority)
+	}
+
+	// Reserve a slot for the compilation before the model is added, so a full queue doesn't add
+	// and then remove the model
+	var slot *reservation
+	if !cached {
+		slot, err = s.workers.reserve(modelInfo.String(), priority)
+		if err != nil {
+			_ = entry.Unlock(context.Background())
+			log.Warnf("PushModelRequest '%s@%s' failed: %s", request.Model.Name, request.Model.Version, err)
+			return nil, getSubmitStatus(err).Err()
+		}
+	}
+
+	// Add the model to the registry, with its plugin if it's already present
+	if cached {
+		modelInfo.Plugin.SetArtifact(newPluginArtifact(platform))
+	}
+	err = s.registry.AddModel(modelInfo)
 	if err != nil {
-		log.Errorf("Failed to compile plugin for model '%s@%s': %s", request.Model.Name, request.Model.Version, err)
+		if slot != nil {
+			slot.release()
+		}
+		_ = entry.Unlock(context.Background())
+		log.Warnf("PushModelRequest '%s@%s' failed: %s", request.Model.Name, request.Model.Version, err)
 		return nil, errors.Status(err).Err()
 	}
 
-	// If the plugin is not present in the cache, compile it
-	if !cached {
-		go func() {
+	// If the plugin is already present in the cache, release the lock
+	done := make(chan error, 1)
+	if cached {
+		done <- nil
+		if err := entry.Unlock(context.Background()); err != nil {
+			log.Errorf("Failed to release cache lock: %s", err)
+		}
+	} else {
+		slot.submit(func() {
 			defer func() {
 				if err := recover(); err != nil {
 					_ = entry.Unlock(context.Background())
+					done <- errors.NewInternal("compiling plugin for model '%s@%s' panicked: %v", request.Model.Name, request.Model.Version, err)
 				}
 			}()
 
@@ -226,31 +518,476 @@ func (s *Server) PushModel(ctx context.Context, request *configmodelapi.PushMode
 				}
 			}()
 
-			err = s.compiler.CompilePlugin(modelInfo, entry.Path)
+			err := s.compilePluginWithProgress(compileCtx, modelInfo, entry.Path, progress)
 			if err != nil {
-				log.Errorf("Failed to compile plugin for model '%s@%s': %s", request.Model.Name, request.Model.Version, err)
+				log.Errorw("Failed to compile plugin", modellogging.ModelField, modelInfo.Name, modellogging.VersionField, modelInfo.Version, "error", err)
+			} else {
+				s.recordBuildInfo(modelInfo, entry.Path)
+				s.recordPluginArtifact(modelInfo)
+			}
+			done <- err
+		})
+	}
+
+	s.notifyModelEvent(ModelAdded, modelInfo)
+	return done, nil
+}
+
+// newModelInfo creates the model info for the given model
+func newModelInfo(model *configmodelapi.ConfigModel) configmodel.ModelInfo {
+	fileInfos := make([]configmodel.FileInfo, 0, len(model.Files))
+	for path, data := range model.Files {
+		if isTestConfig(path) {
+			continue
+		}
+		fileInfos = append(fileInfos, configmodel.FileInfo{
+			Path: path,
+			Data: []byte(data),
+		})
+	}
+
+	moduleInfos := make([]configmodel.ModuleInfo, len(model.Modules))
+	for i, module := range model.Modules {
+		moduleInfos[i] = configmodel.ModuleInfo{
+			Name:         configmodel.Name(module.Name),
+			File:         module.File,
+			Organization: module.Organization,
+			Revision:     configmodel.Revision(module.Revision),
+		}
+	}
+
+	modelInfo := configmodel.ModelInfo{
+		Name:         configmodel.Name(model.Name),
+		Version:      configmodel.Version(model.Version),
+		GetStateMode: NewGetStateMode(model.GetStateMode),
+		Files:        fileInfos,
+		Modules:      moduleInfos,
+		Plugin: configmodel.PluginInfo{
+			Name:    configmodel.Name(model.Name),
+			Version: configmodel.Version(model.Version),
+		},
+	}
+	inferModuleIdentity(&modelInfo)
+	return modelInfo
+}
+
+// NewGetStateMode returns the model get state mode for the given API get state mode
+func NewGetStateMode(mode configmodelapi.GetStateMode) configmodel.GetStateMode {
+	switch mode {
+	case configmodelapi.GetStateMode_NONE:
+		return configmodel.GetStateNone
+	case configmodelapi.GetStateMode_OP_STATE:
+		return configmodel.GetStateOpState
+	case configmodelapi.GetStateMode_EXPLICIT_RO_PATHS:
+		return configmodel.GetStateExplicitRoPaths
+	case configmodelapi.GetStateMode_EXPLICIT_RO_PATHS_EXPAND_WILDCARDS:
+		return configmodel.GetStateExplicitRoPathsExpandWildcards
+	}
+	return ""
+}
+
+// newAPIGetStateMode returns the API get state mode for the given model get state mode
+// Models added before the mode was stored have no mode, which the API reports as NONE.
+func newAPIGetStateMode(mode configmodel.GetStateMode) configmodelapi.GetStateMode {
+	switch mode {
+	case configmodel.GetStateOpState:
+		return configmodelapi.GetStateMode_OP_STATE
+	case configmodel.GetStateExplicitRoPaths:
+		return configmodelapi.GetStateMode_EXPLICIT_RO_PATHS
+	case configmodel.GetStateExplicitRoPathsExpandWildcards:
+		return configmodelapi.GetStateMode_EXPLICIT_RO_PATHS_EXPAND_WILDCARDS
+	}
+	return configmodelapi.GetStateMode_NONE
+}
+
+// addTestedModel validates the given test configs against a pushed model's plugin on the worker pool, and adds
+// the model to the registry if they're valid. The cache entry must be locked, and is unlocked once the configs
+// have been validated. The returned channel receives the result of the validation.
+func (s *Server) addTestedModel(ctx context.Context, modelInfo configmodel.ModelInfo, entry *plugincache.PluginEntry, cached bool, configs [][]byte, priority Priority) (<-chan error, error) {
+	key := modelInfo.String()
+	s.testing[key] = true
+	done := make(chan error, 1)
+	err := s.workers.submit(key, priority, func() {
+		defer func() {
+			if err := recover(); err != nil {
+				_ = entry.Unlock(context.Background())
+				s.mu.Lock()
+				delete(s.testing, key)
+				s.mu.Unlock()
+				done <- errors.NewInternal("testing model '%s' panicked: %v", modelInfo, err)
 			}
 		}()
+
+		err := s.testPlugin(ctx, modelInfo, entry, cached, configs)
+		if err := entry.Unlock(context.Background()); err != nil {
+			log.Errorf("Failed to release cache lock: %s", err)
+		}
+
+		s.mu.Lock()
+		delete(s.testing, key)
+		if err == nil {
+			modelInfo.Plugin.SetArtifact(newPluginArtifact(s.cache.Platform()))
+			err = s.registry.AddModel(modelInfo)
+		}
+		s.mu.Unlock()
+		if err != nil {
+			log.Warnf("PushModelRequest '%s' failed: %s", modelInfo, err)
+			done <- err
+			return
+		}
+
+		// Plugins compiled for testing are built before the model is added, so record the build info now
+		if !cached {
+			s.recordBuildInfo(modelInfo, entry.Path)
+		}
+		s.notifyModelEvent(ModelAdded, modelInfo)
+		done <- nil
+	})
+	if err != nil {
+		delete(s.testing, key)
+		_ = entry.Unlock(context.Background())
+		log.Warnf("PushModelRequest '%s' failed: %s", modelInfo, err)
+		return nil, getSubmitStatus(err).Err()
 	}
+	return done, nil
+}
 
-	response := &configmodelapi.PushModelResponse{}
-	log.Debugf("Sending PushModelResponse %+v", response)
-	return response, nil
+// testPlugin compiles the plugin if necessary and validates the given configs against it
+// If validation fails, a plugin compiled for the test is removed from the cache.
+func (s *Server) testPlugin(ctx context.Context, modelInfo configmodel.ModelInfo, entry *plugincache.PluginEntry, cached bool, configs [][]byte) error {
+	if !cached {
+		if err := s.compilePlugin(ctx, modelInfo, entry.Path); err != nil {
+			if errors.IsCanceled(err) {
+				return err
+			}
+			return errors.NewInvalid("failed to compile model '%s': %s", modelInfo, err)
+		}
+	}
+	plugin, err := entry.LoadFresh()
+	if err == nil {
+		err = validateConfigs(plugin.Model(), configs)
+	}
+	if err != nil && !cached {
+		if err := entry.Remove(); err != nil {
+			log.Errorf("Failed to remove plugin '%s': %s", entry.Path, err)
+		}
+	}
+	return err
+}
+
+// verifyPrebuiltPlugins verifies the plugins cached for a model pushed without compiling its plugin
+// A plugin for the model may be staged at its content addressed path and in place of the cached plugin.
+func (s *Server) verifyPrebuiltPlugins(ctx context.Context, modelInfo configmodel.ModelInfo) error {
+	entry := s.cache.Entry(modelInfo.Name, modelInfo.Version)
+	for _, path := range []string{entry.VersionPath(modelInfo.ComputeChecksum()), entry.Path} {
+		if !exists(path) {
+			continue
+		}
+		if err := s.verifyPrebuilt(ctx, modelInfo, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyPrebuiltPlugin checks that the plugin at the given path was compiled from the given model and can be
+// loaded by the running binary. The plugin is loaded by a separate process, so a plugin that fails verification
+// is never loaded by the server.
+func (s *Server) verifyPrebuiltPlugin(ctx context.Context, modelInfo configmodel.ModelInfo, path string) error {
+	result, err := s.inspect(ctx, path)
+	if err != nil {
+		return err
+	}
+	if result.Error != "" {
+		return errors.NewInvalid("plugin '%s' for model '%s' cannot be loaded: %s", path, modelInfo, result.Error)
+	}
+	if checksum := modelInfo.ComputeChecksum(); result.Checksum != checksum {
+		return errors.NewInvalid("plugin '%s' was not compiled from model '%s': expected checksum '%s', found '%s'", path, modelInfo, checksum, result.Checksum)
+	}
+	return nil
+}
+
+// validateConfigs unmarshals and validates each of the given configs with the model
+func validateConfigs(model configmodel.ConfigModel, configs [][]byte) error {
+	var failures []string
+	for i, config := range configs {
+		value, err := model.Unmarshaler()(config)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("test config %d could not be unmarshaled: %s", i, err))
+			continue
+		}
+		if err := model.Validator()(value); err != nil {
+			failures = append(failures, fmt.Sprintf("test config %d is not valid: %s", i, err))
+		}
+	}
+	if len(failures) > 0 {
+		return errors.NewInvalid("model '%s' failed validation: %s", model.Info(), strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// LoadPlugin loads the plugin for the given model from the cache
+// Plugins can only be loaded by binaries built for the same platform, so the plugin built for the platform
+// of the running binary is loaded, even if the registry compiles plugins for another platform.
+func (s *Server) LoadPlugin(ctx context.Context, name configmodel.Name, version configmodel.Version) (modelplugin.ConfigModelPlugin, error) {
+	if platform := pluginmodule.NewPlatform("", ""); platform != s.cache.Platform() {
+		return s.loadPlatformPlugin(ctx, name, version, platform)
+	}
+	entry := s.cache.Entry(name, version)
+
+	// Models in a read-only registry are staged without plugins, and the plugins of unpinned models may have
+	// been evicted from the cache, so missing plugins are compiled on load
+	if err := s.ensurePlugin(ctx, entry, name, version); err != nil {
+		return nil, err
+	}
+
+	if err := entry.RLock(ctx); err != nil {
+		return nil, err
+	}
+	plugin, err := s.load(entry)
+	if err := entry.RUnlock(ctx); err != nil {
+		log.Errorf("Failed to release cache lock: %s", err)
+	}
+	if err == nil || !modelplugin.IsABIMismatch(err) || !s.config.AutoRecompileOnABIMismatch {
+		return plugin, err
+	}
+
+	// Recompile the plugin with the current toolchain and retry the load once
+	log.Warnf("Plugin for model '%s@%s' is incompatible with the running binary; recompiling: %s", name, version, err)
+	modelInfo, err := s.registry.GetModel(name, version)
+	if err != nil {
+		return nil, err
+	}
+	if err := entry.Lock(ctx); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := entry.Unlock(context.Background()); err != nil {
+			log.Errorf("Failed to release cache lock: %s", err)
+		}
+	}()
+	if err := s.compilePlugin(ctx, modelInfo, entry.Path); err != nil {
+		return nil, err
+	}
+	s.recordBuildInfo(modelInfo, entry.Path)
+	s.recordPluginArtifact(modelInfo)
+
+	// The failed load is cached by path for the lifetime of the process, so load a fresh copy
+	plugin, err = entry.LoadFresh()
+	if err != nil {
+		log.Errorf("Failed to load recompiled plugin for model '%s@%s': %s", name, version, err)
+		return nil, err
+	}
+	log.Infof("Recompiled and loaded plugin for model '%s@%s'", name, version)
+	return plugin, nil
+}
+
+// checkPlugin loads the plugin of a model served by GetModel if AutoRecompileOnABIMismatch is enabled, so a plugin
+// built with an incompatible toolchain is recompiled as soon as the model is requested
+// Plugins that have not been compiled are not waited for, and plugins that cannot be loaded are logged rather than
+// failing the request, since the model is served whether or not its plugin can be loaded.
+func (s *Server) checkPlugin(ctx context.Context, modelInfo configmodel.ModelInfo) {
+	if !s.config.AutoRecompileOnABIMismatch || s.cache.Platform() != pluginmodule.NewPlatform("", "") {
+		return
+	}
+	if _, err := os.Stat(s.cache.Entry(modelInfo.Name, modelInfo.Version).Path); err != nil {
+		return
+	}
+	if _, err := s.LoadPlugin(ctx, modelInfo.Name, modelInfo.Version); err != nil {
+		log.Warnf("Failed to load plugin for model '%s': %s", modelInfo, err)
+	}
+}
+
+// compileMissingPlugins submits compilations for the given models' plugins that are missing from the cache
+// Models in a read-only registry are staged without plugins, so their plugins are compiled into the writable
+// cache as the models are served. Each model is scheduled once until its plugin is compiled, so plugins that
+// fail to compile are only retried when they're loaded, and compilations queue behind pushed models.
+func (s *Server) compileMissingPlugins(modelInfos ...configmodel.ModelInfo) {
+	if !s.registry.IsReadOnly() {
+		return
+	}
+	for _, modelInfo := range modelInfos {
+		entry := s.cache.Entry(modelInfo.Name, modelInfo.Version)
+		if _, err := os.Stat(entry.Path); err == nil {
+			continue
+		}
+		key := modelInfo.String()
+		s.scheduledMu.Lock()
+		if s.scheduled[key] {
+			s.scheduledMu.Unlock()
+			continue
+		}
+		s.scheduled[key] = true
+		s.scheduledMu.Unlock()
+
+		name, version := modelInfo.Name, modelInfo.Version
+		err := s.workers.submit(key, PriorityLow, func() {
+			if err := s.ensurePlugin(context.Background(), entry, name, version); err != nil {
+				log.Warnf("Failed to compile plugin for model '%s@%s' from read-only registry '%s': %s", name, version, s.registry, err)
+				return
+			}
+			s.scheduledMu.Lock()
+			delete(s.scheduled, key)
+			s.scheduledMu.Unlock()
+		})
+		if err != nil {
+			log.Warnf("Failed to schedule compiling plugin for model '%s': %s", modelInfo, err)
+			s.scheduledMu.Lock()
+			delete(s.scheduled, key)
+			s.scheduledMu.Unlock()
+		}
+	}
+}
+
+// ensurePlugin compiles the plugin for the given model if it's not in the cache
+func (s *Server) ensurePlugin(ctx context.Context, entry *plugincache.PluginEntry, name configmodel.Name, version configmodel.Version) error {
+	if _, err := os.Stat(entry.Path); err == nil {
+		return nil
+	}
+	modelInfo, err := s.registry.GetModel(name, version)
+	if err != nil {
+		return err
+	}
+	if err := entry.Lock(ctx); err != nil {
+		return err
+	}
+	defer func() {
+		if err := entry.Unlock(context.Background()); err != nil {
+			log.Errorf("Failed to release cache lock: %s", err)
+		}
+	}()
+
+	// The plugin may have been compiled while waiting for the lock
+	if _, err := os.Stat(entry.Path); err == nil {
+		return nil
+	}
+	log.Infof("Compiling missing plugin for model '%s@%s' from registry '%s'", name, version, s.registry)
+	if err := s.compilePlugin(ctx, modelInfo, entry.Path); err != nil {
+		return err
+	}
+	s.recordBuildInfo(modelInfo, entry.Path)
+	s.recordPluginArtifact(modelInfo)
+	return nil
+}
+
+// compilePlugin compiles the plugin for the given model, recording the attempt in the model's compile history
+// The compilation is aborted and the build commands killed if the given context is canceled.
+func (s *Server) compilePlugin(ctx context.Context, modelInfo configmodel.ModelInfo, path string) error {
+	return s.compilePluginWithProgress(ctx, modelInfo, path, nil)
+}
+
+// compilePluginWithProgress compiles the plugin for the given model, reporting progress to the given function
+func (s *Server) compilePluginWithProgress(ctx context.Context, modelInfo configmodel.ModelInfo, path string, progress plugincompiler.ProgressFunc) error {
+	start := time.Now()
+	compiler := s.compiler.WithContext(ctx)
+	var err error
+	if progress != nil {
+		err = compiler.CompilePluginWithProgress(modelInfo, path, progress)
+	} else {
+		err = compiler.CompilePlugin(modelInfo, path)
+	}
+	attempt := CompileAttempt{
+		Time:     start,
+		Duration: time.Since(start),
+		Success:  err == nil,
+	}
+	s.metrics.recordCompile(attempt.Duration, err)
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	if !s.registry.IsReadOnly() {
+		if err := s.registry.AddCompileAttempt(modelInfo.Name, modelInfo.Version, attempt); err != nil {
+			log.Warnf("Failed to record compile attempt for model '%s': %s", modelInfo, err)
+		}
+	}
+	if s.webhook != nil {
+		s.webhook.notify(fmt.Sprintf("compile event for model '%s'", modelInfo), newCompileEvent(modelInfo, attempt))
+	}
+	if err != nil {
+		return err
+	}
+
+	// The plugin being compiled is locked, so it cannot be evicted to make room for itself
+	if _, err := EvictPlugins(s.registry, s.cache); err != nil {
+		log.Warnf("Failed to evict plugins from cache '%s': %s", s.cache.Config.Path, err)
+	}
+	return nil
 }
 
 // DeleteModel :
 func (s *Server) DeleteModel(ctx context.Context, request *configmodelapi.DeleteModelRequest) (*configmodelapi.DeleteModelResponse, error) {
 	log.Debugf("Received DeleteModelRequest %+v", request)
+	s.sendCapabilities(ctx)
+	if err := s.checkMutable(); err != nil {
+		log.Warnf("DeleteModelRequest %+v failed: %v", request, err)
+		return nil, errors.Status(err).Err()
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	err := s.registry.RemoveModel(configmodel.Name(request.Name), configmodel.Version(request.Version))
-	if err != nil {
+	name, version := configmodel.Name(request.Name), configmodel.Version(request.Version)
+
+	// Pinned models can only be deleted when forced
+	modelInfo, err := s.registry.GetModel(name, version)
+	exists := err == nil
+	if exists && modelInfo.Pinned && !getBoolMetadata(ctx, ForceKey) {
+		err = errors.NewForbidden("model '%s@%s' is pinned", request.Name, request.Version)
 		log.Warnf("DeleteModelRequest %+v failed: %v", request, err)
 		return nil, errors.Status(err).Err()
 	}
 
 	response := &configmodelapi.DeleteModelResponse{}
+	if getBoolMetadata(ctx, DryRunKey) {
+		sendDeletedFiles(ctx, s.getModelFiles(name, version))
+		log.Debugf("Sending DeleteModelResponse %+v", response)
+		return response, nil
+	}
+	// Models being tested are not in the registry yet, but their cache entries are locked until they're added
+	if s.testing[configmodel.ModelInfo{Name: name, Version: version}.String()] {
+		err = errors.NewConflict("model '%s@%s' is being pushed", request.Name, request.Version)
+		log.Warnf("DeleteModelRequest %+v failed: %v", request, err)
+		return nil, errors.Status(err).Err()
+	}
+
+	// Hold the cache lock while the model and its plugin are removed so concurrent loads
+	// and compiles never observe the model without its plugin.
+	entry := s.cache.Entry(name, version)
+	if err := entry.Lock(ctx); err != nil {
+		log.Warnf("DeleteModelRequest %+v failed: %v", request, err)
+		return nil, errors.Status(err).Err()
+	}
+	defer func() {
+		_ = entry.Unlock(context.Background())
+	}()
+
+	// The plugin is moved aside before the model is removed so it can be restored if the removal fails
+	files := s.getModelFiles(name, version)
+	if err := entry.Detach(); err != nil {
+		err = errors.NewInternal("failed to remove plugin for model '%s@%s': %s", request.Name, request.Version, err)
+		log.Warnf("DeleteModelRequest %+v failed: %v", request, err)
+		return nil, errors.Status(err).Err()
+	}
+	err = s.registry.RemoveModel(name, version)
+	if err != nil {
+		if err := entry.Attach(); err != nil {
+			log.Errorf("Failed to restore plugin for model '%s@%s': %s", request.Name, request.Version, err)
+		}
+		log.Warnf("DeleteModelRequest %+v failed: %v", request, err)
+		return nil, errors.Status(err).Err()
+	}
+	// The model is already removed, so failing to remove its plugin doesn't fail the deletion
+	if err := entry.Remove(); err != nil {
+		log.Warnf("Failed to remove plugin for model '%s@%s': %s", request.Name, request.Version, err)
+	}
+	if err := s.removePlatformPlugins(ctx, modelInfo); err != nil {
+		log.Warnf("DeleteModelRequest %+v failed: %v", request, err)
+		return nil, errors.Status(err).Err()
+	}
+	sendDeletedFiles(ctx, files)
+	if exists {
+		s.notifyModelEvent(ModelRemoved, modelInfo)
+	}
+
 	log.Debugf("Sending DeleteModelResponse %+v", response)
 	return response, nil
 }
@@ -0,0 +1,465 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	"encoding/json"
+	goerrors "errors"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	modelplugin "github.com/onosproject/onos-config-model/pkg/model/plugin"
+	plugincache "github.com/onosproject/onos-config-model/pkg/model/plugin/cache"
+	plugincompiler "github.com/onosproject/onos-config-model/pkg/model/plugin/compiler"
+	pluginmodule "github.com/onosproject/onos-config-model/pkg/model/plugin/module"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/openconfig/ygot/ygot"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func newTestServer(t *testing.T) *Server {
+	dir, err := ioutil.TempDir("", "config-model-registry")
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = os.RemoveAll(dir)
+	})
+
+	// Pre-populate the resolved module to avoid fetching the target module
+	modPath := filepath.Join(dir, "mod")
+	assert.NoError(t, os.MkdirAll(modPath, os.ModePerm))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(modPath, "go.mod"), []byte("module github.com/onosproject/onos-config\n"), 0666))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(modPath, "mod.md5"), []byte("test"), 0666))
+	resolver := pluginmodule.NewResolver(pluginmodule.ResolverConfig{
+		Path: modPath,
+	})
+
+	cache, err := plugincache.NewPluginCache(plugincache.CacheConfig{
+		Path: filepath.Join(dir, "cache"),
+	}, resolver)
+	assert.NoError(t, err)
+
+	compiler := plugincompiler.NewPluginCompiler(plugincompiler.CompilerConfig{
+		BuildPath: filepath.Join(dir, "build"),
+	}, resolver)
+
+	registry := NewConfigModelRegistry(Config{
+		Path: filepath.Join(dir, "registry"),
+	})
+
+	// Plugins placed in the cache by tests cannot be loaded, so they're not verified when pushed
+	server := NewServer(ServiceConfig{}, registry, cache, compiler)
+	server.verifyPrebuilt = func(ctx context.Context, modelInfo configmodel.ModelInfo, path string) error {
+		return nil
+	}
+	return server
+}
+
+func newTestClient(t *testing.T, server *Server) configmodelapi.ConfigModelRegistryServiceClient {
+	return configmodelapi.NewConfigModelRegistryServiceClient(newTestConn(t, server))
+}
+
+func newTestConn(t *testing.T, server *Server) *grpc.ClientConn {
+	lis := bufconn.Listen(1024 * 1024)
+	s := grpc.NewServer()
+	configmodelapi.RegisterConfigModelRegistryServiceServer(s, server)
+	registerPushStream(s, server)
+	registerModuleService(s, server)
+	registerWatchService(s, server)
+	registerConfigService(s, server)
+	registerValidationService(s, server)
+	registerHistoryService(s, server)
+	registerMaintenanceService(s, server)
+	go func() {
+		_ = s.Serve(lis)
+	}()
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) {
+		return lis.Dial()
+	}))
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return conn
+}
+
+func newIncomingContext(md metadata.MD) context.Context {
+	return metadata.NewIncomingContext(context.Background(), md)
+}
+
+func TestPushModelSkipCompile(t *testing.T) {
+	server := newTestServer(t)
+	ctx := newIncomingContext(metadata.Pairs(SkipCompileKey, "true"))
+
+	request := &configmodelapi.PushModelRequest{
+		Model: &configmodelapi.ConfigModel{
+			Name:    "test",
+			Version: "1.0.0",
+			Modules: []*configmodelapi.ConfigModule{
+				{
+					Name:     "test",
+					Revision: "2020-11-18",
+					File:     "test@2020-11-18.yang",
+				},
+			},
+		},
+	}
+
+	// Pushing without a cached plugin should fail
+	_, err := server.PushModel(ctx, request)
+	assert.Error(t, err)
+	assert.True(t, errors.IsNotFound(errors.FromGRPC(err)))
+	_, err = server.registry.GetModel("test", "1.0.0")
+	assert.True(t, errors.IsNotFound(err))
+
+	// Place a prebuilt plugin in the cache
+	entry := server.cache.Entry("test", "1.0.0")
+	assert.NoError(t, ioutil.WriteFile(entry.Path, []byte("plugin"), 0666))
+
+	_, err = server.PushModel(ctx, request)
+	assert.NoError(t, err)
+	model, err := server.registry.GetModel("test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Len(t, model.Modules, 1)
+	assert.False(t, entry.IsLocked())
+}
+
+func TestCompileHistoryRecorded(t *testing.T) {
+	server := newTestServer(t)
+
+	// Compilation fails because the model has no modules from which to generate bindings
+	model := configmodel.ModelInfo{
+		Name:    "test",
+		Version: "1.0.0",
+	}
+	err := server.compilePlugin(context.Background(), model, server.cache.Entry("test", "1.0.0").Path)
+	assert.Error(t, err)
+
+	history, err := server.registry.GetCompileHistory("test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+	assert.False(t, history[0].Success)
+	assert.NotEmpty(t, history[0].Error)
+}
+
+func TestLoadPluginABIMismatch(t *testing.T) {
+	server := newTestServer(t)
+	err := server.registry.AddModel(configmodel.ModelInfo{
+		Name:    "test",
+		Version: "1.0.0",
+	})
+	assert.NoError(t, err)
+
+	// Simulate a plugin built with a different toolchain
+	assert.NoError(t, ioutil.WriteFile(server.cache.Entry("test", "1.0.0").Path, []byte("plugin"), 0666))
+	loads := 0
+	server.load = func(entry *plugincache.PluginEntry) (modelplugin.ConfigModelPlugin, error) {
+		loads++
+		return nil, goerrors.New(`plugin.Open("test-1.0.0"): plugin was built with a different version of package runtime/internal/sys`)
+	}
+
+	_, err = server.LoadPlugin(context.Background(), "test", "1.0.0")
+	assert.True(t, modelplugin.IsABIMismatch(err))
+	history, err := server.registry.GetCompileHistory("test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Len(t, history, 0)
+
+	// Recompilation is attempted exactly once (and fails since the templates are not available to the test)
+	server.config.AutoRecompileOnABIMismatch = true
+	_, err = server.LoadPlugin(context.Background(), "test", "1.0.0")
+	assert.Error(t, err)
+	assert.Equal(t, 2, loads)
+	history, err = server.registry.GetCompileHistory("test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+	assert.False(t, server.cache.Entry("test", "1.0.0").IsLocked())
+}
+
+func TestGetModelABIMismatch(t *testing.T) {
+	if testing.Short() || raceEnabled {
+		t.Skip("building plugins is slow and they cannot be loaded by binaries built with -race")
+	}
+
+	moduleRoot, err := filepath.Abs(filepath.Join("..", "..", ".."))
+	assert.NoError(t, err)
+	server := newTestServer(t)
+	server.compiler.Config.ModFile = writeTryoutModFile(t, t.TempDir(), moduleRoot)
+	server.compiler.Config.SumFile = filepath.Join(moduleRoot, "go.sum")
+	server.config.AutoRecompileOnABIMismatch = true
+	client := newTestClient(t, server)
+
+	yang, err := ioutil.ReadFile(filepath.Join(moduleRoot, "test", "test@2020-11-18.yang"))
+	assert.NoError(t, err)
+	assert.NoError(t, server.registry.AddModel(configmodel.ModelInfo{
+		Name:         "test",
+		Version:      "1.0.0",
+		GetStateMode: configmodel.GetStateNone,
+		Modules: []configmodel.ModuleInfo{
+			{
+				Name:     "test",
+				Revision: "2020-11-18",
+				File:     "test@2020-11-18.yang",
+			},
+		},
+		Files: []configmodel.FileInfo{
+			{
+				Path: "test@2020-11-18.yang",
+				Data: yang,
+			},
+		},
+		Plugin: configmodel.PluginInfo{
+			Name:    "test",
+			Version: "1.0.0",
+		},
+	}))
+
+	// Simulate a cached plugin built with a different toolchain, which 'go build' overwrites as an object file
+	entry := server.cache.Entry("test", "1.0.0")
+	assert.NoError(t, ioutil.WriteFile(entry.Path, []byte("\x7fELF plugin"), 0666))
+	loads := 0
+	server.load = func(entry *plugincache.PluginEntry) (modelplugin.ConfigModelPlugin, error) {
+		loads++
+		return nil, goerrors.New(`plugin.Open("test-1.0.0"): plugin was built with a different version of package runtime/internal/sys`)
+	}
+
+	// Requesting the model recompiles the plugin from the persisted YANG files
+	response, err := client.GetModel(context.Background(), &configmodelapi.GetModelRequest{Name: "test", Version: "1.0.0"})
+	assert.NoError(t, err)
+	assert.Equal(t, "test", response.Model.Name)
+	assert.Equal(t, 1, loads)
+	history, err := server.registry.GetCompileHistory("test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+	assert.True(t, history[0].Success)
+	model, err := server.registry.GetModel("test", "1.0.0")
+	assert.NoError(t, err)
+	assert.NotNil(t, model.Build)
+	assert.False(t, entry.IsLocked())
+}
+
+type testModel struct {
+	configmodel.ConfigModel
+}
+
+func (m testModel) Info() configmodel.ModelInfo {
+	return configmodel.ModelInfo{Name: "test", Version: "1.0.0"}
+}
+
+func (m testModel) Unmarshaler() configmodel.Unmarshaler {
+	return func(bytes []byte) (*ygot.ValidatedGoStruct, error) {
+		var value map[string]interface{}
+		if err := json.Unmarshal(bytes, &value); err != nil {
+			return nil, err
+		}
+		var vgs ygot.ValidatedGoStruct
+		return &vgs, nil
+	}
+}
+
+func (m testModel) Validator() configmodel.Validator {
+	return func(model *ygot.ValidatedGoStruct, opts ...ygot.ValidationOption) error {
+		return nil
+	}
+}
+
+func TestValidateConfigs(t *testing.T) {
+	err := validateConfigs(testModel{}, [][]byte{[]byte(`{"foo": "bar"}`)})
+	assert.NoError(t, err)
+
+	err = validateConfigs(testModel{}, [][]byte{[]byte(`{"foo": "bar"}`), []byte(`{"foo": `)})
+	assert.Error(t, err)
+	assert.True(t, errors.IsInvalid(err))
+	assert.Contains(t, err.Error(), "test config 1")
+	assert.NotContains(t, err.Error(), "test config 0")
+}
+
+func TestTestConfigs(t *testing.T) {
+	model := &configmodelapi.ConfigModel{
+		Name:    "test",
+		Version: "1.0.0",
+		Files:   map[string]string{"test.yang": "test"},
+	}
+	configs := make([][]byte, 12)
+	for i := range configs {
+		configs[i] = []byte(strconv.Itoa(i))
+	}
+	AddTestConfigs(model, configs[:2]...)
+	AddTestConfigs(model, configs[2:]...)
+	assert.Equal(t, configs, getTestConfigs(model))
+
+	// Test configs are not part of the model's definition
+	modelInfo := newModelInfo(model)
+	assert.Len(t, modelInfo.Files, 1)
+	assert.Equal(t, "test.yang", modelInfo.Files[0].Path)
+}
+
+func TestPushModelTestConfigs(t *testing.T) {
+	server := newTestServer(t)
+	client := newTestClient(t, server)
+
+	// The cached plugin can't be loaded to validate the test configs, so the model is not added
+	entry := server.cache.Entry("test", "1.0.0")
+	assert.NoError(t, ioutil.WriteFile(entry.Path, []byte("plugin"), 0666))
+	model := &configmodelapi.ConfigModel{
+		Name:    "test",
+		Version: "1.0.0",
+		Files:   map[string]string{"test.yang": "test"},
+	}
+	AddTestConfigs(model, []byte(`{}`))
+	_, err := client.PushModel(context.Background(), &configmodelapi.PushModelRequest{Model: model})
+	assert.Error(t, err)
+	_, err = server.registry.GetModel("test", "1.0.0")
+	assert.True(t, errors.IsNotFound(err))
+	assert.Empty(t, server.testing)
+
+	// Cached plugins are not removed when they fail validation
+	_, err = os.Stat(entry.Path)
+	assert.NoError(t, err)
+}
+
+func TestDeletePinnedModel(t *testing.T) {
+	server := newTestServer(t)
+	assert.NoError(t, server.registry.AddModel(configmodel.ModelInfo{Name: "test", Version: "1.0.0"}))
+	assert.NoError(t, server.registry.PinModel("test", "1.0.0"))
+
+	model, err := server.registry.GetModel("test", "1.0.0")
+	assert.NoError(t, err)
+	assert.True(t, model.Pinned)
+
+	request := &configmodelapi.DeleteModelRequest{Name: "test", Version: "1.0.0"}
+	_, err = server.DeleteModel(context.Background(), request)
+	assert.True(t, errors.IsForbidden(errors.FromGRPC(err)))
+	_, err = server.registry.GetModel("test", "1.0.0")
+	assert.NoError(t, err)
+
+	_, err = server.DeleteModel(newIncomingContext(metadata.Pairs(ForceKey, "true")), request)
+	assert.NoError(t, err)
+	_, err = server.registry.GetModel("test", "1.0.0")
+	assert.True(t, errors.IsNotFound(err))
+
+	assert.NoError(t, server.registry.AddModel(configmodel.ModelInfo{Name: "test", Version: "1.0.0"}))
+	assert.NoError(t, server.registry.PinModel("test", "1.0.0"))
+	assert.NoError(t, server.registry.UnpinModel("test", "1.0.0"))
+	_, err = server.DeleteModel(context.Background(), request)
+	assert.NoError(t, err)
+}
+
+func TestGetModelErrorCodes(t *testing.T) {
+	server := newTestServer(t)
+	client := newTestClient(t, server)
+
+	_, err := client.GetModel(context.Background(), &configmodelapi.GetModelRequest{Name: "test", Version: "1.0.0"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	assert.NoError(t, os.Mkdir(server.registry.(*ConfigModelRegistry).getDescriptorFile("test", "1.0.0"), os.ModePerm))
+	_, err = client.GetModel(context.Background(), &configmodelapi.GetModelRequest{Name: "test", Version: "1.0.0"})
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestGetStateModeRoundTrip(t *testing.T) {
+	server := newTestServer(t)
+	client := newTestClient(t, server)
+	entry := server.cache.Entry("test", "1.0.0")
+	assert.NoError(t, ioutil.WriteFile(entry.Path, []byte("plugin"), 0666))
+
+	_, err := client.PushModel(WithSkipCompile(context.Background()), &configmodelapi.PushModelRequest{
+		Model: &configmodelapi.ConfigModel{
+			Name:         "test",
+			Version:      "1.0.0",
+			GetStateMode: configmodelapi.GetStateMode_EXPLICIT_RO_PATHS,
+		},
+	})
+	assert.NoError(t, err)
+
+	// The mode is persisted in the model's descriptor
+	registry := NewConfigModelRegistry(server.registry.(*ConfigModelRegistry).Config)
+	model, err := registry.GetModel("test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, configmodel.GetStateExplicitRoPaths, model.GetStateMode)
+
+	response, err := client.GetModel(context.Background(), &configmodelapi.GetModelRequest{Name: "test", Version: "1.0.0"})
+	assert.NoError(t, err)
+	assert.Equal(t, configmodelapi.GetStateMode_EXPLICIT_RO_PATHS, response.Model.GetStateMode)
+
+	list, err := client.ListModels(context.Background(), &configmodelapi.ListModelsRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, list.Models, 1)
+	assert.Equal(t, configmodelapi.GetStateMode_EXPLICIT_RO_PATHS, list.Models[0].GetStateMode)
+}
+
+func TestPushModelSkipCompileMismatch(t *testing.T) {
+	server := newTestServer(t)
+	server.verifyPrebuilt = server.verifyPrebuiltPlugin
+	client := newTestClient(t, server)
+	entry := server.cache.Entry("test", "1.0.0")
+	assert.NoError(t, ioutil.WriteFile(entry.Path, []byte("plugin"), 0666))
+	model := &configmodelapi.ConfigModel{
+		Name:    "test",
+		Version: "1.0.0",
+		Files:   map[string]string{"test.yang": "test"},
+	}
+	checksum := newModelInfo(model).ComputeChecksum()
+
+	// Plugins compiled from another definition of the model are rejected
+	server.inspect = func(ctx context.Context, path string) (TryoutResult, error) {
+		return TryoutResult{Name: "test", Version: "1.0.0", Checksum: "other"}, nil
+	}
+	_, err := client.PushModel(WithSkipCompile(context.Background()), &configmodelapi.PushModelRequest{Model: model})
+	assert.True(t, errors.IsInvalid(errors.FromGRPC(err)))
+	assert.Contains(t, err.Error(), "was not compiled from model")
+
+	// Plugins built with another toolchain are rejected
+	server.inspect = func(ctx context.Context, path string) (TryoutResult, error) {
+		return TryoutResult{Error: "plugin was built with a different version of package runtime/internal/sys"}, nil
+	}
+	_, err = client.PushModel(WithSkipCompile(context.Background()), &configmodelapi.PushModelRequest{Model: model})
+	assert.True(t, errors.IsInvalid(errors.FromGRPC(err)))
+	_, err = server.registry.GetModel("test", "1.0.0")
+	assert.True(t, errors.IsNotFound(err))
+
+	server.inspect = func(ctx context.Context, path string) (TryoutResult, error) {
+		return TryoutResult{Name: "test", Version: "1.0.0", Checksum: checksum}, nil
+	}
+	_, err = client.PushModel(WithSkipCompile(context.Background()), &configmodelapi.PushModelRequest{Model: model})
+	assert.NoError(t, err)
+	_, err = server.registry.GetModel("test", "1.0.0")
+	assert.NoError(t, err)
+}
+
+func TestPushModelPriority(t *testing.T) {
+	server := newTestServer(t)
+	client := newTestClient(t, server)
+	entry := server.cache.Entry("test", "1.0.0")
+	assert.NoError(t, ioutil.WriteFile(entry.Path, []byte("plugin"), 0666))
+
+	request := &configmodelapi.PushModelRequest{
+		Model: &configmodelapi.ConfigModel{
+			Name:    "test",
+			Version: "1.0.0",
+		},
+	}
+	_, err := client.PushModel(WithPriority(WithSkipCompile(context.Background()), "urgent"), request)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.PushModel(WithPriority(WithSkipCompile(context.Background()), PriorityHigh), request)
+	assert.NoError(t, err)
+
+	// Models whose plugins are not waiting to be compiled have no queue position
+	position, err := GetQueuePosition(context.Background(), client, "test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, position)
+}
@@ -0,0 +1,152 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	"encoding/json"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	modellogging "github.com/onosproject/onos-config-model/pkg/model/logging"
+	plugincompiler "github.com/onosproject/onos-config-model/pkg/model/plugin/compiler"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"io"
+)
+
+// The registry API has no streaming push method, so PushModelStream is provided by a separate service.
+// The service reuses the API's PushModelRequest, and streams JSON encoded PushEvents as string values.
+const (
+	pushStreamServiceName = "onos.configmodel.ConfigModelRegistryStreamService"
+	pushModelStreamMethod = "PushModelStream"
+)
+
+// PushEvent is a progress event streamed while a pushed model's plugin is compiled
+type PushEvent struct {
+	plugincompiler.Progress
+}
+
+// pushModelStreamServer is the server API for the streaming push service
+type pushModelStreamServer interface {
+	PushModelStream(request *configmodelapi.PushModelRequest, stream grpc.ServerStream) error
+}
+
+var pushStreamServiceDesc = grpc.ServiceDesc{
+	ServiceName: pushStreamServiceName,
+	HandlerType: (*pushModelStreamServer)(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    pushModelStreamMethod,
+			Handler:       pushModelStreamHandler,
+			ServerStreams: true,
+		},
+	},
+}
+
+func pushModelStreamHandler(srv interface{}, stream grpc.ServerStream) error {
+	request := &configmodelapi.PushModelRequest{}
+	if err := stream.RecvMsg(request); err != nil {
+		return err
+	}
+	return srv.(pushModelStreamServer).PushModelStream(request, stream)
+}
+
+// registerPushStream registers the streaming push service for the given server
+func registerPushStream(r *grpc.Server, server *Server) {
+	r.RegisterService(&pushStreamServiceDesc, server)
+}
+
+// PushModelStream pushes a model to the registry, calling the given function with the progress of its compilation
+// Unlike PushModel, PushModelStream returns once the plugin has been compiled, and returns an error if
+// the compilation fails. If the plugin is already cached, no events are streamed.
+func PushModelStream(ctx context.Context, conn grpc.ClientConnInterface, model *configmodelapi.ConfigModel, f func(PushEvent)) error {
+	stream, err := conn.NewStream(ctx, &pushStreamServiceDesc.Streams[0], "/"+pushStreamServiceName+"/"+pushModelStreamMethod)
+	if err != nil {
+		return err
+	}
+	if err := stream.SendMsg(&configmodelapi.PushModelRequest{Model: model}); err != nil {
+		return err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+	for {
+		value := &wrapperspb.StringValue{}
+		if err := stream.RecvMsg(value); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		var event PushEvent
+		if err := json.Unmarshal([]byte(value.Value), &event); err != nil {
+			return err
+		}
+		f(event)
+	}
+}
+
+// PushModelStream :
+func (s *Server) PushModelStream(request *configmodelapi.PushModelRequest, stream grpc.ServerStream) error {
+	log.Debugw("Received PushModelStream request", modellogging.ModelField, request.Model.GetName(), modellogging.VersionField, request.Model.GetVersion(), "files", len(request.Model.GetFiles()))
+	ctx := stream.Context()
+
+	// Tried out models are not compiled to the cache, so there's no progress to stream
+	if getBoolMetadata(ctx, TryoutKey) {
+		_, err := s.PushModel(ctx, request)
+		return err
+	}
+	s.sendCapabilities(ctx)
+	if err := s.decodeModelFiles(ctx, request.Model); err != nil {
+		log.Warnf("PushModelStream request '%s@%s' failed: %s", request.Model.GetName(), request.Model.GetVersion(), err)
+		return errors.Status(err).Err()
+	}
+	if err := s.checkLimits(request.Model); err != nil {
+		log.Warnf("PushModelStream request '%s@%s' failed: %s", request.Model.GetName(), request.Model.GetVersion(), err)
+		return errors.Status(err).Err()
+	}
+
+	events := make(chan PushEvent, 16)
+	done, err := s.pushModel(ctx, request, func(progress plugincompiler.Progress) {
+		select {
+		case events <- PushEvent{Progress: progress}:
+		case <-ctx.Done():
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case event := <-events:
+			if err := sendPushEvent(stream, event); err != nil {
+				return err
+			}
+		case err := <-done:
+			// All events are queued before the compilation completes
+			for len(events) > 0 {
+				if err := sendPushEvent(stream, <-events); err != nil {
+					return err
+				}
+			}
+			if err != nil {
+				log.Warnf("PushModelStream request '%s@%s' failed: %s", request.Model.Name, request.Model.Version, err)
+				return errors.Status(err).Err()
+			}
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// sendPushEvent sends a JSON encoded push event on the given stream
+func sendPushEvent(stream grpc.ServerStream, event PushEvent) error {
+	bytes, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return stream.SendMsg(wrapperspb.String(string(bytes)))
+}
@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	plugincompiler "github.com/onosproject/onos-config-model/pkg/model/plugin/compiler"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func getPhases(events []PushEvent) []plugincompiler.Phase {
+	var phases []plugincompiler.Phase
+	for _, event := range events {
+		phases = append(phases, event.Phase)
+	}
+	return phases
+}
+
+func TestPushModelStreamFailure(t *testing.T) {
+	server := newTestServer(t)
+	conn := newTestConn(t, server)
+
+	// Compilation fails because the model has no modules from which to generate bindings
+	var events []PushEvent
+	err := PushModelStream(context.Background(), conn, &configmodelapi.ConfigModel{Name: "test", Version: "1.0.0"}, func(event PushEvent) {
+		events = append(events, event)
+	})
+	assert.Error(t, err)
+	assert.Equal(t, []plugincompiler.Phase{plugincompiler.FetchingModulesPhase, plugincompiler.GeneratingBindingsPhase, plugincompiler.FailedPhase}, getPhases(events))
+	assert.NotEmpty(t, events[2].Message)
+	assert.False(t, server.cache.Entry("test", "1.0.0").IsLocked())
+
+	// Models with cached plugins are pushed without compiling them
+	entry := server.cache.Entry("foo", "1.0.0")
+	assert.NoError(t, ioutil.WriteFile(entry.Path, []byte("plugin"), 0666))
+	events = nil
+	err = PushModelStream(context.Background(), conn, &configmodelapi.ConfigModel{Name: "foo", Version: "1.0.0"}, func(event PushEvent) {
+		events = append(events, event)
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+	_, err = server.registry.GetModel("foo", "1.0.0")
+	assert.NoError(t, err)
+}
+
+func TestPushModelStream(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping plugin build in short mode")
+	}
+
+	moduleRoot, err := filepath.Abs(filepath.Join("..", "..", ".."))
+	assert.NoError(t, err)
+	dir, err := ioutil.TempDir("", "config-model-stream-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	server := newTestServer(t)
+	server.compiler.Config.TemplatePath = filepath.Join(moduleRoot, "pkg", "model", "plugin", "compiler", "templates")
+	server.compiler.Config.ModFile = writeTryoutModFile(t, dir, moduleRoot)
+	server.compiler.Config.SumFile = filepath.Join(moduleRoot, "go.sum")
+	conn := newTestConn(t, server)
+
+	yang, err := ioutil.ReadFile(filepath.Join(moduleRoot, "test", "test@2020-11-18.yang"))
+	assert.NoError(t, err)
+	model := &configmodelapi.ConfigModel{
+		Name:         "stream",
+		Version:      "1.0.0",
+		GetStateMode: configmodelapi.GetStateMode_NONE,
+		Modules: []*configmodelapi.ConfigModule{
+			{
+				Name:     "test",
+				Revision: "2020-11-18",
+				File:     "test@2020-11-18.yang",
+			},
+		},
+		Files: map[string]string{
+			"test@2020-11-18.yang": string(yang),
+		},
+	}
+
+	var events []PushEvent
+	err = PushModelStream(context.Background(), conn, model, func(event PushEvent) {
+		events = append(events, event)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []plugincompiler.Phase{
+		plugincompiler.FetchingModulesPhase,
+		plugincompiler.GeneratingBindingsPhase,
+		plugincompiler.BuildStartedPhase,
+		plugincompiler.BuildFinishedPhase,
+	}, getPhases(events))
+
+	// The plugin is compiled by the time the stream completes
+	_, err = os.Stat(server.cache.Entry("stream", "1.0.0").Path)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,171 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	modelplugin "github.com/onosproject/onos-config-model/pkg/model/plugin"
+	plugincache "github.com/onosproject/onos-config-model/pkg/model/plugin/cache"
+	plugincompiler "github.com/onosproject/onos-config-model/pkg/model/plugin/compiler"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// replaceOptions are the push options applied when a model is replaced
+type replaceOptions struct {
+	priority    Priority
+	progress    plugincompiler.ProgressFunc
+	skipCompile bool
+	testConfigs [][]byte
+}
+
+// replaceModel replaces an existing model with a pushed model
+// The new plugin is compiled to a content addressed path while the existing plugin continues to be served,
+// then the model descriptor and the cached plugin are swapped together, so new loads get either the old or
+// the new plugin but never find the model without one. The returned channel receives the result of the swap.
+// The compilation is aborted if the given context is canceled, in which case the existing model is kept.
+// If compilation is skipped, the new plugin must already be present at its content addressed path, or
+// in place of the cached plugin.
+func (s *Server) replaceModel(ctx context.Context, modelInfo configmodel.ModelInfo, options replaceOptions) (<-chan error, error) {
+	entry := s.cache.Entry(modelInfo.Name, modelInfo.Version)
+	path := entry.VersionPath(modelInfo.ComputeChecksum())
+	if options.skipCompile && !exists(path) && !exists(entry.Path) {
+		err := errors.NewNotFound("plugin for model '%s' not found in cache '%s'", modelInfo, s.cache.Config.Path)
+		log.Warnf("PushModelRequest '%s' failed: %s", modelInfo, err)
+		return nil, errors.Status(err).Err()
+	}
+	log.Infof("Replacing model '%s' with plugin '%s'", modelInfo, path)
+
+	done := make(chan error, 1)
+	err := s.workers.submit(modelInfo.String(), options.priority, func() {
+		defer func() {
+			if err := recover(); err != nil {
+				done <- errors.NewInternal("replacing model '%s' panicked: %v", modelInfo, err)
+			}
+		}()
+		err := s.swapPlugin(ctx, modelInfo, entry, path, options)
+		if err != nil {
+			log.Errorf("Failed to replace model '%s': %s", modelInfo, err)
+		}
+		done <- err
+	})
+	if err != nil {
+		log.Warnf("PushModelRequest '%s' failed: %s", modelInfo, err)
+		return nil, getSubmitStatus(err).Err()
+	}
+	return done, nil
+}
+
+// swapPlugin compiles the plugin version at the given path if necessary and swaps it in with the model
+func (s *Server) swapPlugin(ctx context.Context, modelInfo configmodel.ModelInfo, entry *plugincache.PluginEntry, path string, options replaceOptions) error {
+	// Versions are content addressed, so a version that's already compiled can be reused
+	compiled := false
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if !options.skipCompile {
+			if err := s.compilePluginWithProgress(ctx, modelInfo, path, options.progress); err != nil {
+				_ = os.Remove(path)
+				return err
+			}
+			compiled = true
+		} else if exists(entry.Path) {
+			// Plugins placed in the cache out of band replace the cached plugin in place
+			path = entry.Path
+		} else {
+			return errors.NewNotFound("plugin for model '%s' not found in cache '%s'", modelInfo, s.cache.Config.Path)
+		}
+	}
+
+	// If test configs were provided, the model is only replaced once they've been validated against the new plugin
+	if len(options.testConfigs) > 0 {
+		if err := testPluginVersion(path, options.testConfigs); err != nil {
+			if compiled {
+				_ = os.Remove(path)
+			}
+			return err
+		}
+	}
+
+	if err := entry.Lock(context.Background()); err != nil {
+		return err
+	}
+	defer func() {
+		if err := entry.Unlock(context.Background()); err != nil {
+			log.Errorf("Failed to release cache lock: %s", err)
+		}
+	}()
+	// Plugins built externally for other platforms were built from the replaced definition
+	replaced, err := s.registry.GetModel(modelInfo.Name, modelInfo.Version)
+	if err != nil {
+		return err
+	}
+	// Replacing the definition of a pinned model doesn't unpin it
+	modelInfo.Pinned = replaced.Pinned
+	modelInfo.Plugin.SetArtifact(newPluginArtifact(s.cache.Platform()))
+	if err := s.registry.AddModel(modelInfo); err != nil {
+		return err
+	}
+	s.notifyModelEvent(ModelAdded, modelInfo)
+	previous := ""
+	if path != entry.Path {
+		if previous, err = entry.Swap(path); err != nil {
+			return err
+		}
+	}
+	s.recordBuildInfo(modelInfo, path)
+	if err := s.removePlatformPlugins(context.Background(), replaced); err != nil {
+		log.Warnf("Failed to remove plugins replaced with model '%s': %s", modelInfo, err)
+	}
+	log.Infof("Replaced model '%s'", modelInfo)
+	if previous != "" {
+		s.removePluginVersion(entry, previous)
+	}
+	return nil
+}
+
+// exists returns whether a file exists at the given path
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// testPluginVersion validates the given test configs with the plugin version at the given path
+func testPluginVersion(path string, configs [][]byte) error {
+	plugin, err := modelplugin.LoadFresh(path)
+	if err != nil {
+		return err
+	}
+	return validateConfigs(plugin.Model(), configs)
+}
+
+// removePluginVersion removes a replaced plugin version once the grace period has elapsed
+func (s *Server) removePluginVersion(entry *plugincache.PluginEntry, path string) {
+	if s.config.PluginGracePeriod == 0 {
+		return
+	}
+	time.AfterFunc(s.config.PluginGracePeriod, func() {
+		if err := entry.Lock(context.Background()); err != nil {
+			log.Warnf("Failed to remove replaced plugin '%s': %s", path, err)
+			return
+		}
+		defer func() {
+			if err := entry.Unlock(context.Background()); err != nil {
+				log.Errorf("Failed to release cache lock: %s", err)
+			}
+		}()
+
+		// The version may have been swapped back in during the grace period
+		if target, err := os.Readlink(entry.Path); err == nil && target == filepath.Base(path) {
+			return
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Warnf("Failed to remove replaced plugin '%s': %s", path, err)
+			return
+		}
+		log.Infof("Removed replaced plugin '%s'", path)
+	})
+}
@@ -0,0 +1,237 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	modelplugin "github.com/onosproject/onos-config-model/pkg/model/plugin"
+	plugincache "github.com/onosproject/onos-config-model/pkg/model/plugin/cache"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReplaceModelDuringLoad(t *testing.T) {
+	server := newTestServer(t)
+	server.config.PluginGracePeriod = 10 * time.Millisecond
+	client := newTestClient(t, server)
+	assert.True(t, server.Capabilities().Has(ReplaceCapability))
+
+	model := &configmodelapi.ConfigModel{
+		Name:    "test",
+		Version: "1.0.0",
+		Files:   map[string]string{"test.yang": "old"},
+	}
+	assert.NoError(t, server.registry.AddModel(newModelInfo(model)))
+	entry := server.cache.Entry("test", "1.0.0")
+	assert.NoError(t, ioutil.WriteFile(entry.Path, []byte("old"), 0666))
+
+	// Loads open the plugin and then block until released, like a load of a large plugin
+	var once sync.Once
+	loading := make(chan struct{})
+	release := make(chan struct{})
+	loaded := make(chan string, 2)
+	server.load = func(entry *plugincache.PluginEntry) (modelplugin.ConfigModelPlugin, error) {
+		file, err := os.Open(entry.Path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		once.Do(func() {
+			close(loading)
+		})
+		<-release
+		bytes, err := ioutil.ReadAll(file)
+		loaded <- string(bytes)
+		return nil, err
+	}
+	go func() {
+		_, _ = server.LoadPlugin(context.Background(), "test", "1.0.0")
+	}()
+	<-loading
+
+	// Existing models are only replaced when forced
+	replacement := &configmodelapi.ConfigModel{
+		Name:    "test",
+		Version: "1.0.0",
+		Files:   map[string]string{"test.yang": "new"},
+	}
+	_, err := client.PushModel(context.Background(), &configmodelapi.PushModelRequest{Model: replacement})
+	assert.True(t, errors.IsAlreadyExists(errors.FromGRPC(err)))
+
+	// Stage the compiled plugin for the replacement, which is content addressed
+	path := entry.VersionPath(newModelInfo(replacement).ComputeChecksum())
+	assert.NoError(t, ioutil.WriteFile(path, []byte("new"), 0666))
+	// The plugin is never missing while it's swapped
+	missing := make(chan bool, 1)
+	stop := make(chan struct{})
+	go func() {
+		defer close(missing)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if _, err := os.Stat(entry.Path); err != nil {
+				missing <- true
+				return
+			}
+		}
+	}()
+	_, err = client.PushModel(WithForce(context.Background()), &configmodelapi.PushModelRequest{Model: replacement})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		modelInfo, err := server.registry.GetModel("test", "1.0.0")
+		if err != nil || string(modelInfo.Files[0].Data) != "new" {
+			return false
+		}
+		bytes, err := ioutil.ReadFile(entry.Path)
+		return err == nil && string(bytes) == "new"
+	}, 5*time.Second, 10*time.Millisecond)
+	close(stop)
+	assert.False(t, <-missing)
+
+	// The load in progress keeps the plugin it opened, while new loads get the replacement
+	close(release)
+	assert.Equal(t, "old", <-loaded)
+	_, err = server.LoadPlugin(context.Background(), "test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "new", <-loaded)
+
+	// The replaced plugin is removed after the grace period
+	assert.Eventually(t, func() bool {
+		paths, err := ioutil.ReadDir(server.cache.Config.Path)
+		if err != nil {
+			return false
+		}
+		for _, info := range paths {
+			name := info.Name()
+			if name != "test-1.0.0.so" && name != "test-1.0.0.lock" && name != "test-1.0.0.so."+newModelInfo(replacement).ComputeChecksum()[:16] {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+	_, err = os.Stat(path)
+	assert.NoError(t, err)
+}
+
+func TestReplacePinnedModel(t *testing.T) {
+	server := newTestServer(t)
+	client := newTestClient(t, server)
+
+	model := &configmodelapi.ConfigModel{
+		Name:    "test",
+		Version: "1.0.0",
+		Files:   map[string]string{"test.yang": "old"},
+	}
+	assert.NoError(t, server.registry.AddModel(newModelInfo(model)))
+	assert.NoError(t, server.registry.PinModel("test", "1.0.0"))
+	assert.NoError(t, server.registry.AddCompileAttempt("test", "1.0.0", CompileAttempt{Time: time.Now(), Success: true}))
+	entry := server.cache.Entry("test", "1.0.0")
+	assert.NoError(t, ioutil.WriteFile(entry.Path, []byte("old"), 0666))
+
+	replacement := &configmodelapi.ConfigModel{
+		Name:    "test",
+		Version: "1.0.0",
+		Files:   map[string]string{"test.yang": "new"},
+	}
+	path := entry.VersionPath(newModelInfo(replacement).ComputeChecksum())
+	assert.NoError(t, ioutil.WriteFile(path, []byte("new"), 0666))
+	_, err := client.PushModel(WithForce(context.Background()), &configmodelapi.PushModelRequest{Model: replacement})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		modelInfo, err := server.registry.GetModel("test", "1.0.0")
+		return err == nil && string(modelInfo.Files[0].Data) == "new"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The replaced model is still pinned and keeps its compile history
+	modelInfo, err := server.registry.GetModel("test", "1.0.0")
+	assert.NoError(t, err)
+	assert.True(t, modelInfo.Pinned)
+	history, err := server.registry.GetCompileHistory("test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+}
+
+func TestReplaceModelSkipCompile(t *testing.T) {
+	server := newTestServer(t)
+	client := newTestClient(t, server)
+
+	model := &configmodelapi.ConfigModel{
+		Name:    "test",
+		Version: "1.0.0",
+		Files:   map[string]string{"test.yang": "old"},
+	}
+	assert.NoError(t, server.registry.AddModel(newModelInfo(model)))
+	entry := server.cache.Entry("test", "1.0.0")
+
+	// The replacement plugin must be staged or cached if compilation is skipped
+	replacement := &configmodelapi.ConfigModel{
+		Name:    "test",
+		Version: "1.0.0",
+		Files:   map[string]string{"test.yang": "new"},
+	}
+	ctx := WithSkipCompile(WithForce(context.Background()))
+	_, err := client.PushModel(ctx, &configmodelapi.PushModelRequest{Model: replacement})
+	assert.True(t, errors.IsNotFound(errors.FromGRPC(err)))
+	modelInfo, err := server.registry.GetModel("test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "old", string(modelInfo.Files[0].Data))
+
+	path := entry.VersionPath(newModelInfo(replacement).ComputeChecksum())
+	assert.NoError(t, ioutil.WriteFile(path, []byte("new"), 0666))
+	_, err = client.PushModel(ctx, &configmodelapi.PushModelRequest{Model: replacement})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		bytes, err := ioutil.ReadFile(entry.Path)
+		return err == nil && string(bytes) == "new"
+	}, 5*time.Second, 10*time.Millisecond)
+	history, err := server.registry.GetCompileHistory("test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Len(t, history, 0)
+}
+
+func TestReplaceModelTestConfigs(t *testing.T) {
+	server := newTestServer(t)
+	client := newTestClient(t, server)
+
+	model := &configmodelapi.ConfigModel{
+		Name:    "test",
+		Version: "1.0.0",
+		Files:   map[string]string{"test.yang": "old"},
+	}
+	assert.NoError(t, server.registry.AddModel(newModelInfo(model)))
+	entry := server.cache.Entry("test", "1.0.0")
+	assert.NoError(t, ioutil.WriteFile(entry.Path, []byte("old"), 0666))
+
+	// The staged replacement can't be loaded to validate the test configs, so the model is not replaced
+	replacement := &configmodelapi.ConfigModel{
+		Name:    "test",
+		Version: "1.0.0",
+		Files:   map[string]string{"test.yang": "new"},
+	}
+	path := entry.VersionPath(newModelInfo(replacement).ComputeChecksum())
+	assert.NoError(t, ioutil.WriteFile(path, []byte("new"), 0666))
+	AddTestConfigs(replacement, []byte(`{}`))
+	_, err := client.PushModel(WithForce(context.Background()), &configmodelapi.PushModelRequest{Model: replacement})
+	assert.Error(t, err)
+	modelInfo, err := server.registry.GetModel("test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "old", string(modelInfo.Files[0].Data))
+	bytes, err := ioutil.ReadFile(entry.Path)
+	assert.NoError(t, err)
+	assert.Equal(t, "old", string(bytes))
+
+	// Staged plugins are not removed when they fail validation
+	_, err = os.Stat(path)
+	assert.NoError(t, err)
+}
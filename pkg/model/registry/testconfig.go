@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"fmt"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	"sort"
+	"strings"
+)
+
+// TestConfigDir is the directory of a pushed model's files containing sample configs to validate against the model
+// Test configs are carried with the model's files rather than in the request metadata, which gRPC limits in size.
+// When present, the model is only added once its plugin is compiled and the push fails if any config is not valid.
+// Test configs are not part of the model's definition, so they're not stored with the model.
+const TestConfigDir = ".test-configs"
+
+// AddTestConfigs adds sample configs to validate against the given model when it's pushed
+func AddTestConfigs(model *configmodelapi.ConfigModel, configs ...[]byte) {
+	if model.Files == nil {
+		model.Files = make(map[string]string)
+	}
+	n := len(getTestConfigs(model))
+	for i, config := range configs {
+		model.Files[fmt.Sprintf("%s/%d", TestConfigDir, n+i)] = string(config)
+	}
+}
+
+// getTestConfigs returns the test configs in the given model's files in the order they were added
+func getTestConfigs(model *configmodelapi.ConfigModel) [][]byte {
+	var paths []string
+	for path := range model.Files {
+		if isTestConfig(path) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if len(paths[i]) != len(paths[j]) {
+			return len(paths[i]) < len(paths[j])
+		}
+		return paths[i] < paths[j]
+	})
+	configs := make([][]byte, len(paths))
+	for i, path := range paths {
+		configs[i] = []byte(model.Files[path])
+	}
+	return configs
+}
+
+// isTestConfig returns whether the given model file path is a test config
+func isTestConfig(path string) bool {
+	return strings.HasPrefix(path, TestConfigDir+"/")
+}
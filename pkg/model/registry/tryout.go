@@ -0,0 +1,239 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	modelplugin "github.com/onosproject/onos-config-model/pkg/model/plugin"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// TryoutResultKey is the PushModel response header containing the JSON encoded result of a model tryout
+const TryoutResultKey = "config-model-tryout-result"
+
+// InspectPluginCommand is the command of the server's executable with which the plugins of tried out models are inspected
+// The command is run with an --output file to which to write the result, the --plugin-symbol flags of the
+// server, and the path of the plugin.
+const InspectPluginCommand = "inspect-plugin"
+
+const defaultMaxTryouts = 1
+
+// TryoutResult is the result of compiling and loading the plugin for a model
+type TryoutResult struct {
+	// Name is the name of the model provided by the plugin
+	Name configmodel.Name `json:"name"`
+	// Version is the version of the model provided by the plugin
+	Version configmodel.Version `json:"version"`
+	// GetStateMode is the get state mode of the model provided by the plugin
+	GetStateMode configmodel.GetStateMode `json:"getStateMode"`
+	// Data is the model data provided by the plugin
+	Data []TryoutModelData `json:"data,omitempty"`
+	// Schema is the names of the entries in the plugin's schema
+	Schema []string `json:"schema,omitempty"`
+	// Checksum is the checksum of the model definition from which the plugin was compiled, if it's recorded
+	Checksum string `json:"checksum,omitempty"`
+	// Error is the error loading the plugin or reading its model, if any
+	Error string `json:"error,omitempty"`
+}
+
+// TryoutModelData is a module in the model data provided by a plugin
+type TryoutModelData struct {
+	Name         string `json:"name"`
+	Organization string `json:"organization"`
+	Version      string `json:"version"`
+}
+
+func newTryoutLimiter(size int) chan struct{} {
+	if size <= 0 {
+		size = defaultMaxTryouts
+	}
+	return make(chan struct{}, size)
+}
+
+// TryModelRemote compiles and loads the plugin for a model with the registry server without adding it to the registry
+func TryModelRemote(ctx context.Context, client configmodelapi.ConfigModelRegistryServiceClient, model *configmodelapi.ConfigModel) (*TryoutResult, error) {
+	var header metadata.MD
+	ctx = WithTryout(ctx)
+	_, err := client.PushModel(ctx, &configmodelapi.PushModelRequest{Model: model}, grpc.Header(&header))
+	values := header.Get(TryoutResultKey)
+	if len(values) == 0 {
+		return nil, err
+	}
+	var result TryoutResult
+	if err := json.Unmarshal([]byte(values[0]), &result); err != nil {
+		return nil, err
+	}
+	return &result, err
+}
+
+// TryModel compiles the plugin for the given model in a temporary directory, loads it, and inspects its model
+// The model is not added to the registry and the plugin is removed once it has been inspected. Go cannot unload
+// plugins, so the plugin is loaded by a short-lived process rather than the server. Because tryouts are expensive,
+// the number of concurrent tryouts is limited by the MaxTryouts service config.
+func (s *Server) TryModel(ctx context.Context, modelInfo configmodel.ModelInfo) (TryoutResult, error) {
+	select {
+	case s.tryouts <- struct{}{}:
+		defer func() {
+			<-s.tryouts
+		}()
+	case <-ctx.Done():
+		return TryoutResult{}, errors.NewTimeout("timed out waiting to try out model '%s'", modelInfo)
+	}
+
+	dir, err := ioutil.TempDir("", "config-model-tryout")
+	if err != nil {
+		return TryoutResult{}, errors.NewInternal(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	compiler := *s.compiler
+	compiler.Config.BuildPath = filepath.Join(dir, "build")
+	compiler.Config.SkipCleanUp = false
+	path := filepath.Join(dir, configmodel.GetFileName(modelInfo.Name, modelInfo.Version)+".so")
+	if err := compiler.WithContext(ctx).CompilePlugin(modelInfo, path); err != nil {
+		return TryoutResult{}, errors.NewInvalid("failed to compile model '%s': %s", modelInfo, err)
+	}
+	return s.inspect(ctx, path)
+}
+
+// execInspectPlugin inspects the plugin at the given path with the InspectPluginCommand of the server's executable
+// Plugins that crash the inspecting process are reported in the result like plugins that fail to load.
+func execInspectPlugin(ctx context.Context, path string) (TryoutResult, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return TryoutResult{}, errors.NewInternal("failed to find the executable with which to inspect plugin '%s': %s", path, err)
+	}
+	output := path + ".json"
+	args := []string{InspectPluginCommand, "--output", output}
+	for _, symbol := range modelplugin.GetPluginSymbols() {
+		args = append(args, "--plugin-symbol", symbol)
+	}
+	args = append(args, path)
+
+	cmd := exec.CommandContext(ctx, executable, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return TryoutResult{}, errors.NewCanceled("inspecting plugin '%s' was aborted: %s", path, ctx.Err())
+		}
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		return TryoutResult{Error: fmt.Sprintf("inspecting plugin failed: %s", message)}, nil
+	}
+
+	data, err := ioutil.ReadFile(output)
+	if err != nil {
+		return TryoutResult{}, errors.NewInternal("failed to read the inspection of plugin '%s': %s", path, err)
+	}
+	var result TryoutResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return TryoutResult{}, errors.NewInternal("failed to decode the inspection of plugin '%s': %s", path, err)
+	}
+	return result, nil
+}
+
+// InspectPluginToFile inspects the plugin at the given path, writing the JSON encoded result to the given output file
+// The plugin is loaded into the calling process, which is why it's used by the InspectPluginCommand rather than the server.
+func InspectPluginToFile(path string, output string) error {
+	data, err := json.Marshal(InspectPlugin(path))
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(output, data, 0644)
+}
+
+// InspectPlugin loads the plugin at the given path and summarizes the model it provides
+// Plugins cannot be unloaded, so the plugin stays loaded for the lifetime of the calling process.
+func InspectPlugin(path string) (result TryoutResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			result.Error = fmt.Sprintf("plugin panicked: %v", r)
+		}
+	}()
+
+	plugin, err := modelplugin.Load(path)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	model := plugin.Model()
+	info := model.Info()
+	result.Name = info.Name
+	result.Version = info.Version
+	result.Checksum = info.Checksum
+	result.GetStateMode = model.GetStateMode()
+	for _, data := range model.Data() {
+		result.Data = append(result.Data, TryoutModelData{
+			Name:         data.Name,
+			Organization: data.Organization,
+			Version:      data.Version,
+		})
+	}
+	schema, err := model.Schema()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	for name := range schema {
+		result.Schema = append(result.Schema, name)
+	}
+	sort.Strings(result.Schema)
+	return result
+}
+
+// tryModel tries out a pushed model, returning the result in the response headers
+func (s *Server) tryModel(ctx context.Context, request *configmodelapi.PushModelRequest) (*configmodelapi.PushModelResponse, error) {
+	if err := validateModules(request.Model); err != nil {
+		log.Warnf("PushModelRequest '%s@%s' failed: %s", request.Model.Name, request.Model.Version, err)
+		return nil, errors.Status(err).Err()
+	}
+	modelInfo := newModelInfo(request.Model)
+	if err := setFeatures(ctx, &modelInfo); err != nil {
+		log.Warnf("PushModelRequest '%s' failed: %s", modelInfo, err)
+		return nil, errors.Status(err).Err()
+	}
+	result, err := s.TryModel(ctx, modelInfo)
+	if err != nil {
+		log.Warnf("PushModelRequest '%s@%s' failed: %s", request.Model.Name, request.Model.Version, err)
+		return nil, errors.Status(err).Err()
+	}
+	sendTryoutResult(ctx, result)
+	if result.Error != "" {
+		err := errors.NewInvalid("failed to load model '%s@%s': %s", request.Model.Name, request.Model.Version, result.Error)
+		log.Warnf("PushModelRequest '%s@%s' failed: %s", request.Model.Name, request.Model.Version, err)
+		return nil, errors.Status(err).Err()
+	}
+	response := &configmodelapi.PushModelResponse{}
+	log.Debugf("Sending PushModelResponse %+v", response)
+	return response, nil
+}
+
+// sendTryoutResult sends the given tryout result in the response headers
+func sendTryoutResult(ctx context.Context, result TryoutResult) {
+	bytes, err := json.Marshal(result)
+	if err != nil {
+		log.Warnf("Failed to encode tryout result: %s", err)
+		return
+	}
+	if err := grpc.SetHeader(ctx, metadata.Pairs(TryoutResultKey, string(bytes))); err != nil {
+		log.Debugf("Failed to send tryout result: %s", err)
+	}
+}
@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	"fmt"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestMain runs the test binary as the InspectPluginCommand when the server inspects a tried out plugin
+func TestMain(m *testing.M) {
+	if len(os.Args) > 1 && os.Args[1] == InspectPluginCommand {
+		var output string
+		for i, arg := range os.Args {
+			if arg == "--output" && i+1 < len(os.Args) {
+				output = os.Args[i+1]
+			}
+		}
+		if err := InspectPluginToFile(os.Args[len(os.Args)-1], output); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// writeTryoutModFile writes a go.mod pinning the plugin dependencies to those of this module
+func writeTryoutModFile(t *testing.T, dir string, moduleRoot string) string {
+	modPath := filepath.Join(dir, "go.mod")
+	mod := fmt.Sprintf(`module example.com/pinned
+
+go 1.16
+
+require (
+	github.com/golang/protobuf v1.5.2
+	github.com/onosproject/onos-config-model v0.0.0
+	github.com/openconfig/gnmi v0.0.0-20210914185457-51254b657b7d
+	github.com/openconfig/goyang v0.3.1
+	github.com/openconfig/ygot v0.12.4
+)
+
+replace github.com/onosproject/onos-config-model => %s
+`, moduleRoot)
+	assert.NoError(t, ioutil.WriteFile(modPath, []byte(mod), 0666))
+	return modPath
+}
+
+func TestTryModel(t *testing.T) {
+	if testing.Short() || raceEnabled {
+		t.Skip("building plugins is slow and they cannot be loaded by binaries built with -race")
+	}
+
+	moduleRoot, err := filepath.Abs(filepath.Join("..", "..", ".."))
+	assert.NoError(t, err)
+	dir, err := ioutil.TempDir("", "config-model-tryout-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	server := newTestServer(t)
+	server.compiler.Config.TemplatePath = filepath.Join(moduleRoot, "pkg", "model", "plugin", "compiler", "templates")
+	server.compiler.Config.ModFile = writeTryoutModFile(t, dir, moduleRoot)
+	server.compiler.Config.SumFile = filepath.Join(moduleRoot, "go.sum")
+	client := newTestClient(t, server)
+
+	yang, err := ioutil.ReadFile(filepath.Join(moduleRoot, "test", "test@2020-11-18.yang"))
+	assert.NoError(t, err)
+	model := &configmodelapi.ConfigModel{
+		Name:         "test",
+		Version:      "1.0.0",
+		GetStateMode: configmodelapi.GetStateMode_NONE,
+		Modules: []*configmodelapi.ConfigModule{
+			{
+				Name:     "test",
+				Revision: "2020-11-18",
+				File:     "test@2020-11-18.yang",
+			},
+		},
+		Files: map[string]string{
+			"test@2020-11-18.yang": string(yang),
+		},
+	}
+
+	result, err := TryModelRemote(context.Background(), client, model)
+	assert.NoError(t, err)
+	assert.Equal(t, configmodel.Name("test"), result.Name)
+	assert.Equal(t, configmodel.Version("1.0.0"), result.Version)
+	assert.Equal(t, []TryoutModelData{{Name: "test", Organization: "Open Networking Foundation.", Version: "2020-11-18"}}, result.Data)
+	assert.NotEmpty(t, result.Schema)
+	assert.Equal(t, newModelInfo(model).ComputeChecksum(), result.Checksum)
+	assert.Empty(t, result.Error)
+
+	// Nothing is registered or cached
+	_, err = server.registry.GetModel("test", "1.0.0")
+	assert.True(t, errors.IsNotFound(err))
+	plugins, err := server.cache.List()
+	assert.NoError(t, err)
+	assert.Empty(t, plugins)
+
+	// The same model can be tried out again, since each plugin is loaded by its own process
+	result, err = TryModelRemote(context.Background(), client, model)
+	assert.NoError(t, err)
+	assert.Empty(t, result.Error)
+
+	// Models that cannot be compiled are reported as invalid
+	model.Files["test@2020-11-18.yang"] = "module test {"
+	result, err = TryModelRemote(context.Background(), client, model)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Nil(t, result)
+}
+
+func TestExecInspectPlugin(t *testing.T) {
+	// Plugins that cannot be loaded are reported in the result by the inspecting process
+	path := filepath.Join(t.TempDir(), "test-1.0.0.so")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("plugin"), 0666))
+	result, err := execInspectPlugin(context.Background(), path)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, result.Error)
+	assert.Empty(t, result.Name)
+
+	// Canceled inspections are reported as such
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = execInspectPlugin(ctx, path)
+	assert.True(t, errors.IsCanceled(err))
+}
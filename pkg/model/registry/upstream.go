@@ -0,0 +1,179 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/certs"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"sync"
+	"time"
+)
+
+// upstreamTimeout is the maximum duration of a request to the upstream registry
+const upstreamTimeout = time.Minute
+
+// Connect connects to the registry at the given address
+func Connect(address string) (*grpc.ClientConn, error) {
+	cert, err := tls.X509KeyPair([]byte(certs.DefaultClientCrt), []byte(certs.DefaultClientKey))
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		Certificates:       []tls.Certificate{cert},
+		InsecureSkipVerify: true,
+	}
+
+	// Connect to the first matching service
+	return grpc.Dial(address, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+}
+
+// newUpstream returns the upstream registry at the given address, or nil if the address is empty
+func newUpstream(address string) *upstream {
+	if address == "" {
+		return nil
+	}
+	return &upstream{
+		address: address,
+		dial:    Connect,
+		fetches: make(map[string]*upstreamFetch),
+	}
+}
+
+// upstream is a registry from which models that are not found locally are fetched
+type upstream struct {
+	address string
+	dial    func(address string) (*grpc.ClientConn, error)
+	conn    *grpc.ClientConn
+	fetches map[string]*upstreamFetch
+	mu      sync.Mutex
+}
+
+// upstreamFetch is an in-flight fetch of a model from the upstream registry
+type upstreamFetch struct {
+	done      chan struct{}
+	modelInfo configmodel.ModelInfo
+	err       error
+}
+
+// getClient returns a client for the upstream registry, connecting on first use
+func (u *upstream) getClient() (configmodelapi.ConfigModelRegistryServiceClient, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.conn == nil {
+		conn, err := u.dial(u.address)
+		if err != nil {
+			return nil, errors.NewUnavailable("failed to connect to upstream registry '%s': %s", u.address, err)
+		}
+		u.conn = conn
+	}
+	return configmodelapi.NewConfigModelRegistryServiceClient(u.conn), nil
+}
+
+// getModel gets the given model with its YANG files from the upstream registry, with the metadata with which
+// it's pushed: its labels, features and deviation modules
+func (u *upstream) getModel(name configmodel.Name, version configmodel.Version) (*configmodelapi.ConfigModel, metadata.MD, error) {
+	client, err := u.getClient()
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), upstreamTimeout)
+	defer cancel()
+	var header metadata.MD
+	request := &configmodelapi.GetModelRequest{
+		Name:    string(name),
+		Version: string(version),
+	}
+	response, err := client.GetModel(WithIncludeFiles(ctx), request, grpc.Header(&header))
+	if err != nil {
+		return nil, nil, errors.FromGRPC(err)
+	}
+	md := metadata.MD{}
+	if values := header.Get(ModelLabelsKey); len(values) > 0 {
+		var labels map[string]string
+		if err := json.Unmarshal([]byte(values[0]), &labels); err != nil {
+			return nil, nil, errors.NewInternal("failed to decode labels of upstream model '%s@%s': %s", name, version, err)
+		}
+		md.Append(LabelsKey, formatLabels(labels)...)
+	}
+	md.Append(FeaturesKey, header.Get(FeaturesKey)...)
+	md.Append(DeviationsKey, header.Get(DeviationsKey)...)
+	return response.Model, md, nil
+}
+
+// coalesce calls f for the given model unless a call for the same model is in flight, in which case
+// the result of the in-flight call is returned
+func (u *upstream) coalesce(key string, f func() (configmodel.ModelInfo, error)) (configmodel.ModelInfo, error) {
+	u.mu.Lock()
+	fetch, ok := u.fetches[key]
+	if !ok {
+		fetch = &upstreamFetch{
+			done: make(chan struct{}),
+		}
+		u.fetches[key] = fetch
+	}
+	u.mu.Unlock()
+
+	if ok {
+		<-fetch.done
+		return fetch.modelInfo, fetch.err
+	}
+
+	fetch.modelInfo, fetch.err = f()
+	u.mu.Lock()
+	delete(u.fetches, key)
+	u.mu.Unlock()
+	close(fetch.done)
+	return fetch.modelInfo, fetch.err
+}
+
+// getModel gets the given model from the registry, falling back to the upstream registry if one is configured
+// Models fetched from the upstream registry are added to the registry as if they were pushed, so their
+// plugins are compiled into the cache. Concurrent requests for the same missing model are coalesced into
+// a single upstream request.
+func (s *Server) getModel(name configmodel.Name, version configmodel.Version) (configmodel.ModelInfo, error) {
+	s.mu.RLock()
+	modelInfo, err := s.registry.GetModel(name, version)
+	s.mu.RUnlock()
+	if err == nil || !errors.IsNotFound(err) || s.upstream == nil || s.registry.IsReadOnly() {
+		return modelInfo, err
+	}
+	return s.upstream.coalesce(configmodel.ModelInfo{Name: name, Version: version}.String(), func() (configmodel.ModelInfo, error) {
+		return s.fetchModel(name, version)
+	})
+}
+
+// fetchModel fetches the given model from the upstream registry and adds it to the registry
+func (s *Server) fetchModel(name configmodel.Name, version configmodel.Version) (configmodel.ModelInfo, error) {
+	log.Infof("Fetching model '%s@%s' from upstream registry '%s'", name, version, s.upstream.address)
+	model, md, err := s.upstream.getModel(name, version)
+	if err != nil {
+		log.Warnf("Failed to fetch model '%s@%s' from upstream registry '%s': %s", name, version, s.upstream.address, err)
+		return configmodel.ModelInfo{}, err
+	}
+
+	// The model is added as a push with the upstream model's labels, features and deviations, whose plugin
+	// is compiled asynchronously
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+	if _, err := s.addModel(ctx, &configmodelapi.PushModelRequest{Model: model}, nil); err != nil {
+		err = errors.FromGRPC(err)
+		if !errors.IsAlreadyExists(err) {
+			log.Warnf("Failed to add model '%s@%s' fetched from upstream registry '%s': %s", name, version, s.upstream.address, err)
+			return configmodel.ModelInfo{}, err
+		}
+	}
+
+	// The requested version may be a constraint resolved by the upstream registry
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.registry.GetModel(name, configmodel.Version(model.Version))
+}
@@ -0,0 +1,109 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"io/ioutil"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestUpstream(t *testing.T) {
+	upstreamServer := newTestServer(t)
+	assert.NoError(t, upstreamServer.registry.AddModel(configmodel.ModelInfo{
+		Name:    "test",
+		Version: "1.0.0",
+		Files: []configmodel.FileInfo{
+			{Path: "test.yang", Data: []byte("module test { namespace \"urn:test\"; prefix t; }")},
+			{Path: "test-deviations.yang", Data: []byte("module test-deviations { namespace \"urn:test-deviations\"; prefix td; }")},
+		},
+		Modules: []configmodel.ModuleInfo{
+			{Name: "test", File: "test.yang"},
+		},
+		Features:   []string{"feature-a"},
+		Deviations: []configmodel.ModuleInfo{{Name: "test-deviations", File: "test-deviations.yang"}},
+		Labels:     map[string]string{"vendor": "test"},
+	}))
+
+	// Count the requests to the upstream registry, delaying them so concurrent misses overlap
+	var requests int32
+	lis := bufconn.Listen(1024 * 1024)
+	s := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		atomic.AddInt32(&requests, 1)
+		time.Sleep(50 * time.Millisecond)
+		return handler(ctx, req)
+	}))
+	configmodelapi.RegisterConfigModelRegistryServiceServer(s, upstreamServer)
+	go func() {
+		_ = s.Serve(lis)
+	}()
+	t.Cleanup(s.Stop)
+
+	server := newTestServer(t)
+	server.upstream = newUpstream("upstream:5151")
+	server.upstream.dial = func(address string) (*grpc.ClientConn, error) {
+		return grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) {
+			return lis.Dial()
+		}))
+	}
+	client := newTestClient(t, server)
+
+	// The plugin is already cached, so the fetched model isn't compiled
+	assert.NoError(t, ioutil.WriteFile(server.cache.Entry("test", "1.0.0").Path, []byte("plugin"), 0666))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response, err := client.GetModel(context.Background(), &configmodelapi.GetModelRequest{
+				Name:    "test",
+				Version: "1.0.0",
+			})
+			if assert.NoError(t, err) {
+				assert.Equal(t, "test", response.Model.Name)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	// The fetched model is persisted with its files, labels, features and deviations
+	modelInfo, err := server.registry.GetModel("test", "1.0.0")
+	assert.NoError(t, err)
+	assert.Len(t, modelInfo.Files, 2)
+	assert.Equal(t, map[string]string{"vendor": "test"}, modelInfo.Labels)
+	assert.Len(t, modelInfo.Modules, 1)
+	assert.Equal(t, "urn:test", modelInfo.Modules[0].Namespace)
+	assert.Equal(t, []string{"feature-a"}, modelInfo.Features)
+	assert.Len(t, modelInfo.Deviations, 1)
+	assert.Equal(t, configmodel.Name("test-deviations"), modelInfo.Deviations[0].Name)
+
+	_, err = client.GetModel(context.Background(), &configmodelapi.GetModelRequest{
+		Name:    "test",
+		Version: "1.0.0",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	// Models not found upstream are not found
+	_, err = client.GetModel(context.Background(), &configmodelapi.GetModelRequest{
+		Name:    "missing",
+		Version: "1.0.0",
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
@@ -0,0 +1,310 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/openconfig/goyang/pkg/yang"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The registry API has no method for validating models, so ValidateModel is provided by a separate service.
+// The service reuses the API's PushModelRequest, and returns the JSON encoded ModelValidationResult as a
+// string value.
+const (
+	validationServiceName = "onos.configmodel.ConfigModelRegistryValidationService"
+	validateModelMethod   = "ValidateModel"
+)
+
+// ModelValidationResult is the result of validating a model's YANG files
+type ModelValidationResult struct {
+	// Valid indicates no errors were found, though warnings may have been
+	Valid bool `json:"valid"`
+	// Diagnostics are the errors and warnings found in the model's YANG files
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
+}
+
+// Diagnostic is a problem found in a model's YANG files
+type Diagnostic struct {
+	Module  string `json:"module,omitempty"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+	// Warning indicates the problem does not prevent the model from being compiled
+	Warning bool `json:"warning,omitempty"`
+}
+
+func (d Diagnostic) String() string {
+	message := d.Message
+	if d.Warning {
+		message = fmt.Sprintf("warning: %s", message)
+	}
+	if d.File == "" {
+		return message
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", d.File, d.Line, d.Column, message)
+}
+
+// ValidateModel checks that the YANG files for the given model parse and that all imports resolve
+// Suspicious but valid definitions, e.g. misordered revisions, are reported as warnings.
+// The model is not compiled.
+func ValidateModel(model configmodel.ModelInfo) []Diagnostic {
+	modules, diagnostics := parseModules(model)
+	if len(diagnostics) > 0 {
+		return diagnostics
+	}
+	return checkRevisions(model, modules)
+}
+
+// getErrors returns the diagnostics that are not warnings
+func getErrors(diagnostics []Diagnostic) []Diagnostic {
+	var problems []Diagnostic
+	for _, diagnostic := range diagnostics {
+		if !diagnostic.Warning {
+			problems = append(problems, diagnostic)
+		}
+	}
+	return problems
+}
+
+// validationServer is the server API for the validation service
+type validationServer interface {
+	ValidateModel(ctx context.Context, request *configmodelapi.PushModelRequest) (*wrapperspb.StringValue, error)
+}
+
+var validationServiceDesc = grpc.ServiceDesc{
+	ServiceName: validationServiceName,
+	HandlerType: (*validationServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: validateModelMethod,
+			Handler:    validateModelHandler,
+		},
+	},
+}
+
+func validateModelHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	request := &configmodelapi.PushModelRequest{}
+	if err := dec(request); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(validationServer).ValidateModel(ctx, request)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/" + validationServiceName + "/" + validateModelMethod,
+	}
+	handler := func(ctx context.Context, request interface{}) (interface{}, error) {
+		return srv.(validationServer).ValidateModel(ctx, request.(*configmodelapi.PushModelRequest))
+	}
+	return interceptor(ctx, request, info, handler)
+}
+
+// registerValidationService registers the validation service for the given server
+func registerValidationService(r *grpc.Server, server *Server) {
+	r.RegisterService(&validationServiceDesc, server)
+}
+
+// ValidateModelRemote validates a model with the registry server without adding it to the registry
+// Problems found in the model's YANG files are reported in the result rather than as an error, so errors
+// indicate the model could not be checked, e.g. because its files could not be decoded.
+func ValidateModelRemote(ctx context.Context, conn grpc.ClientConnInterface, model *configmodelapi.ConfigModel) (*ModelValidationResult, error) {
+	response := &wrapperspb.StringValue{}
+	if err := conn.Invoke(ctx, "/"+validationServiceName+"/"+validateModelMethod, &configmodelapi.PushModelRequest{Model: model}, response); err != nil {
+		return nil, err
+	}
+	var result ModelValidationResult
+	if err := json.Unmarshal([]byte(response.Value), &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ValidateModel :
+func (s *Server) ValidateModel(ctx context.Context, request *configmodelapi.PushModelRequest) (*wrapperspb.StringValue, error) {
+	log.Debugf("Received ValidateModelRequest '%s@%s'", request.Model.GetName(), request.Model.GetVersion())
+	s.sendCapabilities(ctx)
+	if request.Model == nil {
+		err := errors.NewInvalid("no model provided")
+		log.Warnf("ValidateModelRequest failed: %s", err)
+		return nil, errors.Status(err).Err()
+	}
+	if err := s.decodeModelFiles(ctx, request.Model); err != nil {
+		log.Warnf("ValidateModelRequest '%s@%s' failed: %s", request.Model.Name, request.Model.Version, err)
+		return nil, errors.Status(err).Err()
+	}
+	if err := s.checkLimits(request.Model); err != nil {
+		log.Warnf("ValidateModelRequest '%s@%s' failed: %s", request.Model.Name, request.Model.Version, err)
+		return nil, errors.Status(err).Err()
+	}
+
+	diagnostics := ValidateModel(newModelInfo(request.Model))
+	bytes, err := json.Marshal(ModelValidationResult{
+		Valid:       len(getErrors(diagnostics)) == 0,
+		Diagnostics: diagnostics,
+	})
+	if err != nil {
+		log.Warnf("ValidateModelRequest '%s@%s' failed: %s", request.Model.Name, request.Model.Version, err)
+		return nil, errors.Status(errors.NewInternal(err.Error())).Err()
+	}
+	response := wrapperspb.String(string(bytes))
+	log.Debugf("Sending ValidateModelResponse %+v", response)
+	return response, nil
+}
+
+// parseModules parses and processes the YANG files for the given model
+func parseModules(model configmodel.ModelInfo) (*yang.Modules, []Diagnostic) {
+	var diagnostics []Diagnostic
+	modules := yang.NewModules()
+	for _, file := range model.Files {
+		file, err := file.Decompress()
+		if err != nil {
+			diagnostics = append(diagnostics, Diagnostic{File: file.Path, Message: err.Error()})
+			continue
+		}
+		if err := modules.Parse(string(file.Data), file.Path); err != nil {
+			diagnostics = append(diagnostics, newDiagnostics(model, err)...)
+		}
+	}
+	if len(diagnostics) > 0 {
+		return nil, diagnostics
+	}
+
+	for _, err := range modules.Process() {
+		diagnostics = append(diagnostics, newDiagnostics(model, err)...)
+	}
+	if len(diagnostics) > 0 {
+		return nil, diagnostics
+	}
+
+	for _, module := range model.Modules {
+		if module.IsSubmodule() {
+			continue
+		}
+		if _, errs := modules.GetModule(string(module.Name)); len(errs) > 0 {
+			for _, err := range errs {
+				for _, diagnostic := range newDiagnostics(model, err) {
+					diagnostic.Module = string(module.Name)
+					diagnostics = append(diagnostics, diagnostic)
+				}
+			}
+		}
+	}
+	if len(diagnostics) > 0 {
+		return nil, diagnostics
+	}
+	return modules, nil
+}
+
+// checkRevisions warns of modules whose revision statements are not in descending date order,
+// or whose newest revision is not the revision declared for the module
+func checkRevisions(model configmodel.ModelInfo, modules *yang.Modules) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, info := range model.Modules {
+		module := findFileModule(modules, info.File)
+		if module == nil {
+			continue
+		}
+		for i := 1; i < len(module.Revision); i++ {
+			previous, revision := module.Revision[i-1], module.Revision[i]
+			if revision.Name >= previous.Name {
+				message := fmt.Sprintf("revision %s follows revision %s; revisions should be in descending date order", revision.Name, previous.Name)
+				diagnostics = append(diagnostics, newWarnings(model, revision.Source, message)...)
+			}
+		}
+		if newest := module.Current(); info.Revision != "" && string(info.Revision) != newest {
+			message := fmt.Sprintf("module '%s' is declared with revision %s but its newest revision is %s", info.Name, info.Revision, newest)
+			diagnostics = append(diagnostics, newWarnings(model, module.Source, message)...)
+		}
+	}
+	return diagnostics
+}
+
+// findFileModule finds the module or submodule defined in the given file
+// Modules are looked up by file rather than by name as a model may include multiple revisions of a module.
+func findFileModule(modules *yang.Modules, file string) *yang.Module {
+	for _, set := range []map[string]*yang.Module{modules.Modules, modules.SubModules} {
+		for _, module := range set {
+			if module.Source == nil {
+				continue
+			}
+			location := module.Source.Location()
+			if i := strings.Index(location, ":"); i >= 0 {
+				location = location[:i]
+			}
+			if filepath.Base(location) == filepath.Base(file) {
+				return module
+			}
+		}
+	}
+	return nil
+}
+
+// newWarnings creates warning diagnostics located at the given statement
+func newWarnings(model configmodel.ModelInfo, source *yang.Statement, message string) []Diagnostic {
+	if source == nil {
+		return []Diagnostic{{Message: message, Warning: true}}
+	}
+	var diagnostics []Diagnostic
+	for _, diagnostic := range newDiagnostics(model, fmt.Errorf("%s: %s", source.Location(), message)) {
+		diagnostic.Warning = true
+		diagnostics = append(diagnostics, diagnostic)
+	}
+	return diagnostics
+}
+
+// diagnosticPattern matches goyang errors of the form 'file:line:column: message'
+var diagnosticPattern = regexp.MustCompile(`^(.+?):(\d+):(\d+): (.*)$`)
+
+// newDiagnostics creates diagnostics from a goyang error, which may report multiple problems on separate lines
+func newDiagnostics(model configmodel.ModelInfo, err error) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, line := range strings.Split(err.Error(), "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		matches := diagnosticPattern.FindStringSubmatch(line)
+		if matches == nil {
+			diagnostics = append(diagnostics, Diagnostic{Message: line})
+			continue
+		}
+		lineNum, _ := strconv.Atoi(matches[2])
+		column, _ := strconv.Atoi(matches[3])
+		diagnostics = append(diagnostics, Diagnostic{
+			Module:  getFileModule(model, matches[1]),
+			File:    matches[1],
+			Line:    lineNum,
+			Column:  column,
+			Message: matches[4],
+		})
+	}
+	return diagnostics
+}
+
+// getFileModule returns the name of the module defined in the given file
+func getFileModule(model configmodel.ModelInfo, file string) string {
+	for _, module := range model.Modules {
+		if filepath.Base(module.File) == filepath.Base(file) {
+			return string(module.Name)
+		}
+	}
+	name := strings.TrimSuffix(filepath.Base(file), ".yang")
+	if i := strings.Index(name, "@"); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
@@ -0,0 +1,164 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const invalidYang = `module invalid {
+  namespace "http://opennetworking.org/test/invalid";
+  prefix inv;
+
+  foo bar;
+}
+`
+
+const importYang = `module importer {
+  namespace "http://opennetworking.org/test/importer";
+  prefix imp;
+
+  import missing { prefix m; }
+}
+`
+
+func TestValidateModel(t *testing.T) {
+	diagnostics := ValidateModel(configmodel.ModelInfo{
+		Name:    "state",
+		Version: "1.0.0",
+		Modules: []configmodel.ModuleInfo{{Name: "state", File: "state.yang"}},
+		Files:   []configmodel.FileInfo{{Path: "state.yang", Data: []byte(stateYang)}},
+	})
+	assert.Len(t, diagnostics, 0)
+
+	diagnostics = ValidateModel(configmodel.ModelInfo{
+		Name:    "invalid",
+		Version: "1.0.0",
+		Modules: []configmodel.ModuleInfo{{Name: "invalid", File: "invalid@2021-01-01.yang"}},
+		Files:   []configmodel.FileInfo{{Path: "invalid@2021-01-01.yang", Data: []byte(invalidYang)}},
+	})
+	assert.Len(t, diagnostics, 1)
+	assert.Equal(t, "invalid", diagnostics[0].Module)
+	assert.Equal(t, "invalid@2021-01-01.yang", diagnostics[0].File)
+	assert.Equal(t, 5, diagnostics[0].Line)
+	assert.Equal(t, 3, diagnostics[0].Column)
+	assert.Contains(t, diagnostics[0].Message, "foo")
+
+	diagnostics = ValidateModel(configmodel.ModelInfo{
+		Name:    "importer",
+		Version: "1.0.0",
+		Modules: []configmodel.ModuleInfo{{Name: "importer", File: "importer.yang"}},
+		Files:   []configmodel.FileInfo{{Path: "importer.yang", Data: []byte(importYang)}},
+	})
+	assert.Len(t, diagnostics, 1)
+	assert.Contains(t, diagnostics[0].Message, "missing")
+
+	// Modules must be defined by the model's files
+	diagnostics = ValidateModel(configmodel.ModelInfo{
+		Name:    "state",
+		Version: "1.0.0",
+		Modules: []configmodel.ModuleInfo{{Name: "other", File: "other.yang"}},
+		Files:   []configmodel.FileInfo{{Path: "state.yang", Data: []byte(stateYang)}},
+	})
+	assert.Len(t, diagnostics, 1)
+	assert.Equal(t, "other", diagnostics[0].Module)
+}
+
+func TestValidateModelRemote(t *testing.T) {
+	server := newTestServer(t)
+	conn := newTestConn(t, server)
+
+	result, err := ValidateModelRemote(context.Background(), conn, &configmodelapi.ConfigModel{
+		Name:    "state",
+		Version: "1.0.0",
+		Modules: []*configmodelapi.ConfigModule{{Name: "state", File: "state.yang"}},
+		Files:   map[string]string{"state.yang": stateYang},
+	})
+	assert.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Len(t, result.Diagnostics, 0)
+
+	// Problems are reported in the result rather than as errors
+	result, err = ValidateModelRemote(context.Background(), conn, &configmodelapi.ConfigModel{
+		Name:    "invalid",
+		Version: "1.0.0",
+		Modules: []*configmodelapi.ConfigModule{{Name: "invalid", File: "invalid.yang"}},
+		Files:   map[string]string{"invalid.yang": invalidYang},
+	})
+	assert.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Len(t, result.Diagnostics, 1)
+	assert.Equal(t, 5, result.Diagnostics[0].Line)
+
+	// Nothing is written to the registry or the cache
+	models, err := server.registry.ListModels()
+	assert.NoError(t, err)
+	assert.Len(t, models, 0)
+	paths, err := server.cache.List()
+	assert.NoError(t, err)
+	assert.Len(t, paths, 0)
+}
+
+const revisionsYang = `module revisions {
+  namespace "http://opennetworking.org/test/revisions";
+  prefix rev;
+
+  revision 2020-01-01;
+  revision 2021-06-01;
+  revision 2021-01-01;
+}
+`
+
+func TestValidateRevisions(t *testing.T) {
+	model := configmodel.ModelInfo{
+		Name:    "revisions",
+		Version: "1.0.0",
+		Modules: []configmodel.ModuleInfo{{Name: "revisions", File: "revisions.yang", Revision: "2021-01-01"}},
+		Files:   []configmodel.FileInfo{{Path: "revisions.yang", Data: []byte(revisionsYang)}},
+	}
+	diagnostics := ValidateModel(model)
+	assert.Len(t, diagnostics, 2)
+	assert.Len(t, getErrors(diagnostics), 0)
+
+	assert.True(t, diagnostics[0].Warning)
+	assert.Equal(t, "revisions", diagnostics[0].Module)
+	assert.Equal(t, "revisions.yang", diagnostics[0].File)
+	assert.Equal(t, 6, diagnostics[0].Line)
+	assert.Contains(t, diagnostics[0].Message, "revision 2021-06-01 follows revision 2020-01-01")
+	assert.Contains(t, diagnostics[0].String(), "warning: ")
+
+	assert.True(t, diagnostics[1].Warning)
+	assert.Equal(t, 1, diagnostics[1].Line)
+	assert.Contains(t, diagnostics[1].Message, "newest revision is 2021-06-01")
+
+	// Revisions in descending order matching the declared revision are not reported
+	model.Modules[0].Revision = "2021-06-01"
+	model.Files[0].Data = []byte(`module revisions {
+  namespace "http://opennetworking.org/test/revisions";
+  prefix rev;
+
+  revision 2021-06-01;
+  revision 2021-01-01;
+}
+`)
+	assert.Len(t, ValidateModel(model), 0)
+
+	// Warnings do not fail validation
+	server := newTestServer(t)
+	conn := newTestConn(t, server)
+	result, err := ValidateModelRemote(context.Background(), conn, &configmodelapi.ConfigModel{
+		Name:    "revisions",
+		Version: "1.0.0",
+		Modules: []*configmodelapi.ConfigModule{{Name: "revisions", File: "revisions.yang", Revision: "2021-01-01"}},
+		Files:   map[string]string{"revisions.yang": revisionsYang},
+	})
+	assert.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Len(t, result.Diagnostics, 2)
+}
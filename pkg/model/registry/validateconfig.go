@@ -0,0 +1,157 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	"encoding/json"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/openconfig/ygot/util"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// The registry API has no method for validating configurations, so ValidateConfig is provided by a separate
+// service. The service accepts a JSON encoded ConfigValidationRequest and returns the JSON encoded
+// ConfigValidationResult as string values.
+const (
+	configServiceName    = "onos.configmodel.ConfigModelRegistryConfigService"
+	validateConfigMethod = "ValidateConfig"
+)
+
+// ConfigValidationRequest is a request to validate a configuration against a model in the registry
+type ConfigValidationRequest struct {
+	Name    configmodel.Name    `json:"name"`
+	Version configmodel.Version `json:"version"`
+	// Config is the JSON encoded configuration, as accepted by the model's unmarshaler
+	Config []byte `json:"config"`
+}
+
+// ConfigValidationResult is the result of validating a configuration against a model
+type ConfigValidationResult struct {
+	// Valid indicates the configuration was unmarshaled and validated by the model
+	Valid bool `json:"valid"`
+	// Errors are the problems found unmarshaling or validating the configuration
+	Errors []string `json:"errors,omitempty"`
+}
+
+// configServer is the server API for the config service
+type configServer interface {
+	ValidateConfig(ctx context.Context, request *wrapperspb.StringValue) (*wrapperspb.StringValue, error)
+}
+
+var configServiceDesc = grpc.ServiceDesc{
+	ServiceName: configServiceName,
+	HandlerType: (*configServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: validateConfigMethod,
+			Handler:    validateConfigHandler,
+		},
+	},
+}
+
+func validateConfigHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	request := &wrapperspb.StringValue{}
+	if err := dec(request); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(configServer).ValidateConfig(ctx, request)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/" + configServiceName + "/" + validateConfigMethod,
+	}
+	handler := func(ctx context.Context, request interface{}) (interface{}, error) {
+		return srv.(configServer).ValidateConfig(ctx, request.(*wrapperspb.StringValue))
+	}
+	return interceptor(ctx, request, info, handler)
+}
+
+// registerConfigService registers the config service for the given server
+func registerConfigService(r *grpc.Server, server *Server) {
+	r.RegisterService(&configServiceDesc, server)
+}
+
+// ValidateConfig validates a JSON encoded configuration against the given model with the registry server
+// A configuration that cannot be unmarshaled or is not valid for the model is reported in the result rather
+// than as an error, so errors indicate the configuration could not be checked, e.g. because the model is
+// not in the registry or its plugin cannot be loaded.
+func ValidateConfig(ctx context.Context, conn grpc.ClientConnInterface, name configmodel.Name, version configmodel.Version, config []byte) (*ConfigValidationResult, error) {
+	bytes, err := json.Marshal(ConfigValidationRequest{
+		Name:    name,
+		Version: version,
+		Config:  config,
+	})
+	if err != nil {
+		return nil, err
+	}
+	response := &wrapperspb.StringValue{}
+	if err := conn.Invoke(ctx, "/"+configServiceName+"/"+validateConfigMethod, wrapperspb.String(string(bytes)), response); err != nil {
+		return nil, err
+	}
+	var result ConfigValidationResult
+	if err := json.Unmarshal([]byte(response.Value), &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ValidateConfig :
+func (s *Server) ValidateConfig(ctx context.Context, request *wrapperspb.StringValue) (*wrapperspb.StringValue, error) {
+	log.Debugf("Received ValidateConfigRequest")
+	s.sendCapabilities(ctx)
+
+	var validation ConfigValidationRequest
+	if err := json.Unmarshal([]byte(request.Value), &validation); err != nil {
+		err = errors.NewInvalid("malformed validation request: %s", err)
+		log.Warnf("ValidateConfigRequest failed: %v", err)
+		return nil, errors.Status(err).Err()
+	}
+
+	if _, err := s.registry.GetModel(validation.Name, validation.Version); err != nil {
+		log.Warnf("ValidateConfigRequest '%s@%s' failed: %v", validation.Name, validation.Version, err)
+		return nil, errors.Status(err).Err()
+	}
+	plugin, err := s.LoadPlugin(ctx, validation.Name, validation.Version)
+	if err != nil {
+		err = errors.NewUnavailable("failed to load plugin for model '%s@%s': %s", validation.Name, validation.Version, err)
+		log.Warnf("ValidateConfigRequest '%s@%s' failed: %v", validation.Name, validation.Version, err)
+		return nil, errors.Status(err).Err()
+	}
+
+	bytes, err := json.Marshal(validateConfig(plugin.Model(), validation.Config))
+	if err != nil {
+		log.Warnf("ValidateConfigRequest '%s@%s' failed: %v", validation.Name, validation.Version, err)
+		return nil, errors.Status(errors.NewInternal(err.Error())).Err()
+	}
+	response := wrapperspb.String(string(bytes))
+	log.Debugf("Sending ValidateConfigResponse %+v", response)
+	return response, nil
+}
+
+// validateConfig unmarshals and validates the given config with the model
+// ygot reports each validation failure separately, so each is returned as a separate error.
+func validateConfig(model configmodel.ConfigModel, config []byte) ConfigValidationResult {
+	value, err := model.Unmarshaler()(config)
+	if err != nil {
+		return ConfigValidationResult{Errors: []string{err.Error()}}
+	}
+	err = model.Validator()(value)
+	if err == nil {
+		return ConfigValidationResult{Valid: true}
+	}
+	var problems []string
+	if errs, ok := err.(util.Errors); ok {
+		for _, err := range errs {
+			problems = append(problems, err.Error())
+		}
+	} else {
+		problems = append(problems, err.Error())
+	}
+	return ConfigValidationResult{Errors: problems}
+}
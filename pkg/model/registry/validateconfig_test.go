@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	goerrors "errors"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	modelplugin "github.com/onosproject/onos-config-model/pkg/model/plugin"
+	plugincache "github.com/onosproject/onos-config-model/pkg/model/plugin/cache"
+	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/ygot"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io/ioutil"
+	"testing"
+)
+
+type testModelPlugin struct {
+	model configmodel.ConfigModel
+}
+
+func (p testModelPlugin) Model() configmodel.ConfigModel {
+	return p.model
+}
+
+type invalidTestModel struct {
+	testModel
+}
+
+func (m invalidTestModel) Validator() configmodel.Validator {
+	return func(model *ygot.ValidatedGoStruct, opts ...ygot.ValidationOption) error {
+		return util.Errors{goerrors.New("foo is required"), goerrors.New("bar is out of range")}
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	server := newTestServer(t)
+	conn := newTestConn(t, server)
+
+	// Models must be in the registry
+	_, err := ValidateConfig(context.Background(), conn, "test", "1.0.0", []byte(`{"foo": "bar"}`))
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	assert.NoError(t, server.registry.AddModel(configmodel.ModelInfo{Name: "test", Version: "1.0.0"}))
+	assert.NoError(t, ioutil.WriteFile(server.cache.Entry("test", "1.0.0").Path, []byte("plugin"), 0666))
+	var model configmodel.ConfigModel = testModel{}
+	server.load = func(entry *plugincache.PluginEntry) (modelplugin.ConfigModelPlugin, error) {
+		return testModelPlugin{model: model}, nil
+	}
+
+	result, err := ValidateConfig(context.Background(), conn, "test", "1.0.0", []byte(`{"foo": "bar"}`))
+	assert.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Empty(t, result.Errors)
+
+	// Configs that cannot be unmarshaled are not valid
+	result, err = ValidateConfig(context.Background(), conn, "test", "1.0.0", []byte(`{"foo": `))
+	assert.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Len(t, result.Errors, 1)
+
+	// Each validation failure is reported
+	model = invalidTestModel{}
+	result, err = ValidateConfig(context.Background(), conn, "test", "1.0.0", []byte(`{"foo": "bar"}`))
+	assert.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, []string{"foo is required", "bar is out of range"}, result.Errors)
+
+	server.load = func(entry *plugincache.PluginEntry) (modelplugin.ConfigModelPlugin, error) {
+		return nil, goerrors.New("plugin not found")
+	}
+	_, err = ValidateConfig(context.Background(), conn, "test", "1.0.0", []byte(`{"foo": "bar"}`))
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
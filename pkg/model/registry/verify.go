@@ -0,0 +1,413 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	"encoding/json"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	plugincache "github.com/onosproject/onos-config-model/pkg/model/plugin/cache"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"os"
+	"sort"
+)
+
+// The registry API has no maintenance methods, so VerifyRegistry is provided by a separate service.
+// The service accepts a JSON encoded VerificationRequest and returns the JSON encoded VerificationReport
+// as string values.
+const (
+	maintenanceServiceName = "onos.configmodel.ConfigModelRegistryMaintenanceService"
+	verifyRegistryMethod   = "VerifyRegistry"
+)
+
+// PluginStatus is the status of a model's cached plugin
+type PluginStatus string
+
+const (
+	// PluginValid indicates the plugin is cached and can be loaded
+	PluginValid PluginStatus = "valid"
+	// PluginMissing indicates the plugin is not in the cache
+	PluginMissing PluginStatus = "missing"
+	// PluginStale indicates the plugin was compiled from another definition of the model
+	PluginStale PluginStatus = "stale"
+	// PluginUnloadable indicates the plugin cannot be loaded by the registry
+	PluginUnloadable PluginStatus = "unloadable"
+)
+
+// VerificationRequest is a request to verify the registry's models against the plugin cache
+type VerificationRequest struct {
+	// Repair indicates whether to recompile missing, stale and unloadable plugins and prune orphaned files
+	Repair bool `json:"repair"`
+}
+
+// ModelVerification is the result of verifying a model's cached plugin
+type ModelVerification struct {
+	// Model is the model, formatted as name@version
+	Model  string       `json:"model"`
+	Status PluginStatus `json:"status"`
+	// Error is the reason the plugin is not valid
+	Error string `json:"error,omitempty"`
+	// Repaired indicates the plugin was recompiled
+	Repaired bool `json:"repaired,omitempty"`
+	// RepairError is the reason the plugin could not be repaired
+	RepairError string `json:"repairError,omitempty"`
+}
+
+// VerificationReport is a report of the consistency of the registry and the plugin cache
+type VerificationReport struct {
+	Models []ModelVerification `json:"models"`
+	// OrphanedPlugins are the cached plugin files with no model descriptor
+	OrphanedPlugins []string `json:"orphanedPlugins"`
+	// OrphanedLocks are the plugin lock files with no model descriptor
+	OrphanedLocks []string `json:"orphanedLocks"`
+	// Pruned are the orphaned files removed by a repair
+	Pruned []string `json:"pruned,omitempty"`
+}
+
+// IsConsistent returns whether every model has a valid or repaired plugin and no orphaned files remain
+func (r VerificationReport) IsConsistent() bool {
+	for _, model := range r.Models {
+		if model.Status != PluginValid && !model.Repaired {
+			return false
+		}
+	}
+	return len(r.OrphanedPlugins)+len(r.OrphanedLocks) == len(r.Pruned)
+}
+
+// maintenanceServer is the server API for the maintenance service
+type maintenanceServer interface {
+	VerifyRegistry(ctx context.Context, request *wrapperspb.StringValue) (*wrapperspb.StringValue, error)
+}
+
+var maintenanceServiceDesc = grpc.ServiceDesc{
+	ServiceName: maintenanceServiceName,
+	HandlerType: (*maintenanceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: verifyRegistryMethod,
+			Handler:    verifyRegistryHandler,
+		},
+	},
+}
+
+func verifyRegistryHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	request := &wrapperspb.StringValue{}
+	if err := dec(request); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(maintenanceServer).VerifyRegistry(ctx, request)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/" + maintenanceServiceName + "/" + verifyRegistryMethod,
+	}
+	handler := func(ctx context.Context, request interface{}) (interface{}, error) {
+		return srv.(maintenanceServer).VerifyRegistry(ctx, request.(*wrapperspb.StringValue))
+	}
+	return interceptor(ctx, request, info, handler)
+}
+
+// registerMaintenanceService registers the maintenance service for the given server
+func registerMaintenanceService(r *grpc.Server, server *Server) {
+	r.RegisterService(&maintenanceServiceDesc, server)
+}
+
+// VerifyRegistry verifies that each model in the registry has a valid plugin with the registry server
+// Plugins are verified by loading them in the registry server. If repair is set, missing, stale and unloadable
+// plugins are recompiled and orphaned plugin and lock files are removed from the cache.
+func VerifyRegistry(ctx context.Context, conn grpc.ClientConnInterface, repair bool) (*VerificationReport, error) {
+	bytes, err := json.Marshal(VerificationRequest{Repair: repair})
+	if err != nil {
+		return nil, err
+	}
+	response := &wrapperspb.StringValue{}
+	if err := conn.Invoke(ctx, "/"+maintenanceServiceName+"/"+verifyRegistryMethod, wrapperspb.String(string(bytes)), response); err != nil {
+		return nil, err
+	}
+	var report VerificationReport
+	if err := json.Unmarshal([]byte(response.Value), &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// VerifyRegistry :
+func (s *Server) VerifyRegistry(ctx context.Context, request *wrapperspb.StringValue) (*wrapperspb.StringValue, error) {
+	log.Debugf("Received VerifyRegistryRequest %+v", request)
+	s.sendCapabilities(ctx)
+
+	var verification VerificationRequest
+	if err := json.Unmarshal([]byte(request.Value), &verification); err != nil {
+		err = errors.NewInvalid("malformed verification request: %s", err)
+		log.Warnf("VerifyRegistryRequest failed: %v", err)
+		return nil, errors.Status(err).Err()
+	}
+	if verification.Repair {
+		if err := s.checkMutable(); err != nil {
+			log.Warnf("VerifyRegistryRequest failed: %v", err)
+			return nil, errors.Status(err).Err()
+		}
+	}
+
+	report, err := s.verifyRegistry(ctx, verification.Repair)
+	if err != nil {
+		log.Warnf("VerifyRegistryRequest failed: %v", err)
+		return nil, errors.Status(err).Err()
+	}
+	bytes, err := json.Marshal(report)
+	if err != nil {
+		log.Warnf("VerifyRegistryRequest failed: %v", err)
+		return nil, errors.Status(errors.NewInternal(err.Error())).Err()
+	}
+	response := wrapperspb.String(string(bytes))
+	log.Debugf("Sending VerifyRegistryResponse %+v", response)
+	return response, nil
+}
+
+// verifyRegistry verifies the plugin of each model in the registry and finds orphaned files in the cache
+func (s *Server) verifyRegistry(ctx context.Context, repair bool) (VerificationReport, error) {
+	report := VerificationReport{
+		Models:          []ModelVerification{},
+		OrphanedPlugins: []string{},
+		OrphanedLocks:   []string{},
+	}
+
+	s.mu.RLock()
+	modelInfos, err := s.registry.ListModels()
+	s.mu.RUnlock()
+	if err != nil {
+		return report, err
+	}
+	sort.Slice(modelInfos, func(i, j int) bool {
+		return modelInfos[i].String() < modelInfos[j].String()
+	})
+
+	for _, modelInfo := range modelInfos {
+		verification, ok, err := s.verifyModel(ctx, modelInfo.Name, modelInfo.Version, repair)
+		if err != nil {
+			return report, err
+		}
+		if ok {
+			report.Models = append(report.Models, verification)
+		}
+	}
+
+	paths, err := s.cache.List()
+	if err != nil {
+		return report, err
+	}
+	locks, err := s.cache.ListLocks()
+	if err != nil {
+		return report, err
+	}
+	s.mu.RLock()
+	entries, err := s.getModelEntries()
+	s.mu.RUnlock()
+	if err != nil {
+		return report, err
+	}
+	for _, path := range paths {
+		if !entries[s.cache.PathEntry(path)] {
+			report.OrphanedPlugins = append(report.OrphanedPlugins, path)
+		}
+	}
+	for _, path := range locks {
+		if !entries[s.cache.PathEntry(path)] {
+			report.OrphanedLocks = append(report.OrphanedLocks, path)
+		}
+	}
+	if repair {
+		report.Pruned = s.pruneOrphans(append(append([]string{}, report.OrphanedPlugins...), report.OrphanedLocks...))
+	}
+	return report, nil
+}
+
+// verifyModel verifies the cached plugin for the given model, recompiling it if it's not valid and repair is set
+// The plugin is locked while it's verified, so models being pushed are verified once their plugin is compiled.
+// If the model was removed while waiting for the lock, false is returned.
+func (s *Server) verifyModel(ctx context.Context, name configmodel.Name, version configmodel.Version, repair bool) (ModelVerification, bool, error) {
+	entry := s.cache.Entry(name, version)
+	if repair {
+		if err := entry.Lock(ctx); err != nil {
+			return ModelVerification{}, false, err
+		}
+		defer func() {
+			if err := entry.Unlock(context.Background()); err != nil {
+				log.Errorf("Failed to release cache lock: %s", err)
+			}
+		}()
+	} else {
+		if err := entry.RLock(ctx); err != nil {
+			return ModelVerification{}, false, err
+		}
+		defer func() {
+			if err := entry.RUnlock(context.Background()); err != nil {
+				log.Errorf("Failed to release cache lock: %s", err)
+			}
+		}()
+	}
+
+	modelInfo, err := s.registry.GetModel(name, version)
+	if errors.IsNotFound(err) {
+		return ModelVerification{}, false, nil
+	} else if err != nil {
+		return ModelVerification{}, false, err
+	}
+
+	verification := ModelVerification{
+		Model:  modelInfo.String(),
+		Status: PluginValid,
+	}
+	if err := s.verifyPlugin(modelInfo, entry); err != nil {
+		verification.Status = getPluginStatus(err)
+		verification.Error = err.Error()
+	}
+	if verification.Status == PluginValid || !repair {
+		return verification, true, nil
+	}
+
+	if err := s.repairPlugin(ctx, modelInfo, entry, verification.Status); err != nil {
+		log.Warnf("Failed to repair plugin for model '%s': %s", modelInfo, err)
+		verification.RepairError = err.Error()
+	} else {
+		log.Infof("Repaired %s plugin for model '%s'", verification.Status, modelInfo)
+		verification.Repaired = true
+	}
+	return verification, true, nil
+}
+
+// verifyPlugin checks that the given model's plugin is cached, was compiled from the model's definition and
+// can be loaded
+func (s *Server) verifyPlugin(modelInfo configmodel.ModelInfo, entry *plugincache.PluginEntry) error {
+	if _, err := os.Stat(entry.Path); os.IsNotExist(err) {
+		return errors.NewNotFound("plugin '%s' not found", entry.Path)
+	}
+	checksum := modelInfo.ComputeChecksum()
+	if modelInfo.Checksum != "" && modelInfo.Checksum != checksum {
+		return errors.NewConflict("descriptor checksum '%s' does not match the model definition checksum '%s'", modelInfo.Checksum, checksum)
+	}
+	stale, err := entry.IsStale(checksum)
+	if err != nil {
+		return err
+	}
+	if stale {
+		return errors.NewConflict("plugin '%s' was not compiled from the model definition with checksum '%s'", entry.Path, checksum)
+	}
+	if _, err := s.load(entry); err != nil {
+		return errors.NewInvalid("failed to load plugin '%s': %s", entry.Path, err)
+	}
+	return nil
+}
+
+// getPluginStatus returns the status of a plugin that failed verification with the given error
+func getPluginStatus(err error) PluginStatus {
+	switch {
+	case errors.IsNotFound(err):
+		return PluginMissing
+	case errors.IsConflict(err):
+		return PluginStale
+	}
+	return PluginUnloadable
+}
+
+// repairPlugin recompiles the plugin for the given model and swaps it into the cache
+// Plugins are compiled to the version addressed by the checksum of the model definition, which is reused
+// if it's already compiled and the plugin could be loaded, as plugins are swapped in by a forced push.
+func (s *Server) repairPlugin(ctx context.Context, modelInfo configmodel.ModelInfo, entry *plugincache.PluginEntry, status PluginStatus) error {
+	if len(modelInfo.Files) == 0 {
+		return errors.NewNotFound("model '%s' has no YANG files from which to compile its plugin", modelInfo)
+	}
+	path := entry.VersionPath(modelInfo.ComputeChecksum())
+	if status == PluginUnloadable {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := s.compilePlugin(ctx, modelInfo, path); err != nil {
+			_ = os.Remove(path)
+			return err
+		}
+	}
+	previous, err := entry.Swap(path)
+	if err != nil {
+		return err
+	}
+
+	// Descriptors whose checksum does not match their definition are rewritten with the definition's checksum
+	if modelInfo.Checksum != "" && modelInfo.Checksum != modelInfo.ComputeChecksum() {
+		modelInfo.Plugin.SetArtifact(newPluginArtifact(s.cache.Platform()))
+		if err := s.registry.AddModel(modelInfo); err != nil {
+			return err
+		}
+	} else {
+		s.recordPluginArtifact(modelInfo)
+	}
+	s.recordBuildInfo(modelInfo, path)
+	if previous != "" {
+		s.removePluginVersion(entry, previous)
+	}
+	return nil
+}
+
+// getModelEntries returns the cache entries of the plugins of the models in the registry
+func (s *Server) getModelEntries() (map[*plugincache.PluginEntry]bool, error) {
+	modelInfos, err := s.registry.ListModels()
+	if err != nil {
+		return nil, err
+	}
+	entries := make(map[*plugincache.PluginEntry]bool)
+	for _, modelInfo := range modelInfos {
+		entries[s.cache.Entry(modelInfo.Name, modelInfo.Version)] = true
+	}
+	return entries, nil
+}
+
+// pruneOrphans removes the given orphaned files from the cache, returning the removed paths
+// Files locked by a push in progress are skipped, and each file is checked again for a descriptor once it's
+// locked, as pushes add the model descriptor while holding the plugin's lock.
+func (s *Server) pruneOrphans(paths []string) []string {
+	// Pushes lock the plugin while holding the server lock, so the server lock is acquired first
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	pruned := []string{}
+	for _, path := range paths {
+		entry := s.cache.PathEntry(path)
+		locked, err := entry.TryLock()
+		if err != nil {
+			log.Warnf("Failed to prune orphaned file '%s': %s", path, err)
+			continue
+		} else if !locked {
+			log.Infof("Skipping pruning locked file '%s'", path)
+			continue
+		}
+		if err := s.pruneOrphan(entry); err != nil {
+			log.Warnf("Failed to prune orphaned file '%s': %s", path, err)
+		} else {
+			log.Infof("Pruned orphaned file '%s'", path)
+			pruned = append(pruned, path)
+		}
+		if err := entry.Unlock(context.Background()); err != nil {
+			log.Errorf("Failed to release cache lock: %s", err)
+		}
+	}
+	return pruned
+}
+
+// pruneOrphan removes the given locked plugin entry if it still has no model descriptor
+func (s *Server) pruneOrphan(entry *plugincache.PluginEntry) error {
+	entries, err := s.getModelEntries()
+	if err != nil {
+		return err
+	}
+	if entries[entry] {
+		return errors.NewConflict("plugin '%s' was added to the registry", entry.Path)
+	}
+	return entry.Prune()
+}
@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	goerrors "errors"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	modelplugin "github.com/onosproject/onos-config-model/pkg/model/plugin"
+	plugincache "github.com/onosproject/onos-config-model/pkg/model/plugin/cache"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifyRegistry(t *testing.T) {
+	server := newTestServer(t)
+	conn := newTestConn(t, server)
+	server.load = func(entry *plugincache.PluginEntry) (modelplugin.ConfigModelPlugin, error) {
+		if entry == server.cache.Entry("qux", "1.0.0") {
+			return nil, goerrors.New("plugin was built with a different version of package")
+		}
+		return testModelPlugin{model: testModel{}}, nil
+	}
+
+	// A model with a valid plugin
+	assert.NoError(t, server.registry.AddModel(configmodel.ModelInfo{Name: "foo", Version: "1.0.0"}))
+	assert.NoError(t, ioutil.WriteFile(server.cache.Entry("foo", "1.0.0").Path, []byte("plugin"), 0666))
+
+	// A model with no plugin and no YANG files from which to compile it
+	assert.NoError(t, server.registry.AddModel(configmodel.ModelInfo{Name: "bar", Version: "1.0.0"}))
+
+	// A model whose plugin was compiled from another definition, with the version for its definition compiled
+	assert.NoError(t, server.registry.AddModel(configmodel.ModelInfo{
+		Name:    "baz",
+		Version: "1.0.0",
+		Files:   []configmodel.FileInfo{{Path: "baz.yang", Data: []byte("module baz {}")}},
+	}))
+	baz, err := server.registry.GetModel("baz", "1.0.0")
+	assert.NoError(t, err)
+	entry := server.cache.Entry("baz", "1.0.0")
+	assert.NoError(t, ioutil.WriteFile(entry.VersionPath("0123456789abcdef"), []byte("v1"), 0666))
+	assert.NoError(t, ioutil.WriteFile(entry.VersionPath(baz.Checksum), []byte("v2"), 0666))
+	assert.NoError(t, os.Symlink(filepath.Base(entry.VersionPath("0123456789abcdef")), entry.Path))
+
+	// A model whose plugin cannot be loaded
+	assert.NoError(t, server.registry.AddModel(configmodel.ModelInfo{Name: "qux", Version: "1.0.0"}))
+	assert.NoError(t, ioutil.WriteFile(server.cache.Entry("qux", "1.0.0").Path, []byte("plugin"), 0666))
+
+	// An orphaned plugin, an orphaned lock and an orphaned plugin locked by a push in progress
+	orphan := server.cache.Entry("orphan", "1.0.0")
+	assert.NoError(t, ioutil.WriteFile(orphan.Path, []byte("plugin"), 0666))
+	assert.NoError(t, ioutil.WriteFile(server.cache.Entry("deleted", "1.0.0").LockPath(), []byte{}, 0666))
+	pushed := server.cache.Entry("pushed", "1.0.0")
+	assert.NoError(t, pushed.Lock(context.Background()))
+	assert.NoError(t, ioutil.WriteFile(pushed.Path, []byte("plugin"), 0666))
+
+	report, err := VerifyRegistry(context.Background(), conn, false)
+	assert.NoError(t, err)
+	assert.False(t, report.IsConsistent())
+	assert.Len(t, report.Models, 4)
+	assert.Equal(t, ModelVerification{Model: "bar@1.0.0", Status: PluginMissing, Error: report.Models[0].Error}, report.Models[0])
+	assert.Equal(t, PluginStale, report.Models[1].Status)
+	assert.Equal(t, ModelVerification{Model: "foo@1.0.0", Status: PluginValid}, report.Models[2])
+	assert.Equal(t, PluginUnloadable, report.Models[3].Status)
+	assert.ElementsMatch(t, []string{orphan.Path, pushed.Path}, report.OrphanedPlugins)
+	assert.ElementsMatch(t, []string{server.cache.Entry("deleted", "1.0.0").LockPath(), pushed.LockPath()}, report.OrphanedLocks)
+	assert.Empty(t, report.Pruned)
+
+	// Stale plugins are repaired with the version compiled from the model's definition, and locked files are kept
+	report, err = VerifyRegistry(context.Background(), conn, true)
+	assert.NoError(t, err)
+	assert.False(t, report.IsConsistent())
+	assert.NotEmpty(t, report.Models[0].RepairError)
+	assert.True(t, report.Models[1].Repaired)
+	assert.NotEmpty(t, report.Models[3].RepairError)
+	assert.ElementsMatch(t, []string{orphan.Path, server.cache.Entry("deleted", "1.0.0").LockPath()}, report.Pruned)
+	_, err = os.Stat(orphan.Path)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(pushed.Path)
+	assert.NoError(t, err)
+	target, err := os.Readlink(entry.Path)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Base(entry.VersionPath(baz.Checksum)), target)
+	assert.NoError(t, pushed.Unlock(context.Background()))
+
+	report, err = VerifyRegistry(context.Background(), conn, false)
+	assert.NoError(t, err)
+	assert.Equal(t, PluginValid, report.Models[1].Status)
+}
+
+func TestGetPluginStatus(t *testing.T) {
+	server := newTestServer(t)
+	modelInfo := configmodel.ModelInfo{Name: "foo", Version: "1.0.0"}
+	modelInfo.Checksum = "0123456789abcdef"
+	entry := server.cache.Entry("foo", "1.0.0")
+	assert.NoError(t, entry.RLock(context.Background()))
+	defer entry.RUnlock(context.Background())
+	assert.Equal(t, PluginMissing, getPluginStatus(server.verifyPlugin(modelInfo, entry)))
+
+	// Descriptors whose checksum does not match the model definition are stale
+	assert.NoError(t, ioutil.WriteFile(entry.Path, []byte("plugin"), 0666))
+	assert.Equal(t, PluginStale, getPluginStatus(server.verifyPlugin(modelInfo, entry)))
+}
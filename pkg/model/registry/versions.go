@@ -0,0 +1,160 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"fmt"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/rogpeppe/go-internal/semver"
+	"sort"
+	"strings"
+)
+
+const (
+	// LatestVersion is the version constraint matching the highest release version of a model
+	LatestVersion configmodel.Version = "latest"
+	// caretPrefix prefixes constraints matching versions compatible with the given version, e.g. ^1.2.0
+	caretPrefix = "^"
+	// tildePrefix prefixes constraints matching patch versions of the given version, e.g. ~1.2
+	tildePrefix = "~"
+)
+
+// IsVersionConstraint returns whether the given version is a constraint rather than an exact version
+// Constraints are 'latest', caret constraints such as '^1.2.0', which match versions with the same major
+// version, and tilde constraints such as '~1.2', which match versions with the same minor version.
+func IsVersionConstraint(version configmodel.Version) bool {
+	return version == LatestVersion || strings.HasPrefix(string(version), caretPrefix) || strings.HasPrefix(string(version), tildePrefix)
+}
+
+// resolveVersion returns the highest of the given versions of a model matching the given constraint
+// Versions are compared as semantic versions with or without a 'v' prefix. Prerelease versions and versions
+// that are not semantic versions can only be requested exactly, so they never match a constraint.
+func resolveVersion(name configmodel.Name, constraint configmodel.Version, versions []configmodel.Version) (configmodel.Version, error) {
+	match, err := newVersionMatcher(constraint)
+	if err != nil {
+		return "", err
+	}
+	var resolved configmodel.Version
+	for _, version := range versions {
+		v := toSemver(version)
+		if !semver.IsValid(v) || semver.Prerelease(v) != "" || !match(v) {
+			continue
+		}
+		if resolved == "" || semver.Compare(v, toSemver(resolved)) > 0 {
+			resolved = version
+		}
+	}
+	if resolved == "" {
+		return "", errors.NewNotFound("no version of model '%s' matches '%s'", name, constraint)
+	}
+	return resolved, nil
+}
+
+// newVersionMatcher returns a function matching the semantic versions satisfying the given constraint
+func newVersionMatcher(constraint configmodel.Version) (func(string) bool, error) {
+	if constraint == LatestVersion {
+		return func(string) bool {
+			return true
+		}, nil
+	}
+
+	value := string(constraint)
+	caret := strings.HasPrefix(value, caretPrefix)
+	value = strings.TrimPrefix(strings.TrimPrefix(value, caretPrefix), tildePrefix)
+	lower := toSemver(configmodel.Version(value))
+	if !semver.IsValid(lower) || semver.Build(lower) != "" {
+		return nil, errors.NewInvalid("version constraint '%s' is not a valid semantic version constraint", constraint)
+	}
+
+	// Shorthand versions such as 1.2 are completed with zeros, and bound the constraint at the last given component
+	var major, minor, patch int
+	if _, err := fmt.Sscanf(strings.TrimSuffix(semver.Canonical(lower), semver.Prerelease(lower)), "v%d.%d.%d", &major, &minor, &patch); err != nil {
+		return nil, errors.NewInvalid("version constraint '%s' is not a valid semantic version constraint", constraint)
+	}
+	components := strings.Count(strings.TrimSuffix(lower, semver.Prerelease(lower)), ".") + 1
+	var upper string
+	switch {
+	case caret && (major > 0 || components == 1), !caret && components == 1:
+		upper = fmt.Sprintf("v%d.0.0", major+1)
+	case caret && (minor > 0 || components < 3), !caret:
+		upper = fmt.Sprintf("v%d.%d.0", major, minor+1)
+	default:
+		upper = fmt.Sprintf("v%d.%d.%d", major, minor, patch+1)
+	}
+	return func(version string) bool {
+		return semver.Compare(version, lower) >= 0 && semver.Compare(version, upper) < 0
+	}, nil
+}
+
+// toSemver returns the given model version in the form of a semantic version with a 'v' prefix
+func toSemver(version configmodel.Version) string {
+	if strings.HasPrefix(string(version), "v") {
+		return string(version)
+	}
+	return "v" + string(version)
+}
+
+// getModelVersions returns the versions of the given models with the given name, ordered by semantic version
+// Versions that are not semantic versions are ordered before semantic versions, by name.
+func getModelVersions(models []configmodel.ModelInfo, name configmodel.Name) []configmodel.Version {
+	versions := []configmodel.Version{}
+	for _, model := range models {
+		if model.Name == name {
+			versions = append(versions, model.Version)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		vi, vj := toSemver(versions[i]), toSemver(versions[j])
+		if semver.IsValid(vi) != semver.IsValid(vj) {
+			return !semver.IsValid(vi)
+		}
+		if c := semver.Compare(vi, vj); c != 0 {
+			return c < 0
+		}
+		return versions[i] < versions[j]
+	})
+	return versions
+}
+
+// ListModelVersions lists the versions of the model with the given name, ordered by semantic version
+func (r *ConfigModelRegistry) ListModelVersions(name configmodel.Name) ([]configmodel.Version, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	models, _, err := r.listModelsPage("", 0)
+	if err != nil {
+		return nil, err
+	}
+	return getModelVersions(models, name), nil
+}
+
+// resolveModelVersion returns the version of the model with the given name matching the given constraint
+func (r *ConfigModelRegistry) resolveModelVersion(name configmodel.Name, constraint configmodel.Version) (configmodel.Version, error) {
+	models, _, err := r.listModelsPage("", 0)
+	if err != nil {
+		return "", err
+	}
+	return resolveVersion(name, constraint, getModelVersions(models, name))
+}
+
+// ListModelVersions lists the versions of the model with the given name, ordered by semantic version
+func (r *memRegistry) ListModelVersions(name configmodel.Name) ([]configmodel.Version, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	models, _, err := r.listModelsPage("", 0)
+	if err != nil {
+		return nil, err
+	}
+	return getModelVersions(models, name), nil
+}
+
+// resolveModelVersion returns the version of the model with the given name matching the given constraint
+func (r *memRegistry) resolveModelVersion(name configmodel.Name, constraint configmodel.Version) (configmodel.Version, error) {
+	models, _, err := r.listModelsPage("", 0)
+	if err != nil {
+		return "", err
+	}
+	return resolveVersion(name, constraint, getModelVersions(models, name))
+}
@@ -0,0 +1,122 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestResolveVersion(t *testing.T) {
+	versions := []configmodel.Version{"0.1.0", "0.1.5", "0.2.0", "1.0.0", "1.2.0", "1.2.3", "v1.3.0", "1.4.0-rc1", "2.0.0", "2.1.0-beta", "2020-11-18"}
+	tests := []struct {
+		constraint configmodel.Version
+		expected   configmodel.Version
+	}{
+		{"latest", "2.0.0"},
+		{"^1.0.0", "v1.3.0"},
+		{"^1.2.3", "v1.3.0"},
+		{"^1", "v1.3.0"},
+		{"^0.1.0", "0.1.5"},
+		{"^0", "0.2.0"},
+		{"~1.2", "1.2.3"},
+		{"~1.2.1", "1.2.3"},
+		{"~1", "v1.3.0"},
+		{"~0.1", "0.1.5"},
+		{"^v2.0.0", "2.0.0"},
+	}
+	for _, test := range tests {
+		version, err := resolveVersion("test", test.constraint, versions)
+		assert.NoError(t, err, test.constraint)
+		assert.Equal(t, test.expected, version, test.constraint)
+	}
+
+	// Prerelease versions only match exactly
+	_, err := resolveVersion("test", "^3.0.0", versions)
+	assert.True(t, errors.IsNotFound(err))
+	_, err = resolveVersion("test", "~1.4", versions)
+	assert.True(t, errors.IsNotFound(err))
+	_, err = resolveVersion("test", "latest", []configmodel.Version{"1.0.0-rc1"})
+	assert.True(t, errors.IsNotFound(err))
+
+	_, err = resolveVersion("test", "^foo", versions)
+	assert.True(t, errors.IsInvalid(err))
+	_, err = resolveVersion("test", "~1.0.0+build", versions)
+	assert.True(t, errors.IsInvalid(err))
+
+	assert.True(t, IsVersionConstraint("latest"))
+	assert.True(t, IsVersionConstraint("^1.0.0"))
+	assert.True(t, IsVersionConstraint("~1.2"))
+	assert.False(t, IsVersionConstraint("1.0.0"))
+}
+
+func TestGetModelVersionConstraint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-model-registry")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, registry := range []interface {
+		Registry
+		ListModelVersions(name configmodel.Name) ([]configmodel.Version, error)
+	}{
+		NewConfigModelRegistry(Config{Path: dir}),
+		NewMemoryRegistry().(*memRegistry),
+	} {
+		for _, version := range []configmodel.Version{"1.10.0", "1.2.0", "2.0.0", "latest"} {
+			assert.NoError(t, registry.AddModel(configmodel.ModelInfo{Name: "test", Version: version}))
+		}
+		assert.NoError(t, registry.AddModel(configmodel.ModelInfo{Name: "other", Version: "1.11.0"}))
+
+		versions, err := registry.ListModelVersions("test")
+		assert.NoError(t, err)
+		assert.Equal(t, []configmodel.Version{"latest", "1.2.0", "1.10.0", "2.0.0"}, versions)
+		versions, err = registry.ListModelVersions("none")
+		assert.NoError(t, err)
+		assert.Empty(t, versions)
+
+		model, err := registry.GetModel("test", "^1.0.0")
+		assert.NoError(t, err)
+		assert.Equal(t, configmodel.Version("1.10.0"), model.Version)
+		model, err = registry.GetModel("test", "~1.2")
+		assert.NoError(t, err)
+		assert.Equal(t, configmodel.Version("1.2.0"), model.Version)
+
+		// Exact versions are matched before constraints
+		model, err = registry.GetModel("test", "1.2.0")
+		assert.NoError(t, err)
+		assert.Equal(t, configmodel.Version("1.2.0"), model.Version)
+		model, err = registry.GetModel("test", "latest")
+		assert.NoError(t, err)
+		assert.Equal(t, configmodel.Version("latest"), model.Version)
+
+		_, err = registry.GetModel("test", "^3.0.0")
+		assert.True(t, errors.IsNotFound(err))
+		_, err = registry.GetModel("none", "latest")
+		assert.True(t, errors.IsNotFound(err))
+	}
+}
+
+func TestGetModelVersionConstraintRequest(t *testing.T) {
+	server := newTestServer(t)
+	client := newTestClient(t, server)
+	assert.NoError(t, server.registry.AddModel(configmodel.ModelInfo{Name: "test", Version: "1.0.0"}))
+	assert.NoError(t, server.registry.AddModel(configmodel.ModelInfo{Name: "test", Version: "1.1.0"}))
+
+	// The resolved version is returned
+	response, err := client.GetModel(context.Background(), &configmodelapi.GetModelRequest{Name: "test", Version: "latest"})
+	assert.NoError(t, err)
+	assert.Equal(t, "1.1.0", response.Model.Version)
+
+	_, err = client.GetModel(context.Background(), &configmodelapi.GetModelRequest{Name: "test", Version: "^2.0.0"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,254 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	"encoding/json"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"io"
+	"sync"
+	"time"
+)
+
+// The registry API has no method for watching models, so WatchModels is provided by a separate service.
+// The service streams JSON encoded ModelEvents as string values.
+const (
+	watchServiceName  = "onos.configmodel.ConfigModelRegistryWatchService"
+	watchModelsMethod = "WatchModels"
+)
+
+// defaultWatchBufferSize is the number of events buffered for each watcher
+const defaultWatchBufferSize = 100
+
+// ModelAction is the change to the registry described by a model event
+type ModelAction string
+
+const (
+	// ModelAdded indicates a model was pushed to the registry, replacing any existing model of the same version
+	ModelAdded ModelAction = "added"
+	// ModelRemoved indicates a model was deleted from the registry
+	ModelRemoved ModelAction = "removed"
+)
+
+// ModelEvent is a change to the models in the registry
+type ModelEvent struct {
+	Action    ModelAction         `json:"action"`
+	Name      configmodel.Name    `json:"name"`
+	Version   configmodel.Version `json:"version"`
+	Checksum  string              `json:"checksum"`
+	Timestamp time.Time           `json:"timestamp"`
+	// Snapshot indicates the event is for a model that was in the registry when the watch started
+	Snapshot bool `json:"snapshot,omitempty"`
+}
+
+func newModelEvent(action ModelAction, modelInfo configmodel.ModelInfo) ModelEvent {
+	checksum := modelInfo.Checksum
+	if checksum == "" {
+		checksum = modelInfo.ComputeChecksum()
+	}
+	return ModelEvent{
+		Action:    action,
+		Name:      modelInfo.Name,
+		Version:   modelInfo.Version,
+		Checksum:  checksum,
+		Timestamp: time.Now(),
+	}
+}
+
+// ModelWatcher receives the events published by a registry
+type ModelWatcher struct {
+	events    chan ModelEvent
+	err       error
+	publisher *modelPublisher
+}
+
+// Events returns the channel on which events are received
+// The channel is closed when the watcher is closed or dropped for falling behind, after which Err
+// returns the reason the watcher was dropped.
+func (w *ModelWatcher) Events() <-chan ModelEvent {
+	return w.events
+}
+
+// Err returns the error with which the watcher was dropped, if any
+func (w *ModelWatcher) Err() error {
+	w.publisher.mu.Lock()
+	defer w.publisher.mu.Unlock()
+	return w.err
+}
+
+// Close stops the watcher
+func (w *ModelWatcher) Close() {
+	w.publisher.unsubscribe(w, nil)
+}
+
+// modelPublisher publishes the changes to a registry to its watchers
+// Events are published without blocking the registry: a watcher whose buffer is full is dropped with an
+// error, after which it must watch again to resynchronize with the registry.
+type modelPublisher struct {
+	watchers   map[*ModelWatcher]bool
+	bufferSize int
+	mu         sync.Mutex
+}
+
+// subscribe adds a watcher
+func (p *modelPublisher) subscribe() *ModelWatcher {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.watchers == nil {
+		p.watchers = make(map[*ModelWatcher]bool)
+	}
+	bufferSize := p.bufferSize
+	if bufferSize == 0 {
+		bufferSize = defaultWatchBufferSize
+	}
+	watcher := &ModelWatcher{
+		events:    make(chan ModelEvent, bufferSize),
+		publisher: p,
+	}
+	p.watchers[watcher] = true
+	return watcher
+}
+
+// unsubscribe removes a watcher, closing its events with the given error
+func (p *modelPublisher) unsubscribe(watcher *ModelWatcher, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.remove(watcher, err)
+}
+
+func (p *modelPublisher) remove(watcher *ModelWatcher, err error) {
+	if !p.watchers[watcher] {
+		return
+	}
+	delete(p.watchers, watcher)
+	watcher.err = err
+	close(watcher.events)
+}
+
+// publish sends an event to all watchers, dropping watchers that have fallen behind
+func (p *modelPublisher) publish(event ModelEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for watcher := range p.watchers {
+		select {
+		case watcher.events <- event:
+		default:
+			log.Warnf("Dropping registry watcher with %d pending events", len(watcher.events))
+			p.remove(watcher, errors.NewUnavailable("watch fell behind the registry by more than %d events; watch again to resynchronize", cap(watcher.events)))
+		}
+	}
+}
+
+// watchServer is the server API for the watch service
+type watchServer interface {
+	WatchModels(request *emptypb.Empty, stream grpc.ServerStream) error
+}
+
+var watchServiceDesc = grpc.ServiceDesc{
+	ServiceName: watchServiceName,
+	HandlerType: (*watchServer)(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    watchModelsMethod,
+			Handler:       watchModelsHandler,
+			ServerStreams: true,
+		},
+	},
+}
+
+func watchModelsHandler(srv interface{}, stream grpc.ServerStream) error {
+	request := &emptypb.Empty{}
+	if err := stream.RecvMsg(request); err != nil {
+		return err
+	}
+	return srv.(watchServer).WatchModels(request, stream)
+}
+
+// registerWatchService registers the watch service for the given server
+func registerWatchService(r *grpc.Server, server *Server) {
+	r.RegisterService(&watchServiceDesc, server)
+}
+
+// WatchModels watches the models in the registry, calling the given function for each event
+// The models in the registry when the watch starts are sent first as snapshot events, followed by the
+// models added and removed until the context is canceled. If the watch falls behind the registry, it fails
+// with an Unavailable error and must be restarted to resynchronize.
+func WatchModels(ctx context.Context, conn grpc.ClientConnInterface, f func(ModelEvent)) error {
+	stream, err := conn.NewStream(ctx, &watchServiceDesc.Streams[0], "/"+watchServiceName+"/"+watchModelsMethod)
+	if err != nil {
+		return err
+	}
+	if err := stream.SendMsg(&emptypb.Empty{}); err != nil {
+		return err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+	for {
+		value := &wrapperspb.StringValue{}
+		if err := stream.RecvMsg(value); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		var event ModelEvent
+		if err := json.Unmarshal([]byte(value.Value), &event); err != nil {
+			return err
+		}
+		f(event)
+	}
+}
+
+// WatchModels :
+func (s *Server) WatchModels(request *emptypb.Empty, stream grpc.ServerStream) error {
+	log.Debugf("Received WatchModels request %+v", request)
+	ctx := stream.Context()
+	s.sendCapabilities(ctx)
+
+	snapshot, watcher, err := s.registry.Watch()
+	if err != nil {
+		log.Warnf("WatchModels request %+v failed: %v", request, err)
+		return errors.Status(err).Err()
+	}
+	defer watcher.Close()
+
+	for _, modelInfo := range snapshot {
+		event := newModelEvent(ModelAdded, modelInfo)
+		event.Snapshot = true
+		if err := sendModelEvent(stream, event); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case event, ok := <-watcher.Events():
+			if !ok {
+				err := watcher.Err()
+				log.Warnf("WatchModels request %+v failed: %v", request, err)
+				return errors.Status(err).Err()
+			}
+			if err := sendModelEvent(stream, event); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// sendModelEvent sends a JSON encoded model event on the given stream
+func sendModelEvent(stream grpc.ServerStream, event ModelEvent) error {
+	bytes, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return stream.SendMsg(wrapperspb.String(string(bytes)))
+}
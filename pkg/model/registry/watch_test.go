@@ -0,0 +1,149 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func newWatchTestModel(version configmodel.Version) configmodel.ModelInfo {
+	return configmodel.ModelInfo{
+		Name:    "test",
+		Version: version,
+		Files: []configmodel.FileInfo{
+			{Path: "test.yang", Data: []byte("module test {}")},
+		},
+		Modules: []configmodel.ModuleInfo{
+			{Name: "test", File: "test.yang"},
+		},
+	}
+}
+
+func TestWatchModels(t *testing.T) {
+	server := newTestServer(t)
+	assert.NoError(t, server.registry.AddModel(newWatchTestModel("1.0.0")))
+	conn := newTestConn(t, server)
+	client := configmodelapi.NewConfigModelRegistryServiceClient(conn)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan ModelEvent, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- WatchModels(ctx, conn, func(event ModelEvent) {
+			events <- event
+		})
+	}()
+
+	nextEvent := func() ModelEvent {
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event received")
+			return ModelEvent{}
+		}
+	}
+
+	// The models in the registry are sent first
+	event := nextEvent()
+	assert.Equal(t, ModelAdded, event.Action)
+	assert.Equal(t, configmodel.Version("1.0.0"), event.Version)
+	assert.True(t, event.Snapshot)
+
+	entry := server.cache.Entry("test", "2.0.0")
+	assert.NoError(t, ioutil.WriteFile(entry.Path, []byte("plugin"), 0666))
+	_, err := client.PushModel(WithSkipCompile(context.Background()), &configmodelapi.PushModelRequest{
+		Model: &configmodelapi.ConfigModel{
+			Name:    "test",
+			Version: "2.0.0",
+			Modules: []*configmodelapi.ConfigModule{
+				{Name: "test", File: "test.yang"},
+			},
+			Files: map[string]string{
+				"test.yang": "module test {}",
+			},
+		},
+	})
+	assert.NoError(t, err)
+	modelInfo, err := server.registry.GetModel("test", "2.0.0")
+	assert.NoError(t, err)
+
+	event = nextEvent()
+	assert.Equal(t, ModelAdded, event.Action)
+	assert.Equal(t, configmodel.Name("test"), event.Name)
+	assert.Equal(t, configmodel.Version("2.0.0"), event.Version)
+	assert.Equal(t, modelInfo.Checksum, event.Checksum)
+	assert.False(t, event.Snapshot)
+
+	_, err = client.DeleteModel(context.Background(), &configmodelapi.DeleteModelRequest{Name: "test", Version: "1.0.0"})
+	assert.NoError(t, err)
+	event = nextEvent()
+	assert.Equal(t, ModelRemoved, event.Action)
+	assert.Equal(t, configmodel.Version("1.0.0"), event.Version)
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.Equal(t, codes.Canceled, status.Code(err))
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch did not stop")
+	}
+}
+
+func TestWatchSlowSubscriber(t *testing.T) {
+	for _, registry := range []Registry{newTestServer(t).registry, NewMemoryRegistry()} {
+		models, watcher, err := registry.Watch()
+		assert.NoError(t, err)
+		assert.Empty(t, models)
+
+		var publisher *modelPublisher
+		switch r := registry.(type) {
+		case *ConfigModelRegistry:
+			publisher = &r.publisher
+		case *memRegistry:
+			publisher = &r.publisher
+		}
+		publisher.bufferSize = 1
+		slow := publisher.subscribe()
+
+		// Slow watchers never block changes to the registry
+		assert.NoError(t, registry.AddModel(newWatchTestModel("1.0.0")))
+		assert.NoError(t, registry.AddModel(newWatchTestModel("2.0.0")))
+
+		event, ok := <-slow.Events()
+		assert.True(t, ok)
+		assert.Equal(t, configmodel.Version("1.0.0"), event.Version)
+		_, ok = <-slow.Events()
+		assert.False(t, ok)
+		assert.True(t, errors.IsUnavailable(slow.Err()))
+		slow.Close()
+
+		// Other watchers are unaffected
+		assert.Equal(t, configmodel.Version("1.0.0"), (<-watcher.Events()).Version)
+		assert.Equal(t, configmodel.Version("2.0.0"), (<-watcher.Events()).Version)
+
+		// Removing a missing model publishes no event
+		assert.NoError(t, registry.RemoveModel("test", "3.0.0"))
+		assert.NoError(t, registry.RemoveModel("test", "1.0.0"))
+		event = <-watcher.Events()
+		assert.Equal(t, ModelRemoved, event.Action)
+		assert.Equal(t, configmodel.Version("1.0.0"), event.Version)
+
+		// Closed watchers receive no more events
+		watcher.Close()
+		_, ok = <-watcher.Events()
+		assert.False(t, ok)
+		assert.NoError(t, watcher.Err())
+	}
+}
@@ -0,0 +1,144 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultWebhookTimeout   = 10 * time.Second
+	defaultWebhookRetries   = 5
+	defaultWebhookBackoff   = time.Second
+	defaultWebhookQueueSize = 100
+	maxWebhookBackoff       = time.Minute
+	webhookContentType      = "application/json"
+)
+
+// CompileEvent is the payload posted to the compile webhook when a model finishes compiling
+type CompileEvent struct {
+	Name        configmodel.Name    `json:"name"`
+	Version     configmodel.Version `json:"version"`
+	Time        time.Time           `json:"time"`
+	Duration    time.Duration       `json:"duration"`
+	Success     bool                `json:"success"`
+	Error       string              `json:"error,omitempty"`
+	Diagnostics []Diagnostic        `json:"diagnostics,omitempty"`
+}
+
+func newCompileEvent(modelInfo configmodel.ModelInfo, attempt CompileAttempt) CompileEvent {
+	event := CompileEvent{
+		Name:     modelInfo.Name,
+		Version:  modelInfo.Version,
+		Time:     attempt.Time,
+		Duration: attempt.Duration,
+		Success:  attempt.Success,
+		Error:    attempt.Error,
+	}
+	if !attempt.Success {
+		event.Diagnostics = ValidateModel(modelInfo)
+	}
+	return event
+}
+
+// notifyModelEvent posts an event for the given change to the registry to the model webhook, if one is configured
+func (s *Server) notifyModelEvent(action ModelAction, modelInfo configmodel.ModelInfo) {
+	if s.modelWebhook == nil {
+		return
+	}
+	s.modelWebhook.notify(fmt.Sprintf("%s event for model '%s'", action, modelInfo), newModelEvent(action, modelInfo))
+}
+
+func newWebhook(url string) *webhook {
+	if url == "" {
+		return nil
+	}
+	return &webhook{
+		url:     url,
+		client:  &http.Client{Timeout: defaultWebhookTimeout},
+		retries: defaultWebhookRetries,
+		backoff: defaultWebhookBackoff,
+		queue:   make(chan webhookEvent, defaultWebhookQueueSize),
+	}
+}
+
+// webhook posts events to a URL
+type webhook struct {
+	url     string
+	client  *http.Client
+	retries int
+	backoff time.Duration
+	queue   chan webhookEvent
+	once    sync.Once
+}
+
+// webhookEvent is an event queued for delivery to a webhook
+type webhookEvent struct {
+	description string
+	payload     interface{}
+}
+
+// notify queues the given event to be posted in the background, in the order events are queued
+// Failed posts are retried with exponential backoff. If the queue is full because the webhook is slow or
+// unavailable, the event is dropped. Webhook failures are logged and never affect the caller.
+func (w *webhook) notify(description string, payload interface{}) {
+	w.once.Do(func() {
+		go w.run()
+	})
+	select {
+	case w.queue <- webhookEvent{description: description, payload: payload}:
+	default:
+		log.Errorf("Failed to post %s to '%s': webhook queue is full", description, w.url)
+	}
+}
+
+// run posts queued events until the process exits
+func (w *webhook) run() {
+	for event := range w.queue {
+		w.deliver(event)
+	}
+}
+
+// deliver posts the given event, retrying with exponential backoff on failure
+func (w *webhook) deliver(event webhookEvent) {
+	backoff := w.backoff
+	for attempt := 0; ; attempt++ {
+		err := w.post(event.payload)
+		if err == nil {
+			return
+		}
+		if attempt == w.retries {
+			log.Errorf("Failed to post %s to '%s': %s", event.description, w.url, err)
+			return
+		}
+		log.Warnf("Failed to post %s to '%s'; retrying in %s: %s", event.description, w.url, backoff, err)
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > maxWebhookBackoff {
+			backoff = maxWebhookBackoff
+		}
+	}
+}
+
+func (w *webhook) post(event interface{}) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	response, err := w.client.Post(w.url, webhookContentType, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", response.Status)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	"encoding/json"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	configmodel "github.com/onosproject/onos-config-model/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCompileWebhook(t *testing.T) {
+	events := make(chan CompileEvent, 1)
+	requests := 0
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Fail the first request to exercise retries
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		assert.Equal(t, webhookContentType, r.Header.Get("Content-Type"))
+		var event CompileEvent
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		events <- event
+	}))
+	defer hook.Close()
+
+	server := newTestServer(t)
+	server.webhook = newWebhook(hook.URL)
+	server.webhook.backoff = 10 * time.Millisecond
+
+	// Compilation fails because the model's YANG file is malformed
+	model := configmodel.ModelInfo{
+		Name:    "test",
+		Version: "1.0.0",
+		Files: []configmodel.FileInfo{
+			{Path: "test.yang", Data: []byte("module test {")},
+		},
+	}
+	err := server.compilePlugin(context.Background(), model, server.cache.Entry("test", "1.0.0").Path)
+	assert.Error(t, err)
+
+	select {
+	case event := <-events:
+		assert.Equal(t, configmodel.Name("test"), event.Name)
+		assert.Equal(t, configmodel.Version("1.0.0"), event.Version)
+		assert.False(t, event.Success)
+		assert.Equal(t, err.Error(), event.Error)
+		assert.NotEmpty(t, event.Diagnostics)
+		assert.Equal(t, "test.yang", event.Diagnostics[0].File)
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not called")
+	}
+	assert.Equal(t, 2, requests)
+}
+
+func TestCompileWebhookUnavailable(t *testing.T) {
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer hook.Close()
+
+	// Webhook failures do not affect the compile
+	webhook := newWebhook(hook.URL)
+	webhook.retries = 1
+	webhook.backoff = time.Millisecond
+	assert.Error(t, webhook.post(CompileEvent{Name: "test", Version: "1.0.0"}))
+	assert.Nil(t, newWebhook(""))
+}
+
+func TestModelWebhook(t *testing.T) {
+	events := make(chan ModelEvent, 2)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event ModelEvent
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		events <- event
+	}))
+	defer hook.Close()
+
+	server := newTestServer(t)
+	server.modelWebhook = newWebhook(hook.URL)
+	client := newTestClient(t, server)
+
+	entry := server.cache.Entry("test", "1.0.0")
+	assert.NoError(t, ioutil.WriteFile(entry.Path, []byte("plugin"), 0666))
+	_, err := client.PushModel(WithSkipCompile(context.Background()), &configmodelapi.PushModelRequest{
+		Model: &configmodelapi.ConfigModel{
+			Name:    "test",
+			Version: "1.0.0",
+			Modules: []*configmodelapi.ConfigModule{
+				{Name: "test", File: "test.yang"},
+			},
+			Files: map[string]string{
+				"test.yang": "module test {}",
+			},
+		},
+	})
+	assert.NoError(t, err)
+	modelInfo, err := server.registry.GetModel("test", "1.0.0")
+	assert.NoError(t, err)
+
+	_, err = client.DeleteModel(context.Background(), &configmodelapi.DeleteModelRequest{Name: "test", Version: "1.0.0"})
+	assert.NoError(t, err)
+
+	// Events are delivered in order
+	for _, action := range []ModelAction{ModelAdded, ModelRemoved} {
+		select {
+		case event := <-events:
+			assert.Equal(t, action, event.Action)
+			assert.Equal(t, configmodel.Name("test"), event.Name)
+			assert.Equal(t, configmodel.Version("1.0.0"), event.Version)
+			assert.Equal(t, modelInfo.Checksum, event.Checksum)
+			assert.False(t, event.Timestamp.IsZero())
+		case <-time.After(5 * time.Second):
+			t.Fatalf("webhook was not called for %s event", action)
+		}
+	}
+
+	// Deleting a missing model doesn't emit an event
+	_, err = client.DeleteModel(context.Background(), &configmodelapi.DeleteModelRequest{Name: "test", Version: "1.0.0"})
+	assert.NoError(t, err)
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected %s event", event.Action)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestWebhookQueueFull(t *testing.T) {
+	release := make(chan struct{})
+	requests := make(chan struct{}, 10)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- struct{}{}
+		<-release
+	}))
+	defer hook.Close()
+
+	webhook := newWebhook(hook.URL)
+	webhook.queue = make(chan webhookEvent, 1)
+
+	// A slow webhook never blocks notifications; events that don't fit in the queue are dropped
+	webhook.notify("event 1", ModelEvent{Name: "test", Version: "1.0.0"})
+	<-requests
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 5; i++ {
+			webhook.notify("event", ModelEvent{Name: "test", Version: "1.0.0"})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("notify blocked on a slow webhook")
+	}
+	close(release)
+
+	// Only the queued event is delivered
+	<-requests
+	select {
+	case <-requests:
+		t.Fatal("dropped event was delivered")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,264 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	goerrors "errors"
+	"fmt"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"runtime"
+	"sync"
+	"time"
+)
+
+const defaultCompileQueueSize = 100
+
+// getDefaultCompileWorkers returns the number of compile workers for the given number of CPUs when
+// each build compiles the given number of packages in parallel
+// Each build is already parallel, so the pool is sized to keep the total parallelism within the CPUs.
+func getDefaultCompileWorkers(cpus int, buildParallelism int) int {
+	if buildParallelism <= 0 || buildParallelism >= cpus {
+		return 1
+	}
+	return cpus / buildParallelism
+}
+
+// newWorkerPool creates a new bounded worker pool
+// Workers are started on demand up to the given size. If an idle timeout is configured, workers
+// that have been idle for the timeout exit and are recreated when new tasks are submitted.
+func newWorkerPool(size int, queueSize int, idleTimeout time.Duration) *workerPool {
+	if size <= 0 {
+		size = runtime.NumCPU()
+	}
+	if queueSize <= 0 {
+		queueSize = defaultCompileQueueSize
+	}
+	return &workerPool{
+		size:        size,
+		queueSize:   queueSize,
+		idleTimeout: idleTimeout,
+		ready:       make(chan struct{}, queueSize),
+	}
+}
+
+// workerPool is a bounded pool of workers
+// Queued tasks are ordered by priority, and tasks with the same priority are run in the order they're submitted.
+type workerPool struct {
+	size        int
+	queueSize   int
+	idleTimeout time.Duration
+	// queue is the tasks waiting for a worker in the order in which they'll be run
+	queue []*queuedTask
+	// ready receives a signal for each queued task
+	ready chan struct{}
+	// reserved is the number of queue slots reserved for tasks that have not been submitted yet
+	reserved int
+	workers  int
+	idle     int
+	mu       sync.Mutex
+}
+
+// queuedTask is a task waiting for a worker
+type queuedTask struct {
+	key      string
+	priority Priority
+	run      func()
+}
+
+// queueFullError is returned for tasks submitted to a pool whose queue is full
+type queueFullError struct {
+	size int
+}
+
+func (e *queueFullError) Error() string {
+	return fmt.Sprintf("compile queue is full (%d compilations waiting)", e.size)
+}
+
+// isQueueFull returns whether the given error was returned for a task submitted to a full queue
+func isQueueFull(err error) bool {
+	var queueFull *queueFullError
+	return goerrors.As(err, &queueFull)
+}
+
+// getSubmitStatus returns the gRPC status for an error returned when submitting a compilation
+// Compilations submitted to a full queue fail with ResourceExhausted, so clients can back off and retry the push.
+func getSubmitStatus(err error) *status.Status {
+	if isQueueFull(err) {
+		return status.New(codes.ResourceExhausted, err.Error())
+	}
+	return errors.Status(err)
+}
+
+// submit submits a task with the given key and priority to the pool
+// The task is queued ahead of any queued tasks with a lower priority. Running tasks are never preempted.
+// If the queue is full, the task is rejected with a queueFullError.
+func (p *workerPool) submit(key string, priority Priority, task func()) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.checkCapacity(); err != nil {
+		return err
+	}
+	p.enqueue(key, priority, task)
+	return nil
+}
+
+// reserve reserves a slot in the queue for a task with the given key and priority
+// The slot counts toward the queue size until the task is submitted with the reservation or the
+// reservation is released, so callers can check the queue has room before making changes the task depends on.
+// If the queue is full, the reservation is rejected with a queueFullError.
+func (p *workerPool) reserve(key string, priority Priority) (*reservation, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.checkCapacity(); err != nil {
+		return nil, err
+	}
+	p.reserved++
+	return &reservation{
+		pool:     p,
+		key:      key,
+		priority: priority,
+	}, nil
+}
+
+// checkCapacity returns a queueFullError if no task can be queued
+func (p *workerPool) checkCapacity() error {
+	if len(p.queue)+p.reserved >= p.queueSize {
+		return &queueFullError{size: len(p.queue) + p.reserved}
+	}
+	return nil
+}
+
+// enqueue queues a task ahead of any queued tasks with a lower priority
+func (p *workerPool) enqueue(key string, priority Priority, task func()) {
+	i := len(p.queue)
+	for i > 0 && p.queue[i-1].priority.rank() < priority.rank() {
+		i--
+	}
+	p.queue = append(p.queue, nil)
+	copy(p.queue[i+1:], p.queue[i:])
+	p.queue[i] = &queuedTask{
+		key:      key,
+		priority: priority,
+		run:      task,
+	}
+	p.ready <- struct{}{}
+
+	// Idle workers each take one queued task, so a worker is started for each task they can't take
+	if len(p.queue) > p.idle && p.workers < p.size {
+		p.workers++
+		go p.work()
+	}
+}
+
+// reservation is a slot reserved in the queue of a worker pool
+type reservation struct {
+	pool     *workerPool
+	key      string
+	priority Priority
+}
+
+// submit queues the task in the reserved slot
+func (r *reservation) submit(task func()) {
+	r.pool.mu.Lock()
+	defer r.pool.mu.Unlock()
+	r.pool.reserved--
+	r.pool.enqueue(r.key, r.priority, task)
+}
+
+// release releases the reserved slot without queueing a task
+func (r *reservation) release() {
+	r.pool.mu.Lock()
+	defer r.pool.mu.Unlock()
+	r.pool.reserved--
+}
+
+// resize changes the maximum number of workers and the idle timeout
+// Running tasks are not interrupted; surplus workers exit once their current task completes.
+func (p *workerPool) resize(size int, idleTimeout time.Duration) {
+	if size <= 0 {
+		size = runtime.NumCPU()
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.size = size
+	p.idleTimeout = idleTimeout
+
+	// Start workers for tasks that were queued waiting on the previous limit
+	for pending := len(p.queue) - p.idle; pending > 0 && p.workers < p.size; pending-- {
+		p.workers++
+		go p.work()
+	}
+}
+
+// limits returns the maximum number of workers and the idle timeout
+func (p *workerPool) limits() (int, time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.size, p.idleTimeout
+}
+
+// position returns the 1-based position in the queue of the first task with the given key
+// If no task with the key is waiting for a worker, 0 is returned.
+func (p *workerPool) position(key string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, task := range p.queue {
+		if task.key == key {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// numWorkers returns the number of running workers
+func (p *workerPool) numWorkers() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.workers
+}
+
+func (p *workerPool) work() {
+	for {
+		p.mu.Lock()
+		// Surplus workers exit when the pool has been shrunk
+		if p.workers > p.size {
+			p.workers--
+			p.mu.Unlock()
+			return
+		}
+		p.idle++
+		idleTimeout := p.idleTimeout
+		p.mu.Unlock()
+
+		var idle <-chan time.Time
+		if idleTimeout > 0 {
+			idle = time.After(idleTimeout)
+		}
+
+		select {
+		case <-p.ready:
+			p.mu.Lock()
+			p.idle--
+			task := p.queue[0]
+			p.queue = p.queue[1:]
+			p.mu.Unlock()
+			task.run()
+		case <-idle:
+			p.mu.Lock()
+			// Tasks submitted while this worker was idle must not be stranded in the queue
+			if len(p.queue) > 0 {
+				p.idle--
+				p.mu.Unlock()
+				continue
+			}
+			p.idle--
+			p.workers--
+			p.mu.Unlock()
+			return
+		}
+	}
+}
@@ -0,0 +1,231 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modelregistry
+
+import (
+	"context"
+	configmodelapi "github.com/onosproject/onos-api/go/onos/configmodel"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestWorkerPoolIdleTimeout(t *testing.T) {
+	pool := newWorkerPool(2, 10, 50*time.Millisecond)
+	assert.Equal(t, 0, pool.numWorkers())
+
+	// In-flight tasks outlive the idle timeout
+	release := make(chan struct{})
+	done := make(chan struct{}, 2)
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, pool.submit("", PriorityNormal, func() {
+			<-release
+			done <- struct{}{}
+		}))
+	}
+	assert.Equal(t, 2, pool.numWorkers())
+	time.Sleep(150 * time.Millisecond)
+	assert.Equal(t, 2, pool.numWorkers())
+	close(release)
+	<-done
+	<-done
+
+	// Idle workers shut down
+	assert.Eventually(t, func() bool {
+		return pool.numWorkers() == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Workers are recreated for later tasks
+	release = make(chan struct{})
+	assert.NoError(t, pool.submit("", PriorityNormal, func() {
+		<-release
+		done <- struct{}{}
+	}))
+	assert.Equal(t, 1, pool.numWorkers())
+	close(release)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("task was not executed")
+	}
+}
+
+func TestWorkerPoolBurst(t *testing.T) {
+	pool := newWorkerPool(3, 10, time.Minute)
+	done := make(chan struct{}, 3)
+	assert.NoError(t, pool.submit("", PriorityNormal, func() {
+		done <- struct{}{}
+	}))
+	<-done
+	assert.Eventually(t, func() bool {
+		pool.mu.Lock()
+		defer pool.mu.Unlock()
+		return pool.idle == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// A burst of tasks submitted while a worker is idle runs in parallel up to the pool size
+	started := make(chan struct{}, 3)
+	release := make(chan struct{})
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, pool.submit("", PriorityNormal, func() {
+			started <- struct{}{}
+			<-release
+			done <- struct{}{}
+		}))
+	}
+	assert.Equal(t, 3, pool.numWorkers())
+	for i := 0; i < 3; i++ {
+		select {
+		case <-started:
+		case <-time.After(5 * time.Second):
+			t.Fatal("tasks were not run in parallel")
+		}
+	}
+	close(release)
+	for i := 0; i < 3; i++ {
+		<-done
+	}
+}
+
+func TestDefaultCompileWorkers(t *testing.T) {
+	assert.Equal(t, 1, getDefaultCompileWorkers(8, 0))
+	assert.Equal(t, 1, getDefaultCompileWorkers(8, 8))
+	assert.Equal(t, 1, getDefaultCompileWorkers(8, 16))
+	assert.Equal(t, 4, getDefaultCompileWorkers(8, 2))
+	assert.Equal(t, 2, getDefaultCompileWorkers(8, 3))
+	assert.Equal(t, 8, getDefaultCompileWorkers(8, 1))
+
+	// The pool is sized by the compiler's build parallelism unless the number of workers is configured
+	server := newTestServer(t)
+	server.compiler.Config.BuildParallelism = 1
+	size, _ := NewServer(ServiceConfig{}, server.registry, server.cache, server.compiler).workers.limits()
+	assert.Equal(t, runtime.NumCPU(), size)
+	size, _ = NewServer(ServiceConfig{CompileWorkers: 3}, server.registry, server.cache, server.compiler).workers.limits()
+	assert.Equal(t, 3, size)
+}
+
+func TestWorkerPoolPriority(t *testing.T) {
+	pool := newWorkerPool(1, 10, 0)
+
+	// Occupy the only worker so that later tasks are queued
+	release := make(chan struct{})
+	started := make(chan struct{})
+	assert.NoError(t, pool.submit("running", PriorityLow, func() {
+		close(started)
+		<-release
+	}))
+	<-started
+
+	order := make(chan string, 4)
+	submit := func(key string, priority Priority) {
+		assert.NoError(t, pool.submit(key, priority, func() {
+			order <- key
+		}))
+	}
+	submit("low", PriorityLow)
+	submit("normal-1", PriorityNormal)
+	submit("high", PriorityHigh)
+	submit("normal-2", PriorityNormal)
+
+	// Higher priority tasks are queued ahead of lower priority tasks without preempting the running task
+	assert.Equal(t, 0, pool.position("running"))
+	assert.Equal(t, 1, pool.position("high"))
+	assert.Equal(t, 2, pool.position("normal-1"))
+	assert.Equal(t, 3, pool.position("normal-2"))
+	assert.Equal(t, 4, pool.position("low"))
+
+	close(release)
+	for _, key := range []string{"high", "normal-1", "normal-2", "low"} {
+		select {
+		case next := <-order:
+			assert.Equal(t, key, next)
+		case <-time.After(5 * time.Second):
+			t.Fatal("task was not executed")
+		}
+	}
+	assert.Equal(t, 0, pool.position("low"))
+}
+
+func TestWorkerPoolQueueFull(t *testing.T) {
+	pool := newWorkerPool(1, 1, 0)
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	assert.NoError(t, pool.submit("running", PriorityNormal, func() {
+		close(started)
+		<-release
+	}))
+	<-started
+	assert.NoError(t, pool.submit("queued", PriorityNormal, func() {}))
+	err := pool.submit("high", PriorityHigh, func() {})
+	assert.True(t, isQueueFull(err))
+	assert.Equal(t, codes.ResourceExhausted, getSubmitStatus(err).Code())
+}
+
+func TestWorkerPoolReserve(t *testing.T) {
+	pool := newWorkerPool(1, 1, 0)
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	assert.NoError(t, pool.submit("running", PriorityNormal, func() {
+		close(started)
+		<-release
+	}))
+	<-started
+
+	// Reserved slots count toward the queue size until they're released
+	slot, err := pool.reserve("reserved", PriorityNormal)
+	assert.NoError(t, err)
+	_, err = pool.reserve("full", PriorityNormal)
+	assert.True(t, isQueueFull(err))
+	assert.True(t, isQueueFull(pool.submit("full", PriorityHigh, func() {})))
+	slot.release()
+
+	// Tasks submitted with a reservation take its slot
+	slot, err = pool.reserve("reserved", PriorityNormal)
+	assert.NoError(t, err)
+	slot.submit(func() {})
+	assert.Equal(t, 1, pool.position("reserved"))
+	assert.True(t, isQueueFull(pool.submit("full", PriorityHigh, func() {})))
+}
+
+func TestPushModelQueueFull(t *testing.T) {
+	server := newTestServer(t)
+	server.workers = newWorkerPool(1, 1, 0)
+	client := newTestClient(t, server)
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	assert.NoError(t, server.workers.submit("running", PriorityNormal, func() {
+		close(started)
+		<-release
+	}))
+	<-started
+	assert.NoError(t, server.workers.submit("queued", PriorityNormal, func() {}))
+
+	// Pushes are rejected with ResourceExhausted while the queue is full, and the model is not added
+	_, err := client.PushModel(context.Background(), &configmodelapi.PushModelRequest{
+		Model: &configmodelapi.ConfigModel{
+			Name:    "test",
+			Version: "1.0.0",
+			Modules: []*configmodelapi.ConfigModule{{Name: "test", File: "test.yang"}},
+			Files:   map[string]string{"test.yang": "module test {}"},
+		},
+	})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	_, err = server.registry.GetModel("test", "1.0.0")
+	assert.True(t, errors.IsNotFound(err))
+
+	// The model's cache entry is released for the next push
+	entry := server.cache.Entry("test", "1.0.0")
+	locked, err := entry.TryLock()
+	assert.NoError(t, err)
+	assert.True(t, locked)
+	assert.NoError(t, entry.Unlock(context.Background()))
+}